@@ -0,0 +1,18 @@
+// Command dlq-replay publishes the CDC events of the dead-letter topic back
+// to the topic they failed on, once the cause has been fixed
+package main
+
+import (
+	"flag"
+	"gold-gym-be/internal/boot"
+	"log"
+)
+
+func main() {
+	limit := flag.Int("limit", 0, "replay at most this many messages, 0 replays all of them")
+	flag.Parse()
+
+	if err := boot.ReplayDLQ(*limit); err != nil {
+		log.Fatalln("[DLQ] failed to replay dead-lettered messages due to " + err.Error())
+	}
+}
@@ -0,0 +1,16 @@
+// Command reindex-catalogue rebuilds the Elasticsearch stock and sales
+// indices from MySQL without downtime
+package main
+
+import (
+	"gold-gym-be/internal/boot"
+	"log"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	if err := boot.ReindexCatalogue(); err != nil {
+		log.Fatalln("[ES] failed to reindex stock and sales due to " + err.Error())
+	}
+}
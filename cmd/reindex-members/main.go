@@ -0,0 +1,16 @@
+// Command reindex-members rebuilds the Elasticsearch member index from MySQL
+// without downtime
+package main
+
+import (
+	"gold-gym-be/internal/boot"
+	"log"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	if err := boot.ReindexMembers(); err != nil {
+		log.Fatalln("[ES] failed to reindex members due to " + err.Error())
+	}
+}
@@ -0,0 +1,16 @@
+// Command sync-reconcile compares the synced tables of the local and prod
+// databases row by row and reports the rows that drifted apart
+package main
+
+import (
+	"gold-gym-be/internal/boot"
+	"log"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	if err := boot.Reconcile(); err != nil {
+		log.Fatalln("[SYNC] failed to reconcile local and prod due to " + err.Error())
+	}
+}
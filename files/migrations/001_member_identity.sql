@@ -0,0 +1,22 @@
+-- External (OIDC) identities linked to data_peserta
+CREATE TABLE IF NOT EXISTS member_identity (
+    gold_id            INT          NOT NULL,
+    identity_provider  VARCHAR(50)  NOT NULL,
+    identity_subject   VARCHAR(255) NOT NULL,
+    identity_email     VARCHAR(255) NOT NULL DEFAULT '',
+    identity_linked_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (identity_provider, identity_subject),
+    UNIQUE KEY uq_member_identity_member_provider (gold_id, identity_provider),
+    KEY idx_member_identity_gold_id (gold_id)
+);
+
+-- Pending authorization requests (state, nonce and PKCE verifier), single use
+CREATE TABLE IF NOT EXISTS oidc_login_state (
+    state_id            VARCHAR(64)  NOT NULL PRIMARY KEY,
+    state_provider      VARCHAR(50)  NOT NULL,
+    state_code_verifier VARCHAR(128) NOT NULL,
+    state_nonce         VARCHAR(64)  NOT NULL,
+    gold_id             INT          NOT NULL DEFAULT 0,
+    state_expires_at    DATETIME     NOT NULL,
+    KEY idx_oidc_login_state_expires (state_expires_at)
+);
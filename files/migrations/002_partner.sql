@@ -0,0 +1,21 @@
+-- B2B partners using the SNAP-style partner API
+CREATE TABLE IF NOT EXISTS partner_client (
+    partner_id            INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    partner_client_key    VARCHAR(64)  NOT NULL,
+    partner_name          VARCHAR(255) NOT NULL,
+    partner_public_key    TEXT         NOT NULL,
+    partner_client_secret VARCHAR(255) NOT NULL,
+    partner_active_yn     CHAR(1)      NOT NULL DEFAULT 'Y',
+    partner_created_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_partner_client_key (partner_client_key)
+);
+
+-- Members enrolled by a partner (corporate memberships)
+CREATE TABLE IF NOT EXISTS partner_member (
+    partner_id  INT          NOT NULL,
+    gold_id     INT          NOT NULL,
+    employee_id VARCHAR(64)  NOT NULL DEFAULT '',
+    enrolled_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (partner_id, gold_id),
+    KEY idx_partner_member_gold_id (gold_id)
+);
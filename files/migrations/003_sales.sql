@@ -0,0 +1,24 @@
+-- Front desk (POS) sales of supplements and drinks
+CREATE TABLE IF NOT EXISTS sales_header (
+    sale_id           VARCHAR(32)    NOT NULL PRIMARY KEY,
+    sale_transdate    CHAR(10)       NOT NULL, -- YYYY-MM-DD, server local time
+    sale_transtime    CHAR(8)        NOT NULL, -- HH:MM:SS, server local time
+    sale_transtotal   DECIMAL(15, 2) NOT NULL,
+    sale_transpayment DECIMAL(15, 2) NOT NULL,
+    sale_transchange  DECIMAL(15, 2) NOT NULL,
+    sale_salesperson  VARCHAR(255)   NOT NULL,
+    KEY idx_sales_header_transdate (sale_transdate),
+    KEY idx_sales_header_salesperson (sale_salesperson, sale_transdate)
+);
+
+CREATE TABLE IF NOT EXISTS sales_detail (
+    sale_id         VARCHAR(32)    NOT NULL,
+    sale_stockid    VARCHAR(64)    NOT NULL,
+    sale_stockcode  VARCHAR(64)    NOT NULL,
+    sale_stockname  VARCHAR(255)   NOT NULL,
+    sale_qty        INT            NOT NULL,
+    sale_salesprice DECIMAL(15, 2) NOT NULL,
+    sale_pack       VARCHAR(64)    NOT NULL DEFAULT '',
+    sale_lastupdate DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (sale_id, sale_stockcode)
+);
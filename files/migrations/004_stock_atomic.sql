@@ -0,0 +1,6 @@
+-- Restocks are upserts on stock_code and new stock_id values come from the
+-- database instead of the last stock_id + 1. Assumes stock_id is already the
+-- primary key; clean up any duplicated stock_code rows before running.
+ALTER TABLE stock
+    MODIFY stock_id INT NOT NULL AUTO_INCREMENT,
+    ADD UNIQUE KEY uq_stock_code (stock_code);
@@ -0,0 +1,19 @@
+-- Stock ledger: every change of stock.stock_qty is one signed movement, so
+-- stock_qty can be rebuilt as SUM(movement_qty) per stock_code
+CREATE TABLE IF NOT EXISTS stock_movement (
+    movement_id     BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    stock_code      VARCHAR(64)  NOT NULL,
+    movement_type   VARCHAR(16)  NOT NULL, -- restock, sale, adjustment, writeoff, transfer, return
+    movement_qty    INT          NOT NULL,
+    movement_reason VARCHAR(255) NOT NULL DEFAULT '',
+    movement_ref    VARCHAR(64)  NOT NULL DEFAULT '', -- e.g. sale_id
+    movement_by     VARCHAR(255) NOT NULL,
+    movement_at     DATETIME     NOT NULL,
+    KEY idx_stock_movement_code_at (stock_code, movement_at)
+);
+
+-- opening balance so the ledger agrees with the current quantities
+INSERT INTO stock_movement (stock_code, movement_type, movement_qty, movement_reason, movement_by, movement_at)
+SELECT stock_code, 'adjustment', stock_qty, 'opening balance', 'migration', NOW()
+FROM stock
+WHERE stock_qty <> 0;
@@ -0,0 +1,7 @@
+-- Reorder points: a product is low when 0 < stock_reorder_point and
+-- stock_qty <= stock_reorder_point. stock_low_alerted_at keeps the checker
+-- from notifying again until the product has been restocked above the point.
+ALTER TABLE stock
+    ADD COLUMN stock_reorder_point  INT      NOT NULL DEFAULT 0,
+    ADD COLUMN stock_reorder_qty    INT      NOT NULL DEFAULT 0,
+    ADD COLUMN stock_low_alerted_at DATETIME NULL;
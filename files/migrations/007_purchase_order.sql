@@ -0,0 +1,54 @@
+-- Suppliers and purchase orders. Receiving a PO adds to stock.stock_qty,
+-- writes a restock movement (movement_ref = po_id), logs it in td_stock and
+-- moves stock.stock_cost_price to the weighted average cost.
+ALTER TABLE stock
+    ADD COLUMN stock_cost_price DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS supplier (
+    supplier_id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    supplier_code       VARCHAR(64)  NOT NULL,
+    supplier_name       VARCHAR(255) NOT NULL,
+    supplier_phone      VARCHAR(32)  NOT NULL DEFAULT '',
+    supplier_email      VARCHAR(255) NOT NULL DEFAULT '',
+    supplier_address    VARCHAR(512) NOT NULL DEFAULT '',
+    supplier_active     TINYINT(1)   NOT NULL DEFAULT 1,
+    supplier_created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_supplier_code (supplier_code)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order (
+    po_id            VARCHAR(32)    NOT NULL PRIMARY KEY,
+    po_supplier_id   BIGINT         NOT NULL,
+    po_status        VARCHAR(16)    NOT NULL, -- open, partial, received, cancelled
+    po_date          CHAR(10)       NOT NULL, -- YYYY-MM-DD, server local time
+    po_expected_date CHAR(10)       NOT NULL DEFAULT '',
+    po_note          VARCHAR(512)   NOT NULL DEFAULT '',
+    po_total         DECIMAL(15, 2) NOT NULL,
+    po_created_by    VARCHAR(255)   NOT NULL,
+    po_created_at    DATETIME       NOT NULL,
+    KEY idx_purchase_order_status (po_status, po_expected_date),
+    KEY idx_purchase_order_supplier (po_supplier_id, po_date)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order_line (
+    pol_id           BIGINT         NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    po_id            VARCHAR(32)    NOT NULL,
+    stock_code       VARCHAR(64)    NOT NULL,
+    pol_qty_ordered  INT            NOT NULL,
+    pol_qty_received INT            NOT NULL DEFAULT 0,
+    pol_unit_cost    DECIMAL(15, 2) NOT NULL,
+    UNIQUE KEY uq_purchase_order_line (po_id, stock_code)
+);
+
+CREATE TABLE IF NOT EXISTS po_receipt (
+    receipt_id        BIGINT         NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    po_id             VARCHAR(32)    NOT NULL,
+    stock_code        VARCHAR(64)    NOT NULL,
+    receipt_qty       INT            NOT NULL,
+    receipt_unit_cost DECIMAL(15, 2) NOT NULL,
+    receipt_note      VARCHAR(512)   NOT NULL DEFAULT '',
+    receipt_by        VARCHAR(255)   NOT NULL,
+    receipt_at        DATETIME       NOT NULL,
+    KEY idx_po_receipt_po (po_id),
+    KEY idx_po_receipt_stock (stock_code, receipt_at)
+);
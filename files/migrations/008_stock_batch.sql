@@ -0,0 +1,33 @@
+-- Lots and expiry dates. Every purchase order receipt becomes a batch; sales
+-- take from the batch that expires first (FEFO) and expired batches are
+-- written off through the stock ledger. stock.stock_qty stays the total, stock
+-- received before batches existed is simply not in any batch.
+CREATE TABLE IF NOT EXISTS stock_batch (
+    batch_id           BIGINT         NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    stock_code         VARCHAR(64)    NOT NULL,
+    batch_lot          VARCHAR(64)    NOT NULL DEFAULT '',
+    batch_expiry       CHAR(10)       NOT NULL DEFAULT '', -- YYYY-MM-DD, empty when it does not expire
+    batch_qty_received INT            NOT NULL,
+    batch_qty          INT            NOT NULL,
+    batch_unit_cost    DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    batch_ref          VARCHAR(64)    NOT NULL DEFAULT '', -- e.g. po_id
+    batch_received_at  DATETIME       NOT NULL,
+    KEY idx_stock_batch_fefo (stock_code, batch_expiry),
+    KEY idx_stock_batch_expiry (batch_expiry)
+);
+
+CREATE TABLE IF NOT EXISTS stock_batch_usage (
+    usage_id   BIGINT      NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    batch_id   BIGINT      NOT NULL,
+    stock_code VARCHAR(64) NOT NULL,
+    usage_type VARCHAR(16) NOT NULL, -- sale, expired
+    usage_qty  INT         NOT NULL,
+    usage_ref  VARCHAR(64) NOT NULL DEFAULT '', -- e.g. sale_id
+    usage_at   DATETIME    NOT NULL,
+    KEY idx_stock_batch_usage_batch (batch_id),
+    KEY idx_stock_batch_usage_ref (usage_ref)
+);
+
+ALTER TABLE po_receipt
+    ADD COLUMN receipt_lot    VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN receipt_expiry CHAR(10)    NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Cost of goods sold. Every sales line keeps its cost at the moment of sale
+-- under both valuation methods, so the reports can switch method without
+-- recomputing history:
+--   sale_cost_avg  qty x stock.stock_cost_price (weighted average)
+--   sale_cost_fifo cost of the oldest cost layers (stock_cost_layer) the
+--                  units were taken from, units not in any layer at the
+--                  weighted average
+-- Sales made before this migration have no cost (0).
+ALTER TABLE sales_detail
+    ADD COLUMN sale_cost_avg  DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN sale_cost_fifo DECIMAL(15, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,34 @@
+-- Product catalogue. A product is what the customer sees ("Whey Protein");
+-- every stock row is one sellable variant of it (flavour and/or size), with
+-- its own quantity and price. Stock rows without a product are standalone
+-- items. A variant may have several barcodes (EAN-13, EAN-8, UPC-A).
+CREATE TABLE IF NOT EXISTS product_category (
+    category_id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    category_name       VARCHAR(128) NOT NULL,
+    category_created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_product_category_name (category_name)
+);
+
+CREATE TABLE IF NOT EXISTS product (
+    product_code        VARCHAR(64)   NOT NULL PRIMARY KEY,
+    product_name        VARCHAR(255)  NOT NULL,
+    product_category_id BIGINT        NOT NULL DEFAULT 0, -- 0 when uncategorised
+    product_description VARCHAR(1024) NOT NULL DEFAULT '',
+    product_image_url   VARCHAR(512)  NOT NULL DEFAULT '',
+    product_created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_product_category (product_category_id),
+    KEY idx_product_name (product_name)
+);
+
+ALTER TABLE stock
+    ADD COLUMN stock_product_code VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN stock_flavour      VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN stock_size         VARCHAR(64) NOT NULL DEFAULT '',
+    ADD KEY idx_stock_product (stock_product_code);
+
+CREATE TABLE IF NOT EXISTS stock_barcode (
+    barcode            VARCHAR(14) NOT NULL PRIMARY KEY,
+    stock_code         VARCHAR(64) NOT NULL,
+    barcode_created_at DATETIME    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_stock_barcode_stock (stock_code)
+);
@@ -0,0 +1,20 @@
+-- Money columns that predate the migrations were FLOAT/DOUBLE, which is how
+-- rupiah totals drifted a sen here and there. Every amount is now
+-- DECIMAL(15, 2), the precision of entity.Money; existing values are rounded
+-- half away from zero to the sen first, the same rule the application uses.
+-- The subscription amounts stay nullable (read as 0).
+UPDATE stock SET stock_price = ROUND(COALESCE(stock_price, 0), 2);
+ALTER TABLE stock
+    MODIFY COLUMN stock_price DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+UPDATE subscription SET gold_totalharga = ROUND(gold_totalharga, 2);
+ALTER TABLE subscription
+    MODIFY COLUMN gold_totalharga DECIMAL(15, 2) NULL;
+
+UPDATE subscription_detail SET gold_harga = ROUND(gold_harga, 2);
+ALTER TABLE subscription_detail
+    MODIFY COLUMN gold_harga DECIMAL(15, 2) NULL;
+
+UPDATE subscription_product SET gold_harga = ROUND(gold_harga, 2);
+ALTER TABLE subscription_product
+    MODIFY COLUMN gold_harga DECIMAL(15, 2) NULL;
@@ -0,0 +1,59 @@
+-- Branches. Everything that existed before belongs to branch 1, the original
+-- site. stock.stock_qty stays the company total; stock_branch holds what each
+-- branch has on hand and the two are kept equal by every stock write. Both
+-- can be rebuilt from the ledger, which now records the branch of every
+-- movement. A transfer is an out movement at one branch and an in movement at
+-- the other, so it never changes the total.
+CREATE TABLE IF NOT EXISTS branch (
+    branch_id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    branch_code       VARCHAR(32)  NOT NULL,
+    branch_name       VARCHAR(255) NOT NULL,
+    branch_address    VARCHAR(255) NOT NULL DEFAULT '',
+    branch_phone      VARCHAR(32)  NOT NULL DEFAULT '',
+    branch_active     TINYINT(1)   NOT NULL DEFAULT 1,
+    branch_created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_branch_code (branch_code)
+);
+
+INSERT INTO branch (branch_id, branch_code, branch_name) VALUES (1, 'MAIN', 'Gold Gym');
+
+CREATE TABLE IF NOT EXISTS stock_branch (
+    branch_id  BIGINT      NOT NULL,
+    stock_code VARCHAR(64) NOT NULL,
+    stock_qty  INT         NOT NULL DEFAULT 0,
+    PRIMARY KEY (branch_id, stock_code)
+);
+
+INSERT INTO stock_branch (branch_id, stock_code, stock_qty)
+SELECT 1, stock_code, stock_qty FROM stock;
+
+ALTER TABLE stock_movement
+    ADD COLUMN movement_branch_id BIGINT NOT NULL DEFAULT 1,
+    ADD KEY idx_stock_movement_branch (movement_branch_id, stock_code, movement_at);
+
+ALTER TABLE stock_batch
+    ADD COLUMN batch_branch_id BIGINT NOT NULL DEFAULT 1,
+    ADD KEY idx_stock_batch_branch_fefo (batch_branch_id, stock_code, batch_expiry);
+
+ALTER TABLE sales_header
+    ADD COLUMN sale_branch_id BIGINT NOT NULL DEFAULT 1,
+    ADD KEY idx_sales_header_branch (sale_branch_id, sale_transdate);
+
+ALTER TABLE purchase_order
+    ADD COLUMN po_branch_id BIGINT NOT NULL DEFAULT 1; -- where the goods are received
+
+-- members: a home branch, and per package whether it opens every branch
+ALTER TABLE data_peserta
+    ADD COLUMN gold_branch_id BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE subscription_product
+    ADD COLUMN gold_branch_access VARCHAR(8) NOT NULL DEFAULT 'home'; -- home, all
+
+CREATE TABLE IF NOT EXISTS member_checkin (
+    checkin_id BIGINT   NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    gold_id    INT      NOT NULL,
+    branch_id  BIGINT   NOT NULL,
+    checkin_at DATETIME NOT NULL,
+    KEY idx_member_checkin_branch_at (branch_id, checkin_at),
+    KEY idx_member_checkin_member (gold_id, checkin_at)
+);
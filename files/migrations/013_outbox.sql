@@ -0,0 +1,19 @@
+-- Transactional outbox. Domain events are written in the same transaction as
+-- the change they describe and published to Kafka by the relay afterwards.
+-- outbox_envelope is the versioned JSON envelope sent as the message value.
+-- The relay publishes the oldest unpublished event of every aggregate first,
+-- so events of one aggregate reach Kafka in the order they were written.
+CREATE TABLE IF NOT EXISTS outbox_event (
+    outbox_id             BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    outbox_event_id       CHAR(36)     NOT NULL,
+    outbox_event_type     VARCHAR(64)  NOT NULL,
+    outbox_aggregate_type VARCHAR(32)  NOT NULL,
+    outbox_aggregate_id   VARCHAR(64)  NOT NULL,
+    outbox_envelope       JSON         NOT NULL,
+    outbox_created_at     DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    outbox_published_at   DATETIME     NULL,
+    outbox_attempts       INT          NOT NULL DEFAULT 0,
+    outbox_last_error     VARCHAR(512) NOT NULL DEFAULT '',
+    UNIQUE KEY uq_outbox_event_id (outbox_event_id),
+    KEY idx_outbox_pending (outbox_published_at, outbox_aggregate_type, outbox_aggregate_id, outbox_id)
+);
@@ -0,0 +1,19 @@
+-- Origin tag of two-way sync. A row written by replication carries the
+-- database the change came from and a token unique to that write, such as
+-- 'local#<uuid>'. The consumer writing into local skips events of rows
+-- tagged 'local', they are its own writes coming back from prod, and the
+-- other way round. Any other write leaves the tag as it was, so the
+-- triggers clear it and the change is replicated like before.
+ALTER TABLE data_peserta        ADD COLUMN sync_origin VARCHAR(64) NULL;
+ALTER TABLE subscription        ADD COLUMN sync_origin VARCHAR(64) NULL;
+ALTER TABLE subscription_detail ADD COLUMN sync_origin VARCHAR(64) NULL;
+ALTER TABLE stock               ADD COLUMN sync_origin VARCHAR(64) NULL;
+
+CREATE TRIGGER trg_data_peserta_sync_origin BEFORE UPDATE ON data_peserta FOR EACH ROW
+    SET NEW.sync_origin = IF(NEW.sync_origin <=> OLD.sync_origin, NULL, NEW.sync_origin);
+CREATE TRIGGER trg_subscription_sync_origin BEFORE UPDATE ON subscription FOR EACH ROW
+    SET NEW.sync_origin = IF(NEW.sync_origin <=> OLD.sync_origin, NULL, NEW.sync_origin);
+CREATE TRIGGER trg_subscription_detail_sync_origin BEFORE UPDATE ON subscription_detail FOR EACH ROW
+    SET NEW.sync_origin = IF(NEW.sync_origin <=> OLD.sync_origin, NULL, NEW.sync_origin);
+CREATE TRIGGER trg_stock_sync_origin BEFORE UPDATE ON stock FOR EACH ROW
+    SET NEW.sync_origin = IF(NEW.sync_origin <=> OLD.sync_origin, NULL, NEW.sync_origin);
@@ -0,0 +1,21 @@
+-- FIFO cost layers. Every purchase order receipt line is a layer; sales and
+-- expired write-offs take their cost from the oldest layers first, whichever
+-- batch the units physically came from (batches are consumed FEFO).
+-- Transfers between branches move batches, not layers. FIFO valuation values
+-- the layers left, units outside any layer at the weighted average.
+CREATE TABLE IF NOT EXISTS stock_cost_layer (
+    layer_id           BIGINT         NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    stock_code         VARCHAR(64)    NOT NULL,
+    layer_qty_received INT            NOT NULL,
+    layer_qty          INT            NOT NULL,
+    layer_unit_cost    DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    layer_ref          VARCHAR(64)    NOT NULL DEFAULT '', -- po_id
+    layer_received_at  DATETIME       NOT NULL,
+    KEY idx_stock_cost_layer_fifo (stock_code, layer_received_at)
+);
+
+-- the batches left seed the layers
+INSERT INTO stock_cost_layer (stock_code, layer_qty_received, layer_qty, layer_unit_cost, layer_ref, layer_received_at)
+SELECT stock_code, batch_qty_received, batch_qty, batch_unit_cost, batch_ref, batch_received_at
+FROM stock_batch
+WHERE batch_qty > 0;
@@ -0,0 +1,10 @@
+-- The low-stock check claims a product twice: stock_low_alerted_at when it
+-- stores the product's StockLow event, stock_low_emailed_at when it emails
+-- the digest. A failed email releases only the second, so the email is
+-- retried without publishing StockLow again. Both are cleared once the
+-- product is restocked above its reorder point.
+ALTER TABLE stock
+    ADD COLUMN stock_low_emailed_at DATETIME NULL;
+
+-- products alerted before this migration were emailed with their alert
+UPDATE stock SET stock_low_emailed_at = stock_low_alerted_at WHERE stock_low_alerted_at IS NOT NULL;
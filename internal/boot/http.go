@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,6 +17,12 @@ import (
 
 	// "gold-gym-be/pkg/firebaseclient"
 
+	"errors"
+	"fmt"
+	"gold-gym-be/own-pkg/crypto"
+	"gold-gym-be/pkg/jwtkeys"
+	"gold-gym-be/pkg/oidc"
+	"gold-gym-be/pkg/ratelimit"
 	"gold-gym-be/pkg/tracing"
 	"log"
 
@@ -27,6 +34,7 @@ import (
 	"cloud.google.com/go/firestore"
 	firebase "firebase.google.com/go"
 	"firebase.google.com/go/db"
+	"firebase.google.com/go/storage"
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-redis/redis/v8"
 	"github.com/jmoiron/sqlx"
@@ -54,18 +62,36 @@ import (
 
 	beegoHandler "gold-gym-be/internal/delivery/http/beego"
 
-	elasticData    "gold-gym-be/internal/data/elastic"
 	elasticHandler "gold-gym-be/internal/delivery/http/elastic"
-	elasticService "gold-gym-be/internal/service/elastic"
 
+	idempotencyData "gold-gym-be/internal/data/idempotency"
+	outboxData "gold-gym-be/internal/data/outbox"
+	partnerData "gold-gym-be/internal/data/partner"
 	middlewareHandler "gold-gym-be/internal/delivery/http/middleware"
+	partnerHandler "gold-gym-be/internal/delivery/http/partner"
+	"gold-gym-be/internal/registry"
+	"gold-gym-be/internal/resources"
 	middlewareService "gold-gym-be/internal/service/middleware"
+	partnerService "gold-gym-be/internal/service/partner"
 
 	healthHandler "gold-gym-be/internal/delivery/http/health"
 
+	notificationData "gold-gym-be/internal/data/notification"
 	goldgymStockData "gold-gym-be/internal/data/stock"
 	goldgymStockService "gold-gym-be/internal/service/stock"
 
+	salesData "gold-gym-be/internal/data/sales"
+	salesHandler "gold-gym-be/internal/delivery/http/sales"
+	salesService "gold-gym-be/internal/service/sales"
+
+	branchData "gold-gym-be/internal/data/branch"
+	branchHandler "gold-gym-be/internal/delivery/http/branch"
+	branchService "gold-gym-be/internal/service/branch"
+
+	catalogueHandler "gold-gym-be/internal/delivery/http/catalogue"
+	purchasingHandler "gold-gym-be/internal/delivery/http/purchasing"
+	reportHandler "gold-gym-be/internal/delivery/http/report"
+
 	pb "gold-gym-be/proto"
 	"net"
 
@@ -93,11 +119,12 @@ func HTTP() error {
 	// firebase
 	cfg, _ = config.Get()
 
-	// rdb := newRedisClient(cfg.Redis)
-	// if err := rdb.Ping(context.Background()).Err(); err != nil {
-	// 	log.Fatalf("[REDIS] Failed to connect: %v", err)
-	// }
-	// defer rdb.Close()
+	rdb := newRedisClient(cfg.Redis)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		// not fatal: redis-backed features degrade instead of blocking startup
+		log.Printf("[REDIS] Failed to connect: %v", err)
+	}
+	defer rdb.Close()
 
 	// t, err := trace.New(ctx, cfg.Trace.Exporter)
 	// if err != nil {
@@ -111,11 +138,14 @@ func HTTP() error {
 		log.Fatalf("[DB] Failed to initialize database connection: %v", err)
 	}
 
-	// // Open MySQL DB Connection
-	// dbprod, dbrprod, err := openDatabasesProd(cfg)
-	// if err != nil {
-	// 	log.Fatalf("[DB] Failed to initialize database connection: %v", err)
-	// }
+	// prod is only written by the CDC consumers, without it they are left off
+	var dbprod *gorm.DB
+	if cfg.Database.Production != "" {
+		dbprod, _, err = openDatabasesProd(cfg)
+		if err != nil {
+			log.Printf("[DB] Failed to connect to production: %v", err)
+		}
+	}
 
 	// firebase
 	// // Open MySQL DB Connection
@@ -170,12 +200,17 @@ func HTTP() error {
 	// httpc := httpclient.NewClient(tracer)
 	// ad := auth.New(httpc, cfg.API.Auth)
 
-	sdst := goldgymStockData.New(db, nil, nil, nil, tracer, zlogger)
-	ssst := goldgymStockService.New(sdst, tracer, zlogger)
+	sdst := goldgymStockData.New(db, nil, openFirebaseStorage(context.Background(), cfg.Firebase), nil, tracer, zlogger)
+	ntf := notificationData.New(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, os.Getenv(cfg.SMTP.PasswordEnv), cfg.SMTP.From, tracer, zlogger)
+	ssst := goldgymStockService.New(sdst, ntf, cfg.StockAlert.Recipients, tracer, zlogger)
 
 	sd := goldgymData.New(db, dbr, tracer, zlogger)
 	// ss := goldgymService.New(sd, ad, tracer, zlogger)
-	ss := goldgymService.New(sd, tracer, zlogger)
+	tokenKeys, err := newTokenKeys(cfg)
+	if err != nil {
+		log.Fatalf("[JWT] Failed to load signing keys: %v", err)
+	}
+	ss := goldgymService.New(sd, newIdentityProviders(cfg.OIDC), tokenKeys, tracer, zlogger)
 	sh := goldgymHandler.New(ss, ssst, tracer, zlogger)
 
 	echoH := echoHandler.New(ss, ssst, tracer, zlogger)
@@ -193,15 +228,57 @@ func HTTP() error {
 	if err != nil {
 		log.Fatalf("[ES] Failed to create Elasticsearch client: %v", err)
 	}
-	sed := elasticData.New(esClient, tracer, zlogger)
-	ses := elasticService.New(sed, tracer, zlogger)
+	ses := newSearchService(esClient, db, searchIndices(cfg.Elasticsearch), tracer, zlogger)
 	seh := elasticHandler.New(ses, tracer, zlogger)
 
 	//middleware
-	ms := middlewareService.New(sd, tracer, zlogger)
+	idd := idempotencyData.New(rdb, tracer, zlogger)
+	ms := middlewareService.New(idd, cfg.Idempotency.TTL, cfg.Idempotency.LockTTL, tracer, zlogger)
 	mh := middlewareHandler.New(ms, ss, ssst, tracer, zlogger)
 
-	hh := healthHandler.New(db)
+	// CDC consumers, started together with the servers below
+	res := &resources.BootResources{
+		DBLocal:     db,
+		DBProd:      dbprod,
+		Redis:       rdb,
+		SearchIndex: ses,
+		Tracer:      tracer,
+		Logger:      logger,
+	}
+	syncTables, err := registry.Configure(registry.Tables, cfg.Replication.Tables)
+	if err != nil {
+		log.Fatalf("[CONFIG] Invalid replication tables: %v", err)
+	}
+	var probes []healthHandler.Probe
+	kafkaSup := newKafkaSupervisor(cfg.Kafka, syncTables, res)
+	if kafkaSup != nil {
+		probes = append(probes, kafkaSup)
+	}
+	hh := healthHandler.New(db, probes...)
+
+	// partner (B2B) API
+	pd := partnerData.New(db, rdb, tracer, zlogger)
+	ps := partnerService.New(pd, ss, tokenKeys, cfg.Partner.TokenTTL, cfg.Partner.TimestampSkew, tracer, zlogger)
+	ph := partnerHandler.New(ps, tracer, zlogger)
+
+	// front desk (POS) sales
+	sld := salesData.New(db, tracer, zlogger)
+	sls := salesService.New(sld, tracer, zlogger)
+	slh := salesHandler.New(sls, tracer, zlogger)
+
+	// branches and member check-ins
+	brd := branchData.New(db, tracer, zlogger)
+	brs := branchService.New(brd, tracer, zlogger)
+	brh := branchHandler.New(brs, tracer, zlogger)
+
+	// suppliers and purchase orders
+	poh := purchasingHandler.New(ssst, tracer, zlogger)
+
+	// product catalogue: categories, variants, barcodes and images
+	cth := catalogueHandler.New(ssst, tracer, zlogger)
+
+	// finance reports: inventory valuation, COGS and gross margin
+	rph := reportHandler.New(ssst, sls, cfg.Valuation.Method, tracer, zlogger)
 
 	// sdprod := goldgymData.New(dbprod, tracer, zlogger)
 	// ssprod := goldgymService.New(sdprod, tracer, zlogger)
@@ -310,6 +387,16 @@ func HTTP() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	startStockJobs(ctx, ssst, cfg.StockAlert.Interval, cfg.StockExpiry.WriteOffInterval)
+	startOutboxRelay(ctx, cfg.Kafka, outboxData.New(db, tracer, zlogger))
+	startIndexMigration(ctx, ses)
+	if kafkaSup != nil {
+		kafkaSup.Start(ctx)
+	}
+	if dbprod != nil {
+		startReconcile(ctx, cfg.Replication.ReconcileInterval, newReconciler(db, dbprod, syncTables, tracer, zlogger))
+	}
+
 	s := goldgymServer.Server{
 		Goldgym:      sh,
 		Auth:         sha,
@@ -319,6 +406,14 @@ func HTTP() error {
 		MuxGoldGym:   muxH,
 		BeegoGoldGym: beegoH,
 		Elastic:      seh,
+		Partner:      ph,
+		Sales:        slh,
+		Purchasing:   poh,
+		Branch:       brh,
+		Catalogue:    cth,
+		Report:       rph,
+		Tokens:       ss,
+		RateLimiter:  newRateLimiter(cfg.RateLimit, rdb, ss.ParseAccessToken, ps.TokenClient),
 		Logger:       zlogger,
 		Config:       cfg,
 		// PushNotification: spnh,
@@ -377,6 +472,16 @@ func HTTP() error {
 	// Graceful shutdown for both HTTP and gRPC
 	_ = s.Shutdown(context.Background())
 	grpcServer.GracefulStop()
+	if kafkaSup != nil {
+		// in-flight CDC events finish and are committed before exit
+		kafkaSup.Wait()
+	}
+	// member changes still buffered for ES are sent before exit
+	flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := ses.FlushMembers(flushCtx); err != nil {
+		log.Printf("[ES] flush member changes: %v", err)
+	}
+	cancel()
 	log.Println("servers shutdown complete")
 
 	return nil
@@ -392,7 +497,7 @@ func openDatabases(cfg *config.Config) (master *gorm.DB, masterDB *sqlx.DB, err
 }
 
 func openDatabasesProd(cfg *config.Config) (master *gorm.DB, masterDB *sqlx.DB, err error) {
-	master, masterDB, err = openConnectionPool(cfg.Database.Master)
+	master, masterDB, err = openConnectionPool(cfg.Database.Production)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -459,6 +564,113 @@ func newRedisClient(cred config.Redis) *redis.Client {
 	return client
 }
 
+func newIdentityProviders(cfg config.OIDCConfig) map[string]goldgymService.IdentityProvider {
+	providers := make(map[string]goldgymService.IdentityProvider, len(cfg.Providers))
+	for name, p := range cfg.Providers {
+		if p.ClientID == "" {
+			continue
+		}
+		providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil)
+	}
+	return providers
+}
+
+// newRateLimiter builds the limiter shared by every router. Rules keyed by
+// user resolve the member from the bearer token with parseToken, rules keyed
+// by client resolve the partner from its access token with tokenClient.
+func newRateLimiter(cfg config.RateLimitConfig, rdb *redis.Client, parseToken func(ctx context.Context, accessToken string) (map[string]interface{}, error), tokenClient func(accessToken string) (string, error)) *ratelimit.Enforcer {
+	var limiter ratelimit.Limiter
+	if cfg.Backend == "memory" {
+		limiter = ratelimit.NewMemory()
+	} else {
+		limiter = ratelimit.NewRedis(rdb)
+	}
+
+	rule := func(r config.RateLimitRule) ratelimit.Rule {
+		return ratelimit.Rule{
+			Name:      r.Name,
+			Method:    r.Method,
+			Path:      r.Path,
+			Type:      r.Type,
+			Limit:     r.Limit,
+			Window:    r.Window,
+			KeyBy:     r.KeyBy,
+			Algorithm: r.Algorithm,
+		}
+	}
+	rules := make([]ratelimit.Rule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, rule(r))
+	}
+
+	enforcer := ratelimit.NewEnforcer(limiter, rule(cfg.Default), rules...)
+	enforcer.IdentifyUser = func(ctx context.Context, authorization string) string {
+		scheme, accessToken, ok := strings.Cut(authorization, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		claims, err := parseToken(ctx, accessToken)
+		if err != nil {
+			return ""
+		}
+		user, _ := claims["user"].(string)
+		return user
+	}
+	enforcer.IdentifyClient = func(ctx context.Context, authorization string) string {
+		scheme, accessToken, ok := strings.Cut(authorization, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		client, err := tokenClient(accessToken)
+		if err != nil {
+			return ""
+		}
+		return client
+	}
+	return enforcer
+}
+
+func newTokenKeys(cfg *config.Config) (*jwtkeys.KeySet, error) {
+	if len(cfg.JWT.Keys) == 0 {
+		if cfg.Server.Env == "production" || cfg.Server.Env == "staging" {
+			return nil, errors.New("no signing keys configured")
+		}
+		log.Println("[JWT] No signing keys configured, using an ephemeral key; tokens will not survive a restart")
+		return jwtkeys.Generate("ephemeral")
+	}
+
+	keys := make([]jwtkeys.Key, 0, len(cfg.JWT.Keys))
+	for _, k := range cfg.JWT.Keys {
+		var material string
+		switch {
+		case k.PrivateKeyFile != "":
+			b, err := os.ReadFile(k.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.KID, err)
+			}
+			material = string(b)
+		case k.PrivateKeyEnv != "":
+			material = os.Getenv(k.PrivateKeyEnv)
+		}
+		if material == "" {
+			return nil, fmt.Errorf("key %q: no private key material", k.KID)
+		}
+
+		signer, err := crypto.ParseSigningKey(material)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.KID, err)
+		}
+		keys = append(keys, jwtkeys.Key{KID: k.KID, Algorithm: k.Algorithm, Signer: signer})
+	}
+	return jwtkeys.New(cfg.JWT.ActiveKID, keys...)
+}
+
 func openFirebaseClient(ctx context.Context, cfg config.FirebaseConfig, cred map[string]string) (*firebase.App, error) {
 	credBytes, err := json.Marshal(cred)
 	if err != nil {
@@ -479,6 +691,29 @@ func openFirebaseClient(ctx context.Context, cfg config.FirebaseConfig, cred map
 	return app, nil
 }
 
+// openFirebaseStorage connects to the Firebase storage bucket used for product
+// images with the application default credentials. Without it image uploads
+// are refused, the rest of the service runs as usual.
+func openFirebaseStorage(ctx context.Context, cfg config.FirebaseConfig) *storage.Client {
+	if cfg.StorageBucket == "" {
+		return nil
+	}
+	app, err := firebase.NewApp(ctx, &firebase.Config{
+		ProjectID:     cfg.ProjectID,
+		StorageBucket: cfg.StorageBucket,
+	})
+	if err != nil {
+		log.Printf("[FIREBASE] Failed to initialize firebase app, image uploads disabled: %v", err)
+		return nil
+	}
+	client, err := app.Storage(ctx)
+	if err != nil {
+		log.Printf("[FIREBASE] Failed to initialize storage client, image uploads disabled: %v", err)
+		return nil
+	}
+	return client
+}
+
 func openFirestoreClient(ctx context.Context, app *firebase.App) (*firestore.Client, error) {
 	client, err := app.Firestore(ctx)
 	if err != nil {
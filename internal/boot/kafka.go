@@ -1,33 +1,116 @@
 package boot
 
 import (
+	"context"
 	"gold-gym-be/internal/config"
 	"gold-gym-be/internal/consumer"
+	replicationEntity "gold-gym-be/internal/entity/replication"
 	"gold-gym-be/internal/registry"
+	"gold-gym-be/internal/resources"
 	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
 )
 
-func StartKafkaConsumers(cfg config.KafkaConfig, reg *registry.Registry) {
+// dlqIdle ends a DLQ replay once no message arrived for this long
+const dlqIdle = 5 * time.Second
+
+// newKafkaSupervisor builds a consumer for every configured binding whose
+// target database is open, nil when there is none. A binding only applies
+// the tables whose sync direction points to its target. Nothing runs until
+// Start.
+func newKafkaSupervisor(cfg config.KafkaConfig, tables []replicationEntity.Table, res *resources.BootResources) *consumer.Supervisor {
+	var bindings []consumer.Binding
+	for _, c := range consumerConfigs(cfg) {
+		reg, err := registry.ForTarget(res, c.Target, tables)
+		if err != nil {
+			log.Printf("[KAFKA] consumer %s disabled: %v", c.Name, err)
+			continue
+		}
+		if len(c.Topics) == 0 || c.GroupID == "" {
+			log.Printf("[KAFKA] consumer %s disabled: topics and group_id are required", c.Name)
+			continue
+		}
+		bindings = append(bindings, consumer.Binding{
+			Name:     c.Name,
+			Topics:   c.Topics,
+			GroupID:  c.GroupID,
+			Workers:  c.Workers,
+			Handlers: reg,
+		})
+	}
+	if len(bindings) == 0 {
+		log.Println("[KAFKA] no consumers configured")
+		return nil
+	}
+
+	opts := consumer.Options{
+		Retry: consumer.RetryPolicy{
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			Backoff:     cfg.Retry.Backoff,
+			MaxBackoff:  cfg.Retry.MaxBackoff,
+		},
+		DLQTopic: cfg.Topics.DLQ,
+	}
+	return consumer.NewSupervisor(cfg.Brokers, opts, bindings...)
+}
+
+// consumerConfigs is kafka.consumers, or the local_to_prod and prod_to_local
+// topics when it is empty
+func consumerConfigs(cfg config.KafkaConfig) []config.KafkaConsumerConfig {
+	if len(cfg.Consumers) > 0 {
+		return cfg.Consumers
+	}
+
+	var configs []config.KafkaConsumerConfig
 	if cfg.Topics.LocalToProd != "" {
-		// Local → Prod
-		go func() {
-			consumer.ConsumeLoop(
-				cfg.Brokers,
-				cfg.Topics.LocalToProd,
-				cfg.GroupID+"-local-to-prod",
-				reg,
-			)
-		}()
-	}
-	// // Prod → Local
-	// go func() {
-	// 	consumer.ConsumeLoop(
-	// 		cfg.Brokers,
-	// 		cfg.Topics.ProdToLocal,
-	// 		cfg.GroupID+"-prod-to-local",
-	// 		reg,
-	// 	)
-	// }()
-
-	log.Println("[BOOT] Kafka consumers started")
+		configs = append(configs, config.KafkaConsumerConfig{
+			Name:    "local-to-prod",
+			Topics:  []string{cfg.Topics.LocalToProd},
+			GroupID: cfg.GroupID + "-local-to-prod",
+			Target:  registry.TargetProd,
+		})
+	}
+	// the same topic both ways would write every change straight back
+	if cfg.Topics.ProdToLocal != "" && cfg.Topics.ProdToLocal != cfg.Topics.LocalToProd {
+		configs = append(configs, config.KafkaConsumerConfig{
+			Name:    "prod-to-local",
+			Topics:  []string{cfg.Topics.ProdToLocal},
+			GroupID: cfg.GroupID + "-prod-to-local",
+			Target:  registry.TargetLocal,
+		})
+	}
+	return configs
+}
+
+// ReplayDLQ loads the configuration and publishes up to limit messages of
+// the dead-letter topic, all of them when limit is zero, back to the topic
+// they failed on
+func ReplayDLQ(limit int) error {
+	if err := config.Init(); err != nil {
+		return err
+	}
+	cfg, _ := config.Get()
+	if cfg.Kafka.Topics.DLQ == "" {
+		log.Println("[DLQ] kafka.topics.dlq is not configured, nothing to replay")
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     cfg.Kafka.Brokers,
+		Topic:       cfg.Kafka.Topics.DLQ,
+		GroupID:     cfg.Kafka.GroupID + "-dlq-replay",
+		StartOffset: kafka.FirstOffset,
+	})
+	defer reader.Close()
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Kafka.Brokers...),
+		RequiredAcks: kafka.RequireAll,
+	}
+	defer writer.Close()
+
+	replayed, err := consumer.ReplayDLQ(context.Background(), reader, writer, limit, dlqIdle)
+	log.Printf("[DLQ] replayed %d message(s)", replayed)
+	return err
 }
@@ -0,0 +1,53 @@
+package boot
+
+import (
+	"context"
+	"gold-gym-be/internal/config"
+	outboxData "gold-gym-be/internal/data/outbox"
+	"log"
+)
+
+const defaultOutboxBatch = 100
+
+type outboxRelay interface {
+	Relay(ctx context.Context, limit int, publish outboxData.PublishFunc) (int, error)
+}
+
+// startOutboxRelay publishes the outbox to the events topic every
+// relay_interval until ctx is done. One round publishes at most one event
+// per aggregate, so a tick keeps relaying until a round publishes nothing.
+func startOutboxRelay(ctx context.Context, cfg config.KafkaConfig, relay outboxRelay) {
+	if cfg.Topics.Events == "" {
+		log.Println("[BOOT] outbox relay disabled, kafka.topics.events is not configured")
+		return
+	}
+	batch := cfg.Outbox.BatchSize
+	if batch <= 0 {
+		batch = defaultOutboxBatch
+	}
+
+	writer := outboxData.NewKafkaWriter(cfg.Brokers, cfg.Topics.Events)
+	publisher := outboxData.NewPublisher(writer)
+	go func() {
+		<-ctx.Done()
+		writer.Close()
+	}()
+
+	runEvery(ctx, "outbox relay", cfg.Outbox.RelayInterval, func(ctx context.Context) {
+		total := 0
+		for ctx.Err() == nil {
+			n, err := relay.Relay(ctx, batch, publisher.Publish)
+			if err != nil {
+				log.Printf("[ERROR] [BOOT] outbox relay: %v", err)
+				break
+			}
+			if n == 0 {
+				break
+			}
+			total += n
+		}
+		if total > 0 {
+			log.Printf("[INFO] [BOOT] published %d outbox event(s)", total)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package boot
+
+import (
+	"context"
+	"gold-gym-be/internal/config"
+	elasticData "gold-gym-be/internal/data/elastic"
+	memberData "gold-gym-be/internal/data/member"
+	productData "gold-gym-be/internal/data/product"
+	salesData "gold-gym-be/internal/data/sales"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	elasticService "gold-gym-be/internal/service/elastic"
+	jaegerLog "gold-gym-be/pkg/log"
+	"log"
+
+	es "github.com/elastic/go-elasticsearch/v8"
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+type indexMigrator interface {
+	MigrateIndices(ctx context.Context) ([]elasticEntity.MigrationResult, error)
+}
+
+// searchIndices are the configured member, stock and sales aliases
+func searchIndices(cfg config.ElasticsearchConfig) elasticEntity.Indices {
+	return elasticEntity.Indices{
+		Members: cfg.MemberIndex,
+		Stock:   cfg.StockIndex,
+		Sales:   cfg.SalesIndex,
+	}
+}
+
+// newSearchService indexes and searches members, stock and sales, read from
+// db
+func newSearchService(esClient *es.Client, db *gorm.DB, indices elasticEntity.Indices, tracer opentracing.Tracer, logger jaegerLog.Factory) *elasticService.Service {
+	return elasticService.New(
+		elasticData.New(esClient, tracer, logger),
+		memberData.New(db, tracer, logger),
+		productData.New(db, tracer, logger),
+		salesData.New(db, tracer, logger),
+		indices,
+		tracer,
+		logger,
+	)
+}
+
+// startIndexMigration installs the index templates and rebuilds the member,
+// stock and sales indices that are missing or outdated, in the background so
+// a rebuild does not hold up startup. Change events indexed meanwhile are
+// caught up by the rebuild.
+func startIndexMigration(ctx context.Context, svc indexMigrator) {
+	go func() {
+		results, err := svc.MigrateIndices(ctx)
+		for _, result := range results {
+			switch {
+			case result.Reindexed:
+				log.Printf("[ES] %s migrated to template v%d, reindexed %d document(s) into %s, removed %v",
+					result.Alias, result.TemplateVersion, result.Reindex.Documents, result.Index, result.Reindex.Removed)
+			case result.Index != "":
+				log.Printf("[ES] %s (%s) is up to date with template v%d", result.Alias, result.Index, result.TemplateVersion)
+			}
+		}
+		if err != nil {
+			log.Printf("[ERROR] [ES] index migration: %v", err)
+		}
+	}()
+}
+
+// openSearch loads the configuration and opens what a reindex command needs
+func openSearch() (*elasticService.Service, error) {
+	if err := config.Init(); err != nil {
+		return nil, err
+	}
+	cfg, _ := config.Get()
+
+	db, _, err := openDatabases(cfg)
+	if err != nil {
+		return nil, err
+	}
+	esClient, err := es.NewClient(es.Config{
+		Addresses: cfg.Elasticsearch.Addresses,
+		Username:  cfg.Elasticsearch.Username,
+		Password:  cfg.Elasticsearch.Password,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSearchService(esClient, db, searchIndices(cfg.Elasticsearch), nil, jaegerLog.Factory{}), nil
+}
+
+// ReindexMembers loads the configuration and rebuilds the member index from
+// MySQL, swapping the member alias over once the new index is complete
+func ReindexMembers() error {
+	svc, err := openSearch()
+	if err != nil {
+		return err
+	}
+	result, err := svc.ReindexMembers(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("[ES] reindexed %d member(s) into %s, removed %v", result.Documents, result.Index, result.Removed)
+	return nil
+}
+
+// ReindexCatalogue loads the configuration and rebuilds the stock and sales
+// indices from MySQL, swapping each alias over once its new index is
+// complete
+func ReindexCatalogue() error {
+	svc, err := openSearch()
+	if err != nil {
+		return err
+	}
+	stock, err := svc.ReindexStock(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("[ES] reindexed %d stock variant(s) into %s, removed %v", stock.Documents, stock.Index, stock.Removed)
+
+	sales, err := svc.ReindexSales(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("[ES] reindexed %d sale(s) into %s, removed %v", sales.Documents, sales.Index, sales.Removed)
+	return nil
+}
@@ -0,0 +1,89 @@
+package boot
+
+import (
+	"context"
+	"errors"
+	"gold-gym-be/internal/config"
+	replicationData "gold-gym-be/internal/data/replication"
+	replicationEntity "gold-gym-be/internal/entity/replication"
+	"gold-gym-be/internal/registry"
+	replicationService "gold-gym-be/internal/service/replication"
+	jaegerLog "gold-gym-be/pkg/log"
+	"log"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+type reconciler interface {
+	Reconcile(ctx context.Context) ([]replicationEntity.Reconciliation, error)
+}
+
+// newReconciler compares the synced tables of local and prod
+func newReconciler(local, prod *gorm.DB, tables []replicationEntity.Table, tracer opentracing.Tracer, logger jaegerLog.Factory) replicationService.Service {
+	return replicationService.New(
+		replicationData.New(local, registry.TargetLocal, registry.TargetProd),
+		replicationData.New(prod, registry.TargetProd, registry.TargetLocal),
+		tables,
+		tracer,
+		logger,
+	)
+}
+
+// startReconcile reports the drift between local and prod every interval
+// until ctx is done
+func startReconcile(ctx context.Context, interval time.Duration, svc reconciler) {
+	runEvery(ctx, "sync reconciliation", interval, func(ctx context.Context) {
+		if _, err := reconcile(ctx, svc); err != nil {
+			log.Printf("[ERROR] [BOOT] sync reconciliation: %v", err)
+		}
+	})
+}
+
+// reconcile runs one reconciliation, logs every table that drifted and
+// returns how many did
+func reconcile(ctx context.Context, svc reconciler) (int, error) {
+	results, err := svc.Reconcile(ctx)
+	drifted := 0
+	for _, result := range results {
+		if len(result.Drift) == 0 {
+			continue
+		}
+		drifted++
+		log.Printf("[WARN] [SYNC] %s drifted, local=%d prod=%d rows, first differences: %v", result.Table, result.LocalRows, result.ProdRows, result.Drift)
+	}
+	return drifted, err
+}
+
+// Reconcile loads the configuration and reports the drift between local and
+// prod once
+func Reconcile() error {
+	if err := config.Init(); err != nil {
+		return err
+	}
+	cfg, _ := config.Get()
+	if cfg.Database.Production == "" {
+		return errors.New("database.production is not configured")
+	}
+
+	tables, err := registry.Configure(registry.Tables, cfg.Replication.Tables)
+	if err != nil {
+		return err
+	}
+	db, _, err := openDatabases(cfg)
+	if err != nil {
+		return err
+	}
+	dbprod, _, err := openDatabasesProd(cfg)
+	if err != nil {
+		return err
+	}
+
+	drifted, err := reconcile(context.Background(), newReconciler(db, dbprod, tables, nil, jaegerLog.Factory{}))
+	if err != nil {
+		return err
+	}
+	log.Printf("[SYNC] %d table(s) drifted", drifted)
+	return nil
+}
@@ -0,0 +1,60 @@
+package boot
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type stockJobs interface {
+	CheckLowStock(ctx context.Context) (int, error)
+	WriteOffExpiredBatches(ctx context.Context) (int, error)
+}
+
+// startStockJobs starts the low-stock check and the expired batch write-off,
+// each on its own interval, until ctx is done. A zero interval leaves that
+// job off.
+func startStockJobs(ctx context.Context, svc stockJobs, lowStockInterval, writeOffInterval time.Duration) {
+	runEvery(ctx, "low stock check", lowStockInterval, func(ctx context.Context) {
+		n, err := svc.CheckLowStock(ctx)
+		if err != nil {
+			log.Printf("[ERROR] [BOOT] low stock check: %v", err)
+			return
+		}
+		if n > 0 {
+			log.Printf("[INFO] [BOOT] low stock alert sent for %d product(s)", n)
+		}
+	})
+	runEvery(ctx, "expired batch write-off", writeOffInterval, func(ctx context.Context) {
+		n, err := svc.WriteOffExpiredBatches(ctx)
+		if err != nil {
+			log.Printf("[ERROR] [BOOT] expired batch write-off: %v", err)
+		}
+		if n > 0 {
+			log.Printf("[INFO] [BOOT] wrote off %d expired unit(s)", n)
+		}
+	})
+}
+
+func runEvery(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context)) {
+	if interval <= 0 {
+		log.Printf("[BOOT] %s disabled", name)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job(ctx)
+			}
+		}
+	}()
+
+	log.Printf("[BOOT] %s started, every %s", name, interval)
+}
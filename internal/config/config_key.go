@@ -1,12 +1,50 @@
 package config
 
+import "time"
+
 type KafkaConfig struct {
 	Brokers []string `yaml:"brokers"`
 	GroupID string   `yaml:"group_id"`
 	Topics  struct {
 		LocalToProd string `yaml:"local_to_prod"`
 		ProdToLocal string `yaml:"prod_to_local"`
+		// DLQ receives the CDC events that still fail after the retries
+		DLQ string `yaml:"dlq"`
+		// Events receives the domain events of the outbox
+		Events string `yaml:"events"`
 	} `yaml:"topics"`
+	Retry  KafkaRetryConfig  `yaml:"retry"`
+	Outbox KafkaOutboxConfig `yaml:"outbox"`
+	// Consumers binds topics to the database their events are applied to.
+	// When empty local_to_prod and prod_to_local are consumed, one worker
+	// each.
+	Consumers []KafkaConsumerConfig `yaml:"consumers"`
+}
+
+// KafkaConsumerConfig is one consumer group. Target is the database the
+// events are written to, "prod" or "local". Workers handle different rows in
+// parallel, the events of one row stay in order.
+type KafkaConsumerConfig struct {
+	Name    string   `yaml:"name"`
+	Topics  []string `yaml:"topics"`
+	GroupID string   `yaml:"group_id"`
+	Target  string   `yaml:"target"`
+	Workers int      `yaml:"workers"`
+}
+
+// KafkaOutboxConfig schedules the outbox relay. A zero interval or an empty
+// events topic disables it, events then wait in the outbox.
+type KafkaOutboxConfig struct {
+	RelayInterval time.Duration `yaml:"relay_interval"`
+	BatchSize     int           `yaml:"batch_size"`
+}
+
+// KafkaRetryConfig bounds the retries of a failing CDC event. Zero values
+// fall back to 5 attempts, waiting from 500ms doubling up to 30s.
+type KafkaRetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	Backoff     time.Duration `yaml:"backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
 }
 
 type (
@@ -21,13 +59,132 @@ type (
 		Redis         Redis               `yaml:"redis"`
 		Kafka         KafkaConfig         `yaml:"kafka"`
 		Elasticsearch ElasticsearchConfig `yaml:"elasticsearch"`
+		OIDC          OIDCConfig          `yaml:"oidc"`
+		JWT           JWTConfig           `yaml:"jwt"`
+		Idempotency   IdempotencyConfig   `yaml:"idempotency"`
+		RateLimit     RateLimitConfig     `yaml:"rate_limit"`
+		Partner       PartnerConfig       `yaml:"partner"`
+		StockAlert    StockAlertConfig    `yaml:"stock_alert"`
+		StockExpiry   StockExpiryConfig   `yaml:"stock_expiry"`
+		Valuation     ValuationConfig     `yaml:"valuation"`
+		SMTP          SMTPConfig          `yaml:"smtp"`
+		Replication   ReplicationConfig   `yaml:"replication"`
+	}
+
+	// ReplicationConfig overrides the sync direction of replicated tables,
+	// table name to both, local_to_prod, prod_to_local or none. The
+	// reconciliation compares local and prod every reconcile_interval, zero
+	// disables it.
+	ReplicationConfig struct {
+		Tables            map[string]string `yaml:"tables"`
+		ReconcileInterval time.Duration     `yaml:"reconcile_interval"`
+	}
+
+	// StockAlertConfig schedules the low-stock checker. A zero interval
+	// disables it.
+	StockAlertConfig struct {
+		Interval   time.Duration `yaml:"interval"`
+		Recipients []string      `yaml:"recipients"`
+	}
+
+	// StockExpiryConfig schedules the write-off of expired batches. A zero
+	// interval disables it.
+	StockExpiryConfig struct {
+		WriteOffInterval time.Duration `yaml:"write_off_interval"`
+	}
+
+	// ValuationConfig picks the default inventory valuation method of the
+	// finance reports, "average" or "fifo"
+	ValuationConfig struct {
+		Method string `yaml:"method"`
+	}
+
+	// SMTPConfig is the outgoing mail server. The password is read from the
+	// environment variable named by password_env.
+	SMTPConfig struct {
+		Host        string `yaml:"host"`
+		Port        int    `yaml:"port"`
+		Username    string `yaml:"username"`
+		PasswordEnv string `yaml:"password_env"`
+		From        string `yaml:"from"`
+	}
+
+	// PartnerConfig tunes the B2B partner API. X-TIMESTAMP values further than
+	// timestamp_skew from the server clock are rejected.
+	PartnerConfig struct {
+		TokenTTL      time.Duration `yaml:"token_ttl"`
+		TimestampSkew time.Duration `yaml:"timestamp_skew"`
+	}
+
+	// RateLimitConfig picks the counter backend (redis or memory) and the
+	// rules. The first rule matching method, path and type wins; requests
+	// matching none fall back to default.
+	RateLimitConfig struct {
+		Backend string          `yaml:"backend"`
+		Default RateLimitRule   `yaml:"default"`
+		Rules   []RateLimitRule `yaml:"rules"`
+	}
+
+	// RateLimitRule allows limit requests per window for each key_by value
+	// (ip, user or client). Empty method, path or type match anything and a
+	// path ending in * matches by prefix.
+	RateLimitRule struct {
+		Name      string        `yaml:"name"`
+		Method    string        `yaml:"method"`
+		Path      string        `yaml:"path"`
+		Type      string        `yaml:"type"`
+		Limit     int           `yaml:"limit"`
+		Window    time.Duration `yaml:"window"`
+		KeyBy     string        `yaml:"key_by"`
+		Algorithm string        `yaml:"algorithm"`
+	}
+
+	// IdempotencyConfig ...
+	IdempotencyConfig struct {
+		TTL     time.Duration `yaml:"ttl"`
+		LockTTL time.Duration `yaml:"lock_ttl"`
+	}
+
+	// JWTConfig lists the access token signing keys. Every key is published in
+	// the JWKS and accepted for verification; only active_kid signs new tokens.
+	JWTConfig struct {
+		ActiveKID string         `yaml:"active_kid"`
+		Keys      []JWTKeyConfig `yaml:"keys"`
+	}
+
+	// JWTKeyConfig points at the private key material, either a file (PEM or
+	// base64) or the name of an environment variable holding it
+	JWTKeyConfig struct {
+		KID            string `yaml:"kid"`
+		Algorithm      string `yaml:"algorithm"`
+		PrivateKeyFile string `yaml:"private_key_file"`
+		PrivateKeyEnv  string `yaml:"private_key_env"`
+	}
+
+	// OIDCConfig holds the social login providers keyed by the name used in the URL
+	OIDCConfig struct {
+		Providers map[string]OIDCProviderConfig `yaml:"providers"`
+	}
+
+	// OIDCProviderConfig ...
+	OIDCProviderConfig struct {
+		Issuer       string   `yaml:"issuer"`
+		ClientID     string   `yaml:"client_id"`
+		ClientSecret string   `yaml:"client_secret"`
+		RedirectURL  string   `yaml:"redirect_url"`
+		Scopes       []string `yaml:"scopes"`
 	}
 
-	// ElasticsearchConfig ...
+	// ElasticsearchConfig ... member_index, stock_index and sales_index are
+	// the aliases the documents are read and written through, a reindex
+	// points them at a new index
 	ElasticsearchConfig struct {
-		Addresses []string `yaml:"addresses"`
-		Username  string   `yaml:"username"`
-		Password  string   `yaml:"password"`
+		Addresses   []string `yaml:"addresses"`
+		Username    string   `yaml:"username"`
+		Password    string   `yaml:"password"`
+		MemberIndex string   `yaml:"member_index"`
+		StockIndex  string   `yaml:"stock_index"`
+		SalesIndex  string   `yaml:"sales_index"`
 	}
 
 	// ServerConfig ...
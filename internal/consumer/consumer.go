@@ -2,51 +2,266 @@ package consumer
 
 import (
 	"context"
-	"encoding/json"
 	"gold-gym-be/internal/registry"
+	"hash/fnv"
 	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
-type CDCEvent struct {
-	Payload struct {
-		Op     string                 `json:"op"`
-		After  map[string]interface{} `json:"after"`
-		Before map[string]interface{} `json:"before"`
-		Source map[string]interface{} `json:"source"`
-	} `json:"payload"`
-}
-
-func ConsumeLoop(brokers []string, topic, groupID string, reg *registry.Registry) {
-	// topic = "mysql_server.u868654674_gold_gym_bez.data_peserta"
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     brokers,
-		Topic:       topic,
-		GroupID:     groupID,
-		StartOffset: kafka.FirstOffset,
-	})
-	defer reader.Close()
+// MessageReader is the part of kafka.Reader the consumer uses
+type MessageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// MessageWriter is the part of kafka.Writer the dead-letter topic uses
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Handlers looks up the CDC handler of a table, *registry.Registry is one
+type Handlers interface {
+	GetHandler(table string) (registry.HandlerFunc, bool)
+}
+
+// RetryPolicy bounds how often a failing event is handled again before it
+// goes to the dead-letter topic. The wait starts at Backoff and doubles up
+// to MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 5
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 500 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	return p
+}
+
+// wait sleeps before the next attempt, false when ctx is done first
+func (p RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+const (
+	// workerQueue is how many fetched messages a worker may have waiting
+	workerQueue = 16
+	// commitTimeout bounds a commit, which still runs during shutdown
+	commitTimeout = 5 * time.Second
+)
+
+// Options tune a consumer. Without a DLQTopic a failing or undecodable event
+// is retried until it succeeds, blocking its worker and the commits of its
+// partition rather than losing the event.
+type Options struct {
+	Retry    RetryPolicy
+	DLQTopic string
+}
+
+// Consumer handles CDC events at least once: a message is committed only
+// after its handler succeeded or it was stored in the dead-letter topic.
+// Messages are spread over workers by key, so the events of one row are
+// handled one after the other while different rows run in parallel.
+type Consumer struct {
+	reader   MessageReader
+	dlq      MessageWriter
+	handlers Handlers
+	groupID  string
+	retry    RetryPolicy
+	workers  int
+
+	offsets *offsetTracker
+	ready   atomic.Bool
+}
+
+// NewConsumer ...
+func NewConsumer(reader MessageReader, dlq MessageWriter, handlers Handlers, groupID string, retry RetryPolicy) *Consumer {
+	return &Consumer{
+		reader:   reader,
+		dlq:      dlq,
+		handlers: handlers,
+		groupID:  groupID,
+		retry:    retry.withDefaults(),
+		workers:  1,
+		offsets:  newOffsetTracker(),
+	}
+}
+
+// Ready reports whether the last probe of the Supervisor reached the brokers
+// and topics
+func (c *Consumer) Ready() bool {
+	return c.ready.Load()
+}
+
+func (c *Consumer) setReady(ready bool) {
+	c.ready.Store(ready)
+	value := 0.0
+	if ready {
+		value = 1
+	}
+	consumerReady.WithLabelValues(c.groupID).Set(value)
+}
+
+// Run fetches messages and hands them to the workers until ctx is done,
+// then waits for the workers to finish what they were given
+func (c *Consumer) Run(ctx context.Context) {
+	queues := make([]chan kafka.Message, c.workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, workerQueue)
+		wg.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer wg.Done()
+			c.work(ctx, queue)
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+		c.setReady(false)
+	}()
+
 	for {
-		msg, err := reader.ReadMessage(context.Background())
+		msg, err := c.reader.FetchMessage(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
 			log.Println("kafka read error:", err)
 			time.Sleep(time.Second)
 			continue
 		}
-		var ev CDCEvent
-		if err := json.Unmarshal(msg.Value, &ev); err != nil {
-			log.Println("unmarshal CDC error:", err)
+		observeLag(c.groupID, msg)
+
+		c.offsets.add(msg)
+		select {
+		case queues[worker(msg, c.workers)] <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// work handles the messages of one worker in order. After a message could
+// not be finished because of shutdown nothing later is committed, as the
+// offset of the unfinished one is still in flight.
+func (c *Consumer) work(ctx context.Context, queue <-chan kafka.Message) {
+	for msg := range queue {
+		if !c.process(ctx, msg) {
+			// shutting down: the message stays uncommitted and is
+			// delivered again
 			continue
 		}
-		table, _ := ev.Payload.Source["table"].(string)
-		if handler, ok := reg.GetHandler(table); ok {
-			if err := handler(context.Background(), ev.Payload.Op, ev.Payload.After, ev.Payload.Before); err != nil {
-				log.Printf("handler error table=%s op=%s: %v", table, ev.Payload.Op, err)
-			}
-		} else {
-			log.Printf("no handler for table=%s op=%s", table, ev.Payload.Op)
+		commit, ok := c.offsets.complete(msg)
+		if !ok {
+			continue
+		}
+		commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commitTimeout)
+		if err := c.reader.CommitMessages(commitCtx, commit); err != nil {
+			log.Printf("kafka commit error topic=%s partition=%d offset=%d: %v", commit.Topic, commit.Partition, commit.Offset, err)
+		}
+		cancel()
+	}
+}
+
+// worker picks the worker of msg by key, messages without a key go by
+// partition
+func worker(msg kafka.Message, workers int) int {
+	if workers <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(msg.Topic))
+	if len(msg.Key) > 0 {
+		h.Write(msg.Key)
+	} else {
+		h.Write([]byte(strconv.Itoa(msg.Partition)))
+	}
+	return int(h.Sum32() % uint32(workers))
+}
+
+// process handles one message and reports whether it may be committed
+func (c *Consumer) process(ctx context.Context, msg kafka.Message) bool {
+	var change Change
+	for attempt := 1; ; attempt++ {
+		var err error
+		if change, err = DecodeChange(msg.Value); err == nil {
+			break
+		}
+		log.Printf("decode CDC error topic=%s partition=%d offset=%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		// retrying cannot fix a malformed message, but without a
+		// dead-letter topic it is held uncommitted until someone looks
+		// at it rather than lost
+		if c.dlq != nil {
+			return c.deadLetter(ctx, msg, err, 0)
+		}
+		if !c.retry.wait(ctx, attempt) {
+			return false
+		}
+	}
+	if change.Tombstone {
+		// follows a delete, the delete event did the work
+		countMessage(c.groupID, msg, outcomeSkipped)
+		return true
+	}
+	if change.Op == OpTruncate {
+		log.Printf("[CDC] table=%s truncated at the source, not replicated", change.Table)
+		countMessage(c.groupID, msg, outcomeSkipped)
+		return true
+	}
+
+	handler, ok := c.handlers.GetHandler(change.Table)
+	if !ok {
+		log.Printf("no handler for table=%s op=%s", change.Table, change.Op)
+		countMessage(c.groupID, msg, outcomeSkipped)
+		return true
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, change.Op, change.After, change.Before)
+		if err == nil {
+			countMessage(c.groupID, msg, outcomeHandled)
+			return true
+		}
+		consumerHandlerErrorsTotal.WithLabelValues(c.groupID, msg.Topic).Inc()
+		log.Printf("handler error table=%s op=%s attempt=%d: %v", change.Table, change.Op, attempt, err)
+
+		if attempt >= c.retry.MaxAttempts && c.dlq != nil {
+			return c.deadLetter(ctx, msg, err, attempt)
+		}
+		if !c.retry.wait(ctx, attempt) {
+			return false
 		}
 	}
 }
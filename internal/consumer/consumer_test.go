@@ -0,0 +1,201 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/registry"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReader mengembalikan pesan berurutan lalu menunggu sampai ctx
+// selesai. Bila stop diisi, consumer dihentikan setelah commit offset
+// stopAt.
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []int64
+	stop      context.CancelFunc
+	stopAt    int64
+}
+
+func (f *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	f.mu.Lock()
+	if len(f.messages) == 0 {
+		f.mu.Unlock()
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+	msg := f.messages[0]
+	f.messages = f.messages[1:]
+	f.mu.Unlock()
+	return msg, nil
+}
+
+func (f *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, msg := range msgs {
+		f.committed = append(f.committed, msg.Offset)
+		if f.stop != nil && msg.Offset == f.stopAt {
+			f.stop()
+		}
+	}
+	return nil
+}
+
+func (f *fakeReader) Close() error { return nil }
+
+type fakeWriter struct {
+	written []kafka.Message
+	err     error
+}
+
+func (f *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.written = append(f.written, msgs...)
+	return nil
+}
+
+// fakeHandlers gagal sebanyak failures kali sebelum berhasil
+type fakeHandlers struct {
+	failures int
+	calls    int
+}
+
+func (f *fakeHandlers) GetHandler(table string) (registry.HandlerFunc, bool) {
+	if table != "data_peserta" {
+		return nil, false
+	}
+	return func(ctx context.Context, op string, after, before map[string]interface{}) error {
+		f.calls++
+		if f.calls <= f.failures {
+			return errors.New("db down")
+		}
+		return nil
+	}, true
+}
+
+func event(offset int64, table string) kafka.Message {
+	return kafka.Message{
+		Topic:  "cdc.data_peserta",
+		Offset: offset,
+		Key:    []byte(`{"gold_id":7}`),
+		Value:  []byte(`{"payload":{"op":"u","after":{"gold_id":7},"source":{"table":"` + table + `"}}}`),
+	}
+}
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestConsumerRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		msg        kafka.Message
+		failures   int
+		calls      int
+		deadLetter bool
+	}{
+		{name: "berhasil langsung", msg: event(1, "data_peserta"), calls: 1},
+		{name: "berhasil setelah retry", msg: event(1, "data_peserta"), failures: 2, calls: 3},
+		{name: "gagal terus masuk DLQ", msg: event(1, "data_peserta"), failures: 10, calls: 3, deadLetter: true},
+		{name: "json rusak langsung masuk DLQ", msg: kafka.Message{Topic: "cdc.data_peserta", Offset: 1, Value: []byte("{rusak")}, deadLetter: true},
+		{name: "tabel tanpa handler", msg: event(1, "users")},
+		{name: "tombstone", msg: kafka.Message{Topic: "cdc.data_peserta", Offset: 1}},
+		{name: "truncate dilewati", msg: kafka.Message{Topic: "cdc.data_peserta", Offset: 1, Value: []byte(`{"payload":{"op":"t","source":{"table":"data_peserta"}}}`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			reader := &fakeReader{messages: []kafka.Message{tt.msg}, stop: cancel, stopAt: 1}
+			dlq := &fakeWriter{}
+			handlers := &fakeHandlers{failures: tt.failures}
+
+			NewConsumer(reader, dlq, handlers, "goldgym-sync", fastRetry).Run(ctx)
+
+			assert.Equal(t, tt.calls, handlers.calls)
+			assert.Equal(t, []int64{1}, reader.committed)
+			if !tt.deadLetter {
+				assert.Empty(t, dlq.written)
+				return
+			}
+			require.Len(t, dlq.written, 1)
+			assert.Equal(t, tt.msg.Value, dlq.written[0].Value)
+			original, err := Original(dlq.written[0])
+			require.NoError(t, err)
+			assert.Equal(t, "cdc.data_peserta", original.Topic)
+		})
+	}
+}
+
+func TestConsumerRunKeepsFailingEventUncommitted(t *testing.T) {
+	// tanpa DLQ event yang gagal tidak di-commit, saat berhenti event
+	// tersebut akan dikirim ulang
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	reader := &fakeReader{messages: []kafka.Message{event(1, "data_peserta")}}
+	handlers := &fakeHandlers{failures: 1 << 30}
+
+	NewConsumer(reader, nil, handlers, "goldgym-sync", fastRetry).Run(ctx)
+
+	assert.Greater(t, handlers.calls, fastRetry.MaxAttempts)
+	assert.Empty(t, reader.committed)
+
+	// begitu juga pesan yang tidak bisa di-decode
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	reader = &fakeReader{messages: []kafka.Message{{Topic: "cdc.data_peserta", Offset: 1, Value: []byte("{rusak")}}}
+	NewConsumer(reader, nil, &fakeHandlers{}, "goldgym-sync", fastRetry).Run(ctx)
+	assert.Empty(t, reader.committed)
+
+	// DLQ yang tidak bisa ditulis juga menahan commit
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	reader = &fakeReader{messages: []kafka.Message{event(1, "data_peserta")}}
+	NewConsumer(reader, &fakeWriter{err: errors.New("broker down")}, &fakeHandlers{failures: 1 << 30}, "goldgym-sync", fastRetry).Run(ctx)
+	assert.Empty(t, reader.committed)
+}
+
+func TestReplayDLQ(t *testing.T) {
+	failedAt := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	first := event(4, "data_peserta")
+	first.Headers = []kafka.Header{{Key: "trace-id", Value: []byte("abc")}}
+	dead := []kafka.Message{
+		DeadLetter(first, "goldgym-sync", errors.New("db down"), 3, failedAt),
+		DeadLetter(event(5, "data_peserta"), "goldgym-sync", errors.New("db down"), 3, failedAt),
+	}
+	dead[0].Offset, dead[1].Offset = 10, 11
+
+	reader := &fakeReader{messages: dead}
+	writer := &fakeWriter{}
+	replayed, err := ReplayDLQ(context.Background(), reader, writer, 0, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Equal(t, []int64{10, 11}, reader.committed)
+	require.Len(t, writer.written, 2)
+	assert.Equal(t, "cdc.data_peserta", writer.written[0].Topic)
+	assert.Equal(t, first.Key, writer.written[0].Key)
+	assert.Equal(t, first.Value, writer.written[0].Value)
+	assert.Equal(t, []kafka.Header{{Key: "trace-id", Value: []byte("abc")}}, writer.written[0].Headers)
+
+	// limit
+	reader = &fakeReader{messages: dead}
+	replayed, err = ReplayDLQ(context.Background(), reader, &fakeWriter{}, 1, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, []int64{10}, reader.committed)
+
+	// pesan tanpa topic asal tidak di-commit
+	reader = &fakeReader{messages: []kafka.Message{{Offset: 12, Value: []byte("{}")}}}
+	_, err = ReplayDLQ(context.Background(), reader, &fakeWriter{}, 0, 10*time.Millisecond)
+	assert.Error(t, err)
+	assert.Empty(t, reader.committed)
+}
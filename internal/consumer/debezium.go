@@ -0,0 +1,302 @@
+package consumer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// Debezium and Kafka Connect logical types
+const (
+	logicalDate             = "io.debezium.time.Date"
+	logicalTimestamp        = "io.debezium.time.Timestamp"
+	logicalMicroTimestamp   = "io.debezium.time.MicroTimestamp"
+	logicalNanoTimestamp    = "io.debezium.time.NanoTimestamp"
+	logicalZonedTimestamp   = "io.debezium.time.ZonedTimestamp"
+	logicalTime             = "io.debezium.time.Time"
+	logicalMicroTime        = "io.debezium.time.MicroTime"
+	logicalVariableDecimal  = "io.debezium.data.VariableScaleDecimal"
+	logicalConnectDate      = "org.apache.kafka.connect.data.Date"
+	logicalConnectTimestamp = "org.apache.kafka.connect.data.Timestamp"
+	logicalConnectDecimal   = "org.apache.kafka.connect.data.Decimal"
+	logicalConnectTime      = "org.apache.kafka.connect.data.Time"
+)
+
+const (
+	// OpSnapshot marks a row read by the initial snapshot
+	OpSnapshot = "r"
+	// OpTruncate is sent when a table is truncated, it carries no row
+	OpTruncate = "t"
+
+	millisPerDay    = 24 * 60 * 60 * 1000
+	timeOfDayLayout = "15:04:05.999999"
+)
+
+// Change is a decoded Debezium change event. Values of after and before
+// follow the schema of the event when it has one:
+//   - integers are int64, floats float64 and bytes []byte
+//   - Date and Timestamp columns are time.Time holding the wall clock of the
+//     column in time.Local, as the database connections use loc=Local
+//   - ZonedTimestamp columns are time.Time of the instant in time.Local
+//   - Time columns are "15:04:05" strings
+//   - Decimal columns are exact decimal strings such as "25000.00"
+//
+// Without a schema integral numbers are int64 and the others float64.
+type Change struct {
+	Op     string
+	Table  string
+	After  map[string]interface{}
+	Before map[string]interface{}
+	Source map[string]interface{}
+	// Tombstone is the empty message Kafka compaction uses to drop the key of
+	// a deleted row, it carries no row
+	Tombstone bool
+}
+
+// Snapshot reports whether the event is a row of the initial snapshot
+func (c Change) Snapshot() bool {
+	return c.Op == OpSnapshot
+}
+
+// CDCEvent is the JSON envelope of a Debezium message, the schema block is
+// present when the connector runs with schemas.enable
+type CDCEvent struct {
+	Schema  *schemaField `json:"schema"`
+	Payload *struct {
+		Op     string                     `json:"op"`
+		After  map[string]json.RawMessage `json:"after"`
+		Before map[string]json.RawMessage `json:"before"`
+		Source map[string]interface{}     `json:"source"`
+	} `json:"payload"`
+}
+
+// schemaField is one node of a Kafka Connect schema
+type schemaField struct {
+	Type       string            `json:"type"`
+	Name       string            `json:"name"`
+	Field      string            `json:"field"`
+	Optional   bool              `json:"optional"`
+	Parameters map[string]string `json:"parameters"`
+	Fields     []schemaField     `json:"fields"`
+	Items      *schemaField      `json:"items"`
+}
+
+func (s *schemaField) child(name string) *schemaField {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Fields {
+		if s.Fields[i].Field == name {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+var errDecimal = errors.New("invalid decimal")
+
+// DecodeChange decodes a Debezium message value, an empty value or a null
+// payload is a tombstone
+func DecodeChange(value []byte) (Change, error) {
+	if len(bytes.TrimSpace(value)) == 0 || bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		return Change{Tombstone: true}, nil
+	}
+
+	var ev CDCEvent
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	if err := dec.Decode(&ev); err != nil {
+		return Change{}, err
+	}
+	if ev.Payload == nil {
+		return Change{Tombstone: true}, nil
+	}
+
+	change := Change{
+		Op:     ev.Payload.Op,
+		Source: ev.Payload.Source,
+	}
+	change.Table, _ = change.Source["table"].(string)
+
+	var err error
+	if change.After, err = decodeRow(ev.Payload.After, ev.Schema.child("after")); err != nil {
+		return Change{}, fmt.Errorf("after: %w", err)
+	}
+	if change.Before, err = decodeRow(ev.Payload.Before, ev.Schema.child("before")); err != nil {
+		return Change{}, fmt.Errorf("before: %w", err)
+	}
+	return change, nil
+}
+
+func decodeRow(raw map[string]json.RawMessage, schema *schemaField) (map[string]interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	row := make(map[string]interface{}, len(raw))
+	for column, value := range raw {
+		decoded, err := decodeValue(value, schema.child(column))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", column, err)
+		}
+		row[column] = decoded
+	}
+	return row, nil
+}
+
+// decodeValue converts one column to the Go value of its schema type, or by
+// its JSON shape when there is no schema
+func decodeValue(raw json.RawMessage, schema *schemaField) (interface{}, error) {
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	if schema == nil {
+		return plain(value), nil
+	}
+
+	switch schema.Name {
+	case logicalDate, logicalConnectDate:
+		days, err := integer(value)
+		if err != nil {
+			return nil, err
+		}
+		return wallClock(time.UnixMilli(days * millisPerDay)), nil
+	case logicalTimestamp, logicalConnectTimestamp:
+		millis, err := integer(value)
+		if err != nil {
+			return nil, err
+		}
+		return wallClock(time.UnixMilli(millis)), nil
+	case logicalMicroTimestamp:
+		micros, err := integer(value)
+		if err != nil {
+			return nil, err
+		}
+		return wallClock(time.UnixMicro(micros)), nil
+	case logicalNanoTimestamp:
+		nanos, err := integer(value)
+		if err != nil {
+			return nil, err
+		}
+		return wallClock(time.Unix(0, nanos)), nil
+	case logicalZonedTimestamp:
+		s, _ := value.(string)
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, err
+		}
+		return t.Local(), nil
+	case logicalTime, logicalConnectTime:
+		millis, err := integer(value)
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMilli(millis).UTC().Format(timeOfDayLayout), nil
+	case logicalMicroTime:
+		micros, err := integer(value)
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMicro(micros).UTC().Format(timeOfDayLayout), nil
+	case logicalConnectDecimal:
+		scale, err := strconv.Atoi(schema.Parameters["scale"])
+		if err != nil {
+			return nil, errDecimal
+		}
+		return decimal(value, scale)
+	case logicalVariableDecimal:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errDecimal
+		}
+		scale, err := integer(fields["scale"])
+		if err != nil {
+			return nil, errDecimal
+		}
+		return decimal(fields["value"], int(scale))
+	}
+
+	switch schema.Type {
+	case "int8", "int16", "int32", "int64":
+		return integer(value)
+	case "float32", "float64":
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", value)
+		}
+		return n.Float64()
+	case "bytes":
+		s, _ := value.(string)
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return plain(value), nil
+}
+
+// wallClock moves the UTC reading of a Debezium time, which is the wall
+// clock of the column, into time.Local
+func wallClock(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
+
+// decimal turns the base64 big-endian two's complement unscaled value of a
+// Connect Decimal into a decimal string
+func decimal(value interface{}, scale int) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", errDecimal
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return "", errDecimal
+	}
+
+	unscaled := new(big.Int).SetBytes(b)
+	if b[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	if scale <= 0 {
+		return unscaled.Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-scale)), nil)).String(), nil
+	}
+	return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)).FloatString(scale), nil
+}
+
+func integer(value interface{}) (int64, error) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("%v is not an integer", value)
+	}
+	return n.Int64()
+}
+
+// plain replaces the json.Number values of a schemaless value with int64
+// when integral and float64 otherwise
+func plain(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = plain(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = plain(item)
+		}
+	}
+	return value
+}
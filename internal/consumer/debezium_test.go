@@ -0,0 +1,107 @@
+package consumer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pesan Debezium dengan blok schema: tanggal sebagai hari sejak epoch,
+// datetime sebagai milidetik dan decimal sebagai base64
+const withSchema = `{
+  "schema": {"type": "struct", "fields": [
+    {"type": "struct", "field": "before", "optional": true, "fields": [
+      {"type": "int32", "field": "gold_id"}
+    ]},
+    {"type": "struct", "field": "after", "optional": true, "fields": [
+      {"type": "int32", "field": "gold_id"},
+      {"type": "string", "field": "gold_nama"},
+      {"type": "int32", "field": "gold_tanggal_lahir", "name": "io.debezium.time.Date"},
+      {"type": "int64", "field": "gold_updated_at", "name": "io.debezium.time.Timestamp"},
+      {"type": "string", "field": "gold_confirmed_at", "name": "io.debezium.time.ZonedTimestamp"},
+      {"type": "bytes", "field": "gold_harga", "name": "org.apache.kafka.connect.data.Decimal", "parameters": {"scale": "2"}},
+      {"type": "bytes", "field": "gold_saldo", "name": "org.apache.kafka.connect.data.Decimal", "parameters": {"scale": "2"}},
+      {"type": "struct", "field": "gold_rasio", "name": "io.debezium.data.VariableScaleDecimal"},
+      {"type": "int64", "field": "gold_jam", "name": "io.debezium.time.MicroTime"},
+      {"type": "double", "field": "gold_berat"},
+      {"type": "int16", "field": "gold_aktif"}
+    ]},
+    {"type": "struct", "field": "source"},
+    {"type": "string", "field": "op"}
+  ]},
+  "payload": {
+    "before": {"gold_id": 7},
+    "after": {
+      "gold_id": 7,
+      "gold_nama": "Budi",
+      "gold_tanggal_lahir": 20745,
+      "gold_updated_at": 1792402200000,
+      "gold_confirmed_at": "2026-10-19T02:30:00Z",
+      "gold_harga": "JiWg",
+      "gold_saldo": "/2o=",
+      "gold_rasio": {"scale": 3, "value": "MDk="},
+      "gold_jam": 34200000000,
+      "gold_berat": 70.5,
+      "gold_aktif": 1
+    },
+    "source": {"table": "data_peserta", "snapshot": "false"},
+    "op": "u"
+  }
+}`
+
+func TestDecodeChangeWithSchema(t *testing.T) {
+	change, err := DecodeChange([]byte(withSchema))
+	require.NoError(t, err)
+
+	assert.Equal(t, "u", change.Op)
+	assert.Equal(t, "data_peserta", change.Table)
+	assert.False(t, change.Snapshot())
+	assert.False(t, change.Tombstone)
+	assert.Equal(t, map[string]interface{}{"gold_id": int64(7)}, change.Before)
+
+	after := change.After
+	assert.Equal(t, int64(7), after["gold_id"])
+	assert.Equal(t, "Budi", after["gold_nama"])
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local), after["gold_tanggal_lahir"])
+	// datetime tetap jam dinding yang sama, bukan digeser zona waktu
+	assert.Equal(t, time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local), after["gold_updated_at"])
+	assert.True(t, time.Date(2026, 10, 19, 2, 30, 0, 0, time.UTC).Equal(after["gold_confirmed_at"].(time.Time)))
+	assert.Equal(t, "25000.00", after["gold_harga"])
+	assert.Equal(t, "-1.50", after["gold_saldo"])
+	assert.Equal(t, "12.345", after["gold_rasio"])
+	assert.Equal(t, "09:30:00", after["gold_jam"])
+	assert.Equal(t, 70.5, after["gold_berat"])
+	assert.Equal(t, int64(1), after["gold_aktif"])
+}
+
+func TestDecodeChange(t *testing.T) {
+	t.Run("tanpa schema", func(t *testing.T) {
+		change, err := DecodeChange([]byte(`{"payload":{"op":"r","after":{"gold_id":7,"gold_berat":70.5,"gold_nama":"Budi"},"source":{"table":"data_peserta"}}}`))
+		require.NoError(t, err)
+		assert.True(t, change.Snapshot())
+		assert.Nil(t, change.Before)
+		assert.Equal(t, map[string]interface{}{"gold_id": int64(7), "gold_berat": 70.5, "gold_nama": "Budi"}, change.After)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		change, err := DecodeChange([]byte(`{"payload":{"op":"d","before":{"gold_id":7},"after":null,"source":{"table":"data_peserta"}}}`))
+		require.NoError(t, err)
+		assert.Nil(t, change.After)
+		assert.Equal(t, int64(7), change.Before["gold_id"])
+	})
+
+	for _, value := range []string{"", "null", `{"schema":null,"payload":null}`} {
+		change, err := DecodeChange([]byte(value))
+		require.NoError(t, err)
+		assert.True(t, change.Tombstone, value)
+	}
+
+	_, err := DecodeChange([]byte("{rusak"))
+	assert.Error(t, err)
+
+	_, err = DecodeChange([]byte(`{"schema":{"fields":[{"field":"after","fields":[{"type":"bytes","field":"gold_harga","name":"org.apache.kafka.connect.data.Decimal","parameters":{"scale":"2"}}]}]},
+		"payload":{"op":"c","after":{"gold_harga":"%%%"},"source":{"table":"subscription_detail"}}}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers added to a dead-lettered message, next to the original headers
+const (
+	HeaderOriginalTopic     = "x-dlq-original-topic"
+	HeaderOriginalPartition = "x-dlq-original-partition"
+	HeaderOriginalOffset    = "x-dlq-original-offset"
+	HeaderGroupID           = "x-dlq-group-id"
+	HeaderError             = "x-dlq-error"
+	HeaderAttempts          = "x-dlq-attempts"
+	HeaderFailedAt          = "x-dlq-failed-at"
+
+	dlqHeaderPrefix = "x-dlq-"
+)
+
+var errNoOriginalTopic = errors.New("dead-lettered message has no original topic")
+
+// deadLetter stores msg with why it failed in the dead-letter topic, the
+// consumer must have one. The write is retried until it succeeds, false when
+// ctx is done first.
+func (c *Consumer) deadLetter(ctx context.Context, msg kafka.Message, cause error, attempts int) bool {
+	dead := DeadLetter(msg, c.groupID, cause, attempts, time.Now())
+	for attempt := 1; ; attempt++ {
+		err := c.dlq.WriteMessages(ctx, dead)
+		if err == nil {
+			log.Printf("[DLQ] stored topic=%s partition=%d offset=%d: %v", msg.Topic, msg.Partition, msg.Offset, cause)
+			countMessage(c.groupID, msg, outcomeDeadLettered)
+			return true
+		}
+		log.Printf("[DLQ] write error attempt=%d: %v", attempt, err)
+		if !c.retry.wait(ctx, attempt) {
+			return false
+		}
+	}
+}
+
+// DeadLetter builds the dead-letter message of msg: the original key, value
+// and headers plus headers telling where it came from and why it failed
+func DeadLetter(msg kafka.Message, groupID string, cause error, attempts int, failedAt time.Time) kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+7)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderGroupID, Value: []byte(groupID)},
+		kafka.Header{Key: HeaderError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderFailedAt, Value: []byte(failedAt.UTC().Format(time.RFC3339))},
+	)
+	return kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+// ReplayDLQ publishes up to limit dead-lettered messages, all of them when
+// limit is zero, back to their original topic and commits them in the
+// dead-letter topic. It stops when no message arrives for idle. The writer
+// must not have a topic of its own.
+func ReplayDLQ(ctx context.Context, reader MessageReader, writer MessageWriter, limit int, idle time.Duration) (int, error) {
+	var replayed int
+	for limit <= 0 || replayed < limit {
+		fetchCtx, cancel := context.WithTimeout(ctx, idle)
+		msg, err := reader.FetchMessage(fetchCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				return replayed, nil
+			}
+			return replayed, err
+		}
+
+		original, err := Original(msg)
+		if err != nil {
+			return replayed, err
+		}
+		if err := writer.WriteMessages(ctx, original); err != nil {
+			return replayed, err
+		}
+		if err := reader.CommitMessages(ctx, msg); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// Original rebuilds the message a dead-letter message was made of
+func Original(dead kafka.Message) (kafka.Message, error) {
+	var (
+		topic   string
+		headers []kafka.Header
+	)
+	for _, header := range dead.Headers {
+		if header.Key == HeaderOriginalTopic {
+			topic = string(header.Value)
+		}
+		if !strings.HasPrefix(header.Key, dlqHeaderPrefix) {
+			headers = append(headers, header)
+		}
+	}
+	if topic == "" {
+		return kafka.Message{}, errNoOriginalTopic
+	}
+	return kafka.Message{
+		Topic:   topic,
+		Key:     dead.Key,
+		Value:   dead.Value,
+		Headers: headers,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package consumer
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages behind the end of the partition when the last message was fetched.",
+	}, []string{"group", "topic", "partition"})
+
+	consumerMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_total",
+		Help: "Total number of CDC messages handled, by outcome.",
+	}, []string{"group", "topic", "outcome"})
+
+	consumerHandlerErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_handler_errors_total",
+		Help: "Total number of failed CDC handler attempts.",
+	}, []string{"group", "topic"})
+
+	consumerReady = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_ready",
+		Help: "1 when the consumer reaches its brokers and topics, 0 otherwise.",
+	}, []string{"group"})
+)
+
+// Outcomes of kafka_consumer_messages_total
+const (
+	outcomeHandled      = "handled"
+	outcomeSkipped      = "skipped"
+	outcomeDeadLettered = "dead_lettered"
+)
+
+func observeLag(groupID string, msg kafka.Message) {
+	lag := msg.HighWaterMark - msg.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	consumerLag.WithLabelValues(groupID, msg.Topic, strconv.Itoa(msg.Partition)).Set(float64(lag))
+}
+
+func countMessage(groupID string, msg kafka.Message, outcome string) {
+	consumerMessagesTotal.WithLabelValues(groupID, msg.Topic, outcome).Inc()
+}
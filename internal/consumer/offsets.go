@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// offsetTracker finds what may be committed when messages of a partition
+// finish out of order. Committing an offset commits everything before it,
+// so only the end of the run of finished messages at the start of the
+// partition is committed.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[partitionKey]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	// inflight holds the fetched offsets not committable yet, in fetch order
+	inflight []int64
+	done     map[int64]kafka.Message
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: map[partitionKey]*partitionOffsets{}}
+}
+
+// add records a fetched message. An offset at or before the last one
+// fetched means the partition was rewound by a rebalance, what was in
+// flight is delivered again and starts over.
+func (t *offsetTracker) add(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := partitionKey{msg.Topic, msg.Partition}
+	p, ok := t.partitions[key]
+	if !ok || (len(p.inflight) > 0 && msg.Offset <= p.inflight[len(p.inflight)-1]) {
+		p = &partitionOffsets{done: map[int64]kafka.Message{}}
+		t.partitions[key] = p
+	}
+	p.inflight = append(p.inflight, msg.Offset)
+}
+
+// complete marks msg finished and returns the message to commit, false when
+// an earlier message of the partition is still running
+func (t *offsetTracker) complete(msg kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[partitionKey{msg.Topic, msg.Partition}]
+	if !ok {
+		return kafka.Message{}, false
+	}
+	p.done[msg.Offset] = msg
+
+	var (
+		commit kafka.Message
+		found  bool
+	)
+	for len(p.inflight) > 0 {
+		finished, ok := p.done[p.inflight[0]]
+		if !ok {
+			break
+		}
+		delete(p.done, p.inflight[0])
+		p.inflight = p.inflight[1:]
+		commit, found = finished, true
+	}
+	return commit, found
+}
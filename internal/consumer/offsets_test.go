@@ -0,0 +1,63 @@
+package consumer
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffsetTrackerOutOfOrder(t *testing.T) {
+	tracker := newOffsetTracker()
+	msgs := []kafka.Message{
+		{Topic: "cdc.stock", Partition: 0, Offset: 10},
+		{Topic: "cdc.stock", Partition: 0, Offset: 11},
+		{Topic: "cdc.stock", Partition: 0, Offset: 12},
+	}
+	for _, msg := range msgs {
+		tracker.add(msg)
+	}
+
+	// offset 10 belum selesai, 11 dan 12 belum boleh di-commit
+	_, ok := tracker.complete(msgs[2])
+	assert.False(t, ok)
+	_, ok = tracker.complete(msgs[1])
+	assert.False(t, ok)
+
+	commit, ok := tracker.complete(msgs[0])
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), commit.Offset)
+}
+
+func TestOffsetTrackerPartitions(t *testing.T) {
+	tracker := newOffsetTracker()
+	a := kafka.Message{Topic: "cdc.stock", Partition: 0, Offset: 5}
+	b := kafka.Message{Topic: "cdc.stock", Partition: 1, Offset: 3}
+	tracker.add(a)
+	tracker.add(b)
+
+	// partisi saling bebas
+	commit, ok := tracker.complete(b)
+	assert.True(t, ok)
+	assert.Equal(t, b, commit)
+	commit, ok = tracker.complete(a)
+	assert.True(t, ok)
+	assert.Equal(t, a, commit)
+}
+
+func TestOffsetTrackerRewind(t *testing.T) {
+	tracker := newOffsetTracker()
+	tracker.add(kafka.Message{Topic: "cdc.stock", Offset: 7})
+	tracker.add(kafka.Message{Topic: "cdc.stock", Offset: 8})
+
+	// rebalance mengirim ulang dari offset 7, yang lama dibuang
+	again := kafka.Message{Topic: "cdc.stock", Offset: 7}
+	tracker.add(again)
+	commit, ok := tracker.complete(again)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), commit.Offset)
+
+	// message yang tidak pernah di-add tidak di-commit
+	_, ok = tracker.complete(kafka.Message{Topic: "cdc.other", Offset: 1})
+	assert.False(t, ok)
+}
@@ -0,0 +1,178 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// probeInterval is how often the brokers and topics of a consumer are
+// checked
+const probeInterval = 10 * time.Second
+
+// Binding consumes Topics as consumer group GroupID with Workers workers and
+// hands the events to Handlers
+type Binding struct {
+	Name     string
+	Topics   []string
+	GroupID  string
+	Workers  int
+	Handlers Handlers
+}
+
+// Supervisor runs a consumer for every binding and stops them together
+type Supervisor struct {
+	brokers  []string
+	opts     Options
+	bindings []Binding
+
+	// seams for tests
+	newReader     func(b Binding) MessageReader
+	probe         func(ctx context.Context, topics []string) error
+	probeInterval time.Duration
+
+	mu        sync.Mutex
+	consumers map[string]*Consumer
+	dlq       *kafka.Writer
+	wg        sync.WaitGroup
+	closeDLQ  sync.Once
+}
+
+// NewSupervisor ...
+func NewSupervisor(brokers []string, opts Options, bindings ...Binding) *Supervisor {
+	s := &Supervisor{
+		brokers:   brokers,
+		opts:      opts,
+		bindings:  bindings,
+		consumers: map[string]*Consumer{},
+	}
+	s.newReader = s.kafkaReader
+	s.probe = s.kafkaProbe
+	s.probeInterval = probeInterval
+	return s
+}
+
+// Start runs the consumers until ctx is done, Wait returns once all of them
+// finished their in-flight messages and closed their readers
+func (s *Supervisor) Start(ctx context.Context) {
+	var dlq MessageWriter
+	if s.opts.DLQTopic != "" {
+		s.dlq = &kafka.Writer{
+			Addr:         kafka.TCP(s.brokers...),
+			Topic:        s.opts.DLQTopic,
+			RequiredAcks: kafka.RequireAll,
+		}
+		dlq = s.dlq
+	}
+
+	for _, b := range s.bindings {
+		reader := s.newReader(b)
+		c := NewConsumer(reader, dlq, b.Handlers, b.GroupID, s.opts.Retry)
+		if b.Workers > 1 {
+			c.workers = b.Workers
+		}
+		s.mu.Lock()
+		s.consumers[b.Name] = c
+		s.mu.Unlock()
+
+		s.wg.Add(2)
+		go func(b Binding) {
+			defer s.wg.Done()
+			defer reader.Close()
+			log.Printf("[KAFKA] consumer %s started, group=%s topics=%v workers=%d", b.Name, b.GroupID, b.Topics, c.workers)
+			c.Run(ctx)
+			log.Printf("[KAFKA] consumer %s stopped", b.Name)
+		}(b)
+		go func(b Binding) {
+			defer s.wg.Done()
+			s.watch(ctx, c, b.Topics)
+		}(b)
+	}
+}
+
+// watch probes the topics of c every probeInterval until ctx is done and
+// keeps c ready while they can be reached. Readiness follows the probe, not
+// the fetches: a consumer with nothing to read blocks in fetch, so only a
+// new message would show that the brokers are back.
+func (s *Supervisor) watch(ctx context.Context, c *Consumer, topics []string) {
+	ticker := time.NewTicker(s.probeInterval)
+	defer ticker.Stop()
+	for {
+		err := s.probe(ctx, topics)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[KAFKA] topics %v not reachable: %v", topics, err)
+		}
+		c.setReady(err == nil)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Wait blocks until every consumer stopped
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+	s.closeDLQ.Do(func() {
+		if s.dlq != nil {
+			s.dlq.Close()
+		}
+	})
+}
+
+// Ready is nil when every consumer reaches its brokers and topics
+func (s *Supervisor) Ready() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var notReady []string
+	for _, b := range s.bindings {
+		if c, ok := s.consumers[b.Name]; !ok || !c.Ready() {
+			notReady = append(notReady, b.Name)
+		}
+	}
+	if len(notReady) > 0 {
+		return fmt.Errorf("kafka consumers not ready: %s", strings.Join(notReady, ", "))
+	}
+	return nil
+}
+
+func (s *Supervisor) kafkaReader(b Binding) MessageReader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     s.brokers,
+		GroupID:     b.GroupID,
+		GroupTopics: b.Topics,
+		StartOffset: kafka.FirstOffset,
+	})
+}
+
+// kafkaProbe checks that a broker answers and knows every topic
+func (s *Supervisor) kafkaProbe(ctx context.Context, topics []string) error {
+	ctx, cancel := context.WithTimeout(ctx, probeInterval)
+	defer cancel()
+
+	err := errors.New("no brokers configured")
+	for _, broker := range s.brokers {
+		var conn *kafka.Conn
+		conn, err = kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			continue
+		}
+		_, err = conn.ReadPartitions(topics...)
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
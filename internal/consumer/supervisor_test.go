@@ -0,0 +1,133 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/registry"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderHandlers mencatat urutan gold_id per key yang diterima handler
+type orderHandlers struct {
+	mu   sync.Mutex
+	seen map[string][]interface{}
+}
+
+func (h *orderHandlers) GetHandler(table string) (registry.HandlerFunc, bool) {
+	return func(ctx context.Context, op string, after, before map[string]interface{}) error {
+		// beri kesempatan worker lain menyalip bila urutan tidak dijaga
+		time.Sleep(time.Millisecond)
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		key := after["key"].(string)
+		h.seen[key] = append(h.seen[key], after["seq"])
+		return nil
+	}, true
+}
+
+func keyed(offset int64, key string, seq int) kafka.Message {
+	return kafka.Message{
+		Topic:  "cdc.data_peserta",
+		Offset: offset,
+		Key:    []byte(key),
+		Value:  []byte(`{"payload":{"op":"u","after":{"key":"` + key + `","seq":` + strconv.Itoa(seq) + `},"source":{"table":"data_peserta"}}}`),
+	}
+}
+
+func TestConsumerWorkersKeepKeyOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var msgs []kafka.Message
+	offset := int64(0)
+	for seq := 1; seq <= 5; seq++ {
+		for _, key := range []string{"a", "b", "c"} {
+			offset++
+			msgs = append(msgs, keyed(offset, key, seq))
+		}
+	}
+	reader := &fakeReader{messages: msgs, stop: cancel, stopAt: offset}
+	handlers := &orderHandlers{seen: map[string][]interface{}{}}
+
+	c := NewConsumer(reader, nil, handlers, "goldgym-sync", fastRetry)
+	c.workers = 3
+	c.Run(ctx)
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Equal(t, []interface{}{int64(1), int64(2), int64(3), int64(4), int64(5)}, handlers.seen[key], key)
+	}
+	// commit hanya naik, offset terakhir ikut ter-commit
+	require.NotEmpty(t, reader.committed)
+	assert.IsIncreasing(t, reader.committed)
+	assert.Equal(t, offset, reader.committed[len(reader.committed)-1])
+}
+
+func TestSupervisor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var (
+		mu      sync.Mutex
+		readers = map[string]*fakeReader{}
+	)
+	sup := NewSupervisor(nil, Options{Retry: fastRetry},
+		Binding{Name: "members", Topics: []string{"cdc.data_peserta"}, GroupID: "g1", Workers: 2, Handlers: &fakeHandlers{}},
+		Binding{Name: "stock", Topics: []string{"cdc.stock"}, GroupID: "g2", Handlers: &fakeHandlers{}},
+	)
+	sup.newReader = func(b Binding) MessageReader {
+		mu.Lock()
+		defer mu.Unlock()
+		// stock tidak punya pesan, jadi hanya probe yang bisa membuatnya siap
+		r := &fakeReader{}
+		if b.Name == "members" {
+			r.messages = []kafka.Message{event(1, "data_peserta")}
+		}
+		readers[b.Name] = r
+		return r
+	}
+	// hasil probe per topic bisa diubah selama test
+	var down sync.Map
+	down.Store("cdc.stock", true)
+	sup.probe = func(ctx context.Context, topics []string) error {
+		if v, _ := down.Load(topics[0]); v == true {
+			return errors.New("unknown topic")
+		}
+		return nil
+	}
+	sup.probeInterval = 5 * time.Millisecond
+
+	// belum dijalankan, semua consumer belum siap
+	assert.EqualError(t, sup.Ready(), "kafka consumers not ready: members, stock")
+
+	sup.Start(ctx)
+	assert.Eventually(t, func() bool {
+		err := sup.Ready()
+		return err != nil && err.Error() == "kafka consumers not ready: stock"
+	}, time.Second, 5*time.Millisecond)
+
+	// probe terus berjalan: topic yang pulih menjadi siap, yang hilang
+	// tidak siap lagi walau tidak ada pesan baru
+	down.Store("cdc.stock", false)
+	down.Store("cdc.data_peserta", true)
+	assert.Eventually(t, func() bool {
+		err := sup.Ready()
+		return err != nil && err.Error() == "kafka consumers not ready: members"
+	}, time.Second, 5*time.Millisecond)
+	down.Store("cdc.data_peserta", false)
+	assert.Eventually(t, func() bool { return sup.Ready() == nil }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	sup.Wait()
+	assert.Error(t, sup.Ready())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int64{1}, readers["members"].committed)
+}
@@ -0,0 +1,167 @@
+package branch
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+const (
+	dbTimeout = 3 * time.Second
+
+	// active: any subscription still running; branch_access: all when one of
+	// them is on an all-branch package
+	qGetMemberAccess = `SELECT m.gold_id, m.gold_email, m.gold_branch_id,
+	CASE WHEN EXISTS (SELECT 1 FROM subscription_detail d
+		JOIN subscription_product p ON p.gold_menuid = d.gold_menuid
+		WHERE d.gold_id = m.gold_id AND d.gold_statuslangganan = 'Berlangganan' AND d.gold_enddate >= NOW() AND p.gold_branch_access = 'all')
+	THEN 'all' ELSE 'home' END AS branch_access,
+	EXISTS (SELECT 1 FROM subscription_detail d
+		WHERE d.gold_id = m.gold_id AND d.gold_statuslangganan = 'Berlangganan' AND d.gold_enddate >= NOW()) AS active
+FROM data_peserta m
+WHERE m.gold_email = ?`
+
+	qUpdateMemberBranch = `UPDATE data_peserta SET gold_branch_id = ? WHERE gold_id = ?`
+
+	qMemberExists = `SELECT COUNT(*) FROM data_peserta WHERE gold_id = ?`
+)
+
+// Data ...
+type Data struct {
+	db *gorm.DB
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(db *gorm.DB, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	return &Data{
+		db:     db,
+		tracer: tracer,
+		logger: logger,
+	}
+}
+
+// InsertBranch stores a new branch and returns its branch_id
+func (d *Data) InsertBranch(ctx context.Context, branch branchEntity.Branch) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	if err := d.db.WithContext(ctx).Create(&branch).Error; err != nil {
+		return 0, errors.Wrap(err, "[DATA][InsertBranch]")
+	}
+	return branch.BranchID, nil
+}
+
+// GetBranches lists every branch, open or closed, by branch_id
+func (d *Data) GetBranches(ctx context.Context) ([]branchEntity.Branch, error) {
+	var branches []branchEntity.Branch
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Order("branch_id").Find(&branches).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetBranches]")
+	}
+	return branches, nil
+}
+
+// GetBranchByID returns ErrNotFound for an unknown branch_id
+func (d *Data) GetBranchByID(ctx context.Context, branchID int64) (branchEntity.Branch, error) {
+	return d.getBranch(ctx, "branch_id = ?", branchID)
+}
+
+// GetBranchByCode returns ErrNotFound for an unknown branch_code
+func (d *Data) GetBranchByCode(ctx context.Context, code string) (branchEntity.Branch, error) {
+	return d.getBranch(ctx, "branch_code = ?", code)
+}
+
+func (d *Data) getBranch(ctx context.Context, query string, arg interface{}) (branchEntity.Branch, error) {
+	var branch branchEntity.Branch
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Where(query, arg).First(&branch).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return branch, errors.Wrap(entity.ErrNotFound, "[DATA][GetBranch]")
+		}
+		return branch, errors.Wrap(err, "[DATA][GetBranch]")
+	}
+	return branch, nil
+}
+
+// GetMemberAccess returns the home branch and branch access of the member
+// with email, ErrNotFound when there is none
+func (d *Data) GetMemberAccess(ctx context.Context, email string) (branchEntity.MemberAccess, error) {
+	var members []branchEntity.MemberAccess
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Raw(qGetMemberAccess, email).Scan(&members).Error
+	if err != nil {
+		return branchEntity.MemberAccess{}, errors.Wrap(err, "[DATA][GetMemberAccess]")
+	}
+	if len(members) == 0 {
+		return branchEntity.MemberAccess{}, errors.Wrap(entity.ErrNotFound, "[DATA][GetMemberAccess]")
+	}
+	return members[0], nil
+}
+
+// UpdateMemberBranch moves member goldID to another home branch, ErrNotFound
+// when there is no such member
+func (d *Data) UpdateMemberBranch(ctx context.Context, goldID int, branchID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	res := d.db.WithContext(ctx).Exec(qUpdateMemberBranch, branchID, goldID)
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "[DATA][UpdateMemberBranch]")
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+
+	// MySQL reports 0 affected rows when the branch did not change
+	var count int64
+	if err := d.db.WithContext(ctx).Raw(qMemberExists, goldID).Scan(&count).Error; err != nil {
+		return errors.Wrap(err, "[DATA][UpdateMemberBranch]")
+	}
+	if count == 0 {
+		return errors.Wrap(entity.ErrNotFound, "[DATA][UpdateMemberBranch]")
+	}
+	return nil
+}
+
+// InsertCheckin stores a front desk visit and returns its checkin_id
+func (d *Data) InsertCheckin(ctx context.Context, checkin branchEntity.Checkin) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	if err := d.db.WithContext(ctx).Create(&checkin).Error; err != nil {
+		return 0, errors.Wrap(err, "[DATA][InsertCheckin]")
+	}
+	return checkin.CheckinID, nil
+}
+
+// GetCheckins lists the visits of one day, of one branch when BranchID is not
+// zero, in order of arrival
+func (d *Data) GetCheckins(ctx context.Context, filter branchEntity.CheckinFilter) ([]branchEntity.Checkin, error) {
+	var checkins []branchEntity.Checkin
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	q := d.db.WithContext(ctx).Where("checkin_at >= ? AND checkin_at < DATE_ADD(?, INTERVAL 1 DAY)", filter.Date, filter.Date)
+	if filter.BranchID != 0 {
+		q = q.Where("branch_id = ?", filter.BranchID)
+	}
+	err := q.Order("checkin_at, checkin_id").Find(&checkins).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetCheckins]")
+	}
+	return checkins, nil
+}
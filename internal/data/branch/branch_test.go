@@ -0,0 +1,113 @@
+package branch
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+func TestGetMemberAccess(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta(qGetMemberAccess)).
+		WithArgs("budi@mail.com").
+		WillReturnRows(sqlmock.NewRows([]string{"gold_id", "gold_email", "gold_branch_id", "branch_access", "active"}).
+			AddRow(4, "budi@mail.com", 2, "all", 1))
+	member, err := repo.GetMemberAccess(ctx, "budi@mail.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), member.HomeBranchID)
+	assert.Equal(t, branchEntity.AccessAll, member.Access)
+	assert.True(t, member.Active)
+
+	mock.ExpectQuery(regexp.QuoteMeta(qGetMemberAccess)).
+		WithArgs("nope@mail.com").
+		WillReturnRows(sqlmock.NewRows([]string{"gold_id"}))
+	_, err = repo.GetMemberAccess(ctx, "nope@mail.com")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateMemberBranch(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta(qUpdateMemberBranch)).
+		WithArgs(int64(2), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.UpdateMemberBranch(ctx, 4, 2))
+
+	// cabang sama, baris tetap ada
+	mock.ExpectExec(regexp.QuoteMeta(qUpdateMemberBranch)).
+		WithArgs(int64(2), 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(qMemberExists)).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	assert.NoError(t, repo.UpdateMemberBranch(ctx, 4, 2))
+
+	mock.ExpectExec(regexp.QuoteMeta(qUpdateMemberBranch)).
+		WithArgs(int64(2), 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(qMemberExists)).
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	err := repo.UpdateMemberBranch(ctx, 99, 2)
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBranchByCode_NotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	mock.ExpectQuery("SELECT \\* FROM `branch` WHERE branch_code = \\?").
+		WithArgs("NORTH", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"branch_id"}))
+	_, err := repo.GetBranchByCode(context.Background(), "NORTH")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCheckins(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	mock.ExpectQuery("SELECT \\* FROM `member_checkin` WHERE \\(checkin_at >= \\? AND checkin_at < DATE_ADD\\(\\?, INTERVAL 1 DAY\\)\\) AND branch_id = \\? ORDER BY checkin_at, checkin_id").
+		WithArgs("2026-10-19", "2026-10-19", int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"checkin_id", "gold_id", "branch_id"}).AddRow(1, 4, 2))
+	checkins, err := repo.GetCheckins(context.Background(), branchEntity.CheckinFilter{BranchID: 2, Date: "2026-10-19"})
+	require.NoError(t, err)
+	require.Len(t, checkins, 1)
+	assert.Equal(t, 4, checkins[0].GoldID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
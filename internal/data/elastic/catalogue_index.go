@@ -0,0 +1,166 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+)
+
+// stockTemplate is the index template of the stock indices, alias-*. Names
+// are matched like member names: stock_name and product_name as written
+// apart from case and accents, their .prefix subfields by the start of every
+// word for autocomplete. Codes and barcodes are keywords, codes compared
+// without case.
+const stockTemplate = `{
+  "index_patterns": [%q],
+  "version": %d,
+  "priority": 100,
+  "template": {
+    "settings": {
+      "analysis": {
+        "filter": {
+          "stock_edge": {"type": "edge_ngram", "min_gram": 1, "max_gram": 20}
+        },
+        "analyzer": {
+          "stock_name":   {"type": "custom", "tokenizer": "standard", "filter": ["lowercase", "asciifolding"]},
+          "stock_prefix": {"type": "custom", "tokenizer": "standard", "filter": ["lowercase", "asciifolding", "stock_edge"]}
+        },
+        "normalizer": {
+          "stock_lowercase": {"type": "custom", "filter": ["lowercase"]}
+        }
+      }
+    },
+    "mappings": {
+      "dynamic": "strict",
+      "_meta": {"version": %d},
+      "properties": {
+        "stock_code": {"type": "keyword", "normalizer": "stock_lowercase"},
+        "stock_name": {
+          "type": "text",
+          "analyzer": "stock_name",
+          "fields": {
+            "prefix":  {"type": "text", "analyzer": "stock_prefix", "search_analyzer": "stock_name"},
+            "keyword": {"type": "keyword", "normalizer": "stock_lowercase", "ignore_above": 256}
+          }
+        },
+        "stock_pack":  {"type": "keyword"},
+        "stock_qty":   {"type": "integer"},
+        "stock_price": {"type": "scaled_float", "scaling_factor": 100},
+        "product_code": {"type": "keyword", "normalizer": "stock_lowercase"},
+        "product_name": {
+          "type": "text",
+          "analyzer": "stock_name",
+          "fields": {
+            "prefix": {"type": "text", "analyzer": "stock_prefix", "search_analyzer": "stock_name"}
+          }
+        },
+        "category_name": {"type": "keyword"},
+        "flavour":       {"type": "keyword"},
+        "size":          {"type": "keyword"},
+        "barcodes":      {"type": "keyword"},
+        "indexed_at":    {"type": "date"}
+      }
+    }
+  }
+}`
+
+// salesTemplate is the index template of the sales indices, alias-*. The
+// lines are nested so the quantity and amount of a stock code are summed
+// per line, not across the lines of the sales it appears in.
+const salesTemplate = `{
+  "index_patterns": [%q],
+  "version": %d,
+  "priority": 100,
+  "template": {
+    "mappings": {
+      "dynamic": "strict",
+      "_meta": {"version": %d},
+      "properties": {
+        "sale_id":     {"type": "keyword"},
+        "branch_id":   {"type": "long"},
+        "salesperson": {"type": "keyword"},
+        "sold_at":     {"type": "date", "format": "yyyy-MM-dd HH:mm:ss"},
+        "total":       {"type": "scaled_float", "scaling_factor": 100},
+        "lines": {
+          "type": "nested",
+          "properties": {
+            "stock_code": {"type": "keyword"},
+            "stock_name": {"type": "keyword"},
+            "qty":        {"type": "integer"},
+            "price":      {"type": "scaled_float", "scaling_factor": 100},
+            "amount":     {"type": "scaled_float", "scaling_factor": 100}
+          }
+        },
+        "indexed_at": {"type": "date"}
+      }
+    }
+  }
+}`
+
+// PutStockTemplate installs the stock template of alias
+func (r *Repository) PutStockTemplate(ctx context.Context, alias string) error {
+	return r.putTemplate(ctx, alias, fmt.Sprintf(stockTemplate, alias+"-*", elasticEntity.StockTemplateVersion, elasticEntity.StockTemplateVersion))
+}
+
+// PutSalesTemplate installs the sales template of alias
+func (r *Repository) PutSalesTemplate(ctx context.Context, alias string) error {
+	return r.putTemplate(ctx, alias, fmt.Sprintf(salesTemplate, alias+"-*", elasticEntity.SalesTemplateVersion, elasticEntity.SalesTemplateVersion))
+}
+
+// IndexStock writes docs whole to index, keyed by stock code, and removes
+// the stock codes in deleted, in one bulk request
+func (r *Repository) IndexStock(ctx context.Context, index string, docs []elasticEntity.StockDocument, deleted []string) error {
+	if len(docs) == 0 && len(deleted) == 0 {
+		return nil
+	}
+
+	indexedAt := time.Now().UTC().Format(time.RFC3339)
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		doc.IndexedAt = indexedAt
+		if err := encodeIndex(enc, doc.StockCode, doc); err != nil {
+			return err
+		}
+	}
+	for _, code := range deleted {
+		if err := enc.Encode(map[string]interface{}{"delete": map[string]interface{}{"_id": code}}); err != nil {
+			return fmt.Errorf("marshal bulk action: %w", err)
+		}
+	}
+	return r.sendBulk(ctx, index, &body)
+}
+
+// IndexSales writes docs whole to index, keyed by sale id, in one bulk
+// request
+func (r *Repository) IndexSales(ctx context.Context, index string, docs []elasticEntity.SaleDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	indexedAt := time.Now().UTC().Format(time.RFC3339)
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, doc := range docs {
+		doc.IndexedAt = indexedAt
+		if err := encodeIndex(enc, doc.SaleID, doc); err != nil {
+			return err
+		}
+	}
+	return r.sendBulk(ctx, index, &body)
+}
+
+// encodeIndex writes the bulk lines indexing doc under id
+func encodeIndex(enc *json.Encoder, id string, doc interface{}) error {
+	if err := enc.Encode(map[string]interface{}{"index": map[string]interface{}{"_id": id}}); err != nil {
+		return fmt.Errorf("marshal bulk action: %w", err)
+	}
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("marshal bulk document: %w", err)
+	}
+	return nil
+}
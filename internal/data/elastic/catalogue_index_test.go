@@ -0,0 +1,130 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// templateMapping membaca versi dan properties dari body index template
+type templateMapping struct {
+	IndexPatterns []string `json:"index_patterns"`
+	Version       int      `json:"version"`
+	Template      struct {
+		Mappings struct {
+			Dynamic string `json:"dynamic"`
+			Meta    struct {
+				Version int `json:"version"`
+			} `json:"_meta"`
+			Properties map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"properties"`
+		} `json:"mappings"`
+	} `json:"template"`
+}
+
+// assertMapped memastikan setiap field doc, termasuk field objek di dalam
+// list, dideklarasikan di mapping strict
+func assertMapped(t *testing.T, mapping templateMapping, doc interface{}) {
+	raw, err := json.Marshal(doc)
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &fields))
+
+	properties := mapping.Template.Mappings.Properties
+	for field, value := range fields {
+		require.Contains(t, properties, field)
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 {
+			continue
+		}
+		if object, ok := list[0].(map[string]interface{}); ok {
+			for sub := range object {
+				assert.Contains(t, properties[field].Properties, sub, field)
+			}
+		}
+	}
+}
+
+func TestPutStockTemplate(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"acknowledged":true}`)
+
+	require.NoError(t, repo.PutStockTemplate(context.Background(), "gold-stock"))
+	assert.Equal(t, "/_index_template/gold-stock", req.URL.Path)
+
+	var mapping templateMapping
+	require.NoError(t, json.Unmarshal([]byte(*body), &mapping))
+	assert.Equal(t, []string{"gold-stock-*"}, mapping.IndexPatterns)
+	assert.Equal(t, elasticEntity.StockTemplateVersion, mapping.Template.Mappings.Meta.Version)
+	assert.Equal(t, "strict", mapping.Template.Mappings.Dynamic)
+	assertMapped(t, mapping, elasticEntity.StockDocument{
+		StockCode: "A", ProductCode: "P", ProductName: "Whey", CategoryName: "Suplemen",
+		Flavour: "Coklat", Size: "1kg", Barcodes: []string{"899"},
+	})
+}
+
+func TestPutSalesTemplate(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"acknowledged":true}`)
+
+	require.NoError(t, repo.PutSalesTemplate(context.Background(), "gold-sales"))
+	assert.Equal(t, "/_index_template/gold-sales", req.URL.Path)
+
+	var mapping templateMapping
+	require.NoError(t, json.Unmarshal([]byte(*body), &mapping))
+	assert.Equal(t, []string{"gold-sales-*"}, mapping.IndexPatterns)
+	assert.Equal(t, elasticEntity.SalesTemplateVersion, mapping.Template.Mappings.Meta.Version)
+	assertMapped(t, mapping, elasticEntity.SaleDocument{
+		SaleID: "S-1",
+		Lines:  []elasticEntity.SaleDocumentLine{{StockCode: "A", Qty: 1}},
+	})
+}
+
+func TestIndexStock(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"errors":false,"items":[]}`)
+
+	err := repo.IndexStock(context.Background(), "gold-stock", []elasticEntity.StockDocument{
+		{StockCode: "WHEY-1", StockName: "Whey Coklat", StockPrice: entity.NewMoney(350000)},
+	}, []string{"OLD"})
+	require.NoError(t, err)
+	assert.Equal(t, "/gold-stock/_bulk", req.URL.Path)
+
+	lines := strings.Split(strings.TrimSpace(*body), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"index":{"_id":"WHEY-1"}}`, lines[0])
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	assert.Equal(t, 350000.0, doc["stock_price"])
+	assert.NotEmpty(t, doc["indexed_at"])
+	assert.JSONEq(t, `{"delete":{"_id":"OLD"}}`, lines[2])
+}
+
+func TestIndexSales(t *testing.T) {
+	repo, _, body := newTestRepository(t, http.StatusOK, `{"errors":false,"items":[]}`)
+
+	err := repo.IndexSales(context.Background(), "gold-sales", []elasticEntity.SaleDocument{{
+		SaleID: "S-1", SoldAt: "2026-10-19 09:30:00", Total: entity.NewMoney(70000),
+		Lines: []elasticEntity.SaleDocumentLine{{StockCode: "A", Qty: 2, Price: entity.NewMoney(35000), Amount: entity.NewMoney(70000)}},
+	}})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(*body), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"index":{"_id":"S-1"}}`, lines[0])
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	assert.Equal(t, "2026-10-19 09:30:00", doc["sold_at"])
+	assert.Len(t, doc["lines"], 1)
+
+	// tanpa dokumen tidak ada request
+	*body = ""
+	require.NoError(t, repo.IndexSales(context.Background(), "gold-sales", nil))
+	assert.Empty(t, *body)
+}
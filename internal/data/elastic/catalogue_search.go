@@ -0,0 +1,237 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+)
+
+// maxSalespeople bounds the salesperson buckets of a sales report
+const maxSalespeople = 100
+
+// soldAtFormat is the format of sold_at and of the report period bounds
+const soldAtFormat = "yyyy-MM-dd HH:mm:ss"
+
+// SuggestStock returns the stock whose name, product name or code starts
+// with the words of search.Query, or whose barcode is search.Query, best
+// match first. search is expected to be validated by the service.
+func (r *Repository) SuggestStock(ctx context.Context, index string, search elasticEntity.StockSearch) ([]elasticEntity.StockDocument, error) {
+	var found struct {
+		Hits struct {
+			Hits []struct {
+				Source elasticEntity.StockDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := r.search(ctx, index, stockQuery(search), &found); err != nil {
+		return nil, err
+	}
+
+	docs := make([]elasticEntity.StockDocument, 0, len(found.Hits.Hits))
+	for _, hit := range found.Hits.Hits {
+		docs = append(docs, hit.Source)
+	}
+	return docs, nil
+}
+
+// stockQuery builds the suggestion request body
+func stockQuery(search elasticEntity.StockSearch) map[string]interface{} {
+	q := strings.TrimSpace(search.Query)
+	return map[string]interface{}{
+		"size": search.Size,
+		"query": map[string]interface{}{"bool": map[string]interface{}{
+			"should": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{
+					"barcodes": map[string]interface{}{"value": q, "boost": 5},
+				}},
+				map[string]interface{}{"prefix": map[string]interface{}{
+					"stock_code": map[string]interface{}{"value": strings.ToLower(q), "boost": 3},
+				}},
+				map[string]interface{}{"match": map[string]interface{}{
+					"stock_name.prefix": map[string]interface{}{"query": q, "operator": "and", "boost": 2},
+				}},
+				map[string]interface{}{"match": map[string]interface{}{
+					"product_name.prefix": map[string]interface{}{"query": q, "operator": "and"},
+				}},
+			},
+			"minimum_should_match": 1,
+		}},
+		"sort": []interface{}{
+			map[string]interface{}{"_score": map[string]interface{}{"order": "desc"}},
+			map[string]interface{}{"stock_name.keyword": map[string]interface{}{"order": "asc"}},
+		},
+	}
+}
+
+// SalesReport aggregates the sales of index matching filter: their count
+// and revenue, the revenue of every day and salesperson and the stock codes
+// that sold the most units. filter is expected to be validated by the
+// service.
+func (r *Repository) SalesReport(ctx context.Context, index string, filter elasticEntity.SalesReportFilter) (elasticEntity.SalesReport, error) {
+	report := elasticEntity.SalesReport{From: filter.From, To: filter.To}
+
+	type value struct {
+		Value float64 `json:"value"`
+	}
+	var found struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations struct {
+			Revenue value `json:"revenue"`
+			Daily   struct {
+				Buckets []struct {
+					Key      string `json:"key_as_string"`
+					DocCount int    `json:"doc_count"`
+					Revenue  value  `json:"revenue"`
+				} `json:"buckets"`
+			} `json:"daily"`
+			Salespeople struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int    `json:"doc_count"`
+					Revenue  value  `json:"revenue"`
+				} `json:"buckets"`
+			} `json:"salespeople"`
+			Lines struct {
+				Top struct {
+					Buckets []struct {
+						Key     string `json:"key"`
+						Qty     value  `json:"qty"`
+						Revenue value  `json:"revenue"`
+						Name    struct {
+							Buckets []struct {
+								Key string `json:"key"`
+							} `json:"buckets"`
+						} `json:"name"`
+					} `json:"buckets"`
+				} `json:"top"`
+			} `json:"lines"`
+		} `json:"aggregations"`
+	}
+	if err := r.search(ctx, index, salesQuery(filter), &found); err != nil {
+		return report, err
+	}
+
+	aggs := found.Aggregations
+	report.Sales = found.Hits.Total.Value
+	report.Revenue = entity.MoneyFromFloat(aggs.Revenue.Value)
+	report.Daily = make([]elasticEntity.RevenueBucket, 0, len(aggs.Daily.Buckets))
+	for _, b := range aggs.Daily.Buckets {
+		report.Daily = append(report.Daily, elasticEntity.RevenueBucket{Key: b.Key, Sales: b.DocCount, Revenue: entity.MoneyFromFloat(b.Revenue.Value)})
+	}
+	report.PerSalesperson = make([]elasticEntity.RevenueBucket, 0, len(aggs.Salespeople.Buckets))
+	for _, b := range aggs.Salespeople.Buckets {
+		report.PerSalesperson = append(report.PerSalesperson, elasticEntity.RevenueBucket{Key: b.Key, Sales: b.DocCount, Revenue: entity.MoneyFromFloat(b.Revenue.Value)})
+	}
+	report.TopSellers = make([]elasticEntity.TopSeller, 0, len(aggs.Lines.Top.Buckets))
+	for _, b := range aggs.Lines.Top.Buckets {
+		seller := elasticEntity.TopSeller{StockCode: b.Key, Qty: int(b.Qty.Value), Revenue: entity.MoneyFromFloat(b.Revenue.Value)}
+		if len(b.Name.Buckets) > 0 {
+			seller.StockName = b.Name.Buckets[0].Key
+		}
+		report.TopSellers = append(report.TopSellers, seller)
+	}
+	return report, nil
+}
+
+// salesQuery builds the sales report request body. Daily buckets cover the
+// whole period so days without sales show as zero. sold_at is local time
+// stored without a zone, the days are bucketed as written.
+func salesQuery(filter elasticEntity.SalesReportFilter) map[string]interface{} {
+	filters := []interface{}{
+		map[string]interface{}{"range": map[string]interface{}{"sold_at": map[string]interface{}{
+			"gte":    filter.From + " 00:00:00",
+			"lte":    filter.To + " 23:59:59",
+			"format": soldAtFormat,
+		}}},
+	}
+	if filter.BranchID > 0 {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"branch_id": filter.BranchID}})
+	}
+	if filter.Salesperson != "" {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"salesperson": filter.Salesperson}})
+	}
+
+	revenue := map[string]interface{}{"sum": map[string]interface{}{"field": "total"}}
+	sumTotal := map[string]interface{}{"revenue": revenue}
+	return map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"query":            map[string]interface{}{"bool": map[string]interface{}{"filter": filters}},
+		"aggs": map[string]interface{}{
+			"revenue": revenue,
+			"daily": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":             "sold_at",
+					"calendar_interval": "day",
+					"format":            "yyyy-MM-dd",
+					"min_doc_count":     0,
+					"extended_bounds":   map[string]interface{}{"min": filter.From, "max": filter.To},
+				},
+				"aggs": sumTotal,
+			},
+			"salespeople": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "salesperson",
+					"size":  maxSalespeople,
+					"order": map[string]interface{}{"revenue": "desc"},
+				},
+				"aggs": sumTotal,
+			},
+			"lines": map[string]interface{}{
+				"nested": map[string]interface{}{"path": "lines"},
+				"aggs": map[string]interface{}{
+					"top": map[string]interface{}{
+						"terms": map[string]interface{}{
+							"field": "lines.stock_code",
+							"size":  filter.Top,
+							"order": map[string]interface{}{"qty": "desc"},
+						},
+						"aggs": map[string]interface{}{
+							"qty":     map[string]interface{}{"sum": map[string]interface{}{"field": "lines.qty"}},
+							"revenue": map[string]interface{}{"sum": map[string]interface{}{"field": "lines.amount"}},
+							"name":    map[string]interface{}{"terms": map[string]interface{}{"field": "lines.stock_name", "size": 1}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// search runs the request body against index and decodes the response into
+// result
+func (r *Repository) search(ctx context.Context, index string, query map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return fmt.Errorf("marshal query: %w", err)
+	}
+
+	res, err := r.client.Search(
+		r.client.Search.WithContext(ctx),
+		r.client.Search.WithIndex(index),
+		r.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return fmt.Errorf("es search: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es search error [%s]: %s", res.Status(), string(b))
+	}
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode search result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestStock(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"hits":{"hits":[
+		{"_source":{"stock_code":"WHEY-1","stock_name":"Whey Coklat","stock_price":350000.00,"barcodes":["899123"]}}]}}`)
+
+	docs, err := repo.SuggestStock(context.Background(), "gold-stock", elasticEntity.StockSearch{Query: "WHE", Size: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "/gold-stock/_search", req.URL.Path)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "WHEY-1", docs[0].StockCode)
+	assert.Equal(t, entity.NewMoney(350000), docs[0].StockPrice)
+
+	var sent map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(*body), &sent))
+	assert.Equal(t, float64(5), sent["size"])
+	should := sent["query"].(map[string]interface{})["bool"].(map[string]interface{})["should"].([]interface{})
+	require.Len(t, should, 4)
+	assert.JSONEq(t, `{"term":{"barcodes":{"value":"WHE","boost":5}}}`, marshal(t, should[0]))
+	// kode dicari tanpa membedakan huruf besar
+	assert.JSONEq(t, `{"prefix":{"stock_code":{"value":"whe","boost":3}}}`, marshal(t, should[1]))
+}
+
+func TestSalesReport(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{
+		"hits":{"total":{"value":3}},
+		"aggregations":{
+			"revenue":{"value":125000.5},
+			"daily":{"buckets":[
+				{"key_as_string":"2026-10-18","doc_count":0,"revenue":{"value":0}},
+				{"key_as_string":"2026-10-19","doc_count":3,"revenue":{"value":125000.5}}]},
+			"salespeople":{"buckets":[{"key":"andi","doc_count":3,"revenue":{"value":125000.5}}]},
+			"lines":{"top":{"buckets":[
+				{"key":"WHEY-1","qty":{"value":4},"revenue":{"value":100000},"name":{"buckets":[{"key":"Whey Coklat"}]}}]}}}}`)
+
+	report, err := repo.SalesReport(context.Background(), "gold-sales", elasticEntity.SalesReportFilter{
+		From: "2026-10-18", To: "2026-10-19", BranchID: 2, Salesperson: "andi", Top: 5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/gold-sales/_search", req.URL.Path)
+	assert.Equal(t, 3, report.Sales)
+	assert.Equal(t, entity.MoneyFromFloat(125000.5), report.Revenue)
+	assert.Equal(t, []elasticEntity.RevenueBucket{
+		{Key: "2026-10-18", Sales: 0, Revenue: 0},
+		{Key: "2026-10-19", Sales: 3, Revenue: entity.MoneyFromFloat(125000.5)},
+	}, report.Daily)
+	assert.Equal(t, "andi", report.PerSalesperson[0].Key)
+	assert.Equal(t, []elasticEntity.TopSeller{
+		{StockCode: "WHEY-1", StockName: "Whey Coklat", Qty: 4, Revenue: entity.NewMoney(100000)},
+	}, report.TopSellers)
+
+	var sent map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(*body), &sent))
+	assert.Equal(t, float64(0), sent["size"])
+	assert.JSONEq(t, `[
+		{"range":{"sold_at":{"gte":"2026-10-18 00:00:00","lte":"2026-10-19 23:59:59","format":"yyyy-MM-dd HH:mm:ss"}}},
+		{"term":{"branch_id":2}},
+		{"term":{"salesperson":"andi"}}]`,
+		marshal(t, sent["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"]))
+	aggs := sent["aggs"].(map[string]interface{})
+	// setiap hari periode punya bucket, juga yang tanpa penjualan
+	assert.JSONEq(t, `{"min":"2026-10-18","max":"2026-10-19"}`,
+		marshal(t, aggs["daily"].(map[string]interface{})["date_histogram"].(map[string]interface{})["extended_bounds"]))
+	top := aggs["lines"].(map[string]interface{})["aggs"].(map[string]interface{})["top"].(map[string]interface{})
+	assert.JSONEq(t, `{"field":"lines.stock_code","size":5,"order":{"qty":"desc"}}`, marshal(t, top["terms"]))
+}
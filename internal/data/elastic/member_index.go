@@ -0,0 +1,211 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+)
+
+// Bulk sends the actions to index in one bulk request. Upserts keep fields
+// of the stored document that UserDocument does not carry, replaces drop
+// them; deleting a document that is already gone is not an error.
+func (r *Repository) Bulk(ctx context.Context, index string, actions []elasticEntity.BulkAction) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	indexedAt := time.Now().UTC().Format(time.RFC3339)
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, action := range actions {
+		meta := map[string]interface{}{"_id": strconv.Itoa(action.Doc.GoldId)}
+		if action.Delete {
+			if err := enc.Encode(map[string]interface{}{"delete": meta}); err != nil {
+				return fmt.Errorf("marshal bulk action: %w", err)
+			}
+			continue
+		}
+
+		doc := action.Doc
+		doc.IndexedAt = indexedAt
+		doc.PhoneNormalized = elasticEntity.NormalizePhone(doc.GoldNomorHp)
+		if action.Replace {
+			if err := enc.Encode(map[string]interface{}{"index": meta}); err != nil {
+				return fmt.Errorf("marshal bulk action: %w", err)
+			}
+			if err := enc.Encode(doc); err != nil {
+				return fmt.Errorf("marshal bulk document: %w", err)
+			}
+			continue
+		}
+		if err := enc.Encode(map[string]interface{}{"update": meta}); err != nil {
+			return fmt.Errorf("marshal bulk action: %w", err)
+		}
+		if err := enc.Encode(map[string]interface{}{"doc": doc, "doc_as_upsert": true}); err != nil {
+			return fmt.Errorf("marshal bulk document: %w", err)
+		}
+	}
+
+	return r.sendBulk(ctx, index, &body)
+}
+
+// sendBulk sends the NDJSON bulk body to index and fails with every item
+// that did not succeed
+func (r *Repository) sendBulk(ctx context.Context, index string, body *bytes.Buffer) error {
+	res, err := r.client.Bulk(
+		body,
+		r.client.Bulk.WithContext(ctx),
+		r.client.Bulk.WithIndex(index),
+	)
+	if err != nil {
+		return fmt.Errorf("es bulk: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es bulk error [%s]: %s", res.Status(), string(b))
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string          `json:"_id"`
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode bulk result: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	var failed []string
+	for _, item := range result.Items {
+		for op, outcome := range item {
+			if outcome.Status < 300 || (op == "delete" && outcome.Status == http.StatusNotFound) {
+				continue
+			}
+			failed = append(failed, fmt.Sprintf("%s %s [%d]: %s", op, outcome.ID, outcome.Status, string(outcome.Error)))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("es bulk: %d item(s) failed: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// CreateIndex creates an empty index. Its settings and mappings come from
+// the template of its alias, which must be in place.
+func (r *Repository) CreateIndex(ctx context.Context, index string) error {
+	res, err := r.client.Indices.Create(
+		index,
+		r.client.Indices.Create.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("es create index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es create index error [%s]: %s", res.Status(), string(b))
+	}
+	return nil
+}
+
+// AliasIndices returns the indices behind alias. concrete is true when alias
+// is not an alias but an index of that name.
+func (r *Repository) AliasIndices(ctx context.Context, alias string) (indices []string, concrete bool, err error) {
+	res, err := r.client.Indices.GetAlias(
+		r.client.Indices.GetAlias.WithContext(ctx),
+		r.client.Indices.GetAlias.WithName(alias),
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("es get alias: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		exists, err := r.client.Indices.Exists([]string{alias}, r.client.Indices.Exists.WithContext(ctx))
+		if err != nil {
+			return nil, false, fmt.Errorf("es index exists: %w", err)
+		}
+		defer exists.Body.Close()
+		return nil, exists.StatusCode == http.StatusOK, nil
+	}
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return nil, false, fmt.Errorf("es get alias error [%s]: %s", res.Status(), string(b))
+	}
+
+	var result map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, false, fmt.Errorf("decode alias result: %w", err)
+	}
+	for index := range result {
+		indices = append(indices, index)
+	}
+	return indices, false, nil
+}
+
+// SwapAlias points alias at index and away from old in one atomic request.
+// With concrete set the index named like the alias is dropped in the same
+// request, so an index created before aliases were used can be replaced.
+func (r *Repository) SwapAlias(ctx context.Context, alias, index string, old []string, concrete bool) error {
+	var actions []map[string]interface{}
+	for _, o := range old {
+		actions = append(actions, map[string]interface{}{"remove": map[string]string{"index": o, "alias": alias}})
+	}
+	if concrete {
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]string{"index": alias}})
+	}
+	actions = append(actions, map[string]interface{}{"add": map[string]string{"index": index, "alias": alias}})
+
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("marshal alias actions: %w", err)
+	}
+	res, err := r.client.Indices.UpdateAliases(
+		bytes.NewReader(body),
+		r.client.Indices.UpdateAliases.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("es update aliases: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es update aliases error [%s]: %s", res.Status(), string(b))
+	}
+	return nil
+}
+
+// DeleteIndices drops indices, used for the ones a reindex replaced
+func (r *Repository) DeleteIndices(ctx context.Context, indices []string) error {
+	if len(indices) == 0 {
+		return nil
+	}
+	res, err := r.client.Indices.Delete(indices, r.client.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("es delete index: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es delete index error [%s]: %s", res.Status(), string(b))
+	}
+	return nil
+}
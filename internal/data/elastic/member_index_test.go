@@ -0,0 +1,115 @@
+package elastic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	es "github.com/elastic/go-elasticsearch/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepository mengarahkan client ES ke server uji yang mencatat path
+// dan body request terakhir
+func newTestRepository(t *testing.T, status int, response string) (*Repository, *http.Request, *string) {
+	var (
+		last = &http.Request{}
+		body = new(string)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		*last = *r
+		*body = string(b)
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := es.NewClient(es.Config{Addresses: []string{srv.URL}})
+	require.NoError(t, err)
+	return New(client, nil, jaegerLog.Factory{}), last, body
+}
+
+func TestBulk(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"errors":true,"items":[
+		{"update":{"_id":"7","status":200}},
+		{"delete":{"_id":"8","status":404}}]}`)
+
+	err := repo.Bulk(context.Background(), "gold-members", []elasticEntity.BulkAction{
+		{Doc: elasticEntity.UserDocument{GoldId: 7, GoldNama: "Budi"}},
+		{Delete: true, Doc: elasticEntity.UserDocument{GoldId: 8}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/gold-members/_bulk", req.URL.Path)
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(*body))
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 3)
+	assert.Equal(t, map[string]interface{}{"_id": "7"}, lines[0]["update"])
+	assert.Equal(t, true, lines[1]["doc_as_upsert"])
+	assert.Equal(t, "Budi", lines[1]["doc"].(map[string]interface{})["gold_nama"])
+	assert.Equal(t, map[string]interface{}{"_id": "8"}, lines[2]["delete"])
+
+	// item yang gagal dilaporkan sebagai error
+	repo, _, _ = newTestRepository(t, http.StatusOK, `{"errors":true,"items":[
+		{"update":{"_id":"7","status":429,"error":{"type":"es_rejected_execution_exception"}}}]}`)
+	err = repo.Bulk(context.Background(), "gold-members", []elasticEntity.BulkAction{{Doc: elasticEntity.UserDocument{GoldId: 7}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "es_rejected_execution_exception")
+}
+
+func TestSwapAlias(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"acknowledged":true}`)
+
+	err := repo.SwapAlias(context.Background(), "gold-members", "gold-members-2", []string{"gold-members-1"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, "/_aliases", req.URL.Path)
+	assert.JSONEq(t, `{"actions":[
+		{"remove":{"index":"gold-members-1","alias":"gold-members"}},
+		{"remove_index":{"index":"gold-members"}},
+		{"add":{"index":"gold-members-2","alias":"gold-members"}}]}`, *body)
+}
+
+func TestAliasIndices(t *testing.T) {
+	repo, req, _ := newTestRepository(t, http.StatusOK, `{"gold-members-1":{"aliases":{"gold-members":{}}}}`)
+
+	indices, concrete, err := repo.AliasIndices(context.Background(), "gold-members")
+	require.NoError(t, err)
+	assert.Equal(t, "/_alias/gold-members", req.URL.Path)
+	assert.Equal(t, []string{"gold-members-1"}, indices)
+	assert.False(t, concrete)
+}
+
+func TestBulkReplace(t *testing.T) {
+	repo, _, body := newTestRepository(t, http.StatusOK, `{"errors":false,"items":[]}`)
+
+	err := repo.Bulk(context.Background(), "gold-members", []elasticEntity.BulkAction{
+		{Replace: true, Doc: elasticEntity.UserDocument{GoldId: 7, GoldNomorHp: "+62 812-3456"}},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(*body), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"index":{"_id":"7"}}`, lines[0])
+	// dokumen ditulis utuh, nomor HP ikut dinormalisasi
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	assert.Equal(t, "628123456", doc["gold_nomorhp_normalized"])
+	assert.NotContains(t, doc, "doc_as_upsert")
+}
@@ -0,0 +1,183 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+)
+
+// minPhoneDigits is the shortest query also searched as a phone number
+const minPhoneDigits = 3
+
+// expiryLayout is how the expiry range bounds are sent
+const expiryLayout = "2006-01-02 15:04:05"
+
+// sortFields maps the sort keys of a search to the fields sorted on
+var sortFields = map[string]string{
+	elasticEntity.SortScore:  "_score",
+	elasticEntity.SortName:   "gold_nama.keyword",
+	elasticEntity.SortID:     "gold_id",
+	elasticEntity.SortExpiry: "subscription_end",
+}
+
+// SearchMembers runs search against index and returns one page of members
+// with their highlights and the number of all members found. search is
+// expected to be validated by the service.
+func (r *Repository) SearchMembers(ctx context.Context, index string, search elasticEntity.MemberSearch) (elasticEntity.SearchResult, error) {
+	var result elasticEntity.SearchResult
+
+	query, err := memberQuery(search)
+	if err != nil {
+		return result, err
+	}
+
+	var found struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source    elasticEntity.UserDocument `json:"_source"`
+				Highlight map[string][]string        `json:"highlight"`
+				Sort      json.RawMessage            `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := r.search(ctx, index, query, &found); err != nil {
+		return result, err
+	}
+
+	result.Total = found.Hits.Total.Value
+	result.Hits = make([]elasticEntity.MemberHit, 0, len(found.Hits.Hits))
+	for _, hit := range found.Hits.Hits {
+		result.Hits = append(result.Hits, elasticEntity.MemberHit{UserDocument: hit.Source, Highlight: hit.Highlight})
+	}
+	if n := len(found.Hits.Hits); n > 0 && n == search.Size {
+		result.Next = elasticEntity.EncodeCursor(found.Hits.Hits[n-1].Sort)
+	}
+	return result, nil
+}
+
+// memberQuery builds the search request body
+func memberQuery(search elasticEntity.MemberSearch) (map[string]interface{}, error) {
+	var filters []interface{}
+	if len(search.Status) > 0 {
+		filters = append(filters, map[string]interface{}{"terms": map[string]interface{}{"subscription_status": search.Status}})
+	}
+	if len(search.Packages) > 0 {
+		filters = append(filters, map[string]interface{}{"terms": map[string]interface{}{"subscription_packages": search.Packages}})
+	}
+	if search.BranchID > 0 {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"gold_branch_id": search.BranchID}})
+	}
+	if !search.ExpiresFrom.IsZero() || !search.ExpiresTo.IsZero() {
+		expiry := map[string]interface{}{"format": "yyyy-MM-dd HH:mm:ss"}
+		if !search.ExpiresFrom.IsZero() {
+			expiry["gte"] = search.ExpiresFrom.Format(expiryLayout)
+		}
+		if !search.ExpiresTo.IsZero() {
+			expiry["lte"] = search.ExpiresTo.Format(expiryLayout)
+		}
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"subscription_end": expiry}})
+	}
+
+	boolQuery := map[string]interface{}{}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+	if q := strings.TrimSpace(search.Query); q != "" {
+		boolQuery["should"] = textQueries(q)
+		boolQuery["minimum_should_match"] = 1
+	}
+
+	body := map[string]interface{}{
+		"query":            map[string]interface{}{"bool": boolQuery},
+		"sort":             memberSort(search),
+		"size":             search.Size,
+		"track_total_hits": true,
+		"highlight": map[string]interface{}{
+			"fields": map[string]interface{}{
+				"gold_nama":        map[string]interface{}{},
+				"gold_nama.prefix": map[string]interface{}{},
+				"gold_email":       map[string]interface{}{},
+				"gold_nomorhp":     map[string]interface{}{},
+			},
+		},
+	}
+	if search.After != "" {
+		after, err := elasticEntity.DecodeCursor(search.After)
+		if err != nil {
+			return nil, err
+		}
+		body["search_after"] = after
+	} else {
+		body["from"] = search.From
+	}
+	return body, nil
+}
+
+// textQueries matches q fuzzily against name, email and the stemmed package
+// names, as the start of a word of the name or of the email, and as the start
+// of a phone number
+func textQueries(q string) []interface{} {
+	queries := []interface{}{
+		map[string]interface{}{"multi_match": map[string]interface{}{
+			"query":         q,
+			"fields":        []string{"gold_nama^3", "gold_email", "subscription_packages.text"},
+			"fuzziness":     "AUTO",
+			"prefix_length": 1,
+		}},
+		map[string]interface{}{"match": map[string]interface{}{
+			"gold_nama.prefix": map[string]interface{}{"query": q, "operator": "and", "boost": 2},
+		}},
+		map[string]interface{}{"prefix": map[string]interface{}{
+			"gold_email": map[string]interface{}{"value": strings.ToLower(q)},
+		}},
+	}
+	if phone := elasticEntity.NormalizePhone(q); len(phone) >= minPhoneDigits && looksLikePhone(q) {
+		queries = append(queries, map[string]interface{}{"prefix": map[string]interface{}{
+			"gold_nomorhp_normalized": map[string]interface{}{"value": phone, "boost": 3},
+		}})
+	}
+	return queries
+}
+
+// looksLikePhone is true when q holds nothing but digits and the characters
+// phone numbers are written with
+func looksLikePhone(q string) bool {
+	for _, r := range q {
+		if (r < '0' || r > '9') && !strings.ContainsRune("+-(). ", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// memberSort sorts by the requested key, by relevance when there is a
+// query and by gold_id otherwise. gold_id always breaks ties, search_after
+// needs every member to have a distinct sort position.
+func memberSort(search elasticEntity.MemberSearch) []interface{} {
+	key := search.Sort
+	if key == "" {
+		key = elasticEntity.SortID
+		if strings.TrimSpace(search.Query) != "" {
+			key = "-" + elasticEntity.SortScore
+		}
+	}
+	order := "asc"
+	if strings.HasPrefix(key, "-") {
+		key, order = key[1:], "desc"
+	}
+
+	field := sortFields[key]
+	sort := []interface{}{map[string]interface{}{field: map[string]interface{}{"order": order}}}
+	if field == "subscription_end" {
+		sort[0] = map[string]interface{}{field: map[string]interface{}{"order": order, "missing": "_last"}}
+	}
+	if field != "gold_id" {
+		sort = append(sort, map[string]interface{}{"gold_id": map[string]interface{}{"order": "asc"}})
+	}
+	return sort
+}
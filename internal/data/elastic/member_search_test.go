@@ -0,0 +1,96 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchMembers(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"hits":{"total":{"value":42},"hits":[
+		{"_source":{"gold_id":7,"gold_nama":"Budi Santoso","gold_nomorhp":"0812-3456"},
+		 "highlight":{"gold_nama.prefix":["<em>Bud</em>i Santoso"]},
+		 "sort":[3.5,7]},
+		{"_source":{"gold_id":9,"gold_nama":"Budiman"},"sort":[2.1,9]}]}}`)
+
+	result, err := repo.SearchMembers(context.Background(), "gold-members", elasticEntity.MemberSearch{
+		Query:       "0812 3",
+		Status:      []string{"aktif"},
+		Packages:    []string{"Gold"},
+		BranchID:    2,
+		ExpiresFrom: time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+		Size:        2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/gold-members/_search", req.URL.Path)
+	assert.Equal(t, 42, result.Total)
+	require.Len(t, result.Hits, 2)
+	assert.Equal(t, "Budi Santoso", result.Hits[0].GoldNama)
+	assert.Equal(t, []string{"<em>Bud</em>i Santoso"}, result.Hits[0].Highlight["gold_nama.prefix"])
+	// halaman penuh, jadi ada cursor ke halaman berikutnya
+	after, err := elasticEntity.DecodeCursor(result.Next)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[2.1,9]`, string(after))
+
+	var sent map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(*body), &sent))
+	assert.Equal(t, true, sent["track_total_hits"])
+	assert.Equal(t, float64(0), sent["from"])
+	assert.JSONEq(t, `[{"_score":{"order":"desc"}},{"gold_id":{"order":"asc"}}]`, marshal(t, sent["sort"]))
+
+	query := sent["query"].(map[string]interface{})["bool"].(map[string]interface{})
+	assert.JSONEq(t, `[
+		{"terms":{"subscription_status":["aktif"]}},
+		{"terms":{"subscription_packages":["Gold"]}},
+		{"term":{"gold_branch_id":2}},
+		{"range":{"subscription_end":{"format":"yyyy-MM-dd HH:mm:ss","gte":"2026-10-01 00:00:00"}}}]`, marshal(t, query["filter"]))
+	// nomor HP dicari dalam bentuk yang dinormalisasi
+	should := query["should"].([]interface{})
+	require.Len(t, should, 4)
+	assert.JSONEq(t, `{"prefix":{"gold_nomorhp_normalized":{"value":"628123","boost":3}}}`, marshal(t, should[3]))
+}
+
+func TestSearchMembersPaging(t *testing.T) {
+	repo, _, body := newTestRepository(t, http.StatusOK, `{"hits":{"total":{"value":1},"hits":[
+		{"_source":{"gold_id":7},"sort":["budi",7]}]}}`)
+
+	cursor := elasticEntity.EncodeCursor([]byte(`["budi",5]`))
+	result, err := repo.SearchMembers(context.Background(), "gold-members", elasticEntity.MemberSearch{
+		Query: "budi",
+		Sort:  "-name",
+		Size:  20,
+		After: cursor,
+	})
+	require.NoError(t, err)
+	// halaman terakhir tidak punya cursor
+	assert.Empty(t, result.Next)
+
+	var sent map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(*body), &sent))
+	assert.NotContains(t, sent, "from")
+	assert.JSONEq(t, `["budi",5]`, marshal(t, sent["search_after"]))
+	assert.JSONEq(t, `[{"gold_nama.keyword":{"order":"desc"}},{"gold_id":{"order":"asc"}}]`, marshal(t, sent["sort"]))
+	// teks biasa tidak dicari sebagai nomor HP
+	should := sent["query"].(map[string]interface{})["bool"].(map[string]interface{})["should"].([]interface{})
+	assert.Len(t, should, 3)
+}
+
+func TestMemberSortWithoutQuery(t *testing.T) {
+	assert.Equal(t, []interface{}{map[string]interface{}{"gold_id": map[string]interface{}{"order": "asc"}}},
+		memberSort(elasticEntity.MemberSearch{}))
+	assert.Equal(t, map[string]interface{}{"subscription_end": map[string]interface{}{"order": "asc", "missing": "_last"}},
+		memberSort(elasticEntity.MemberSearch{Sort: elasticEntity.SortExpiry})[0])
+}
+
+func marshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,175 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+)
+
+// memberTemplate is the index template of the member indices, alias-*.
+// Every index a reindex creates gets these settings and mappings, and
+// dynamic mapping is off so a field only exists once it is declared here.
+//
+// gold_nama keeps names as written apart from case and accents,
+// gold_nama.prefix holds the edge n-grams of every word for autocomplete and
+// gold_nama.keyword sorts by name. Package names are Indonesian text,
+// subscription_packages.text drops Indonesian stop words and stems them so
+// "bulanan" finds "Paket Bulan". _meta.version is compared with
+// MemberTemplateVersion to tell indices built from an older template.
+const memberTemplate = `{
+  "index_patterns": [%q],
+  "version": %d,
+  "priority": 100,
+  "template": {
+    "settings": {
+      "analysis": {
+        "filter": {
+          "member_edge":        {"type": "edge_ngram", "min_gram": 1, "max_gram": 20},
+          "indonesian_stop":    {"type": "stop", "stopwords": "_indonesian_"},
+          "indonesian_stemmer": {"type": "stemmer", "language": "indonesian"}
+        },
+        "analyzer": {
+          "member_name":       {"type": "custom", "tokenizer": "standard", "filter": ["lowercase", "asciifolding"]},
+          "member_prefix":     {"type": "custom", "tokenizer": "standard", "filter": ["lowercase", "asciifolding", "member_edge"]},
+          "member_indonesian": {"type": "custom", "tokenizer": "standard", "filter": ["lowercase", "asciifolding", "indonesian_stop", "indonesian_stemmer"]}
+        },
+        "normalizer": {
+          "member_lowercase": {"type": "custom", "filter": ["lowercase"]}
+        }
+      }
+    },
+    "mappings": {
+      "dynamic": "strict",
+      "_meta": {"version": %d},
+      "properties": {
+        "gold_id":    {"type": "long"},
+        "gold_email": {"type": "keyword", "normalizer": "member_lowercase"},
+        "gold_nama": {
+          "type": "text",
+          "analyzer": "member_name",
+          "fields": {
+            "prefix":  {"type": "text", "analyzer": "member_prefix", "search_analyzer": "member_name"},
+            "keyword": {"type": "keyword", "normalizer": "member_lowercase", "ignore_above": 256}
+          }
+        },
+        "gold_nomorhp":            {"type": "keyword"},
+        "gold_nomorhp_normalized": {"type": "keyword"},
+        "gold_branch_id":          {"type": "long"},
+        "subscription_status":     {"type": "keyword"},
+        "subscription_packages": {
+          "type": "keyword",
+          "fields": {
+            "text": {"type": "text", "analyzer": "member_indonesian"}
+          }
+        },
+        "subscription_end": {"type": "date", "format": "yyyy-MM-dd HH:mm:ss||strict_date_optional_time||epoch_millis"},
+        "indexed_at":       {"type": "date"}
+      }
+    }
+  }
+}`
+
+// PutMemberTemplate installs the member template of alias, replacing the
+// one stored under the same name
+func (r *Repository) PutMemberTemplate(ctx context.Context, alias string) error {
+	return r.putTemplate(ctx, alias, fmt.Sprintf(memberTemplate, alias+"-*", elasticEntity.MemberTemplateVersion, elasticEntity.MemberTemplateVersion))
+}
+
+// putTemplate stores the index template body under the name alias, the
+// indices of alias are named alias-*
+func (r *Repository) putTemplate(ctx context.Context, alias, body string) error {
+	res, err := r.client.Indices.PutIndexTemplate(
+		alias,
+		strings.NewReader(body),
+		r.client.Indices.PutIndexTemplate.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("es put index template: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("es put index template error [%s]: %s", res.Status(), string(b))
+	}
+	return nil
+}
+
+// TemplateVersion returns the version of the stored index template of
+// alias, zero when there is none
+func (r *Repository) TemplateVersion(ctx context.Context, alias string) (int, error) {
+	res, err := r.client.Indices.GetIndexTemplate(
+		r.client.Indices.GetIndexTemplate.WithContext(ctx),
+		r.client.Indices.GetIndexTemplate.WithName(alias),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("es get index template: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return 0, fmt.Errorf("es get index template error [%s]: %s", res.Status(), string(b))
+	}
+
+	var result struct {
+		IndexTemplates []struct {
+			IndexTemplate struct {
+				Version int `json:"version"`
+			} `json:"index_template"`
+		} `json:"index_templates"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("decode index template: %w", err)
+	}
+	if len(result.IndexTemplates) == 0 {
+		return 0, nil
+	}
+	return result.IndexTemplates[0].IndexTemplate.Version, nil
+}
+
+// IndexVersions returns the template version every index behind alias was
+// built from, zero for an index without one such as a dynamically mapped
+// index. An alias that does not exist has no indices.
+func (r *Repository) IndexVersions(ctx context.Context, alias string) (map[string]int, error) {
+	res, err := r.client.Indices.GetMapping(
+		r.client.Indices.GetMapping.WithContext(ctx),
+		r.client.Indices.GetMapping.WithIndex(alias),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("es get mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return map[string]int{}, nil
+	}
+	if res.IsError() {
+		b, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("es get mapping error [%s]: %s", res.Status(), string(b))
+	}
+
+	var result map[string]struct {
+		Mappings struct {
+			Meta struct {
+				Version int `json:"version"`
+			} `json:"_meta"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode mapping: %w", err)
+	}
+	versions := make(map[string]int, len(result))
+	for index, mapping := range result {
+		versions[index] = mapping.Mappings.Meta.Version
+	}
+	return versions, nil
+}
@@ -0,0 +1,84 @@
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutMemberTemplate(t *testing.T) {
+	repo, req, body := newTestRepository(t, http.StatusOK, `{"acknowledged":true}`)
+
+	require.NoError(t, repo.PutMemberTemplate(context.Background(), "gold-members"))
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Equal(t, "/_index_template/gold-members", req.URL.Path)
+
+	var template struct {
+		IndexPatterns []string `json:"index_patterns"`
+		Version       int      `json:"version"`
+		Template      struct {
+			Mappings struct {
+				Dynamic string `json:"dynamic"`
+				Meta    struct {
+					Version int `json:"version"`
+				} `json:"_meta"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"mappings"`
+		} `json:"template"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(*body), &template))
+	assert.Equal(t, []string{"gold-members-*"}, template.IndexPatterns)
+	assert.Equal(t, elasticEntity.MemberTemplateVersion, template.Version)
+	assert.Equal(t, elasticEntity.MemberTemplateVersion, template.Template.Mappings.Meta.Version)
+	assert.Equal(t, "strict", template.Template.Mappings.Dynamic)
+
+	// mapping strict menolak field yang tidak dideklarasikan, semua field
+	// dokumen harus ada di template
+	doc, err := json.Marshal(elasticEntity.UserDocument{
+		GoldId: 1, GoldBranchID: 1, PhoneNormalized: "62",
+		SubscriptionStatus: []string{"aktif"}, SubscriptionPackages: []string{"Bulanan"}, SubscriptionEnd: "2026-12-31 00:00:00",
+	})
+	require.NoError(t, err)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(doc, &fields))
+	for field := range fields {
+		assert.Contains(t, template.Template.Mappings.Properties, field)
+	}
+}
+
+func TestTemplateVersion(t *testing.T) {
+	repo, req, _ := newTestRepository(t, http.StatusOK, `{"index_templates":[{"name":"gold-members","index_template":{"version":1}}]}`)
+
+	version, err := repo.TemplateVersion(context.Background(), "gold-members")
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.Equal(t, "/_index_template/gold-members", req.URL.Path)
+
+	// template yang belum ada berversi 0
+	repo, _, _ = newTestRepository(t, http.StatusNotFound, `{"error":{"type":"resource_not_found_exception"},"status":404}`)
+	version, err = repo.TemplateVersion(context.Background(), "gold-members")
+	require.NoError(t, err)
+	assert.Zero(t, version)
+}
+
+func TestIndexVersions(t *testing.T) {
+	repo, req, _ := newTestRepository(t, http.StatusOK, `{
+		"gold-members-2":{"mappings":{"_meta":{"version":2},"properties":{}}},
+		"gold-members-1":{"mappings":{"properties":{}}}}`)
+
+	versions, err := repo.IndexVersions(context.Background(), "gold-members")
+	require.NoError(t, err)
+	assert.Equal(t, "/gold-members/_mapping", req.URL.Path)
+	assert.Equal(t, map[string]int{"gold-members-1": 0, "gold-members-2": 2}, versions)
+
+	repo, _, _ = newTestRepository(t, http.StatusNotFound, `{"error":{"type":"index_not_found_exception"},"status":404}`)
+	versions, err = repo.IndexVersions(context.Background(), "gold-members")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
@@ -43,6 +43,12 @@ const (
 
 	insertSubscriptionDetail  = "InsertSubscriptionDetail"
 	qInsertSubscriptionDetail = `INSERT INTO subscription_detail (gold_id, gold_menuid, gold_namapaket, gold_namalayanan, gold_harga, gold_jadwal, gold_listlatihan, gold_jumlahpertemuan, gold_durasi, gold_statuslangganan) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// one running all-branch package is enough to open every branch
+	qGetMemberBranchAccess = `SELECT CASE WHEN EXISTS (SELECT 1 FROM subscription_detail d
+	JOIN subscription_product p ON p.gold_menuid = d.gold_menuid
+	WHERE d.gold_id = ? AND d.gold_statuslangganan = 'Berlangganan' AND d.gold_enddate >= NOW() AND p.gold_branch_access = 'all')
+	THEN 'all' ELSE 'home' END`
 )
 
 var (
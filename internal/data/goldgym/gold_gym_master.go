@@ -3,16 +3,22 @@ package goldgym
 import (
 	"context"
 	"database/sql"
+	outboxData "gold-gym-be/internal/data/outbox"
 	goldEntity "gold-gym-be/internal/entity/goldgym"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
 	"gold-gym-be/pkg/errors"
+	"strconv"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"gorm.io/gorm"
 )
 
 const dbTimeout = 3 * time.Second
 const dbTimeoutInsert = 5 * time.Second
 
+const qLastInsertID = `SELECT LAST_INSERT_ID()`
+
 func (d *Data) GetGoldUser(ctx context.Context) ([]goldEntity.GetGoldUser, error) {
 	var (
 		users []goldEntity.GetGoldUser
@@ -72,16 +78,38 @@ func (d *Data) GetGoldUserByEmailLogin(ctx context.Context, email string, passwo
 	return user, err
 }
 
+// InsertGoldUser stores the member and its MemberRegistered event in one
+// transaction
 func (d *Data) InsertGoldUser(ctx context.Context, user goldEntity.GetGoldUsers) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeoutInsert)
 	defer cancel()
-	err := d.db.WithContext(ctx).Create(&user).Error
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if user.GoldId == 0 {
+			// gold_id is assigned by the database
+			if err := tx.Raw(qLastInsertID).Scan(&user.GoldId).Error; err != nil {
+				return err
+			}
+		}
+		return insertMemberRegistered(tx, user.GoldId, user.GoldEmail, user.GoldNama)
+	})
 	if err != nil {
 		return "Gagal", err
 	}
 	return "Sukses", nil
 }
 
+func insertMemberRegistered(tx *gorm.DB, goldID int, email, nama string) error {
+	event, err := outboxEntity.NewEvent(outboxEntity.EventMemberRegistered, outboxEntity.AggregateMember, strconv.Itoa(goldID),
+		outboxEntity.MemberRegistered{GoldID: goldID, Email: email, Nama: nama}, time.Now())
+	if err != nil {
+		return err
+	}
+	return outboxData.Insert(tx, event)
+}
+
 func (d *Data) GetGoldToken(ctx context.Context) (goldEntity.LoginToken, error) {
 	var (
 		user goldEntity.LoginToken
@@ -290,6 +318,32 @@ func (d Data) UpdateValidasiPaymentHeader(ctx context.Context, updatePayment gol
 
 }
 
+// ConfirmPayment marks the subscription of updatePayment.GoldID paid, starts
+// its packages and stores event, the SubscriptionPaid of the payment, in one
+// transaction
+func (d Data) ConfirmPayment(ctx context.Context, updatePayment goldEntity.UpdatePayment, event outboxEntity.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeoutInsert)
+	defer cancel()
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&goldEntity.SubscriptionAll{}).Where("gold_id = ?", updatePayment.GoldID).Updates(map[string]interface{}{
+			"gold_validasipayment": "Y",
+			"gold_lastupdate":      gorm.Expr("NOW()"),
+		}).Error
+		if err != nil {
+			return errors.Wrap(err, "[DATA][ConfirmPayment]")
+		}
+		err = tx.Model(&goldEntity.SubscriptionDetail{}).Where("gold_id = ?", updatePayment.GoldID).Updates(map[string]interface{}{
+			"gold_startdate":       gorm.Expr("NOW()"),
+			"gold_enddate":         gorm.Expr("DATE_ADD(NOW(), INTERVAL 30 DAY)"),
+			"gold_statuslangganan": "Berlangganan",
+		}).Error
+		if err != nil {
+			return errors.Wrap(err, "[DATA][ConfirmPayment]")
+		}
+		return outboxData.Insert(tx, event)
+	})
+}
+
 func (d Data) UpdateValidasiPaymentDetail(ctx context.Context, updatePayment goldEntity.UpdatePayment) error {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
@@ -335,6 +389,19 @@ func (d Data) UpdateLastLogin(ctx context.Context, _user goldEntity.GetGoldUsers
 	}).Error
 }
 
+// GetMemberBranchAccess returns "all" when a running subscription of the
+// member is on an all-branch package, "home" otherwise
+func (d Data) GetMemberBranchAccess(ctx context.Context, goldID int) (string, error) {
+	var access string
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Raw(qGetMemberBranchAccess, goldID).Scan(&access).Error
+	if err != nil {
+		return "", errors.Wrap(err, "[DATA][GetMemberBranchAccess]")
+	}
+	return access, nil
+}
+
 func (d Data) UploadTestingImages(ctx context.Context, testing goldEntity.Testings) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
 	defer cancel()
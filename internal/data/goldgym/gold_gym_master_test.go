@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"gold-gym-be/internal/entity"
 	goldEntity "gold-gym-be/internal/entity/goldgym"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -462,7 +463,7 @@ func TestGetAllSubscription_Success(t *testing.T) {
 	assert.Len(t, subscriptions, 2)
 	assert.Equal(t, "Monthly", subscriptions[0].GoldNamaPaket)
 	assert.Equal(t, "Personal Training", subscriptions[0].GoldNamaLayanan)
-	assert.Equal(t, 500000.0, subscriptions[0].GoldHarga)
+	assert.Equal(t, entity.NewMoney(500000), subscriptions[0].GoldHarga)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -0,0 +1,107 @@
+package goldgym
+
+import (
+	"context"
+	goldEntity "gold-gym-be/internal/entity/goldgym"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (d *Data) GetMemberIdentity(ctx context.Context, provider, subject string) (goldEntity.MemberIdentity, error) {
+	var (
+		identity goldEntity.MemberIdentity
+		err      error
+	)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err = d.db.WithContext(ctx).Where("identity_provider = ? AND identity_subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return goldEntity.MemberIdentity{}, err
+	}
+	return identity, err
+}
+
+func (d *Data) GetMemberIdentities(ctx context.Context, goldID int) ([]goldEntity.MemberIdentity, error) {
+	var (
+		identities []goldEntity.MemberIdentity
+		err        error
+	)
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err = d.db.WithContext(ctx).Where("gold_id = ?", goldID).Order("identity_provider").Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	return identities, err
+}
+
+func (d *Data) InsertMemberIdentity(ctx context.Context, identity goldEntity.MemberIdentity) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeoutInsert)
+	defer cancel()
+	return d.db.WithContext(ctx).Create(&identity).Error
+}
+
+// DeleteMemberIdentity returns gorm.ErrRecordNotFound when nothing was unlinked
+func (d *Data) DeleteMemberIdentity(ctx context.Context, goldID int, provider string) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	res := d.db.WithContext(ctx).Where("gold_id = ? AND identity_provider = ?", goldID, provider).Delete(&goldEntity.MemberIdentity{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (d *Data) InsertOIDCLoginState(ctx context.Context, state goldEntity.OIDCLoginState) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeoutInsert)
+	defer cancel()
+	return d.db.WithContext(ctx).Create(&state).Error
+}
+
+// ConsumeOIDCLoginState reads and deletes a pending state in one transaction so
+// a callback can only be redeemed once. Expired rows are treated as missing.
+func (d *Data) ConsumeOIDCLoginState(ctx context.Context, stateID string) (goldEntity.OIDCLoginState, error) {
+	var state goldEntity.OIDCLoginState
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_id = ? AND state_expires_at > ?", stateID, time.Now()).First(&state).Error; err != nil {
+			return err
+		}
+		res := tx.Where("state_id = ?", stateID).Delete(&goldEntity.OIDCLoginState{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// another callback won the race
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return goldEntity.OIDCLoginState{}, err
+	}
+	return state, nil
+}
+
+// InsertGoldUserOIDC stores a member created from an external identity and
+// its MemberRegistered event in one transaction
+func (d *Data) InsertGoldUserOIDC(ctx context.Context, member goldEntity.OIDCMember) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeoutInsert)
+	defer cancel()
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		return insertMemberRegistered(tx, member.GoldId, member.GoldEmail, member.GoldNama)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return member.GoldId, nil
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	idemEntity "gold-gym-be/internal/entity/idempotency"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+)
+
+const keyPrefix = "idempotency:"
+
+// compare-and-set / compare-and-delete so a request whose lock already expired
+// cannot overwrite or drop the key of the request that took it over
+var (
+	casScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+return false`)
+
+	cadScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Data ...
+type Data struct {
+	rdb *redis.Client
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(rdb *redis.Client, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	return &Data{
+		rdb:    rdb,
+		tracer: tracer,
+		logger: logger,
+	}
+}
+
+// Reserve stores rec under key only if the key is free. When it is taken the
+// existing record is returned with reserved=false.
+func (d Data) Reserve(ctx context.Context, key string, rec idemEntity.Record, lockTTL time.Duration) (idemEntity.Record, bool, error) {
+	var existing idemEntity.Record
+
+	jsoned, err := json.Marshal(rec)
+	if err != nil {
+		return existing, false, errors.Wrap(err, "[DATA][Reserve]")
+	}
+
+	// the key can expire between SETNX and GET, so retry once before giving up
+	for attempt := 0; attempt < 2; attempt++ {
+		ok, err := d.rdb.SetNX(ctx, keyPrefix+key, jsoned, lockTTL).Result()
+		if err != nil {
+			return existing, false, errors.Wrap(err, "[DATA][Reserve]")
+		}
+		if ok {
+			return rec, true, nil
+		}
+
+		raw, err := d.rdb.Get(ctx, keyPrefix+key).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return existing, false, errors.Wrap(err, "[DATA][Reserve]")
+		}
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return existing, false, errors.Wrap(err, "[DATA][Reserve]")
+		}
+		return existing, false, nil
+	}
+
+	// treat a key that keeps flapping as busy
+	return idemEntity.Record{Status: idemEntity.StatusProcessing, Fingerprint: rec.Fingerprint}, false, nil
+}
+
+// Complete replaces our processing record with the completed one
+func (d Data) Complete(ctx context.Context, key string, processing, completed idemEntity.Record, ttl time.Duration) error {
+	current, err := json.Marshal(processing)
+	if err != nil {
+		return errors.Wrap(err, "[DATA][Complete]")
+	}
+	next, err := json.Marshal(completed)
+	if err != nil {
+		return errors.Wrap(err, "[DATA][Complete]")
+	}
+
+	err = casScript.Run(ctx, d.rdb, []string{keyPrefix + key}, current, next, ttl.Milliseconds()).Err()
+	if err != nil && err != redis.Nil {
+		return errors.Wrap(err, "[DATA][Complete]")
+	}
+	return nil
+}
+
+// Release frees the key so the client may retry, but only if we still hold it
+func (d Data) Release(ctx context.Context, key string, processing idemEntity.Record) error {
+	current, err := json.Marshal(processing)
+	if err != nil {
+		return errors.Wrap(err, "[DATA][Release]")
+	}
+
+	err = cadScript.Run(ctx, d.rdb, []string{keyPrefix + key}, current).Err()
+	if err != nil && err != redis.Nil {
+		return errors.Wrap(err, "[DATA][Release]")
+	}
+	return nil
+}
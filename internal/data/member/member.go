@@ -0,0 +1,110 @@
+package member
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+const dbTimeout = 10 * time.Second
+
+// Data reads members from MySQL in the shape of their search document
+type Data struct {
+	db *gorm.DB
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(db *gorm.DB, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	return &Data{
+		db:     db,
+		tracer: tracer,
+		logger: logger,
+	}
+}
+
+// memberRow is a member with its subscriptions folded into one row
+type memberRow struct {
+	elasticEntity.UserDocument
+	Statuses        string     `gorm:"column:statuses"`
+	Packages        string     `gorm:"column:packages"`
+	SubscriptionEnd *time.Time `gorm:"column:subscription_end"`
+}
+
+// qMemberSelect selects the member documents, the caller puts its WHERE
+// clause between it and qMemberGroup
+const (
+	qMemberSelect = `SELECT p.gold_id, p.gold_email, p.gold_nama, p.gold_nomorhp, p.gold_branch_id,
+	GROUP_CONCAT(DISTINCT d.gold_statuslangganan ORDER BY d.gold_statuslangganan SEPARATOR '|') AS statuses,
+	GROUP_CONCAT(DISTINCT d.gold_namapaket ORDER BY d.gold_namapaket SEPARATOR '|') AS packages,
+	MAX(d.gold_enddate) AS subscription_end
+	FROM data_peserta p
+	LEFT JOIN subscription_detail d ON d.gold_id = p.gold_id`
+	qMemberGroup = `GROUP BY p.gold_id, p.gold_email, p.gold_nama, p.gold_nomorhp, p.gold_branch_id`
+
+	subscriptionEndLayout = "2006-01-02 15:04:05"
+)
+
+// GetMemberDocuments returns up to limit members with gold_id above afterID
+// in gold_id order, only the ones updated since then when since is set
+func (d *Data) GetMemberDocuments(ctx context.Context, afterID, limit int, since time.Time) ([]elasticEntity.UserDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	where, args := "WHERE p.gold_id > ?", []interface{}{afterID}
+	if !since.IsZero() {
+		where += " AND p.gold_updated_at >= ?"
+		args = append(args, since)
+	}
+
+	var rows []memberRow
+	query := qMemberSelect + " " + where + " " + qMemberGroup + " ORDER BY p.gold_id LIMIT ?"
+	if err := d.db.WithContext(ctx).Raw(query, append(args, limit)...).Scan(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetMemberDocuments]")
+	}
+	docs := make([]elasticEntity.UserDocument, len(rows))
+	for i, row := range rows {
+		docs[i] = row.document()
+	}
+	return docs, nil
+}
+
+// GetMemberDocument returns the document of one member, ErrNotFound when
+// there is no member with goldID
+func (d *Data) GetMemberDocument(ctx context.Context, goldID int) (elasticEntity.UserDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var rows []memberRow
+	query := qMemberSelect + " WHERE p.gold_id = ? " + qMemberGroup
+	if err := d.db.WithContext(ctx).Raw(query, goldID).Scan(&rows).Error; err != nil {
+		return elasticEntity.UserDocument{}, errors.Wrap(err, "[DATA][GetMemberDocument]")
+	}
+	if len(rows) == 0 {
+		return elasticEntity.UserDocument{}, errors.Wrap(entity.ErrNotFound, "[DATA][GetMemberDocument]")
+	}
+	return rows[0].document(), nil
+}
+
+func (r memberRow) document() elasticEntity.UserDocument {
+	doc := r.UserDocument
+	if r.Statuses != "" {
+		doc.SubscriptionStatus = strings.Split(r.Statuses, "|")
+	}
+	if r.Packages != "" {
+		doc.SubscriptionPackages = strings.Split(r.Packages, "|")
+	}
+	if r.SubscriptionEnd != nil {
+		doc.SubscriptionEnd = r.SubscriptionEnd.Format(subscriptionEndLayout)
+	}
+	return doc
+}
@@ -0,0 +1,88 @@
+package member
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+var memberColumns = []string{"gold_id", "gold_email", "gold_nama", "gold_nomorhp", "gold_branch_id", "statuses", "packages", "subscription_end"}
+
+func TestGetMemberDocuments(t *testing.T) {
+	db, mock := setupMockDB(t)
+	d := New(db, nil, jaegerLog.Factory{})
+	end := time.Date(2026, 12, 31, 23, 59, 59, 0, time.Local)
+
+	mock.ExpectQuery(`SELECT p\.gold_id, .* FROM data_peserta p\s+LEFT JOIN subscription_detail d ON d\.gold_id = p\.gold_id WHERE p\.gold_id > \? GROUP BY .* ORDER BY p\.gold_id LIMIT \?`).
+		WithArgs(0, 2).
+		WillReturnRows(sqlmock.NewRows(memberColumns).
+			AddRow(1, "budi@test.com", "Budi", "0811", 1, "aktif|expired", "Gold|Silver", end).
+			AddRow(2, "sari@test.com", "Sari", "0812", 2, nil, nil, nil))
+
+	docs, err := d.GetMemberDocuments(context.Background(), 0, 2, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, []string{"aktif", "expired"}, docs[0].SubscriptionStatus)
+	assert.Equal(t, []string{"Gold", "Silver"}, docs[0].SubscriptionPackages)
+	assert.Equal(t, "2026-12-31 23:59:59", docs[0].SubscriptionEnd)
+	assert.Equal(t, 2, docs[1].GoldId)
+	assert.Equal(t, int64(2), docs[1].GoldBranchID)
+	assert.Equal(t, "0812", docs[1].GoldNomorHp)
+	// member tanpa langganan tidak punya field langganan
+	assert.Nil(t, docs[1].SubscriptionStatus)
+	assert.Empty(t, docs[1].SubscriptionEnd)
+
+	// hanya yang berubah sejak waktu tertentu
+	since := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	mock.ExpectQuery(`WHERE p\.gold_id > \? AND p\.gold_updated_at >= \? GROUP BY .* ORDER BY p\.gold_id LIMIT \?`).
+		WithArgs(2, since, 500).
+		WillReturnRows(sqlmock.NewRows(memberColumns))
+
+	docs, err = d.GetMemberDocuments(context.Background(), 2, 500, since)
+	require.NoError(t, err)
+	assert.Empty(t, docs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMemberDocument(t *testing.T) {
+	db, mock := setupMockDB(t)
+	d := New(db, nil, jaegerLog.Factory{})
+
+	mock.ExpectQuery(`WHERE p\.gold_id = \? GROUP BY`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(memberColumns).AddRow(7, "budi@test.com", "Budi", "0811", 1, "aktif", "Gold", nil))
+	doc, err := d.GetMemberDocument(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Gold"}, doc.SubscriptionPackages)
+
+	mock.ExpectQuery(`WHERE p\.gold_id = \? GROUP BY`).
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows(memberColumns))
+	_, err = d.GetMemberDocument(context.Background(), 8)
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
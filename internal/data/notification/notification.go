@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/opentracing/opentracing-go"
+	"gopkg.in/gomail.v2"
+)
+
+// ErrNotConfigured is returned when no SMTP host is set
+var ErrNotConfigured = errors.New("smtp is not configured")
+
+// Data sends email through an SMTP server
+type Data struct {
+	dialer *gomail.Dialer
+	from   string
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(host string, port int, username, password, from string, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	d := &Data{
+		from:   from,
+		tracer: tracer,
+		logger: logger,
+	}
+	if host != "" {
+		d.dialer = gomail.NewDialer(host, port, username, password)
+	}
+	return d
+}
+
+// SendEmail sends a plain text email to every address in to
+func (d *Data) SendEmail(ctx context.Context, to []string, subject, body string) error {
+	if d.dialer == nil {
+		return errors.Wrap(ErrNotConfigured, "[DATA][SendEmail]")
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	message := gomail.NewMessage()
+	message.SetHeader("From", d.from)
+	message.SetHeader("To", to...)
+	message.SetHeader("Subject", subject)
+	message.SetBody("text/plain", body)
+
+	if err := d.dialer.DialAndSend(message); err != nil {
+		return errors.Wrap(err, "[DATA][SendEmail]")
+	}
+	return nil
+}
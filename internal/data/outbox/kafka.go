@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"context"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Message headers of a published event, consumers can route on them without
+// decoding the envelope
+const (
+	HeaderEventType = "event_type"
+	HeaderSchemaID  = "schema_id"
+	HeaderEventID   = "event_id"
+)
+
+// MessageWriter is the part of kafka.Writer the publisher uses
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Publisher writes outbox events to one Kafka topic
+type Publisher struct {
+	writer MessageWriter
+}
+
+// NewPublisher ...
+func NewPublisher(writer MessageWriter) *Publisher {
+	return &Publisher{writer: writer}
+}
+
+// NewKafkaWriter returns the writer for the events topic. Messages are keyed
+// by aggregate and the hash balancer sends a key to one partition, which is
+// what keeps the events of an aggregate in order for consumers.
+func NewKafkaWriter(brokers []string, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+}
+
+// Publish is a PublishFunc
+func (p *Publisher) Publish(ctx context.Context, events []outboxEntity.Event) error {
+	msgs := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		msgs = append(msgs, Message(event))
+	}
+
+	err := p.writer.WriteMessages(ctx, msgs...)
+	if writeErrs, ok := err.(kafka.WriteErrors); ok && len(writeErrs) == len(events) {
+		return outboxEntity.PublishErrors(writeErrs)
+	}
+	return err
+}
+
+// Message is the Kafka message of event
+func Message(event outboxEntity.Event) kafka.Message {
+	return kafka.Message{
+		Key:   []byte(event.Key()),
+		Value: event.Envelope,
+		Headers: []kafka.Header{
+			{Key: HeaderEventType, Value: []byte(event.EventType)},
+			{Key: HeaderSchemaID, Value: []byte(outboxEntity.SchemaIDs[event.EventType])},
+			{Key: HeaderEventID, Value: []byte(event.EventID)},
+		},
+		Time: event.CreatedAt,
+	}
+}
@@ -0,0 +1,131 @@
+package outbox
+
+import (
+	"context"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+const (
+	// the relay holds the row locks while it writes to Kafka
+	dbTimeoutRelay = 30 * time.Second
+
+	maxErrorLen = 512
+
+	// the oldest unpublished event of every aggregate. A later event of the
+	// same aggregate is not picked while an earlier one is pending, and rows
+	// locked by another relay are skipped rather than waited on, so each
+	// aggregate is published by one relay at a time and in outbox_id order.
+	qLockPending = `SELECT o.* FROM outbox_event o
+WHERE o.outbox_published_at IS NULL
+AND NOT EXISTS (SELECT 1 FROM outbox_event p
+	WHERE p.outbox_published_at IS NULL
+	AND p.outbox_aggregate_type = o.outbox_aggregate_type
+	AND p.outbox_aggregate_id = o.outbox_aggregate_id
+	AND p.outbox_id < o.outbox_id)
+ORDER BY o.outbox_id
+LIMIT ?
+FOR UPDATE SKIP LOCKED`
+
+	qMarkPublished = `UPDATE outbox_event SET outbox_published_at = ?, outbox_attempts = outbox_attempts + 1, outbox_last_error = '' WHERE outbox_id IN ?`
+
+	qMarkFailed = `UPDATE outbox_event SET outbox_attempts = outbox_attempts + 1, outbox_last_error = ? WHERE outbox_id = ?`
+)
+
+// PublishFunc writes events to the broker in order. When only some of them
+// were written it returns outboxEntity.PublishErrors.
+type PublishFunc func(ctx context.Context, events []outboxEntity.Event) error
+
+// Data ...
+type Data struct {
+	db *gorm.DB
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(db *gorm.DB, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	return &Data{
+		db:     db,
+		tracer: tracer,
+		logger: logger,
+	}
+}
+
+// Insert writes events with tx, the transaction of the change they describe,
+// so an event is stored if and only if its change is committed
+func Insert(tx *gorm.DB, events ...outboxEntity.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return errors.Wrap(err, "[DATA][outbox.Insert]")
+	}
+	return nil
+}
+
+// Relay locks up to limit pending events, at most one per aggregate, hands
+// them to publish and records the outcome of each. It returns how many were
+// published. A failed event stays pending and blocks the later events of its
+// aggregate until it is published.
+func (d *Data) Relay(ctx context.Context, limit int, publish PublishFunc) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeoutRelay)
+	defer cancel()
+
+	published := 0
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []outboxEntity.Event
+		if err := tx.Raw(qLockPending, limit).Scan(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		perEvent := make([]error, len(events))
+		if err := publish(ctx, events); err != nil {
+			if partial, ok := err.(outboxEntity.PublishErrors); ok && len(partial) == len(events) {
+				perEvent = partial
+			} else {
+				for i := range perEvent {
+					perEvent[i] = err
+				}
+			}
+		}
+
+		var ids []int64
+		for i, event := range events {
+			if perEvent[i] == nil {
+				ids = append(ids, event.OutboxID)
+				continue
+			}
+			if err := tx.Exec(qMarkFailed, truncate(perEvent[i].Error(), maxErrorLen), event.OutboxID).Error; err != nil {
+				return err
+			}
+		}
+		if len(ids) > 0 {
+			if err := tx.Exec(qMarkPublished, time.Now(), ids).Error; err != nil {
+				return err
+			}
+		}
+		published = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "[DATA][Relay]")
+	}
+	return published, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,206 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// setupMockDB creates a mock database for testing
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+var outboxColumns = []string{"outbox_id", "outbox_event_id", "outbox_event_type", "outbox_aggregate_type", "outbox_aggregate_id", "outbox_envelope", "outbox_created_at", "outbox_published_at", "outbox_attempts", "outbox_last_error"}
+
+func pendingRows(events ...outboxEntity.Event) *sqlmock.Rows {
+	rows := sqlmock.NewRows(outboxColumns)
+	for _, e := range events {
+		rows.AddRow(e.OutboxID, e.EventID, e.EventType, e.AggregateType, e.AggregateID, e.Envelope, e.CreatedAt, nil, e.Attempts, "")
+	}
+	return rows
+}
+
+func newEvent(t *testing.T, id int64, eventType, aggregateType, aggregateID string) outboxEntity.Event {
+	event, err := outboxEntity.NewEvent(eventType, aggregateType, aggregateID, map[string]string{"id": aggregateID}, time.Now())
+	require.NoError(t, err)
+	event.OutboxID = id
+	return event
+}
+
+func TestRelayPublishesPending(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	member := newEvent(t, 1, outboxEntity.EventMemberRegistered, outboxEntity.AggregateMember, "7")
+	sale := newEvent(t, 3, outboxEntity.EventSaleCompleted, outboxEntity.AggregateSale, "SL1")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(qLockPending)).
+		WithArgs(10).
+		WillReturnRows(pendingRows(member, sale))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE outbox_event SET outbox_published_at = ?")).
+		WithArgs(sqlmock.AnyArg(), int64(1), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	var got []outboxEntity.Event
+	n, err := repo.Relay(context.Background(), 10, func(ctx context.Context, events []outboxEntity.Event) error {
+		got = events
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	require.Len(t, got, 2)
+	assert.Equal(t, member.EventID, got[0].EventID)
+	assert.Equal(t, member.Envelope, got[0].Envelope)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayRecordsFailures(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	member := newEvent(t, 1, outboxEntity.EventMemberRegistered, outboxEntity.AggregateMember, "7")
+	stock := newEvent(t, 2, outboxEntity.EventStockLow, outboxEntity.AggregateStock, "WHEY-1")
+
+	// hanya event kedua yang gagal, yang pertama tetap ditandai terkirim
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(qLockPending)).
+		WithArgs(10).
+		WillReturnRows(pendingRows(member, stock))
+	mock.ExpectExec(regexp.QuoteMeta(qMarkFailed)).
+		WithArgs("leader not available", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE outbox_event SET outbox_published_at = ?")).
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := repo.Relay(context.Background(), 10, func(ctx context.Context, events []outboxEntity.Event) error {
+		return outboxEntity.PublishErrors{nil, errors.New("leader not available")}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// broker mati, semua event tetap pending
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(qLockPending)).
+		WithArgs(10).
+		WillReturnRows(pendingRows(stock))
+	mock.ExpectExec(regexp.QuoteMeta(qMarkFailed)).
+		WithArgs("dial tcp: connection refused", int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err = repo.Relay(context.Background(), 10, func(ctx context.Context, events []outboxEntity.Event) error {
+		return errors.New("dial tcp: connection refused")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayNothingPending(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(qLockPending)).
+		WithArgs(10).
+		WillReturnRows(pendingRows())
+	mock.ExpectCommit()
+
+	n, err := repo.Relay(context.Background(), 10, func(ctx context.Context, events []outboxEntity.Event) error {
+		t.Fatal("publish dipanggil tanpa event")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertInTransaction(t *testing.T) {
+	db, mock := setupMockDB(t)
+	event := newEvent(t, 0, outboxEntity.EventStockLow, outboxEntity.AggregateStock, "WHEY-1")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `outbox_event`").
+		WithArgs(event.EventID, outboxEntity.EventStockLow, outboxEntity.AggregateStock, "WHEY-1", event.Envelope, event.CreatedAt, nil, 0, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	// event ikut batal bersama transaksinya
+	err := db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, Insert(tx, event))
+		return errors.New("stock update failed")
+	})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type fakeWriter struct {
+	msgs []kafka.Message
+	err  error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return w.err
+}
+
+func TestPublisherMessage(t *testing.T) {
+	writer := &fakeWriter{}
+	publisher := NewPublisher(writer)
+	event := newEvent(t, 1, outboxEntity.EventSubscriptionPaid, outboxEntity.AggregateMember, "7")
+
+	require.NoError(t, publisher.Publish(context.Background(), []outboxEntity.Event{event}))
+	require.Len(t, writer.msgs, 1)
+	msg := writer.msgs[0]
+	assert.Equal(t, "member:7", string(msg.Key))
+	assert.Equal(t, event.Envelope, msg.Value)
+	headers := map[string]string{}
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	assert.Equal(t, map[string]string{
+		HeaderEventType: outboxEntity.EventSubscriptionPaid,
+		HeaderSchemaID:  "goldgym.subscription.paid.v1",
+		HeaderEventID:   event.EventID,
+	}, headers)
+}
+
+func TestPublisherPartialWrite(t *testing.T) {
+	failed := errors.New("message too large")
+	writer := &fakeWriter{err: kafka.WriteErrors{nil, failed}}
+	publisher := NewPublisher(writer)
+	events := []outboxEntity.Event{
+		newEvent(t, 1, outboxEntity.EventStockLow, outboxEntity.AggregateStock, "WHEY-1"),
+		newEvent(t, 2, outboxEntity.EventStockLow, outboxEntity.AggregateStock, "ISO-1"),
+	}
+
+	err := publisher.Publish(context.Background(), events)
+	assert.Equal(t, outboxEntity.PublishErrors{nil, failed}, err)
+}
@@ -0,0 +1,69 @@
+package partner
+
+import (
+	"context"
+	partnerEntity "gold-gym-be/internal/entity/partner"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	dbTimeout       = 3 * time.Second
+	dbTimeoutInsert = 5 * time.Second
+
+	externalIDPrefix = "partner:external-id:"
+)
+
+// Data ...
+type Data struct {
+	db  *gorm.DB
+	rdb *redis.Client
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(db *gorm.DB, rdb *redis.Client, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	return &Data{
+		db:     db,
+		rdb:    rdb,
+		tracer: tracer,
+		logger: logger,
+	}
+}
+
+func (d *Data) GetPartnerByClientKey(ctx context.Context, clientKey string) (partnerEntity.Partner, error) {
+	var partner partnerEntity.Partner
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Where("partner_client_key = ?", clientKey).First(&partner).Error
+	if err != nil {
+		return partnerEntity.Partner{}, err
+	}
+	return partner, nil
+}
+
+// InsertPartnerMember is a no-op when the member is already enrolled by the partner
+func (d *Data) InsertPartnerMember(ctx context.Context, member partnerEntity.PartnerMember) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeoutInsert)
+	defer cancel()
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error
+}
+
+// ReserveExternalID returns false when the partner already used externalID
+// within ttl
+func (d *Data) ReserveExternalID(ctx context.Context, clientKey, externalID string, ttl time.Duration) (bool, error) {
+	ok, err := d.rdb.SetNX(ctx, externalIDPrefix+clientKey+":"+externalID, time.Now().Unix(), ttl).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "[DATA][ReserveExternalID]")
+	}
+	return ok, nil
+}
@@ -0,0 +1,104 @@
+package product
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+const dbTimeout = 10 * time.Second
+
+// Data reads stock variants from MySQL in the shape of their search
+// document
+type Data struct {
+	db *gorm.DB
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(db *gorm.DB, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	return &Data{
+		db:     db,
+		tracer: tracer,
+		logger: logger,
+	}
+}
+
+// stockRow is a stock variant with its barcodes folded into one row
+type stockRow struct {
+	elasticEntity.StockDocument
+	Barcodes string `gorm:"column:barcodes"`
+}
+
+// qStockSelect selects the stock documents, the caller puts its WHERE
+// clause between it and qStockGroup
+const (
+	qStockSelect = `SELECT s.stock_code, s.stock_name, s.stock_pack, s.stock_qty, s.stock_price,
+	COALESCE(s.stock_product_code, '') AS product_code, COALESCE(p.product_name, '') AS product_name,
+	COALESCE(c.category_name, '') AS category_name, COALESCE(s.stock_flavour, '') AS flavour, COALESCE(s.stock_size, '') AS size,
+	GROUP_CONCAT(b.barcode ORDER BY b.barcode SEPARATOR '|') AS barcodes
+	FROM stock s
+	LEFT JOIN product p ON p.product_code = s.stock_product_code
+	LEFT JOIN product_category c ON c.category_id = p.product_category_id
+	LEFT JOIN stock_barcode b ON b.stock_code = s.stock_code`
+	qStockGroup = `GROUP BY s.stock_code, s.stock_name, s.stock_pack, s.stock_qty, s.stock_price, s.stock_product_code, p.product_name, c.category_name, s.stock_flavour, s.stock_size`
+)
+
+// GetStockDocuments returns up to limit stock variants with a stock_code
+// after afterCode in stock_code order, only the ones updated since then when
+// since is set
+func (d *Data) GetStockDocuments(ctx context.Context, afterCode string, limit int, since time.Time) ([]elasticEntity.StockDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	where, args := "WHERE s.stock_code > ?", []interface{}{afterCode}
+	if !since.IsZero() {
+		where += " AND s.stock_last_update >= ?"
+		args = append(args, since)
+	}
+
+	var rows []stockRow
+	query := qStockSelect + " " + where + " " + qStockGroup + " ORDER BY s.stock_code LIMIT ?"
+	if err := d.db.WithContext(ctx).Raw(query, append(args, limit)...).Scan(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetStockDocuments]")
+	}
+	docs := make([]elasticEntity.StockDocument, len(rows))
+	for i, row := range rows {
+		docs[i] = row.document()
+	}
+	return docs, nil
+}
+
+// GetStockDocument returns the document of one stock variant, ErrNotFound
+// when there is no stock with code
+func (d *Data) GetStockDocument(ctx context.Context, code string) (elasticEntity.StockDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var rows []stockRow
+	query := qStockSelect + " WHERE s.stock_code = ? " + qStockGroup
+	if err := d.db.WithContext(ctx).Raw(query, code).Scan(&rows).Error; err != nil {
+		return elasticEntity.StockDocument{}, errors.Wrap(err, "[DATA][GetStockDocument]")
+	}
+	if len(rows) == 0 {
+		return elasticEntity.StockDocument{}, errors.Wrap(entity.ErrNotFound, "[DATA][GetStockDocument]")
+	}
+	return rows[0].document(), nil
+}
+
+func (r stockRow) document() elasticEntity.StockDocument {
+	doc := r.StockDocument
+	if r.Barcodes != "" {
+		doc.Barcodes = strings.Split(r.Barcodes, "|")
+	}
+	return doc
+}
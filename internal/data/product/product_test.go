@@ -0,0 +1,84 @@
+package product
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+var stockColumns = []string{"stock_code", "stock_name", "stock_pack", "stock_qty", "stock_price", "product_code", "product_name", "category_name", "flavour", "size", "barcodes"}
+
+func TestGetStockDocuments(t *testing.T) {
+	db, mock := setupMockDB(t)
+	d := New(db, nil, jaegerLog.Factory{})
+
+	mock.ExpectQuery(`SELECT s\.stock_code, .* FROM stock s\s+LEFT JOIN product p .* LEFT JOIN stock_barcode b ON b\.stock_code = s\.stock_code WHERE s\.stock_code > \? GROUP BY .* ORDER BY s\.stock_code LIMIT \?`).
+		WithArgs("", 2).
+		WillReturnRows(sqlmock.NewRows(stockColumns).
+			AddRow("WHEY-1", "Whey Coklat", "pcs", 5, "350000.00", "WHEY", "Whey Protein", "Suplemen", "Coklat", "1kg", "899111|899222").
+			AddRow("TOWEL", "Handuk", "pcs", 10, "25000.00", "", "", "", "", "", nil))
+
+	docs, err := d.GetStockDocuments(context.Background(), "", 2, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, []string{"899111", "899222"}, docs[0].Barcodes)
+	assert.Equal(t, "Whey Protein", docs[0].ProductName)
+	assert.Equal(t, entity.NewMoney(350000), docs[0].StockPrice)
+	// stok tanpa produk dan barcode
+	assert.Empty(t, docs[1].ProductCode)
+	assert.Nil(t, docs[1].Barcodes)
+
+	// hanya yang berubah sejak waktu tertentu
+	since := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	mock.ExpectQuery(`WHERE s\.stock_code > \? AND s\.stock_last_update >= \? GROUP BY`).
+		WithArgs("TOWEL", since, 500).
+		WillReturnRows(sqlmock.NewRows(stockColumns))
+
+	docs, err = d.GetStockDocuments(context.Background(), "TOWEL", 500, since)
+	require.NoError(t, err)
+	assert.Empty(t, docs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetStockDocument(t *testing.T) {
+	db, mock := setupMockDB(t)
+	d := New(db, nil, jaegerLog.Factory{})
+
+	mock.ExpectQuery(`WHERE s\.stock_code = \? GROUP BY`).
+		WithArgs("WHEY-1").
+		WillReturnRows(sqlmock.NewRows(stockColumns).AddRow("WHEY-1", "Whey Coklat", "pcs", 5, "350000.00", "", "", "", "", "", "899111"))
+	doc, err := d.GetStockDocument(context.Background(), "WHEY-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"899111"}, doc.Barcodes)
+
+	mock.ExpectQuery(`WHERE s\.stock_code = \? GROUP BY`).
+		WithArgs("NOPE").
+		WillReturnRows(sqlmock.NewRows(stockColumns))
+	_, err = d.GetStockDocument(context.Background(), "NOPE")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,68 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	replicationEntity "gold-gym-be/internal/entity/replication"
+	"gold-gym-be/pkg/errors"
+	"strings"
+	"time"
+)
+
+// reconcileTimeout bounds one full table scan
+const reconcileTimeout = time.Minute
+
+const qColumns = "SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY COLUMN_NAME"
+
+// Columns lists the columns of table in the database
+func (d *Data) Columns(ctx context.Context, table replicationEntity.Table) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var columns []string
+	if err := d.db.WithContext(ctx).Raw(qColumns, table.Name).Scan(&columns).Error; err != nil {
+		return nil, errors.Wrap(err, "[DATA][Columns] "+table.Name)
+	}
+	return columns, nil
+}
+
+// Checksums returns the MD5 of columns for every row of table, keyed by the
+// values of the table keys joined by "|". QUOTE keeps NULL apart from the
+// text NULL and a value containing the separator apart from two values.
+func (d *Data) Checksums(ctx context.Context, table replicationEntity.Table, columns []string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, reconcileTimeout)
+	defer cancel()
+
+	for _, column := range append(append([]string{}, table.Keys...), columns...) {
+		if !identifier.MatchString(column) {
+			return nil, errors.Wrap(replicationEntity.ErrBadIdentity, column)
+		}
+	}
+	rows, err := d.db.WithContext(ctx).Raw(checksumQuery(table, columns)).Rows()
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][Checksums] "+table.Name)
+	}
+	defer rows.Close()
+
+	checksums := map[string]string{}
+	for rows.Next() {
+		var key, sum string
+		if err := rows.Scan(&key, &sum); err != nil {
+			return nil, errors.Wrap(err, "[DATA][Checksums] "+table.Name)
+		}
+		checksums[key] = sum
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "[DATA][Checksums] "+table.Name)
+	}
+	return checksums, nil
+}
+
+func checksumQuery(table replicationEntity.Table, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = "QUOTE(`" + column + "`)"
+	}
+	return fmt.Sprintf("SELECT CONCAT_WS('|', %s) AS row_key, MD5(CONCAT_WS('|', %s)) AS checksum FROM `%s`",
+		quote(table.Keys), strings.Join(quoted, ", "), table.Name)
+}
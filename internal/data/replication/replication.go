@@ -0,0 +1,246 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gold-gym-be/internal/entity"
+	replicationEntity "gold-gym-be/internal/entity/replication"
+	"gold-gym-be/pkg/errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	dbTimeout = 3 * time.Second
+
+	datetimeLayout = "2006-01-02 15:04:05.000"
+
+	// oldestVersion stands in for an event without a version under
+	// last-writer-wins, any versioned target row is newer than it
+	oldestVersion = "1000-01-01 00:00:00"
+)
+
+var identifier = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Data applies change events of the source database to db, the database
+// named name. Every row it writes is tagged with source.
+type Data struct {
+	db     *gorm.DB
+	name   string
+	source string
+}
+
+// New ...
+func New(db *gorm.DB, name, source string) *Data {
+	return &Data{db: db, name: name, source: source}
+}
+
+// targetRow is what the target holds under the keys of an event. Newer
+// counts rows whose version is newer than the one of the event.
+type targetRow struct {
+	Found int64 `gorm:"column:found"`
+	Newer int64 `gorm:"column:newer"`
+}
+
+// Apply writes one change event of table to the target and reports whether
+// it was applied or skipped. Creates and updates become an insert or an
+// update depending on whether the target has the row, so redelivered and
+// out of order events converge on the same row. The target row is locked
+// while the conflict rule is checked.
+//
+// A create or update whose row was replicated from the target itself is
+// the echo of an earlier Apply and is not written back. Deletes carry no
+// tag of their own, an echoed delete finds nothing left to delete.
+func (d *Data) Apply(ctx context.Context, table replicationEntity.Table, op string, after, before map[string]interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	row := after
+	switch op {
+	case replicationEntity.OpCreate, replicationEntity.OpUpdate, replicationEntity.OpRead:
+		if replicationEntity.Origin(after[replicationEntity.SyncOriginColumn]) == d.name {
+			return replicationEntity.Echo, nil
+		}
+	case replicationEntity.OpDelete:
+		row = before
+	default:
+		return "", errors.Wrap(replicationEntity.ErrUnknownOp, op)
+	}
+	row, err := normalize(table, row)
+	if err != nil {
+		return "", errors.Wrap(err, "[DATA][Apply] "+table.Name)
+	}
+	where, keys, err := keyClause(table, row)
+	if err != nil {
+		return "", errors.Wrap(err, "[DATA][Apply] "+table.Name)
+	}
+	if op != replicationEntity.OpDelete {
+		row[replicationEntity.SyncOriginColumn] = d.source + "#" + uuid.NewString()
+	}
+
+	outcome := replicationEntity.Skipped
+	err = d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target targetRow
+		query, args := lockQuery(table, where, keys, row)
+		if err := tx.Raw(query, args...).Scan(&target).Error; err != nil {
+			return err
+		}
+		if target.Newer > 0 {
+			return nil
+		}
+
+		if op == replicationEntity.OpDelete {
+			if target.Found == 0 {
+				return nil
+			}
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE %s", table.Name, where), keys...).Error; err != nil {
+				return err
+			}
+			outcome = replicationEntity.Applied
+			return nil
+		}
+
+		if target.Found == 0 {
+			columns, values := writeColumns(table, row, true)
+			holders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+			if err := tx.Exec(fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", table.Name, quote(columns), holders), values...).Error; err != nil {
+				return err
+			}
+			outcome = replicationEntity.Applied
+			return nil
+		}
+
+		columns, values := writeColumns(table, row, false)
+		if len(columns) == 0 {
+			return nil
+		}
+		sets := make([]string, len(columns))
+		for i, column := range columns {
+			sets[i] = "`" + column + "` = ?"
+		}
+		if err := tx.Exec(fmt.Sprintf("UPDATE `%s` SET %s WHERE %s", table.Name, strings.Join(sets, ", "), where), append(values, keys...)...).Error; err != nil {
+			return err
+		}
+		outcome = replicationEntity.Applied
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "[DATA][Apply] "+table.Name)
+	}
+	return outcome, nil
+}
+
+// lockQuery locks the target row and, under last-writer-wins, counts it as
+// newer when its version is past the one of the event
+func lockQuery(table replicationEntity.Table, where string, keys []interface{}, row map[string]interface{}) (string, []interface{}) {
+	if table.Conflict != replicationEntity.ConflictLastWriterWins {
+		return fmt.Sprintf("SELECT COUNT(*) AS found, 0 AS newer FROM `%s` WHERE %s FOR UPDATE", table.Name, where), keys
+	}
+
+	version := row[table.Version]
+	if version == nil {
+		version = oldestVersion
+	}
+	query := fmt.Sprintf("SELECT COUNT(*) AS found, COALESCE(SUM(`%s` > ?), 0) AS newer FROM `%s` WHERE %s FOR UPDATE",
+		table.Version, table.Name, where)
+	return query, append([]interface{}{version}, keys...)
+}
+
+// keyClause builds the WHERE clause on the keys of table with their values
+// in row
+func keyClause(table replicationEntity.Table, row map[string]interface{}) (string, []interface{}, error) {
+	conditions := make([]string, len(table.Keys))
+	values := make([]interface{}, len(table.Keys))
+	for i, key := range table.Keys {
+		value, ok := row[key]
+		if !ok || value == nil {
+			return "", nil, errors.Wrap(replicationEntity.ErrMissingKey, key)
+		}
+		conditions[i] = "`" + key + "` = ?"
+		values[i] = value
+	}
+	return strings.Join(conditions, " AND "), values, nil
+}
+
+// writeColumns lists the columns of row to write in a stable order, leaving
+// out the columns the target assigns and, for an update, the keys
+func writeColumns(table replicationEntity.Table, row map[string]interface{}, withKeys bool) ([]string, []interface{}) {
+	var columns []string
+	for column := range row {
+		if contains(table.Skip, column) || (!withKeys && contains(table.Keys, column)) {
+			continue
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = row[column]
+	}
+	return columns, values
+}
+
+// normalize checks the column names of an event, which end up in SQL, and
+// turns Debezium epoch milliseconds of DATETIME columns back into wall clock
+// datetimes
+func normalize(table replicationEntity.Table, row map[string]interface{}) (map[string]interface{}, error) {
+	if row == nil {
+		return nil, errors.Wrap(entity.ErrInvalid, "change event has no row")
+	}
+	normalized := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		if !identifier.MatchString(column) {
+			return nil, errors.Wrap(replicationEntity.ErrBadIdentity, column)
+		}
+		if contains(table.Timestamps, column) {
+			value = datetime(value)
+		}
+		normalized[column] = value
+	}
+	return normalized, nil
+}
+
+// datetime formats epoch milliseconds as a DATETIME, other values such as
+// an already formatted string are kept
+func datetime(value interface{}) interface{} {
+	var millis int64
+	switch v := value.(type) {
+	case float64:
+		millis = int64(v)
+	case int64:
+		millis = v
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return value
+		}
+		millis = n
+	default:
+		return value
+	}
+	return time.UnixMilli(millis).UTC().Format(datetimeLayout)
+}
+
+func quote(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = "`" + column + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
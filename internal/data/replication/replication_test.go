@@ -0,0 +1,253 @@
+package replication
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	replicationEntity "gold-gym-be/internal/entity/replication"
+	"gold-gym-be/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var (
+	peserta = replicationEntity.Table{
+		Name:       "data_peserta",
+		Keys:       []string{"gold_id"},
+		Timestamps: []string{"gold_updated_at"},
+		Conflict:   replicationEntity.ConflictLastWriterWins,
+		Version:    "gold_updated_at",
+	}
+	stock = replicationEntity.Table{
+		Name:     "stock",
+		Keys:     []string{"stock_code"},
+		Skip:     []string{"stock_id"},
+		Conflict: replicationEntity.ConflictSourceWins,
+	}
+
+	qLockPeserta = regexp.QuoteMeta("SELECT COUNT(*) AS found, COALESCE(SUM(`gold_updated_at` > ?), 0) AS newer FROM `data_peserta` WHERE `gold_id` = ? FOR UPDATE")
+	qLockStock   = regexp.QuoteMeta("SELECT COUNT(*) AS found, 0 AS newer FROM `stock` WHERE `stock_code` = ? FOR UPDATE")
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+// originTag cocok dengan tag sync_origin dari local, tokennya acak
+type originTag struct{}
+
+func (originTag) Match(v driver.Value) bool {
+	tag, ok := v.(string)
+	return ok && replicationEntity.Origin(tag) == replicationEntity.Local && len(tag) > len("local#")
+}
+
+// 1700000000000 ms = 2023-11-14 22:13:20 UTC
+func pesertaRow() map[string]interface{} {
+	return map[string]interface{}{
+		"gold_id":         float64(7),
+		"gold_nama":       "Budi",
+		"gold_updated_at": float64(1700000000000),
+	}
+}
+
+func TestApplyLastWriterWins(t *testing.T) {
+	const version = "2023-11-14 22:13:20.000"
+
+	tests := []struct {
+		name    string
+		op      string
+		found   int64
+		newer   int64
+		expect  func(mock sqlmock.Sqlmock)
+		outcome string
+	}{
+		{
+			name: "baris baru di-insert", op: replicationEntity.OpCreate, found: 0,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `data_peserta` (`gold_id`, `gold_nama`, `gold_updated_at`, `sync_origin`) VALUES (?, ?, ?, ?)")).
+					WithArgs(float64(7), "Budi", version, originTag{}).
+					WillReturnResult(sqlmock.NewResult(7, 1))
+			},
+			outcome: replicationEntity.Applied,
+		},
+		{
+			name: "create dikirim ulang menjadi update", op: replicationEntity.OpCreate, found: 1,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `data_peserta` SET `gold_nama` = ?, `gold_updated_at` = ?, `sync_origin` = ? WHERE `gold_id` = ?")).
+					WithArgs("Budi", version, originTag{}, float64(7)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			outcome: replicationEntity.Applied,
+		},
+		{
+			name: "target lebih baru dilewati", op: replicationEntity.OpUpdate, found: 1, newer: 1,
+			expect:  func(mock sqlmock.Sqlmock) {},
+			outcome: replicationEntity.Skipped,
+		},
+		{
+			name: "delete", op: replicationEntity.OpDelete, found: 1,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `data_peserta` WHERE `gold_id` = ?")).
+					WithArgs(float64(7)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			outcome: replicationEntity.Applied,
+		},
+		{
+			name: "delete yang sudah terhapus", op: replicationEntity.OpDelete, found: 0,
+			expect:  func(mock sqlmock.Sqlmock) {},
+			outcome: replicationEntity.Skipped,
+		},
+		{
+			name: "delete kalah dari update yang lebih baru", op: replicationEntity.OpDelete, found: 1, newer: 1,
+			expect:  func(mock sqlmock.Sqlmock) {},
+			outcome: replicationEntity.Skipped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			mock.ExpectBegin()
+			mock.ExpectQuery(qLockPeserta).
+				WithArgs(version, float64(7)).
+				WillReturnRows(sqlmock.NewRows([]string{"found", "newer"}).AddRow(tt.found, tt.newer))
+			tt.expect(mock)
+			mock.ExpectCommit()
+
+			after, before := pesertaRow(), map[string]interface{}(nil)
+			if tt.op == replicationEntity.OpDelete {
+				after, before = nil, pesertaRow()
+			}
+			outcome, err := New(db, replicationEntity.Prod, replicationEntity.Local).Apply(context.Background(), peserta, tt.op, after, before)
+			require.NoError(t, err)
+			assert.Equal(t, tt.outcome, outcome)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestApplySourceWins(t *testing.T) {
+	db, mock := setupMockDB(t)
+	row := map[string]interface{}{"stock_id": float64(3), "stock_code": "BRG-01", "stock_qty": float64(12)}
+
+	// stock_id milik prod tidak ikut ditulis
+	mock.ExpectBegin()
+	mock.ExpectQuery(qLockStock).
+		WithArgs("BRG-01").
+		WillReturnRows(sqlmock.NewRows([]string{"found", "newer"}).AddRow(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `stock` (`stock_code`, `stock_qty`, `sync_origin`) VALUES (?, ?, ?)")).
+		WithArgs("BRG-01", float64(12), originTag{}).
+		WillReturnResult(sqlmock.NewResult(40, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(qLockStock).
+		WithArgs("BRG-01").
+		WillReturnRows(sqlmock.NewRows([]string{"found", "newer"}).AddRow(1, 0))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `stock` SET `stock_qty` = ?, `sync_origin` = ? WHERE `stock_code` = ?")).
+		WithArgs(float64(12), originTag{}, "BRG-01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	d := New(db, replicationEntity.Prod, replicationEntity.Local)
+	outcome, err := d.Apply(context.Background(), stock, replicationEntity.OpRead, row, nil)
+	require.NoError(t, err)
+	assert.Equal(t, replicationEntity.Applied, outcome)
+
+	outcome, err = d.Apply(context.Background(), stock, replicationEntity.OpUpdate, row, row)
+	require.NoError(t, err)
+	assert.Equal(t, replicationEntity.Applied, outcome)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyRejectsBadEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    string
+		after map[string]interface{}
+		want  error
+	}{
+		{"op tidak dikenal", "t", pesertaRow(), replicationEntity.ErrUnknownOp},
+		{"tanpa baris", replicationEntity.OpCreate, nil, entity.ErrInvalid},
+		{"tanpa key", replicationEntity.OpCreate, map[string]interface{}{"gold_nama": "Budi"}, replicationEntity.ErrMissingKey},
+		{"nama kolom tidak valid", replicationEntity.OpCreate, map[string]interface{}{"gold_id": float64(7), "gold_nama`=1;--": "x"}, replicationEntity.ErrBadIdentity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			_, err := New(db, replicationEntity.Prod, replicationEntity.Local).Apply(context.Background(), peserta, tt.op, tt.after, nil)
+			assert.Equal(t, tt.want, errors.Cause(err))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestApplySkipsEcho(t *testing.T) {
+	db, mock := setupMockDB(t)
+	d := New(db, replicationEntity.Prod, replicationEntity.Local)
+
+	// baris ini ditulis ke local oleh replikasi dari prod, event-nya hanya
+	// pantulan dan tidak ditulis balik
+	row := pesertaRow()
+	row[replicationEntity.SyncOriginColumn] = "prod#1b4e28ba-2fa1-11d2-883f-0016d3cca427"
+	outcome, err := d.Apply(context.Background(), peserta, replicationEntity.OpUpdate, row, nil)
+	require.NoError(t, err)
+	assert.Equal(t, replicationEntity.Echo, outcome)
+
+	// tag milik sisi lain tetap diterapkan, tag lama diganti
+	row[replicationEntity.SyncOriginColumn] = "local#6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	mock.ExpectBegin()
+	mock.ExpectQuery(qLockPeserta).
+		WithArgs("2023-11-14 22:13:20.000", float64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"found", "newer"}).AddRow(1, 0))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `data_peserta` SET `gold_nama` = ?, `gold_updated_at` = ?, `sync_origin` = ? WHERE `gold_id` = ?")).
+		WithArgs("Budi", "2023-11-14 22:13:20.000", originTag{}, float64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	outcome, err = d.Apply(context.Background(), peserta, replicationEntity.OpUpdate, row, nil)
+	require.NoError(t, err)
+	assert.Equal(t, replicationEntity.Applied, outcome)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChecksums(t *testing.T) {
+	db, mock := setupMockDB(t)
+	d := New(db, replicationEntity.Local, replicationEntity.Prod)
+
+	mock.ExpectQuery(regexp.QuoteMeta(qColumns)).
+		WithArgs("stock").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("stock_code").AddRow("stock_qty"))
+	columns, err := d.Columns(context.Background(), stock)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stock_code", "stock_qty"}, columns)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CONCAT_WS('|', `stock_code`) AS row_key, MD5(CONCAT_WS('|', QUOTE(`stock_code`), QUOTE(`stock_qty`))) AS checksum FROM `stock`")).
+		WillReturnRows(sqlmock.NewRows([]string{"row_key", "checksum"}).
+			AddRow("BRG-01", "9f1c").
+			AddRow("BRG-02", "07aa"))
+	sums, err := d.Checksums(context.Background(), stock, columns)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"BRG-01": "9f1c", "BRG-02": "07aa"}, sums)
+
+	// nama kolom masuk ke SQL, jadi diperiksa dulu
+	_, err = d.Checksums(context.Background(), stock, []string{"stock_qty`); DROP TABLE stock; --"})
+	assert.Equal(t, replicationEntity.ErrBadIdentity, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,93 @@
+package sales
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	"gold-gym-be/pkg/errors"
+	"time"
+)
+
+// soldAtLayout is how sale_transdate and sale_transtime read together
+const soldAtLayout = "2006-01-02 15:04:05"
+
+// GetSaleDocuments returns up to limit sales with a sale_id after afterID in
+// sale_id order, with their lines, only the ones sold since then when since
+// is set
+func (d *Data) GetSaleDocuments(ctx context.Context, afterID string, limit int, since time.Time) ([]elasticEntity.SaleDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	q := d.db.WithContext(ctx).Where("sale_id > ?", afterID)
+	if !since.IsZero() {
+		q = q.Where("CONCAT(sale_transdate, ' ', sale_transtime) >= ?", since.In(time.Local).Format(soldAtLayout))
+	}
+	var headers []salesEntity.SalesHeader
+	if err := q.Order("sale_id").Limit(limit).Find(&headers).Error; err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetSaleDocuments]")
+	}
+	docs, err := d.saleDocuments(ctx, headers)
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetSaleDocuments]")
+	}
+	return docs, nil
+}
+
+// GetSaleDocument returns the document of one sale, ErrNotFound when there
+// is no sale with saleID
+func (d *Data) GetSaleDocument(ctx context.Context, saleID string) (elasticEntity.SaleDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+
+	var headers []salesEntity.SalesHeader
+	if err := d.db.WithContext(ctx).Where("sale_id = ?", saleID).Find(&headers).Error; err != nil {
+		return elasticEntity.SaleDocument{}, errors.Wrap(err, "[DATA][GetSaleDocument]")
+	}
+	if len(headers) == 0 {
+		return elasticEntity.SaleDocument{}, errors.Wrap(entity.ErrNotFound, "[DATA][GetSaleDocument]")
+	}
+	docs, err := d.saleDocuments(ctx, headers)
+	if err != nil {
+		return elasticEntity.SaleDocument{}, errors.Wrap(err, "[DATA][GetSaleDocument]")
+	}
+	return docs[0], nil
+}
+
+// saleDocuments reads the lines of headers and turns both into documents
+func (d *Data) saleDocuments(ctx context.Context, headers []salesEntity.SalesHeader) ([]elasticEntity.SaleDocument, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(headers))
+	for i, header := range headers {
+		ids[i] = header.SaleID
+	}
+	details, err := d.GetSaleDetails(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	lines := make(map[string][]elasticEntity.SaleDocumentLine, len(headers))
+	for _, detail := range details {
+		lines[detail.SaleID] = append(lines[detail.SaleID], elasticEntity.SaleDocumentLine{
+			StockCode: detail.SaleStockcode,
+			StockName: detail.SaleStockname,
+			Qty:       detail.SaleQty,
+			Price:     detail.SaleSalesprice,
+			Amount:    detail.SaleSalesprice.Mul(detail.SaleQty),
+		})
+	}
+
+	docs := make([]elasticEntity.SaleDocument, len(headers))
+	for i, header := range headers {
+		docs[i] = elasticEntity.SaleDocument{
+			SaleID:      header.SaleID,
+			BranchID:    header.SaleBranchID,
+			Salesperson: header.SaleSalesperson,
+			SoldAt:      header.SaleTransdate + " " + header.SaleTransTime,
+			Total:       header.SaleTranstotal,
+			Lines:       lines[header.SaleID],
+		}
+	}
+	return docs, nil
+}
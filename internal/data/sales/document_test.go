@@ -0,0 +1,62 @@
+package sales
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	headerColumns = []string{"sale_id", "sale_transdate", "sale_transtime", "sale_transtotal", "sale_transpayment", "sale_transchange", "sale_salesperson", "sale_branch_id"}
+	detailColumns = []string{"sale_id", "sale_stockid", "sale_stockcode", "sale_stockname", "sale_qty", "sale_salesprice", "sale_pack"}
+)
+
+func TestGetSaleDocuments(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+	since := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+
+	mock.ExpectQuery("SELECT \\* FROM `sales_header` WHERE sale_id > \\? AND CONCAT\\(sale_transdate, ' ', sale_transtime\\) >= \\? ORDER BY sale_id LIMIT \\?").
+		WithArgs("S-0", "2026-10-19 09:00:00", 2).
+		WillReturnRows(sqlmock.NewRows(headerColumns).
+			AddRow("S-1", "2026-10-19", "09:30:00", "95000.00", "100000.00", "5000.00", "andi", 2).
+			AddRow("S-2", "2026-10-19", "10:00:00", "25000.00", "25000.00", "0.00", "sari", 1))
+	mock.ExpectQuery("SELECT \\* FROM `sales_detail` WHERE sale_id IN \\(\\?,\\?\\) ORDER BY sale_id, sale_stockcode").
+		WithArgs("S-1", "S-2").
+		WillReturnRows(sqlmock.NewRows(detailColumns).
+			AddRow("S-1", "1", "TOWEL", "Handuk", 1, "25000.00", "pcs").
+			AddRow("S-1", "2", "WATER", "Air Mineral", 2, "35000.00", "btl").
+			AddRow("S-2", "1", "TOWEL", "Handuk", 1, "25000.00", "pcs"))
+
+	docs, err := repo.GetSaleDocuments(context.Background(), "S-0", 2, since)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "2026-10-19 09:30:00", docs[0].SoldAt)
+	assert.Equal(t, int64(2), docs[0].BranchID)
+	assert.Equal(t, entity.NewMoney(95000), docs[0].Total)
+	require.Len(t, docs[0].Lines, 2)
+	assert.Equal(t, entity.NewMoney(70000), docs[0].Lines[1].Amount)
+	assert.Len(t, docs[1].Lines, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSaleDocumentNotFound(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	mock.ExpectQuery("SELECT \\* FROM `sales_header` WHERE sale_id = \\?").
+		WithArgs("S-9").
+		WillReturnRows(sqlmock.NewRows(headerColumns))
+
+	_, err := repo.GetSaleDocument(context.Background(), "S-9")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,276 @@
+package sales
+
+import (
+	"context"
+	outboxData "gold-gym-be/internal/data/outbox"
+	stockData "gold-gym-be/internal/data/stock"
+	"gold-gym-be/internal/entity"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"sort"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gorm.io/gorm"
+)
+
+const (
+	dbTimeout       = 3 * time.Second
+	dbTimeoutInsert = 5 * time.Second
+
+	// the stock_qty guard makes the decrement and the availability check one statement
+	qDecrementStock = `UPDATE stock SET stock_qty = stock_qty - ?, stock_last_update = NOW() WHERE stock_code = ? AND stock_qty >= ?`
+
+	// and the same for what the selling branch has on hand
+	qDecrementBranchStock = `UPDATE stock_branch SET stock_qty = stock_qty - ? WHERE branch_id = ? AND stock_code = ? AND stock_qty >= ?`
+
+	// first expiry first, batches without expiry last, expired ones are left
+	// for the write-off
+	qLockSellableBatches = `SELECT * FROM stock_batch WHERE batch_branch_id = ? AND stock_code = ? AND batch_qty > 0 AND (batch_expiry = '' OR batch_expiry >= ?) ORDER BY batch_expiry = '', batch_expiry, batch_id FOR UPDATE`
+
+	qConsumeBatch = `UPDATE stock_batch SET batch_qty = batch_qty - ? WHERE batch_id = ?`
+
+	qGetStockCostPrice = `SELECT stock_cost_price FROM stock WHERE stock_code = ?`
+
+	qGetSaleCOGS = `SELECT h.sale_id, h.sale_transdate, h.sale_transtime, h.sale_salesperson,
+	SUM(d.sale_qty * d.sale_salesprice) AS revenue, SUM(d.sale_cost_avg) AS cost_avg, SUM(d.sale_cost_fifo) AS cost_fifo
+FROM sales_header h
+JOIN sales_detail d ON d.sale_id = h.sale_id
+WHERE h.sale_transdate BETWEEN ? AND ?
+GROUP BY h.sale_id, h.sale_transdate, h.sale_transtime, h.sale_salesperson
+ORDER BY h.sale_transdate, h.sale_transtime, h.sale_id`
+
+	qGetProductMargins = `SELECT d.sale_stockcode AS stock_code, MAX(d.sale_stockname) AS stock_name, SUM(d.sale_qty) AS qty,
+	SUM(d.sale_qty * d.sale_salesprice) AS revenue, SUM(d.sale_cost_avg) AS cost_avg, SUM(d.sale_cost_fifo) AS cost_fifo
+FROM sales_header h
+JOIN sales_detail d ON d.sale_id = h.sale_id
+WHERE h.sale_transdate BETWEEN ? AND ?
+GROUP BY d.sale_stockcode
+ORDER BY d.sale_stockcode`
+)
+
+// Data ...
+type Data struct {
+	db *gorm.DB
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(db *gorm.DB, tracer opentracing.Tracer, logger jaegerLog.Factory) *Data {
+	return &Data{
+		db:     db,
+		tracer: tracer,
+		logger: logger,
+	}
+}
+
+// GetStocksByCode returns the stock rows for codes, unknown codes are left out
+func (d *Data) GetStocksByCode(ctx context.Context, codes []string) ([]goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Table("stock").Where("stock_code IN ?", codes).Find(&stocks).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetStocksByCode]")
+	}
+	return stocks, nil
+}
+
+// InsertSale decrements stock, in total and at the branch of the sale, for
+// every detail and stores the sale together with its ledger movements in one
+// transaction. It returns ErrInsufficientStock and rolls back when any line is
+// no longer available at the branch. Units are taken from the branch's stock
+// batches first-expired-first-out; whatever the batches do not cover is stock
+// that was never received into a batch. The cost of every line is stored with
+// it, see costLine. event, the SaleCompleted of the sale, is stored with it.
+// Lines are taken in stock_code order.
+func (d *Data) InsertSale(ctx context.Context, header salesEntity.SalesHeader, details []salesEntity.SalesDetail, movements []goldStockEntity.StockMovement, event outboxEntity.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, dbTimeoutInsert)
+	defer cancel()
+
+	// rows are locked in stock_code order whatever the order of the lines,
+	// so two sales of the same products cannot deadlock on each other
+	order := make([]int, len(details))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return details[order[a]].SaleStockcode < details[order[b]].SaleStockcode
+	})
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, i := range order {
+			detail := details[i]
+			res := tx.Exec(qDecrementStock, detail.SaleQty, detail.SaleStockcode, detail.SaleQty)
+			if res.Error != nil {
+				return errors.Wrap(res.Error, "[DATA][InsertSale]")
+			}
+			if res.RowsAffected == 0 {
+				return errors.Wrap(salesEntity.ErrInsufficientStock, detail.SaleStockcode)
+			}
+			res = tx.Exec(qDecrementBranchStock, detail.SaleQty, header.SaleBranchID, detail.SaleStockcode, detail.SaleQty)
+			if res.Error != nil {
+				return errors.Wrap(res.Error, "[DATA][InsertSale]")
+			}
+			if res.RowsAffected == 0 {
+				return errors.Wrap(salesEntity.ErrInsufficientStock, detail.SaleStockcode)
+			}
+			if err := consumeBatches(tx, header, detail); err != nil {
+				return errors.Wrap(err, "[DATA][InsertSale]")
+			}
+			if err := costLine(tx, &details[i]); err != nil {
+				return errors.Wrap(err, "[DATA][InsertSale]")
+			}
+		}
+		if err := tx.Create(&header).Error; err != nil {
+			return errors.Wrap(err, "[DATA][InsertSale]")
+		}
+		if err := tx.Create(&details).Error; err != nil {
+			return errors.Wrap(err, "[DATA][InsertSale]")
+		}
+		if err := tx.Create(&movements).Error; err != nil {
+			return errors.Wrap(err, "[DATA][InsertSale]")
+		}
+		return outboxData.Insert(tx, event)
+	})
+}
+
+// GetSales lists sale headers matching filter, newest first
+func (d *Data) GetSales(ctx context.Context, filter salesEntity.SalesFilter) ([]salesEntity.SalesHeader, error) {
+	var headers []salesEntity.SalesHeader
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	q := d.db.WithContext(ctx)
+	if filter.Date != "" {
+		q = q.Where("sale_transdate = ?", filter.Date)
+	}
+	if filter.Salesperson != "" {
+		q = q.Where("sale_salesperson = ?", filter.Salesperson)
+	}
+	if filter.BranchID != 0 {
+		q = q.Where("sale_branch_id = ?", filter.BranchID)
+	}
+	err := q.Order("sale_transdate DESC, sale_transtime DESC").Find(&headers).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetSales]")
+	}
+	return headers, nil
+}
+
+func (d *Data) GetSaleByID(ctx context.Context, saleID string) (salesEntity.SalesHeader, error) {
+	var header salesEntity.SalesHeader
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Where("sale_id = ?", saleID).First(&header).Error
+	if err != nil {
+		return salesEntity.SalesHeader{}, err
+	}
+	return header, nil
+}
+
+func (d *Data) GetSaleDetails(ctx context.Context, saleIDs []string) ([]salesEntity.SalesDetail, error) {
+	var details []salesEntity.SalesDetail
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Where("sale_id IN ?", saleIDs).Order("sale_id, sale_stockcode").Find(&details).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetSaleDetails]")
+	}
+	return details, nil
+}
+
+// GetSaleCOGS sums revenue and stored costs per sale of the period
+func (d *Data) GetSaleCOGS(ctx context.Context, from, to string) ([]salesEntity.SaleCOGS, error) {
+	var sales []salesEntity.SaleCOGS
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Raw(qGetSaleCOGS, from, to).Scan(&sales).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetSaleCOGS]")
+	}
+	return sales, nil
+}
+
+// GetProductMargins sums qty, revenue and stored costs per stock code of the
+// period
+func (d *Data) GetProductMargins(ctx context.Context, from, to string) ([]salesEntity.ProductMargin, error) {
+	var products []salesEntity.ProductMargin
+
+	ctx, cancel := context.WithTimeout(ctx, dbTimeout)
+	defer cancel()
+	err := d.db.WithContext(ctx).Raw(qGetProductMargins, from, to).Scan(&products).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetProductMargins]")
+	}
+	return products, nil
+}
+
+// costLine stores the cost of a sold line under both valuation methods: the
+// weighted-average cost of the stock, and the cost of the oldest cost layers
+// the units are taken from (FIFO), with the units outside any layer at the
+// weighted average. FIFO cost follows the received order, not the FEFO order
+// the batches are physically consumed in.
+func costLine(tx *gorm.DB, detail *salesEntity.SalesDetail) error {
+	var costPrice entity.Money
+	if err := tx.Raw(qGetStockCostPrice, detail.SaleStockcode).Scan(&costPrice).Error; err != nil {
+		return err
+	}
+	layerQty, layerCost, err := stockData.ConsumeCostLayers(tx, detail.SaleStockcode, detail.SaleQty)
+	if err != nil {
+		return err
+	}
+	detail.SaleCostAvg = costPrice.Mul(detail.SaleQty)
+	detail.SaleCostFIFO = layerCost + costPrice.Mul(detail.SaleQty-layerQty)
+	return nil
+}
+
+// consumeBatches takes detail's qty from the sellable batches of the sale's
+// branch
+func consumeBatches(tx *gorm.DB, header salesEntity.SalesHeader, detail salesEntity.SalesDetail) error {
+	var batches []goldStockEntity.StockBatch
+	if err := tx.Raw(qLockSellableBatches, header.SaleBranchID, detail.SaleStockcode, header.SaleTransdate).Scan(&batches).Error; err != nil {
+		return err
+	}
+
+	var usages []goldStockEntity.StockBatchUsage
+	left := detail.SaleQty
+	at, err := time.ParseInLocation("2006-01-02 15:04:05", header.SaleTransdate+" "+header.SaleTransTime, time.Local)
+	if err != nil {
+		at = time.Now()
+	}
+	for _, batch := range batches {
+		if left == 0 {
+			break
+		}
+		qty := batch.BatchQty
+		if qty > left {
+			qty = left
+		}
+		if err := tx.Exec(qConsumeBatch, qty, batch.BatchID).Error; err != nil {
+			return err
+		}
+		usages = append(usages, goldStockEntity.StockBatchUsage{
+			BatchID:   batch.BatchID,
+			StockCode: batch.StockCode,
+			UsageType: goldStockEntity.UsageSale,
+			UsageQty:  qty,
+			UsageRef:  header.SaleID,
+			UsageAt:   at,
+		})
+		left -= qty
+	}
+	if len(usages) == 0 {
+		return nil
+	}
+	return tx.Create(&usages).Error
+}
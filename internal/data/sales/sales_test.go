@@ -0,0 +1,154 @@
+package sales
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+func TestInsertSaleCostsLines(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	header := salesEntity.SalesHeader{SaleID: "SL1", SaleBranchID: 2, SaleTransdate: "2026-10-19", SaleTransTime: "09:30:15", SaleTranstotal: entity.NewMoney(75000), SaleSalesperson: "rina"}
+	details := []salesEntity.SalesDetail{{SaleID: "SL1", SaleStockID: "1", SaleStockcode: "WHEY-1", SaleStockname: "Whey Protein Sachet", SaleQty: 3, SaleSalesprice: entity.NewMoney(25000)}}
+	movements := []goldStockEntity.StockMovement{{StockCode: "WHEY-1", MovementType: goldStockEntity.MovementSale, MovementQty: -3, MovementRef: "SL1", MovementBy: "rina", MovementAt: time.Now()}}
+	event, err := outboxEntity.NewEvent(outboxEntity.EventSaleCompleted, outboxEntity.AggregateSale, "SL1", outboxEntity.SaleCompleted{SaleID: "SL1"}, time.Now())
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qDecrementStock)).
+		WithArgs(3, "WHEY-1", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qDecrementBranchStock)).
+		WithArgs(3, int64(2), "WHEY-1", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// hanya 2 unit yang ada di batch, 1 unit sisanya stok lama di luar batch.
+	// Batch 7 (22000) diambil lebih dulu karena kedaluwarsa lebih dulu (FEFO)
+	mock.ExpectQuery(regexp.QuoteMeta(qLockSellableBatches)).
+		WithArgs(int64(2), "WHEY-1", "2026-10-19").
+		WillReturnRows(sqlmock.NewRows([]string{"batch_id", "stock_code", "batch_qty", "batch_unit_cost"}).
+			AddRow(7, "WHEY-1", 2, 22000))
+	mock.ExpectExec(regexp.QuoteMeta(qConsumeBatch)).
+		WithArgs(2, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `stock_batch_usage`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(qGetStockCostPrice)).
+		WithArgs("WHEY-1").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_cost_price"}).AddRow(21000))
+	// tetapi biaya FIFO diambil dari layer yang diterima paling awal (20000)
+	mock.ExpectQuery("SELECT \\* FROM stock_cost_layer WHERE stock_code = \\? AND layer_qty > 0 ORDER BY layer_received_at, layer_id FOR UPDATE").
+		WithArgs("WHEY-1").
+		WillReturnRows(sqlmock.NewRows([]string{"layer_id", "stock_code", "layer_qty", "layer_unit_cost"}).
+			AddRow(3, "WHEY-1", 2, 20000))
+	mock.ExpectExec("UPDATE stock_cost_layer SET layer_qty = layer_qty - \\? WHERE layer_id = \\?").
+		WithArgs(2, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `sales_header`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `sales_detail`").
+		WithArgs("SL1", "1", "WHEY-1", "Whey Protein Sachet", 3, "25000.00", "", "63000.00", "61000.00").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `stock_movement`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// SaleCompleted ditulis di transaksi yang sama
+	mock.ExpectExec("INSERT INTO `outbox_event`").
+		WithArgs(event.EventID, outboxEntity.EventSaleCompleted, outboxEntity.AggregateSale, "SL1", event.Envelope, event.CreatedAt, nil, 0, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.InsertSale(context.Background(), header, details, movements, event))
+	assert.Equal(t, entity.NewMoney(63000), details[0].SaleCostAvg)
+	assert.Equal(t, entity.NewMoney(61000), details[0].SaleCostFIFO)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSaleInsufficientStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	details := []salesEntity.SalesDetail{{SaleID: "SL1", SaleStockcode: "ISO-1", SaleQty: 5}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qDecrementStock)).
+		WithArgs(5, "ISO-1", 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.InsertSale(context.Background(), salesEntity.SalesHeader{SaleID: "SL1"}, details, nil, outboxEntity.Event{})
+	assert.Equal(t, salesEntity.ErrInsufficientStock, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSaleLocksInStockCodeOrder(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	// baris dikunci urut stock_code, bukan urutan di nota, supaya dua
+	// penjualan produk yang sama tidak saling deadlock
+	details := []salesEntity.SalesDetail{
+		{SaleID: "SL1", SaleStockcode: "WHEY-1", SaleQty: 1},
+		{SaleID: "SL1", SaleStockcode: "ISO-1", SaleQty: 5},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qDecrementStock)).
+		WithArgs(5, "ISO-1", 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.InsertSale(context.Background(), salesEntity.SalesHeader{SaleID: "SL1"}, details, nil, outboxEntity.Event{})
+	assert.Equal(t, salesEntity.ErrInsufficientStock, errors.Cause(err))
+	assert.Equal(t, "WHEY-1", details[0].SaleStockcode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertSaleInsufficientBranchStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := New(db, nil, jaegerLog.Factory{})
+
+	details := []salesEntity.SalesDetail{{SaleID: "SL1", SaleStockcode: "ISO-1", SaleQty: 5}}
+
+	// total perusahaan cukup, stok di cabang penjualan tidak
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qDecrementStock)).
+		WithArgs(5, "ISO-1", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qDecrementBranchStock)).
+		WithArgs(5, int64(2), "ISO-1", 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.InsertSale(context.Background(), salesEntity.SalesHeader{SaleID: "SL1", SaleBranchID: 2}, details, nil, outboxEntity.Event{})
+	assert.Equal(t, salesEntity.ErrInsufficientStock, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
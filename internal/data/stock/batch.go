@@ -0,0 +1,136 @@
+package goldgym
+
+import (
+	"context"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	qGetNearExpiryBatches = `SELECT b.*, s.stock_name
+FROM stock_batch b
+JOIN stock s ON s.stock_code = b.stock_code
+WHERE b.batch_qty > 0 AND b.batch_expiry <> '' AND b.batch_expiry <= ? AND (? = 0 OR b.batch_branch_id = ?)
+ORDER BY b.batch_expiry, b.stock_code, b.batch_id`
+
+	// locks the stock row first, as a sale does before it takes from batches
+	qLockStockQty = `SELECT stock_qty FROM stock WHERE stock_code = ? FOR UPDATE`
+
+	// the batch_qty guard claims the batch, a second instance or a sale that
+	// got there first leaves it for the next run
+	qClaimExpiredBatch = `UPDATE stock_batch SET batch_qty = 0 WHERE batch_id = ? AND batch_qty = ?`
+)
+
+// GetStockBatches lists the batches of stockcode with stock left, in the
+// order sales take from them (first expiry first, no expiry last)
+func (d Data) GetStockBatches(ctx context.Context, stockcode string) ([]goldStockEntity.StockBatch, error) {
+	var batches []goldStockEntity.StockBatch
+
+	err := d.db.WithContext(ctx).
+		Where("stock_code = ? AND batch_qty > 0", stockcode).
+		Order("batch_expiry = '', batch_expiry, batch_id").
+		Find(&batches).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetStockBatches]")
+	}
+	return batches, nil
+}
+
+// GetNearExpiryBatches lists batches with stock left expiring on or before
+// until (YYYY-MM-DD), expired ones included, of one branch when branchID is
+// not zero
+func (d Data) GetNearExpiryBatches(ctx context.Context, until string, branchID int64) ([]goldStockEntity.NearExpiryLine, error) {
+	var lines []goldStockEntity.NearExpiryLine
+
+	err := d.db.WithContext(ctx).Raw(qGetNearExpiryBatches, until, branchID, branchID).Scan(&lines).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetNearExpiryBatches]")
+	}
+	return lines, nil
+}
+
+// GetExpiredBatches lists batches with stock left that expired before today
+func (d Data) GetExpiredBatches(ctx context.Context, today string) ([]goldStockEntity.StockBatch, error) {
+	var batches []goldStockEntity.StockBatch
+
+	err := d.db.WithContext(ctx).
+		Where("batch_qty > 0 AND batch_expiry <> '' AND batch_expiry < ?", today).
+		Order("batch_expiry, batch_id").
+		Find(&batches).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetExpiredBatches]")
+	}
+	return batches, nil
+}
+
+// WriteOffBatch empties an expired batch and takes its quantity out of stock
+// at the batch's branch with a write-off movement, in one transaction.
+// movement carries everything but the quantity. It returns how many units
+// were written off, zero when the batch changed since it was read. Stock
+// never goes below zero, so less than the batch is written off when the
+// branch was already corrected by hand. The units written off leave the
+// oldest cost layers. The stock and branch rows are locked before the batch,
+// in the order a sale locks them.
+func (d Data) WriteOffBatch(ctx context.Context, batch goldStockEntity.StockBatch, movement goldStockEntity.StockMovement) (int, error) {
+	var written int
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var total, onHand int
+		if err := tx.Raw(qLockStockQty, batch.StockCode).Scan(&total).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(qLockBranchQty, batch.BatchBranchID, batch.StockCode).Scan(&onHand).Error; err != nil {
+			return err
+		}
+
+		res := tx.Exec(qClaimExpiredBatch, batch.BatchID, batch.BatchQty)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		written = batch.BatchQty
+		if onHand < written {
+			written = onHand
+		}
+		if total < written {
+			written = total
+		}
+		if written <= 0 {
+			written = 0
+			return nil
+		}
+
+		usage := goldStockEntity.StockBatchUsage{
+			BatchID:   batch.BatchID,
+			StockCode: batch.StockCode,
+			UsageType: goldStockEntity.UsageExpired,
+			UsageQty:  written,
+			UsageRef:  movement.MovementRef,
+			UsageAt:   movement.MovementAt,
+		}
+		if err := tx.Create(&usage).Error; err != nil {
+			return err
+		}
+		if _, _, err := ConsumeCostLayers(tx, batch.StockCode, written); err != nil {
+			return err
+		}
+		if err := tx.Exec(qAdjustStockQty, -written, batch.StockCode, -written).Error; err != nil {
+			return err
+		}
+		if err := moveBranchQty(tx, batch.BatchBranchID, batch.StockCode, -written); err != nil {
+			return err
+		}
+		movement.MovementBranchID = batch.BatchBranchID
+		movement.MovementQty = -written
+		return tx.Create(&movement).Error
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "[DATA][WriteOffBatch]")
+	}
+	return written, nil
+}
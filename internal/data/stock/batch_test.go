@@ -0,0 +1,115 @@
+package goldgym
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expiredBatch() (goldStockEntity.StockBatch, goldStockEntity.StockMovement) {
+	batch := goldStockEntity.StockBatch{BatchID: 7, StockCode: "ISO-1", BatchBranchID: 1, BatchLot: "L1", BatchExpiry: "2026-10-17", BatchQty: 4}
+	movement := goldStockEntity.StockMovement{
+		StockCode:      "ISO-1",
+		MovementType:   goldStockEntity.MovementWriteOff,
+		MovementReason: "expired lot L1 (exp 2026-10-17)",
+		MovementRef:    "BATCH-7",
+		MovementBy:     "system",
+		MovementAt:     time.Date(2026, 10, 19, 1, 0, 0, 0, time.Local),
+	}
+	return batch, movement
+}
+
+func TestWriteOffBatch(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	batch, movement := expiredBatch()
+
+	// stok dan cabang dikunci sebelum batch, urutannya sama dengan penjualan
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(qLockStockQty)).
+		WithArgs("ISO-1").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_qty"}).AddRow(10))
+	mock.ExpectQuery(regexp.QuoteMeta(qLockBranchQty)).
+		WithArgs(int64(1), "ISO-1").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_qty"}).AddRow(3))
+	mock.ExpectExec(regexp.QuoteMeta(qClaimExpiredBatch)).
+		WithArgs(int64(7), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// stok cabang tinggal 3, jadi hanya 3 yang di-write off dan dicatat
+	mock.ExpectExec("INSERT INTO `stock_batch_usage`").
+		WithArgs(int64(7), "ISO-1", goldStockEntity.UsageExpired, 3, "BATCH-7", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// 3 unit itu keluar dari layer biaya tertua
+	mock.ExpectQuery(regexp.QuoteMeta(qLockCostLayers)).
+		WithArgs("ISO-1").
+		WillReturnRows(sqlmock.NewRows([]string{"layer_id", "stock_code", "layer_qty", "layer_unit_cost"}).
+			AddRow(1, "ISO-1", 2, 3000).
+			AddRow(2, "ISO-1", 5, 3500))
+	mock.ExpectExec(regexp.QuoteMeta(qConsumeCostLayer)).
+		WithArgs(2, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qConsumeCostLayer)).
+		WithArgs(1, int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qAdjustStockQty)).
+		WithArgs(-3, "ISO-1", -3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qTakeBranchQty)).
+		WithArgs(3, int64(1), "ISO-1", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `stock_movement`").
+		WithArgs("ISO-1", int64(1), goldStockEntity.MovementWriteOff, -3, movement.MovementReason, "BATCH-7", "system", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	written, err := repo.WriteOffBatch(context.Background(), batch, movement)
+	require.NoError(t, err)
+	assert.Equal(t, 3, written)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWriteOffBatch_AlreadyTaken(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	batch, movement := expiredBatch()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(qLockStockQty)).
+		WithArgs("ISO-1").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_qty"}).AddRow(10))
+	mock.ExpectQuery(regexp.QuoteMeta(qLockBranchQty)).
+		WithArgs(int64(1), "ISO-1").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_qty"}).AddRow(4))
+	mock.ExpectExec(regexp.QuoteMeta(qClaimExpiredBatch)).
+		WithArgs(int64(7), 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	written, err := repo.WriteOffBatch(context.Background(), batch, movement)
+	require.NoError(t, err)
+	assert.Equal(t, 0, written)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetNearExpiryBatches(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+
+	mock.ExpectQuery(regexp.QuoteMeta(qGetNearExpiryBatches)).
+		WithArgs("2026-11-18", int64(0), int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"batch_id", "stock_code", "batch_lot", "batch_expiry", "batch_qty", "stock_name"}).
+			AddRow(7, "ISO-1", "L1", "2026-10-17", 4, "Isotonic 500ml"))
+	lines, err := repo.GetNearExpiryBatches(context.Background(), "2026-11-18", 0)
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, int64(7), lines[0].BatchID)
+	assert.Equal(t, "Isotonic 500ml", lines[0].StockName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,165 @@
+package goldgym
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// stock_branch mirrors stock.stock_qty per branch; an increment may open
+	// the row, the guard of a decrement refuses to go below zero
+	qAddBranchQty  = `INSERT INTO stock_branch (branch_id, stock_code, stock_qty) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE stock_qty = stock_qty + VALUES(stock_qty)`
+	qTakeBranchQty = `UPDATE stock_branch SET stock_qty = stock_qty - ? WHERE branch_id = ? AND stock_code = ? AND stock_qty >= ?`
+
+	qLockBranchQty = `SELECT stock_qty FROM stock_branch WHERE branch_id = ? AND stock_code = ? FOR UPDATE`
+
+	// the per-branch balances are a projection of the ledger like stock_qty
+	qRebuildBranchQty = `INSERT INTO stock_branch (branch_id, stock_code, stock_qty)
+SELECT movement_branch_id, stock_code, SUM(movement_qty) FROM stock_movement WHERE (? = '' OR stock_code = ?) GROUP BY movement_branch_id, stock_code
+ON DUPLICATE KEY UPDATE stock_qty = VALUES(stock_qty)`
+
+	qGetBranchStock = `SELECT s.stock_id, s.stock_code, s.stock_name, s.stock_pack, COALESCE(b.stock_qty, 0) AS stock_qty, s.stock_price, s.stock_last_update, s.stock_update_by, s.stock_cost_price, s.stock_reorder_point, s.stock_reorder_qty, s.stock_product_code, s.stock_flavour, s.stock_size
+FROM stock s
+LEFT JOIN stock_branch b ON b.branch_id = ? AND b.stock_code = s.stock_code
+ORDER BY s.stock_id`
+
+	// same order as a sale takes them, expired batches stay for the write-off
+	qLockTransferBatches = `SELECT * FROM stock_batch WHERE batch_branch_id = ? AND stock_code = ? AND batch_qty > 0 AND (batch_expiry = '' OR batch_expiry >= ?) ORDER BY batch_expiry = '', batch_expiry, batch_id FOR UPDATE`
+
+	qTakeBatchQty = `UPDATE stock_batch SET batch_qty = batch_qty - ? WHERE batch_id = ?`
+
+	qBranchActive = `SELECT COUNT(*) FROM branch WHERE branch_id = ? AND branch_active = 1`
+)
+
+// BranchActive reports whether branchID exists and is open
+func (d Data) BranchActive(ctx context.Context, branchID int64) (bool, error) {
+	var count int64
+
+	err := d.db.WithContext(ctx).Raw(qBranchActive, branchID).Scan(&count).Error
+	if err != nil {
+		return false, errors.Wrap(err, "[DATA][BranchActive]")
+	}
+	return count > 0, nil
+}
+
+// GetBranchStock lists every stock code with what branchID has on hand as
+// stock_qty
+func (d Data) GetBranchStock(ctx context.Context, branchID int64) ([]goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+
+	err := d.db.WithContext(ctx).Raw(qGetBranchStock, branchID).Scan(&stocks).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetBranchStock]")
+	}
+	return stocks, nil
+}
+
+// TransferStock moves transfer.Qty from one branch to the other in one
+// transaction: the branch balances, the out and in movements of the ledger,
+// and the batches, which are split first expiry first into new batches at
+// the receiving branch. stock_qty, the company total, does not change. It
+// returns ErrNegativeStock when the sending branch has less than Qty.
+func (d Data) TransferStock(ctx context.Context, transfer goldStockEntity.StockTransfer, out, in goldStockEntity.StockMovement) error {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := moveBranchQty(tx, transfer.FromBranchID, transfer.StockCode, -transfer.Qty); err != nil {
+			if err == goldStockEntity.ErrNegativeStock {
+				var count int64
+				if err := tx.Raw(qStockExists, transfer.StockCode).Scan(&count).Error; err != nil {
+					return err
+				}
+				if count == 0 {
+					return entity.ErrNotFound
+				}
+			}
+			return err
+		}
+		if err := moveBranchQty(tx, transfer.ToBranchID, transfer.StockCode, transfer.Qty); err != nil {
+			return err
+		}
+		if err := transferBatches(tx, transfer, out); err != nil {
+			return err
+		}
+		movements := []goldStockEntity.StockMovement{out, in}
+		return tx.Create(&movements).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, "[DATA][TransferStock]")
+	}
+	return nil
+}
+
+// transferBatches moves up to transfer.Qty units out of the sending branch's
+// batches into copies at the receiving branch; units outside any batch go
+// without one, as they came
+func transferBatches(tx *gorm.DB, transfer goldStockEntity.StockTransfer, out goldStockEntity.StockMovement) error {
+	var batches []goldStockEntity.StockBatch
+	err := tx.Raw(qLockTransferBatches, transfer.FromBranchID, transfer.StockCode, out.MovementAt.Format("2006-01-02")).Scan(&batches).Error
+	if err != nil {
+		return err
+	}
+
+	var (
+		usages []goldStockEntity.StockBatchUsage
+		moved  []goldStockEntity.StockBatch
+	)
+	left := transfer.Qty
+	for _, batch := range batches {
+		if left == 0 {
+			break
+		}
+		qty := batch.BatchQty
+		if qty > left {
+			qty = left
+		}
+		if err := tx.Exec(qTakeBatchQty, qty, batch.BatchID).Error; err != nil {
+			return err
+		}
+		usages = append(usages, goldStockEntity.StockBatchUsage{
+			BatchID:   batch.BatchID,
+			StockCode: batch.StockCode,
+			UsageType: goldStockEntity.UsageTransfer,
+			UsageQty:  qty,
+			UsageRef:  out.MovementRef,
+			UsageAt:   out.MovementAt,
+		})
+		moved = append(moved, goldStockEntity.StockBatch{
+			StockCode:        batch.StockCode,
+			BatchBranchID:    transfer.ToBranchID,
+			BatchLot:         batch.BatchLot,
+			BatchExpiry:      batch.BatchExpiry,
+			BatchQtyReceived: qty,
+			BatchQty:         qty,
+			BatchUnitCost:    batch.BatchUnitCost,
+			BatchRef:         out.MovementRef,
+			BatchReceivedAt:  out.MovementAt,
+		})
+		left -= qty
+	}
+	if len(usages) == 0 {
+		return nil
+	}
+	if err := tx.Create(&usages).Error; err != nil {
+		return err
+	}
+	return tx.Create(&moved).Error
+}
+
+// moveBranchQty applies qty to what branchID has of stockcode, returning
+// ErrNegativeStock instead of going below zero
+func moveBranchQty(tx *gorm.DB, branchID int64, stockcode string, qty int) error {
+	if qty >= 0 {
+		return tx.Exec(qAddBranchQty, branchID, stockcode, qty).Error
+	}
+	res := tx.Exec(qTakeBranchQty, -qty, branchID, stockcode, -qty)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return goldStockEntity.ErrNegativeStock
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package goldgym
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transferFixture(qty int) (goldStockEntity.StockTransfer, goldStockEntity.StockMovement, goldStockEntity.StockMovement) {
+	transfer := goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 1, ToBranchID: 2, Qty: qty, Reason: "transfer", TransferBy: "rina"}
+	out := goldStockEntity.StockMovement{
+		StockCode:        "ISO-1",
+		MovementBranchID: 1,
+		MovementType:     goldStockEntity.MovementTransfer,
+		MovementQty:      -qty,
+		MovementReason:   "transfer",
+		MovementRef:      "TR1",
+		MovementBy:       "rina",
+		MovementAt:       time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local),
+	}
+	in := out
+	in.MovementBranchID = 2
+	in.MovementQty = qty
+	return transfer, out, in
+}
+
+func TestTransferStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	transfer, out, in := transferFixture(5)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qTakeBranchQty)).
+		WithArgs(5, int64(1), "ISO-1", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qAddBranchQty)).
+		WithArgs(int64(2), "ISO-1", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// batch pertama habis 3, sisanya 2 dari batch berikutnya
+	mock.ExpectQuery(regexp.QuoteMeta(qLockTransferBatches)).
+		WithArgs(int64(1), "ISO-1", "2026-10-19").
+		WillReturnRows(sqlmock.NewRows([]string{"batch_id", "stock_code", "batch_branch_id", "batch_lot", "batch_expiry", "batch_qty", "batch_unit_cost"}).
+			AddRow(7, "ISO-1", 1, "L1", "2026-11-01", 3, "4000.00").
+			AddRow(8, "ISO-1", 1, "L2", "2026-12-01", 10, "4200.00"))
+	mock.ExpectExec(regexp.QuoteMeta(qTakeBatchQty)).
+		WithArgs(3, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qTakeBatchQty)).
+		WithArgs(2, int64(8)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `stock_batch_usage`").
+		WithArgs(int64(7), "ISO-1", goldStockEntity.UsageTransfer, 3, "TR1", sqlmock.AnyArg(),
+			int64(8), "ISO-1", goldStockEntity.UsageTransfer, 2, "TR1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectExec("INSERT INTO `stock_batch`").
+		WithArgs("ISO-1", int64(2), "L1", "2026-11-01", 3, 3, "4000.00", "TR1", sqlmock.AnyArg(),
+			"ISO-1", int64(2), "L2", "2026-12-01", 2, 2, "4200.00", "TR1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(9, 2))
+	mock.ExpectExec("INSERT INTO `stock_movement`").
+		WithArgs("ISO-1", int64(1), goldStockEntity.MovementTransfer, -5, "transfer", "TR1", "rina", sqlmock.AnyArg(),
+			"ISO-1", int64(2), goldStockEntity.MovementTransfer, 5, "transfer", "TR1", "rina", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.TransferStock(context.Background(), transfer, out, in))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransferStock_Rejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		cause error
+	}{
+		{"stok cabang kurang", 1, goldStockEntity.ErrNegativeStock},
+		{"kode tidak ada", 0, entity.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := Data{db: db}
+			transfer, out, in := transferFixture(50)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(qTakeBranchQty)).
+				WithArgs(50, int64(1), "ISO-1", 50).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(qStockExists)).
+				WithArgs("ISO-1").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+			mock.ExpectRollback()
+
+			err := repo.TransferStock(context.Background(), transfer, out, in)
+			assert.Equal(t, tt.cause, errors.Cause(err))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
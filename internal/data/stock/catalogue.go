@@ -0,0 +1,231 @@
+package goldgym
+
+import (
+	"context"
+	"fmt"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	"io"
+	"net/url"
+
+	"gorm.io/gorm"
+)
+
+const (
+	qGetProducts = `SELECT p.*, COALESCE(c.category_name, '') AS category_name
+FROM product p
+LEFT JOIN product_category c ON c.category_id = p.product_category_id
+WHERE (? = 0 OR p.product_category_id = ?) AND (? = '' OR p.product_name LIKE ?)
+ORDER BY p.product_name, p.product_code`
+
+	qGetProductByCode = `SELECT p.*, COALESCE(c.category_name, '') AS category_name
+FROM product p
+LEFT JOIN product_category c ON c.category_id = p.product_category_id
+WHERE p.product_code = ?`
+
+	qSetVariant = `UPDATE stock SET stock_product_code = ?, stock_flavour = ?, stock_size = ? WHERE stock_code = ?`
+
+	qStockByBarcode = `SELECT s.stock_id, s.stock_code, s.stock_name, s.stock_pack, s.stock_qty, s.stock_price, s.stock_last_update, s.stock_update_by, s.stock_cost_price, s.stock_reorder_point, s.stock_reorder_qty, s.stock_product_code, s.stock_flavour, s.stock_size
+FROM stock_barcode b
+JOIN stock s ON s.stock_code = b.stock_code
+WHERE b.barcode = ?`
+)
+
+func (d Data) InsertCategory(ctx context.Context, category goldStockEntity.Category) (int64, error) {
+	if err := d.db.WithContext(ctx).Create(&category).Error; err != nil {
+		return 0, errors.Wrap(err, "[DATA][InsertCategory]")
+	}
+	return category.CategoryID, nil
+}
+
+func (d Data) GetCategoryByName(ctx context.Context, name string) (goldStockEntity.Category, error) {
+	var category goldStockEntity.Category
+
+	err := d.db.WithContext(ctx).Where("category_name = ?", name).First(&category).Error
+	if err != nil {
+		return goldStockEntity.Category{}, err
+	}
+	return category, nil
+}
+
+func (d Data) GetCategoryByID(ctx context.Context, id int64) (goldStockEntity.Category, error) {
+	var category goldStockEntity.Category
+
+	err := d.db.WithContext(ctx).Where("category_id = ?", id).First(&category).Error
+	if err != nil {
+		return goldStockEntity.Category{}, err
+	}
+	return category, nil
+}
+
+func (d Data) GetCategories(ctx context.Context) ([]goldStockEntity.Category, error) {
+	var categories []goldStockEntity.Category
+
+	err := d.db.WithContext(ctx).Order("category_name").Find(&categories).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetCategories]")
+	}
+	return categories, nil
+}
+
+func (d Data) InsertProduct(ctx context.Context, product goldStockEntity.Product) error {
+	if err := d.db.WithContext(ctx).Create(&product).Error; err != nil {
+		return errors.Wrap(err, "[DATA][InsertProduct]")
+	}
+	return nil
+}
+
+// GetProductByCode returns the product with its category name, the raw gorm
+// error when it does not exist
+func (d Data) GetProductByCode(ctx context.Context, code string) (goldStockEntity.Product, error) {
+	var products []goldStockEntity.Product
+
+	err := d.db.WithContext(ctx).Raw(qGetProductByCode, code).Scan(&products).Error
+	if err != nil {
+		return goldStockEntity.Product{}, err
+	}
+	if len(products) == 0 {
+		return goldStockEntity.Product{}, gorm.ErrRecordNotFound
+	}
+	return products[0], nil
+}
+
+// GetProducts lists products matching filter, the name search is a prefix
+// or substring match
+func (d Data) GetProducts(ctx context.Context, filter goldStockEntity.CatalogueFilter) ([]goldStockEntity.Product, error) {
+	var products []goldStockEntity.Product
+
+	like := ""
+	if filter.Query != "" {
+		like = "%" + filter.Query + "%"
+	}
+	err := d.db.WithContext(ctx).Raw(qGetProducts, filter.CategoryID, filter.CategoryID, filter.Query, like).Scan(&products).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetProducts]")
+	}
+	return products, nil
+}
+
+// GetVariants lists the stock rows of the given products
+func (d Data) GetVariants(ctx context.Context, productCodes []string) ([]goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+
+	err := d.db.WithContext(ctx).Table("stock").
+		Where("stock_product_code IN ?", productCodes).
+		Order("stock_product_code, stock_flavour, stock_size, stock_code").
+		Find(&stocks).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetVariants]")
+	}
+	return stocks, nil
+}
+
+// SetVariant makes variant.StockCode a variant of variant.ProductCode, an
+// empty ProductCode detaches it
+func (d Data) SetVariant(ctx context.Context, variant goldStockEntity.Variant) error {
+	res := d.db.WithContext(ctx).Exec(qSetVariant, variant.ProductCode, variant.Flavour, variant.Size, variant.StockCode)
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "[DATA][SetVariant]")
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+
+	// zero rows also means the values did not change
+	var count int64
+	if err := d.db.WithContext(ctx).Raw(qStockExists, variant.StockCode).Scan(&count).Error; err != nil {
+		return errors.Wrap(err, "[DATA][SetVariant]")
+	}
+	if count == 0 {
+		return errors.Wrap(entity.ErrNotFound, "[DATA][SetVariant]")
+	}
+	return nil
+}
+
+func (d Data) UpdateProductImage(ctx context.Context, code, imageURL string) error {
+	err := d.db.WithContext(ctx).Model(&goldStockEntity.Product{}).
+		Where("product_code = ?", code).
+		Update("product_image_url", imageURL).Error
+	if err != nil {
+		return errors.Wrap(err, "[DATA][UpdateProductImage]")
+	}
+	return nil
+}
+
+func (d Data) InsertBarcode(ctx context.Context, barcode goldStockEntity.Barcode) error {
+	if err := d.db.WithContext(ctx).Create(&barcode).Error; err != nil {
+		return errors.Wrap(err, "[DATA][InsertBarcode]")
+	}
+	return nil
+}
+
+func (d Data) GetBarcode(ctx context.Context, barcode string) (goldStockEntity.Barcode, error) {
+	var b goldStockEntity.Barcode
+
+	err := d.db.WithContext(ctx).Where("barcode = ?", barcode).First(&b).Error
+	if err != nil {
+		return goldStockEntity.Barcode{}, err
+	}
+	return b, nil
+}
+
+// GetBarcodes lists the barcodes of the given stock codes
+func (d Data) GetBarcodes(ctx context.Context, stockcodes []string) ([]goldStockEntity.Barcode, error) {
+	var barcodes []goldStockEntity.Barcode
+
+	err := d.db.WithContext(ctx).Where("stock_code IN ?", stockcodes).Order("stock_code, barcode").Find(&barcodes).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetBarcodes]")
+	}
+	return barcodes, nil
+}
+
+func (d Data) DeleteBarcode(ctx context.Context, barcode string) error {
+	res := d.db.WithContext(ctx).Where("barcode = ?", barcode).Delete(&goldStockEntity.Barcode{})
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "[DATA][DeleteBarcode]")
+	}
+	if res.RowsAffected == 0 {
+		return errors.Wrap(entity.ErrNotFound, "[DATA][DeleteBarcode]")
+	}
+	return nil
+}
+
+// GetStockByBarcode resolves a scanned barcode to its stock row
+func (d Data) GetStockByBarcode(ctx context.Context, barcode string) (goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+
+	err := d.db.WithContext(ctx).Raw(qStockByBarcode, barcode).Scan(&stocks).Error
+	if err != nil {
+		return goldStockEntity.GetOneStock{}, errors.Wrap(err, "[DATA][GetStockByBarcode]")
+	}
+	if len(stocks) == 0 {
+		return goldStockEntity.GetOneStock{}, errors.Wrap(entity.ErrNotFound, "[DATA][GetStockByBarcode]")
+	}
+	return stocks[0], nil
+}
+
+// UploadImage stores body in the default Firebase storage bucket under path
+// and returns its public URL
+func (d Data) UploadImage(ctx context.Context, path, contentType string, body io.Reader) (string, error) {
+	if d.s == nil {
+		return "", errors.Wrap(goldStockEntity.ErrStorageNotConfigured, "[DATA][UploadImage]")
+	}
+	bucket, err := d.s.DefaultBucket()
+	if err != nil {
+		return "", errors.Wrap(err, "[DATA][UploadImage]")
+	}
+
+	w := bucket.Object(path).NewWriter(ctx)
+	w.ContentType = contentType
+	w.CacheControl = "public, max-age=86400"
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return "", errors.Wrap(err, "[DATA][UploadImage]")
+	}
+	if err := w.Close(); err != nil {
+		return "", errors.Wrap(err, "[DATA][UploadImage]")
+	}
+	return fmt.Sprintf("https://firebasestorage.googleapis.com/v0/b/%s/o/%s?alt=media", w.Attrs().Bucket, url.QueryEscape(path)), nil
+}
@@ -0,0 +1,85 @@
+package goldgym
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStockByBarcode(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+
+	mock.ExpectQuery(regexp.QuoteMeta(qStockByBarcode)).
+		WithArgs("4006381333931").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_code", "stock_name", "stock_qty", "stock_product_code", "stock_flavour"}).
+			AddRow("WHEY-CHOC-1KG", "Whey Choco 1kg", 5, "WHEY", "Chocolate"))
+	stock, err := repo.GetStockByBarcode(context.Background(), "4006381333931")
+	require.NoError(t, err)
+	assert.Equal(t, "WHEY-CHOC-1KG", stock.StockCode)
+	assert.Equal(t, "WHEY", stock.StockProductCode)
+	assert.Equal(t, "Chocolate", stock.StockFlavour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(qStockByBarcode)).
+		WithArgs("96385074").
+		WillReturnRows(sqlmock.NewRows([]string{"stock_code"}))
+	_, err = repo.GetStockByBarcode(context.Background(), "96385074")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProducts(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+
+	mock.ExpectQuery(regexp.QuoteMeta(qGetProducts)).
+		WithArgs(int64(2), int64(2), "whey", "%whey%").
+		WillReturnRows(sqlmock.NewRows([]string{"product_code", "product_name", "product_category_id", "category_name"}).
+			AddRow("WHEY", "Whey Protein", 2, "Suplemen"))
+	products, err := repo.GetProducts(context.Background(), goldStockEntity.CatalogueFilter{CategoryID: 2, Query: "whey"})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "Suplemen", products[0].CategoryName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetVariant(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		exists   int
+		want     error
+	}{
+		{"updated", 1, 0, nil},
+		// MySQL melaporkan 0 rows bila nilainya sama
+		{"unchanged", 0, 1, nil},
+		{"unknown stock", 0, 0, entity.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := Data{db: db}
+
+			mock.ExpectExec(regexp.QuoteMeta(qSetVariant)).
+				WithArgs("WHEY", "Vanilla", "1kg", "WHEY-VAN-1KG").
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			if tt.affected == 0 {
+				mock.ExpectQuery(regexp.QuoteMeta(qStockExists)).
+					WithArgs("WHEY-VAN-1KG").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.exists))
+			}
+
+			err := repo.SetVariant(context.Background(), goldStockEntity.Variant{ProductCode: "WHEY", StockCode: "WHEY-VAN-1KG", Flavour: "Vanilla", Size: "1kg"})
+			assert.Equal(t, tt.want, errors.Cause(err))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package goldgym
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	qLockCostLayers = `SELECT * FROM stock_cost_layer WHERE stock_code = ? AND layer_qty > 0 ORDER BY layer_received_at, layer_id FOR UPDATE`
+
+	qConsumeCostLayer = `UPDATE stock_cost_layer SET layer_qty = layer_qty - ? WHERE layer_id = ?`
+)
+
+// AddCostLayers writes a cost layer for every batch received with tx
+func AddCostLayers(tx *gorm.DB, batches []goldStockEntity.StockBatch) error {
+	if len(batches) == 0 {
+		return nil
+	}
+	layers := make([]goldStockEntity.StockCostLayer, 0, len(batches))
+	for _, batch := range batches {
+		layers = append(layers, goldStockEntity.StockCostLayer{
+			StockCode:        batch.StockCode,
+			LayerQtyReceived: batch.BatchQty,
+			LayerQty:         batch.BatchQty,
+			LayerUnitCost:    batch.BatchUnitCost,
+			LayerRef:         batch.BatchRef,
+			LayerReceivedAt:  batch.BatchReceivedAt,
+		})
+	}
+	return tx.Create(&layers).Error
+}
+
+// ConsumeCostLayers takes up to qty units of stockCode out of its cost layers
+// with tx, oldest received first, and returns how many units the layers
+// covered and what they cost
+func ConsumeCostLayers(tx *gorm.DB, stockCode string, qty int) (int, entity.Money, error) {
+	var layers []goldStockEntity.StockCostLayer
+	if err := tx.Raw(qLockCostLayers, stockCode).Scan(&layers).Error; err != nil {
+		return 0, 0, err
+	}
+
+	var cost entity.Money
+	left := qty
+	for _, layer := range layers {
+		if left == 0 {
+			break
+		}
+		take := layer.LayerQty
+		if take > left {
+			take = left
+		}
+		if err := tx.Exec(qConsumeCostLayer, take, layer.LayerID).Error; err != nil {
+			return 0, 0, err
+		}
+		cost += layer.LayerUnitCost.Mul(take)
+		left -= take
+	}
+	return qty - left, cost, nil
+}
+
+// GetCostLayers lists every cost layer with units left, per stock code
+// newest received first
+func (d Data) GetCostLayers(ctx context.Context) ([]goldStockEntity.StockCostLayer, error) {
+	var layers []goldStockEntity.StockCostLayer
+
+	err := d.db.WithContext(ctx).
+		Where("layer_qty > 0").
+		Order("stock_code, layer_received_at DESC, layer_id DESC").
+		Find(&layers).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetCostLayers]")
+	}
+	return layers, nil
+}
@@ -0,0 +1,253 @@
+package goldgym
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// the line guard makes the over-receive check and the increment one statement
+	qReceivePOLine = `UPDATE purchase_order_line SET pol_qty_received = pol_qty_received + ? WHERE po_id = ? AND stock_code = ? AND pol_qty_received + ? <= pol_qty_ordered`
+
+	// MySQL assigns left to right, so the average cost is computed from the
+	// quantity before this receipt
+	qReceiveStock = `UPDATE stock SET stock_cost_price = ROUND((GREATEST(stock_qty, 0) * stock_cost_price + ? * CAST(? AS DECIMAL(15, 2))) / (GREATEST(stock_qty, 0) + ?), 2), stock_qty = stock_qty + ?, stock_last_update = NOW(), stock_update_by = ? WHERE stock_code = ?`
+
+	qCountOutstandingPOLines = `SELECT COUNT(*) FROM purchase_order_line WHERE po_id = ? AND pol_qty_received < pol_qty_ordered`
+
+	qUpdatePOStatus = `UPDATE purchase_order SET po_status = ? WHERE po_id = ?`
+
+	qCancelPurchaseOrder = `UPDATE purchase_order SET po_status = 'cancelled' WHERE po_id = ? AND po_status IN ('open', 'partial')`
+
+	qGetOutstandingPOLines = `SELECT po.po_id, s.supplier_code, s.supplier_name, po.po_date, po.po_expected_date, po.po_status,
+	l.stock_code, l.pol_qty_ordered AS qty_ordered, l.pol_qty_received AS qty_received,
+	l.pol_qty_ordered - l.pol_qty_received AS qty_outstanding, l.pol_unit_cost AS unit_cost,
+	ROUND((l.pol_qty_ordered - l.pol_qty_received) * l.pol_unit_cost, 2) AS outstanding_value
+FROM purchase_order po
+JOIN supplier s ON s.supplier_id = po.po_supplier_id
+JOIN purchase_order_line l ON l.po_id = po.po_id
+WHERE po.po_status IN ('open', 'partial') AND l.pol_qty_received < l.pol_qty_ordered AND (? = 0 OR po.po_supplier_id = ?) AND (? = 0 OR po.po_branch_id = ?)
+ORDER BY po.po_expected_date, po.po_id, l.stock_code`
+)
+
+// GetStocksByCode returns the stock rows for codes, unknown codes are left out
+func (d Data) GetStocksByCode(ctx context.Context, codes []string) ([]goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+
+	err := d.db.WithContext(ctx).Table("stock").Where("stock_code IN ?", codes).Find(&stocks).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetStocksByCode]")
+	}
+	return stocks, nil
+}
+
+func (d Data) InsertSupplier(ctx context.Context, supplier goldStockEntity.Supplier) (int64, error) {
+	if err := d.db.WithContext(ctx).Create(&supplier).Error; err != nil {
+		return 0, errors.Wrap(err, "[DATA][InsertSupplier]")
+	}
+	return supplier.SupplierID, nil
+}
+
+func (d Data) GetSupplierByCode(ctx context.Context, code string) (goldStockEntity.Supplier, error) {
+	var supplier goldStockEntity.Supplier
+
+	err := d.db.WithContext(ctx).Where("supplier_code = ?", code).First(&supplier).Error
+	if err != nil {
+		return goldStockEntity.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (d Data) GetSupplierByID(ctx context.Context, id int64) (goldStockEntity.Supplier, error) {
+	var supplier goldStockEntity.Supplier
+
+	err := d.db.WithContext(ctx).Where("supplier_id = ?", id).First(&supplier).Error
+	if err != nil {
+		return goldStockEntity.Supplier{}, err
+	}
+	return supplier, nil
+}
+
+func (d Data) GetSuppliers(ctx context.Context) ([]goldStockEntity.Supplier, error) {
+	var suppliers []goldStockEntity.Supplier
+
+	err := d.db.WithContext(ctx).Order("supplier_name").Find(&suppliers).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetSuppliers]")
+	}
+	return suppliers, nil
+}
+
+// InsertPurchaseOrder stores the PO header and its lines in one transaction
+func (d Data) InsertPurchaseOrder(ctx context.Context, po goldStockEntity.PurchaseOrder) error {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&po).Error; err != nil {
+			return err
+		}
+		return tx.Create(&po.Lines).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, "[DATA][InsertPurchaseOrder]")
+	}
+	return nil
+}
+
+// GetPurchaseOrders lists PO headers matching filter, newest first
+func (d Data) GetPurchaseOrders(ctx context.Context, filter goldStockEntity.POFilter) ([]goldStockEntity.PurchaseOrder, error) {
+	var pos []goldStockEntity.PurchaseOrder
+
+	q := d.db.WithContext(ctx)
+	if filter.SupplierID != 0 {
+		q = q.Where("po_supplier_id = ?", filter.SupplierID)
+	}
+	if filter.BranchID != 0 {
+		q = q.Where("po_branch_id = ?", filter.BranchID)
+	}
+	if filter.Status != "" {
+		q = q.Where("po_status = ?", filter.Status)
+	}
+	err := q.Order("po_created_at DESC").Find(&pos).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetPurchaseOrders]")
+	}
+	return pos, nil
+}
+
+func (d Data) GetPurchaseOrderByID(ctx context.Context, poID string) (goldStockEntity.PurchaseOrder, error) {
+	var po goldStockEntity.PurchaseOrder
+
+	err := d.db.WithContext(ctx).Where("po_id = ?", poID).First(&po).Error
+	if err != nil {
+		return goldStockEntity.PurchaseOrder{}, err
+	}
+	return po, nil
+}
+
+func (d Data) GetPurchaseOrderLines(ctx context.Context, poIDs []string) ([]goldStockEntity.PurchaseOrderLine, error) {
+	var lines []goldStockEntity.PurchaseOrderLine
+
+	err := d.db.WithContext(ctx).Where("po_id IN ?", poIDs).Order("po_id, stock_code").Find(&lines).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetPurchaseOrderLines]")
+	}
+	return lines, nil
+}
+
+// ReceivePurchaseOrder books receipts against poID in one transaction: it
+// increments the PO lines and stock, at the PO's branch too, averages the
+// stock cost, logs each receipt in td_stock, writes the receipts, their
+// batches, cost layers and restock movements at that branch and returns the
+// new PO status. The PO row is locked so concurrent receipts of the same PO
+// run one after another.
+func (d Data) ReceivePurchaseOrder(ctx context.Context, poID string, receipts []goldStockEntity.POReceipt, batches []goldStockEntity.StockBatch, movements []goldStockEntity.StockMovement) (string, error) {
+	var status string
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var po goldStockEntity.PurchaseOrder
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("po_id = ?", poID).First(&po).Error
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return entity.ErrNotFound
+			}
+			return err
+		}
+		if po.POStatus != goldStockEntity.POStatusOpen && po.POStatus != goldStockEntity.POStatusPartial {
+			return goldStockEntity.ErrPOClosed
+		}
+
+		for _, receipt := range receipts {
+			res := tx.Exec(qReceivePOLine, receipt.ReceiptQty, poID, receipt.StockCode, receipt.ReceiptQty)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errors.Wrap(goldStockEntity.ErrOverReceive, receipt.StockCode)
+			}
+
+			res = tx.Exec(qReceiveStock, receipt.ReceiptQty, receipt.ReceiptUnitCost, receipt.ReceiptQty, receipt.ReceiptQty, receipt.ReceiptBy, receipt.StockCode)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errors.Wrap(entity.ErrNotFound, receipt.StockCode)
+			}
+			if err := tx.Exec(qAddStockByDate, receipt.ReceiptQty, receipt.ReceiptBy, receipt.StockCode).Error; err != nil {
+				return err
+			}
+			if err := moveBranchQty(tx, po.POBranchID, receipt.StockCode, receipt.ReceiptQty); err != nil {
+				return err
+			}
+		}
+		for i := range batches {
+			batches[i].BatchBranchID = po.POBranchID
+		}
+		for i := range movements {
+			movements[i].MovementBranchID = po.POBranchID
+		}
+		if err := tx.Create(&receipts).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&batches).Error; err != nil {
+			return err
+		}
+		if err := AddCostLayers(tx, batches); err != nil {
+			return err
+		}
+		if err := tx.Create(&movements).Error; err != nil {
+			return err
+		}
+
+		var outstanding int64
+		if err := tx.Raw(qCountOutstandingPOLines, poID).Scan(&outstanding).Error; err != nil {
+			return err
+		}
+		status = goldStockEntity.POStatusReceived
+		if outstanding > 0 {
+			status = goldStockEntity.POStatusPartial
+		}
+		return tx.Exec(qUpdatePOStatus, status, poID).Error
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "[DATA][ReceivePurchaseOrder]")
+	}
+	return status, nil
+}
+
+// CancelPurchaseOrder closes an open or partially received PO, whatever was
+// already received stays in stock
+func (d Data) CancelPurchaseOrder(ctx context.Context, poID string) error {
+	res := d.db.WithContext(ctx).Exec(qCancelPurchaseOrder, poID)
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "[DATA][CancelPurchaseOrder]")
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+
+	var count int64
+	if err := d.db.WithContext(ctx).Model(&goldStockEntity.PurchaseOrder{}).Where("po_id = ?", poID).Count(&count).Error; err != nil {
+		return errors.Wrap(err, "[DATA][CancelPurchaseOrder]")
+	}
+	if count == 0 {
+		return errors.Wrap(entity.ErrNotFound, "[DATA][CancelPurchaseOrder]")
+	}
+	return errors.Wrap(goldStockEntity.ErrPOClosed, "[DATA][CancelPurchaseOrder]")
+}
+
+// GetOutstandingPOLines lists the lines still to be received on open and
+// partially received POs, of one supplier and/or branch when supplierID and
+// branchID are not zero
+func (d Data) GetOutstandingPOLines(ctx context.Context, supplierID, branchID int64) ([]goldStockEntity.OutstandingPOLine, error) {
+	var lines []goldStockEntity.OutstandingPOLine
+
+	err := d.db.WithContext(ctx).Raw(qGetOutstandingPOLines, supplierID, supplierID, branchID, branchID).Scan(&lines).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetOutstandingPOLines]")
+	}
+	return lines, nil
+}
@@ -0,0 +1,167 @@
+package goldgym
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveFixture() ([]goldStockEntity.POReceipt, []goldStockEntity.StockBatch, []goldStockEntity.StockMovement) {
+	at := time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)
+	receipts := []goldStockEntity.POReceipt{{POID: "PO1", StockCode: "WHEY-1", ReceiptQty: 10, ReceiptUnitCost: entity.NewMoney(22000), ReceiptLot: "L1", ReceiptExpiry: "2027-01-31", ReceiptBy: "budi", ReceiptAt: at}}
+	batches := []goldStockEntity.StockBatch{{StockCode: "WHEY-1", BatchLot: "L1", BatchExpiry: "2027-01-31", BatchQtyReceived: 10, BatchQty: 10, BatchUnitCost: entity.NewMoney(22000), BatchRef: "PO1", BatchReceivedAt: at}}
+	movements := []goldStockEntity.StockMovement{{StockCode: "WHEY-1", MovementType: goldStockEntity.MovementRestock, MovementQty: 10, MovementReason: "purchase order receipt", MovementRef: "PO1", MovementBy: "budi", MovementAt: at}}
+	return receipts, batches, movements
+}
+
+func expectLockPO(mock sqlmock.Sqlmock, status string) {
+	mock.ExpectQuery("SELECT \\* FROM `purchase_order` WHERE po_id = \\? .*FOR UPDATE").
+		WithArgs("PO1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"po_id", "po_status", "po_branch_id"}).AddRow("PO1", status, 2))
+}
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	receipts, batches, movements := receiveFixture()
+
+	mock.ExpectBegin()
+	expectLockPO(mock, goldStockEntity.POStatusOpen)
+	mock.ExpectExec(regexp.QuoteMeta(qReceivePOLine)).
+		WithArgs(10, "PO1", "WHEY-1", 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qReceiveStock)).
+		WithArgs(10, "22000.00", 10, 10, "budi", "WHEY-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qAddStockByDate)).
+		WithArgs(10, "budi", "WHEY-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// diterima di cabang PO
+	mock.ExpectExec(regexp.QuoteMeta(qAddBranchQty)).
+		WithArgs(int64(2), "WHEY-1", 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `po_receipt`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `stock_batch`").
+		WithArgs("WHEY-1", int64(2), "L1", "2027-01-31", 10, 10, "22000.00", "PO1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// setiap batch yang diterima juga menjadi layer biaya FIFO
+	mock.ExpectExec("INSERT INTO `stock_cost_layer`").
+		WithArgs("WHEY-1", 10, 10, "22000.00", "PO1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `stock_movement`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(qCountOutstandingPOLines)).
+		WithArgs("PO1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta(qUpdatePOStatus)).
+		WithArgs(goldStockEntity.POStatusPartial, "PO1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	status, err := repo.ReceivePurchaseOrder(context.Background(), "PO1", receipts, batches, movements)
+	require.NoError(t, err)
+	assert.Equal(t, goldStockEntity.POStatusPartial, status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReceivePurchaseOrder_Rejected(t *testing.T) {
+	receipts, batches, movements := receiveFixture()
+
+	t.Run("over receive", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := Data{db: db}
+
+		mock.ExpectBegin()
+		expectLockPO(mock, goldStockEntity.POStatusPartial)
+		mock.ExpectExec(regexp.QuoteMeta(qReceivePOLine)).
+			WithArgs(10, "PO1", "WHEY-1", 10).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.ReceivePurchaseOrder(context.Background(), "PO1", receipts, batches, movements)
+		assert.Equal(t, goldStockEntity.ErrOverReceive, errors.Cause(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := Data{db: db}
+
+		mock.ExpectBegin()
+		expectLockPO(mock, goldStockEntity.POStatusCancelled)
+		mock.ExpectRollback()
+
+		_, err := repo.ReceivePurchaseOrder(context.Background(), "PO1", receipts, batches, movements)
+		assert.Equal(t, goldStockEntity.ErrPOClosed, errors.Cause(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := Data{db: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT \\* FROM `purchase_order`").
+			WillReturnRows(sqlmock.NewRows([]string{"po_id"}))
+		mock.ExpectRollback()
+
+		_, err := repo.ReceivePurchaseOrder(context.Background(), "PO1", receipts, batches, movements)
+		assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCancelPurchaseOrder(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta(qCancelPurchaseOrder)).
+		WithArgs("PO1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.CancelPurchaseOrder(ctx, "PO1"))
+
+	mock.ExpectExec(regexp.QuoteMeta(qCancelPurchaseOrder)).
+		WithArgs("PO1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `purchase_order`").
+		WithArgs("PO1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	assert.Equal(t, goldStockEntity.ErrPOClosed, errors.Cause(repo.CancelPurchaseOrder(ctx, "PO1")))
+
+	mock.ExpectExec(regexp.QuoteMeta(qCancelPurchaseOrder)).
+		WithArgs("PO2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `purchase_order`").
+		WithArgs("PO2").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(repo.CancelPurchaseOrder(ctx, "PO2")))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOutstandingPOLines(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+
+	mock.ExpectQuery(regexp.QuoteMeta(qGetOutstandingPOLines)).
+		WithArgs(int64(3), int64(3), int64(2), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"po_id", "supplier_code", "stock_code", "qty_outstanding", "outstanding_value"}).
+			AddRow("PO1", "SUP-1", "WHEY-1", 10, 220000.0))
+	lines, err := repo.GetOutstandingPOLines(context.Background(), 3, 2)
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, 10, lines[0].QtyOutstanding)
+	assert.Equal(t, entity.NewMoney(220000), lines[0].OutstandingValue)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
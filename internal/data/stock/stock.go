@@ -36,27 +36,64 @@ type (
 // qGetAllUser = "SELECT * FROM users"
 const (
 	getOneStockProduct  = "GetOneStockProduct"
-	qGetOneStockProduct = `SELECT stock_id, stock_code, stock_name, stock_pack,stock_qty, stock_price, stock_last_update, stock_update_by FROM stock WHERE stock_code = ? AND (? = "" OR stock_name LIKE ?) and (? = "" OR stock_id = ?)`
+	qGetOneStockProduct = `SELECT stock_id, stock_code, stock_name, stock_pack,stock_qty, stock_price, stock_last_update, stock_update_by, stock_cost_price, stock_reorder_point, stock_reorder_qty, stock_product_code, stock_flavour, stock_size FROM stock WHERE stock_code = ? AND (? = "" OR stock_name LIKE ?) and (? = "" OR stock_id = ?)`
 
-	insertStockSales  = "InsertStockSales"
-	qInsertStockSales = `INSERT INTO stock (stock_id, stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by) VALUES (?,?,?,?,?,?,NOW(),?)`
-
-	getLastStock  = "GetLastStock"
-	qGetLastStock = `SELECT stock_id, stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by FROM stock ORDER BY stock_last_update DESC LIMIT 1`
+	// stock_id is AUTO_INCREMENT and stock_code is unique, so a restock of an
+	// existing code becomes a relative increment instead of a second row
+	upsertStock  = "UpsertStock"
+	qUpsertStock = `INSERT INTO stock (stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by) VALUES (?,?,?,?,?,NOW(),?)
+ON DUPLICATE KEY UPDATE stock_qty = stock_qty + VALUES(stock_qty), stock_last_update = NOW(), stock_update_by = VALUES(stock_update_by)`
 
+	// td_stock logs every restock by date, with the stock row it went into
 	addStockByDate  = "AddStockByDate"
-	qAddStockByDate = `INSERT INTO td_stock (stock_id, stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by) VALUES (?, ?,?,?,?,?,NOW(),?)`
+	qAddStockByDate = `INSERT INTO td_stock (stock_id, stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by) SELECT stock_id, stock_code, stock_name, stock_pack, ?, stock_price, NOW(), ? FROM stock WHERE stock_code = ?`
 
 	getStockByID  = "GetStockByID"
 	qGetStockByID = `SELECT stock_id, stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by FROM stock WHERE stock_code = ?`
 
-	updateStockQty  = "UpdateStockQty"
-	qUpdateStockQty = `UPDATE stock SET stock_qty = ?, stock_qty_update = NOW() WHERE stock_code = ?`
+	// the guard refuses to take stock_qty below zero in the same statement
+	adjustStockQty  = "AdjustStockQty"
+	qAdjustStockQty = `UPDATE stock SET stock_qty = stock_qty + ?, stock_last_update = NOW() WHERE stock_code = ? AND stock_qty + ? >= 0`
+
+	stockExists  = "StockExists"
+	qStockExists = `SELECT COUNT(*) FROM stock WHERE stock_code = ?`
+
+	getStockBalanceBefore  = "GetStockBalanceBefore"
+	qGetStockBalanceBefore = `SELECT COALESCE(SUM(movement_qty), 0) FROM stock_movement WHERE stock_code = ? AND (? = 0 OR movement_branch_id = ?) AND movement_at < ?`
+
+	// stock_qty is a projection of the ledger and can always be recomputed
+	rebuildStockQty  = "RebuildStockQty"
+	qRebuildStockQty = `UPDATE stock s SET s.stock_qty = (SELECT COALESCE(SUM(m.movement_qty), 0) FROM stock_movement m WHERE m.stock_code = s.stock_code) WHERE (? = '' OR s.stock_code = ?)`
 
 	getAllStockHeader  = "GetAllStockHeader"
-	qGetAllStockHeader = `SELECT stock_id, stock_code, stock_name, stock_pack,stock_qty, stock_price, stock_last_update, stock_update_by
+	qGetAllStockHeader = `SELECT stock_id, stock_code, stock_name, stock_pack,stock_qty, stock_price, stock_last_update, stock_update_by, stock_cost_price, stock_reorder_point, stock_reorder_qty, stock_product_code, stock_flavour, stock_size
 FROM stock order by stock_id asc`
 
+	updateReorderPoint  = "UpdateReorderPoint"
+	qUpdateReorderPoint = `UPDATE stock SET stock_reorder_point = ?, stock_reorder_qty = ?, stock_low_alerted_at = NULL, stock_low_emailed_at = NULL WHERE stock_code = ?`
+
+	getLowStock  = "GetLowStock"
+	qGetLowStock = `SELECT stock_id, stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by, stock_cost_price, stock_reorder_point, stock_reorder_qty
+FROM stock WHERE stock_reorder_point > 0 AND stock_qty <= stock_reorder_point AND (? = 0 OR stock_low_alerted_at IS NULL) ORDER BY stock_code`
+
+	// claiming per code keeps two instances from sending the same alert
+	claimLowStockAlert  = "ClaimLowStockAlert"
+	qClaimLowStockAlert = `UPDATE stock SET stock_low_alerted_at = NOW() WHERE stock_code = ? AND stock_low_alerted_at IS NULL`
+
+	getLowStockToEmail  = "GetLowStockToEmail"
+	qGetLowStockToEmail = `SELECT stock_id, stock_code, stock_name, stock_pack, stock_qty, stock_price, stock_last_update, stock_update_by, stock_cost_price, stock_reorder_point, stock_reorder_qty
+FROM stock WHERE stock_reorder_point > 0 AND stock_qty <= stock_reorder_point AND stock_low_alerted_at IS NOT NULL AND stock_low_emailed_at IS NULL ORDER BY stock_code`
+
+	claimLowStockEmail  = "ClaimLowStockEmail"
+	qClaimLowStockEmail = `UPDATE stock SET stock_low_emailed_at = NOW() WHERE stock_code = ? AND stock_low_alerted_at IS NOT NULL AND stock_low_emailed_at IS NULL`
+
+	releaseLowStockEmail  = "ReleaseLowStockEmail"
+	qReleaseLowStockEmail = `UPDATE stock SET stock_low_emailed_at = NULL WHERE stock_code IN (?)`
+
+	// products restocked above their point may alert again next time
+	resetLowStockAlerts  = "ResetLowStockAlerts"
+	qResetLowStockAlerts = `UPDATE stock SET stock_low_alerted_at = NULL, stock_low_emailed_at = NULL WHERE stock_low_alerted_at IS NOT NULL AND stock_qty > stock_reorder_point`
+
 // // getJadwal  = "GetJadwal"
 // // qGetJadwal = "SELECT * FROM m_jadwal"
 
@@ -172,9 +209,12 @@ FROM stock order by stock_id asc`
 var (
 	readStmt = []statement{
 		{getOneStockProduct, qGetOneStockProduct},
-		{getLastStock, qGetLastStock},
 		{getStockByID, qGetStockByID},
+		{stockExists, qStockExists},
+		{getStockBalanceBefore, qGetStockBalanceBefore},
 		{getAllStockHeader, qGetAllStockHeader},
+		{getLowStock, qGetLowStock},
+		{getLowStockToEmail, qGetLowStockToEmail},
 		// {getGoldUser, qGetGoldUser},
 		// {getGoldUserByEmail, qGetGoldUserByEmail},
 		// {getGoldUserByEmailLogin, qGetGoldUserByEmailLogin},
@@ -188,14 +228,20 @@ var (
 		// {getPasswordByUser, qGetPasswordByUser},
 	}
 	insertStmt = []statement{
-		{insertStockSales, qInsertStockSales},
+		{upsertStock, qUpsertStock},
 		{addStockByDate, qAddStockByDate},
 		// {insertGoldUser, qInsertGoldUser},
 		// {insertSubscription, qInsertSubscription},
 		// {insertSubscriptionDetail, qInsertSubscriptionDetail},
 	}
 	updateStmt = []statement{
-		{updateStockQty, qUpdateStockQty},
+		{adjustStockQty, qAdjustStockQty},
+		{rebuildStockQty, qRebuildStockQty},
+		{updateReorderPoint, qUpdateReorderPoint},
+		{claimLowStockAlert, qClaimLowStockAlert},
+		{claimLowStockEmail, qClaimLowStockEmail},
+		{releaseLowStockEmail, qReleaseLowStockEmail},
+		{resetLowStockAlerts, qResetLowStockAlerts},
 		// {updateGoldToken, qUpdateGoldToken},
 		// {updateSubscriptionDetail, qUpdateSubscriptionDetail},
 		// {updateDataPeserta, qUpdateDataPeserta},
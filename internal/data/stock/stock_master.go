@@ -3,13 +3,17 @@ package goldgym
 import (
 	"context"
 	"encoding/json"
+	outboxData "gold-gym-be/internal/data/outbox"
+	"gold-gym-be/internal/entity"
 	firebaseEntity "gold-gym-be/internal/entity/firebase"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
 	goldStockEntity "gold-gym-be/internal/entity/stock"
 	"gold-gym-be/pkg/errors"
 	"log"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
 )
 
 // JSON BASED
@@ -34,28 +38,13 @@ func (d Data) getFromRedis(ctx context.Context, key string, dest interface{}) (e
 }
 
 func (d Data) GetOneStockProduct(ctx context.Context, stockcode string, stockname string, stockid string) (goldStockEntity.GetOneStock, error) {
-	var (
-		user goldStockEntity.GetOneStock
-		// users []goldEntity.GetGoldUser
-		err error
-	)
-	log.Println("data GetGoldUser object", stockid, stockid, stockcode, stockcode)
-	log.Println("data GetGoldUser object12345", stockcode)
-	rows, err := (*d.stmt)[getOneStockProduct].QueryxContext(ctx, stockcode, "%"+stockname+"%", "%"+stockname+"%", stockid, stockid)
-	if err != nil {
-		return user, errors.Wrap(err, "[DATA] [GetGoldUser]")
-	}
-	log.Println("datagolduser", user)
-
-	defer rows.Close()
+	var user goldStockEntity.GetOneStock
 
-	for rows.Next() {
-		if err = rows.StructScan(&user); err != nil {
-			return user, errors.Wrap(err, "[DATA] [GetGoldUser]")
-		}
-		// users = append(users, user)
+	err := d.db.WithContext(ctx).Raw(qGetOneStockProduct, stockcode, "%"+stockname+"%", "%"+stockname+"%", stockid, stockid).Scan(&user).Error
+	if err != nil {
+		return user, errors.Wrap(err, "[DATA] [GetOneStockProduct]")
 	}
-	return user, err
+	return user, nil
 }
 
 func (d Data) GetAllStockHeaderToRedis(ctx context.Context) (users []goldStockEntity.GetOneStock, err error) {
@@ -130,80 +119,40 @@ func (d Data) GetAllStockHeaderToRedis(ctx context.Context) (users []goldStockEn
 
 // }
 
-func (d Data) GetLastStock(ctx context.Context) (goldStockEntity.GetOneStock, error) {
-	var (
-		user goldStockEntity.GetOneStock
-		// users []goldEntity.GetGoldUser
-		err error
-	)
-	// log.Println("data GetGoldUser object", stockid, stockid, stockcode, stockcode)
-	rows, err := (*d.stmt)[getLastStock].QueryxContext(ctx)
-	if err != nil {
-		return user, errors.Wrap(err, "[DATA] [GetGoldUser]")
-	}
-	log.Println("datagolduser", user)
-
-	defer rows.Close()
-
-	for rows.Next() {
-		if err = rows.StructScan(&user); err != nil {
-			return user, errors.Wrap(err, "[DATA] [GetGoldUser]")
+// UpsertStock inserts a new stock code or adds stock.StockQTY to an existing
+// one in a single statement, adds it to the branch of movement and records
+// the restock in the ledger and in td_stock in the same transaction. It
+// reports whether a new row was created.
+func (d Data) UpsertStock(ctx context.Context, stock goldStockEntity.InsertStock, movement goldStockEntity.StockMovement) (bool, error) {
+	var created bool
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(qUpsertStock,
+			stock.StockCode,
+			stock.StockName,
+			stock.StockPack,
+			stock.StockQTY,
+			stock.StockPrice,
+			stock.StockUpdateBy,
+		)
+		if res.Error != nil {
+			return res.Error
 		}
-		// users = append(users, user)
-	}
-	return user, err
-}
-
-func (d Data) InsertStockSales(ctx context.Context, stock goldStockEntity.InsertStock) (string, error) {
-	var result string
-	var err error
-
-	_, err = (*d.stmt)[insertStockSales].ExecContext(ctx,
-		stock.StockID,
-		stock.StockCode,
-		stock.StockName,
-		stock.StockPack,
-		stock.StockQTY,
-		stock.StockPrice,
-		stock.StockUpdateBy,
-	)
-
-	log.Println("data stock object", stock)
-
-	if err != nil {
-		result = "Gagal"
-		return result, errors.Wrap(err, "[DATA][InsertStockSales]")
-	}
-	result = "Sukses"
-
-	return result, err
-
-}
-
-func (d Data) AddStockByDate(ctx context.Context, stock goldStockEntity.InsertStock) (string, error) {
-	var result string
-	var err error
-
-	_, err = (*d.stmt)[addStockByDate].ExecContext(ctx,
-		stock.StockID,
-		stock.StockCode,
-		stock.StockName,
-		stock.StockPack,
-		stock.StockQTY,
-		stock.StockPrice,
-		stock.StockUpdateBy,
-	)
-
-	log.Println("data stock object", stock)
+		// MySQL reports 1 affected row for an insert and 2 for an update
+		created = res.RowsAffected == 1
 
+		if err := tx.Exec(qAddStockByDate, stock.StockQTY, stock.StockUpdateBy, stock.StockCode).Error; err != nil {
+			return err
+		}
+		if err := moveBranchQty(tx, movement.MovementBranchID, stock.StockCode, stock.StockQTY); err != nil {
+			return err
+		}
+		return tx.Create(&movement).Error
+	})
 	if err != nil {
-		result = "Gagal"
-		return result, errors.Wrap(err, "[DATA][InsertStockSales]")
+		return false, errors.Wrap(err, "[DATA][UpsertStock]")
 	}
-	result = "Sukses"
-
-	return result, err
-
+	return created, nil
 }
 
 func (d Data) GetStockByID(ctx context.Context, stockcode string) ([]goldStockEntity.GetOneStock, error) {
@@ -230,49 +179,191 @@ func (d Data) GetStockByID(ctx context.Context, stockcode string) ([]goldStockEn
 	return users, err
 }
 
-func (d Data) UpdateStockQty(ctx context.Context, stockQty int, stockcode string) (string, error) {
-	var result string
-	var err error
+// RecordMovement applies movement.MovementQty to stock_qty and to the branch
+// of the movement atomically and appends the movement to the ledger in one
+// transaction. It returns ErrNegativeStock instead of going below zero, in
+// total or at the branch.
+func (d Data) RecordMovement(ctx context.Context, movement goldStockEntity.StockMovement) error {
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(qAdjustStockQty, movement.MovementQty, movement.StockCode, movement.MovementQty)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// nothing matched: either the code is unknown or the guard refused
+			var count int64
+			if err := tx.Raw(qStockExists, movement.StockCode).Scan(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return entity.ErrNotFound
+			}
+			return goldStockEntity.ErrNegativeStock
+		}
+		if err := moveBranchQty(tx, movement.MovementBranchID, movement.StockCode, movement.MovementQty); err != nil {
+			return err
+		}
+		return tx.Create(&movement).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, "[DATA][RecordMovement]")
+	}
+	return nil
+}
+
+// GetStockMovements lists the ledger of stockcode in [from, to), oldest
+// first, of one branch when branchID is not zero
+func (d Data) GetStockMovements(ctx context.Context, stockcode string, branchID int64, from, to time.Time) ([]goldStockEntity.StockMovement, error) {
+	var movements []goldStockEntity.StockMovement
 
-	_, err = (*d.stmt)[updateStockQty].ExecContext(ctx,
-		stockQty,
-		stockcode,
-	)
+	q := d.db.WithContext(ctx).Where("stock_code = ? AND movement_at >= ? AND movement_at < ?", stockcode, from, to)
+	if branchID != 0 {
+		q = q.Where("movement_branch_id = ?", branchID)
+	}
+	err := q.Order("movement_at, movement_id").
+		Find(&movements).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetStockMovements]")
+	}
+	return movements, nil
+}
 
-	log.Println("data stock object", stockQty)
+// GetStockBalanceBefore sums the ledger of stockcode before t, of one branch
+// when branchID is not zero
+func (d Data) GetStockBalanceBefore(ctx context.Context, stockcode string, branchID int64, t time.Time) (int, error) {
+	var balance int
 
+	err := d.db.WithContext(ctx).Raw(qGetStockBalanceBefore, stockcode, branchID, branchID, t).Scan(&balance).Error
 	if err != nil {
-		result = "Gagal"
-		return result, errors.Wrap(err, "[DATA][InsertStockSales]")
+		return 0, errors.Wrap(err, "[DATA][GetStockBalanceBefore]")
 	}
-	result = "Sukses"
+	return balance, nil
+}
 
-	return result, err
+// RebuildStockQty recomputes stock_qty and the branch balances from the
+// ledger, for every stock when stockcode is empty. It returns the number of
+// stock rows whose total had drifted.
+func (d Data) RebuildStockQty(ctx context.Context, stockcode string) (int64, error) {
+	var rows int64
 
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(qRebuildStockQty, stockcode, stockcode)
+		if res.Error != nil {
+			return res.Error
+		}
+		rows = res.RowsAffected
+		return tx.Exec(qRebuildBranchQty, stockcode, stockcode).Error
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "[DATA][RebuildStockQty]")
+	}
+	return rows, nil
 }
 
 func (d Data) GetAllStockHeader(ctx context.Context) ([]goldStockEntity.GetOneStock, error) {
-	var (
-		user  goldStockEntity.GetOneStock
-		users []goldStockEntity.GetOneStock
-		err   error
-	)
-	log.Println("data GetGoldUser object")
-	rows, err := (*d.stmt)[getAllStockHeader].QueryxContext(ctx)
+	var users []goldStockEntity.GetOneStock
+
+	err := d.db.WithContext(ctx).Raw(qGetAllStockHeader).Scan(&users).Error
 	if err != nil {
 		return users, errors.Wrap(err, "[DATA] [GetAllStockHeader]")
 	}
-	log.Println("datagolduser", user)
+	return users, nil
+}
 
-	defer rows.Close()
+func (d Data) UpdateReorderPoint(ctx context.Context, reorder goldStockEntity.ReorderPoint) error {
+	res := d.db.WithContext(ctx).Exec(qUpdateReorderPoint, reorder.ReorderPoint, reorder.ReorderQty, reorder.StockCode)
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "[DATA][UpdateReorderPoint]")
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
 
-	for rows.Next() {
-		if err = rows.StructScan(&user); err != nil {
-			return users, errors.Wrap(err, "[DATA] [GetAllStockHeader]")
+	// zero rows also means the values did not change
+	var count int64
+	if err := d.db.WithContext(ctx).Raw(qStockExists, reorder.StockCode).Scan(&count).Error; err != nil {
+		return errors.Wrap(err, "[DATA][UpdateReorderPoint]")
+	}
+	if count == 0 {
+		return errors.Wrap(entity.ErrNotFound, "[DATA][UpdateReorderPoint]")
+	}
+	return nil
+}
+
+// GetLowStock lists products at or below their reorder point, only those not
+// alerted yet when unalertedOnly is set
+func (d Data) GetLowStock(ctx context.Context, unalertedOnly bool) ([]goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+
+	flag := 0
+	if unalertedOnly {
+		flag = 1
+	}
+	err := d.db.WithContext(ctx).Raw(qGetLowStock, flag).Scan(&stocks).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetLowStock]")
+	}
+	return stocks, nil
+}
+
+// ClaimLowStockAlert marks stockcode as alerted and reports whether this
+// caller was the one to do it. The caller that claims also stores event, the
+// StockLow of the product, in the same transaction.
+func (d Data) ClaimLowStockAlert(ctx context.Context, stockcode string, event outboxEntity.Event) (bool, error) {
+	claimed := false
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(qClaimLowStockAlert, stockcode)
+		if res.Error != nil {
+			return res.Error
 		}
-		users = append(users, user)
+		if res.RowsAffected != 1 {
+			return nil
+		}
+		claimed = true
+		return outboxData.Insert(tx, event)
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "[DATA][ClaimLowStockAlert]")
 	}
-	return users, err
+	return claimed, nil
+}
+
+// GetLowStockToEmail lists the low products whose StockLow was published
+// but that were not emailed yet
+func (d Data) GetLowStockToEmail(ctx context.Context) ([]goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+
+	err := d.db.WithContext(ctx).Raw(qGetLowStockToEmail).Scan(&stocks).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "[DATA][GetLowStockToEmail]")
+	}
+	return stocks, nil
+}
+
+// ClaimLowStockEmail marks stockcode as emailed and reports whether this
+// caller was the one to do it
+func (d Data) ClaimLowStockEmail(ctx context.Context, stockcode string) (bool, error) {
+	res := d.db.WithContext(ctx).Exec(qClaimLowStockEmail, stockcode)
+	if res.Error != nil {
+		return false, errors.Wrap(res.Error, "[DATA][ClaimLowStockEmail]")
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ReleaseLowStockEmail undoes email claims whose email could not be sent.
+// The StockLow claims stay, their events are already stored.
+func (d Data) ReleaseLowStockEmail(ctx context.Context, stockcodes []string) error {
+	if err := d.db.WithContext(ctx).Exec(qReleaseLowStockEmail, stockcodes).Error; err != nil {
+		return errors.Wrap(err, "[DATA][ReleaseLowStockEmail]")
+	}
+	return nil
+}
+
+func (d Data) ResetLowStockAlerts(ctx context.Context) error {
+	if err := d.db.WithContext(ctx).Exec(qResetLowStockAlerts).Error; err != nil {
+		return errors.Wrap(err, "[DATA][ResetLowStockAlerts]")
+	}
+	return nil
 }
 
 // firebaseio
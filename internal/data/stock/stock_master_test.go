@@ -0,0 +1,300 @@
+package goldgym
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// setupMockDB creates a mock database for testing
+func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+func movement(code string, qty int) goldStockEntity.StockMovement {
+	return goldStockEntity.StockMovement{
+		StockCode:        code,
+		MovementBranchID: 1,
+		MovementType:     goldStockEntity.MovementAdjustment,
+		MovementQty:      qty,
+		MovementReason:   "stock opname",
+		MovementBy:       "rina",
+		MovementAt:       time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local),
+	}
+}
+
+func TestRecordMovement(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qAdjustStockQty)).
+		WithArgs(-2, "WHEY-1", -2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qTakeBranchQty)).
+		WithArgs(2, int64(1), "WHEY-1", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `stock_movement`").
+		WithArgs("WHEY-1", int64(1), goldStockEntity.MovementAdjustment, -2, "stock opname", "", "rina", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, repo.RecordMovement(ctx, movement("WHEY-1", -2)))
+
+	// guard refused: the row exists, so the stock would have gone negative
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qAdjustStockQty)).
+		WithArgs(-5, "WHEY-1", -5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(qStockExists)).
+		WithArgs("WHEY-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+	err := repo.RecordMovement(ctx, movement("WHEY-1", -5))
+	assert.Equal(t, goldStockEntity.ErrNegativeStock, errors.Cause(err))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qAdjustStockQty)).
+		WithArgs(3, "NOPE", 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(qStockExists)).
+		WithArgs("NOPE").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+	err = repo.RecordMovement(ctx, movement("NOPE", 3))
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+
+	// total cukup, tapi cabangnya tidak punya stok sebanyak itu
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qAdjustStockQty)).
+		WithArgs(-4, "WHEY-1", -4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qTakeBranchQty)).
+		WithArgs(4, int64(1), "WHEY-1", 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err = repo.RecordMovement(ctx, movement("WHEY-1", -4))
+	assert.Equal(t, goldStockEntity.ErrNegativeStock, errors.Cause(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestAdjustStockQtyStatement memastikan stok diubah lewat satu UPDATE
+// relatif yang dijaga di database, bukan SELECT lalu UPDATE nilai absolut:
+// dua movement yang berjalan bersamaan masing-masing hanya mengirim deltanya,
+// jadi tidak ada update yang hilang dan stok tidak bisa negatif
+func TestAdjustStockQtyStatement(t *testing.T) {
+	assert.Regexp(t, `^UPDATE stock SET stock_qty = stock_qty \+ \?`, qAdjustStockQty)
+	assert.Regexp(t, `WHERE stock_code = \? AND stock_qty \+ \? >= 0$`, qAdjustStockQty)
+
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	ctx := context.Background()
+
+	// tidak ada query lain sebelum UPDATE, sqlmock menolak statement yang
+	// tidak diharapkan
+	for _, qty := range []int{1, 1, -3} {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(qAdjustStockQty)).
+			WithArgs(qty, "WHEY-1", qty).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if qty > 0 {
+			mock.ExpectExec(regexp.QuoteMeta(qAddBranchQty)).
+				WithArgs(int64(1), "WHEY-1", qty).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		} else {
+			mock.ExpectExec(regexp.QuoteMeta(qTakeBranchQty)).
+				WithArgs(-qty, int64(1), "WHEY-1", -qty).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec("INSERT INTO `stock_movement`").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		require.NoError(t, repo.RecordMovement(ctx, movement("WHEY-1", qty)))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	ctx := context.Background()
+	stock := goldStockEntity.InsertStock{StockCode: "WHEY-1", StockName: "Whey", StockPack: "sachet", StockQTY: 10, StockPrice: entity.NewMoney(25000), StockUpdateBy: "rina"}
+	restock := movement("WHEY-1", 10)
+	restock.MovementType = goldStockEntity.MovementRestock
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qUpsertStock)).
+		WithArgs("WHEY-1", "Whey", "sachet", 10, "25000.00", "rina").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qAddStockByDate)).
+		WithArgs(10, "rina", "WHEY-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qAddBranchQty)).
+		WithArgs(int64(1), "WHEY-1", 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `stock_movement`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	created, err := repo.UpsertStock(ctx, stock, restock)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qUpsertStock)).
+		WillReturnResult(sqlmock.NewResult(7, 2))
+	mock.ExpectExec(regexp.QuoteMeta(qAddStockByDate)).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(regexp.QuoteMeta(qAddBranchQty)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO `stock_movement`").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	created, err = repo.UpsertStock(ctx, stock, restock)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuildStockQty(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qRebuildStockQty)).
+		WithArgs("", "").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(qRebuildBranchQty)).
+		WithArgs("", "").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+	rows, err := repo.RebuildStockQty(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), rows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateReorderPoint(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta(qUpdateReorderPoint)).
+		WithArgs(5, 24, "WHEY-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.UpdateReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: "WHEY-1", ReorderPoint: 5, ReorderQty: 24}))
+
+	// nilai sama, baris tetap ada
+	mock.ExpectExec(regexp.QuoteMeta(qUpdateReorderPoint)).
+		WithArgs(5, 24, "WHEY-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(qStockExists)).
+		WithArgs("WHEY-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	assert.NoError(t, repo.UpdateReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: "WHEY-1", ReorderPoint: 5, ReorderQty: 24}))
+
+	mock.ExpectExec(regexp.QuoteMeta(qUpdateReorderPoint)).
+		WithArgs(5, 24, "NOPE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(qStockExists)).
+		WithArgs("NOPE").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	err := repo.UpdateReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: "NOPE", ReorderPoint: 5, ReorderQty: 24})
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLowStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+
+	mock.ExpectQuery(regexp.QuoteMeta(qGetLowStock)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"stock_code", "stock_qty", "stock_reorder_point", "stock_reorder_qty"}).
+			AddRow("WHEY-1", 3, 5, 24))
+	stocks, err := repo.GetLowStock(context.Background(), true)
+	require.NoError(t, err)
+	require.Len(t, stocks, 1)
+	assert.Equal(t, 5, stocks[0].StockReorderPoint)
+	assert.Equal(t, 24, stocks[0].SuggestedOrder())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLowStockAlertClaim(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := Data{db: db}
+	ctx := context.Background()
+
+	event, err := outboxEntity.NewEvent(outboxEntity.EventStockLow, outboxEntity.AggregateStock, "WHEY-1", outboxEntity.StockLow{StockCode: "WHEY-1"}, time.Now())
+	require.NoError(t, err)
+
+	// claim dan event StockLow dalam satu transaksi
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qClaimLowStockAlert)).
+		WithArgs("WHEY-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `outbox_event`").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	claimed, err := repo.ClaimLowStockAlert(ctx, "WHEY-1", event)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// instance lain sudah claim duluan, event tidak ditulis
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(qClaimLowStockAlert)).
+		WithArgs("WHEY-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	claimed, err = repo.ClaimLowStockAlert(ctx, "WHEY-1", event)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// email diklaim terpisah, gagal kirim hanya melepas claim email
+	mock.ExpectQuery(regexp.QuoteMeta(qGetLowStockToEmail)).
+		WillReturnRows(sqlmock.NewRows([]string{"stock_code", "stock_qty", "stock_reorder_point"}).
+			AddRow("WHEY-1", 3, 5))
+	stocks, err := repo.GetLowStockToEmail(ctx)
+	require.NoError(t, err)
+	require.Len(t, stocks, 1)
+	mock.ExpectExec(regexp.QuoteMeta(qClaimLowStockEmail)).
+		WithArgs("WHEY-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	claimed, err = repo.ClaimLowStockEmail(ctx, "WHEY-1")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE stock SET stock_low_emailed_at = NULL WHERE stock_code IN (")).
+		WithArgs("WHEY-1", "ISO-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	assert.NoError(t, repo.ReleaseLowStockEmail(ctx, []string{"WHEY-1", "ISO-1"}))
+
+	mock.ExpectExec(regexp.QuoteMeta(qResetLowStockAlerts)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.ResetLowStockAlerts(ctx))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -232,7 +232,7 @@ func (h *Handler) GetAllSubscription(ctx context.Context, req *pb.GetAllSubscrip
 		pbSubscriptions = append(pbSubscriptions, &pb.Subscription{
 			GoldNamapaket:       sub.GoldNamaPaket,
 			GoldNamalayanan:     sub.GoldNamaLayanan,
-			GoldHarga:           sub.GoldHarga,
+			GoldHarga:           sub.GoldHarga.Float64(),
 			GoldJadwal:          sub.GoldJadwal,
 			GoldListlatihan:     sub.GoldListLatihan,
 			GoldJumlahpertemuan: int32(sub.GoldJumlahpertemuan),
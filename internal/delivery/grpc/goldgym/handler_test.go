@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"gold-gym-be/internal/entity"
 	authV2 "gold-gym-be/internal/entity/auth/v2"
 	goldEntity "gold-gym-be/internal/entity/goldgym"
 	jaegerLog "gold-gym-be/pkg/log"
@@ -618,7 +619,7 @@ func TestGetAllSubscription_Success(t *testing.T) {
 				{
 					GoldNamaPaket:       "Premium",
 					GoldNamaLayanan:     "Personal Training",
-					GoldHarga:           500000,
+					GoldHarga:           entity.NewMoney(500000),
 					GoldJadwal:          "Mon-Fri 08:00-10:00",
 					GoldListLatihan:     "Cardio, Strength",
 					GoldJumlahpertemuan: 12,
@@ -627,7 +628,7 @@ func TestGetAllSubscription_Success(t *testing.T) {
 				{
 					GoldNamaPaket:       "Basic",
 					GoldNamaLayanan:     "Group Class",
-					GoldHarga:           200000,
+					GoldHarga:           entity.NewMoney(200000),
 					GoldJadwal:          "Mon-Wed 18:00-19:00",
 					GoldListLatihan:     "Yoga, Pilates",
 					GoldJumlahpertemuan: 8,
@@ -3,6 +3,8 @@ package goldgym
 import (
 	"context"
 	"gold-gym-be/internal/entity/auth/v2"
+	goldEntity "gold-gym-be/internal/entity/goldgym"
+	"gold-gym-be/pkg/jwtkeys"
 	jaegerLog "gold-gym-be/pkg/log"
 
 	"github.com/opentracing/opentracing-go"
@@ -10,6 +12,14 @@ import (
 
 type IgoldgymSvc interface {
 	LoginUser(ctx context.Context, _user, _password string, _host string) (auth.Token, map[string]interface{}, error)
+
+	BeginOIDCLogin(ctx context.Context, provider string) (goldEntity.OIDCAuthorize, error)
+	BeginOIDCLink(ctx context.Context, provider string, email string) (goldEntity.OIDCAuthorize, error)
+	CompleteOIDCLogin(ctx context.Context, provider, stateID, code, _host string) (auth.Token, map[string]interface{}, error)
+	GetLinkedIdentities(ctx context.Context, email string) ([]goldEntity.MemberIdentity, error)
+	UnlinkIdentity(ctx context.Context, email, provider string) (string, error)
+
+	GetJWKS(ctx context.Context) jwtkeys.JSONWebKeySet
 }
 
 type Handler struct {
@@ -0,0 +1,15 @@
+package goldgym
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS serves the public signing keys so other services can verify our access tokens.
+// The document is returned bare (not wrapped in response.Response) as verifiers expect.
+func (h *Handler) JWKS(c *gin.Context) {
+	// short cache so a newly added key is picked up well before it becomes active
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.goldgymSvc.GetJWKS(c.Request.Context()))
+}
@@ -0,0 +1,130 @@
+package goldgym
+
+import (
+	"gold-gym-be/internal/delivery/http/middleware"
+	"gold-gym-be/internal/entity"
+	"gold-gym-be/pkg/errors"
+	"gold-gym-be/pkg/response"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCAuthorize starts a social login. With ?redirect=true the browser is sent
+// straight to the provider, otherwise the authorization URL is returned.
+func (h *Handler) OIDCAuthorize(c *gin.Context) {
+	resp := response.Response{}
+	ctx := c.Request.Context()
+
+	result, err := h.goldgymSvc.BeginOIDCLogin(ctx, c.Param("provider"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	if c.Query("redirect") == "true" {
+		c.Redirect(http.StatusFound, result.AuthorizationURL)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusOK, resp)
+}
+
+// OIDCCallback is the redirect_uri registered with the provider
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	resp := response.Response{}
+	ctx := c.Request.Context()
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		resp.SetError(errors.New("provider returned "+providerErr), http.StatusUnauthorized)
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		resp.SetError(errors.New("state and code are required"), http.StatusBadRequest)
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+
+	result, metadata, err := h.goldgymSvc.CompleteOIDCLogin(ctx, c.Param("provider"), state, code, c.ClientIP())
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	resp.Data = result
+	resp.Metadata = metadata
+	// the query string carries the authorization code, keep it out of the logs
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL.Path)
+	c.JSON(http.StatusOK, resp)
+}
+
+// OIDCLink starts linking a provider identity to the signed-in member
+func (h *Handler) OIDCLink(c *gin.Context) {
+	resp := response.Response{}
+	ctx := c.Request.Context()
+
+	result, err := h.goldgymSvc.BeginOIDCLink(ctx, c.Param("provider"), c.GetString(middleware.ContextUserKey))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusOK, resp)
+}
+
+// OIDCUnlink removes a provider identity from the signed-in member
+func (h *Handler) OIDCUnlink(c *gin.Context) {
+	resp := response.Response{}
+	ctx := c.Request.Context()
+
+	result, err := h.goldgymSvc.UnlinkIdentity(ctx, c.GetString(middleware.ContextUserKey), c.Param("provider"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusOK, resp)
+}
+
+// OIDCIdentities lists the provider identities linked to the signed-in member
+func (h *Handler) OIDCIdentities(c *gin.Context) {
+	resp := response.Response{}
+	ctx := c.Request.Context()
+
+	result, err := h.goldgymSvc.GetLinkedIdentities(ctx, c.GetString(middleware.ContextUserKey))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) renderError(c *gin.Context, err error) {
+	resp := response.Response{}
+	log.Printf("[ERROR] %s %s - %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+
+	switch errors.Cause(err) {
+	case entity.ErrNotFound:
+		resp.SetError(err, http.StatusNotFound)
+	case entity.ErrInvalid:
+		resp.SetError(err, http.StatusBadRequest)
+	case entity.ErrUnauthorized:
+		resp.SetError(entity.ErrUnauthorized, http.StatusUnauthorized)
+	default:
+		resp.SetError(entity.ErrInternal, http.StatusInternalServerError)
+	}
+	c.JSON(resp.StatusCode, resp)
+}
@@ -0,0 +1,105 @@
+package branch
+
+import (
+	"context"
+	httpHelper "gold-gym-be/internal/delivery/http"
+	"gold-gym-be/internal/delivery/http/middleware"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	jaegerLog "gold-gym-be/pkg/log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+)
+
+type IbranchSvc interface {
+	CreateBranch(ctx context.Context, branch branchEntity.Branch) (branchEntity.Branch, error)
+	ListBranches(ctx context.Context) ([]branchEntity.Branch, error)
+	CheckIn(ctx context.Context, email string, branchID int64) (branchEntity.Checkin, error)
+	ListCheckins(ctx context.Context, filter branchEntity.CheckinFilter) ([]branchEntity.Checkin, error)
+	SetHomeBranch(ctx context.Context, home branchEntity.HomeBranch) (string, error)
+}
+
+type Handler struct {
+	branchSvc IbranchSvc
+	tracer    opentracing.Tracer
+	logger    jaegerLog.Factory
+}
+
+// New for bridging product handler initialization
+func New(bs IbranchSvc, tracer opentracing.Tracer, logger jaegerLog.Factory) *Handler {
+	return &Handler{
+		branchSvc: bs,
+		tracer:    tracer,
+		logger:    logger,
+	}
+}
+
+func (h *Handler) CreateBranch(c *gin.Context) {
+	var body branchEntity.Branch
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+
+	result, err := h.branchSvc.CreateBranch(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusCreated, result, err, errStatus)
+}
+
+func (h *Handler) GetBranches(c *gin.Context) {
+	result, err := h.branchSvc.ListBranches(c.Request.Context())
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// CheckIn lets the member of the access token in at the branch of the body
+func (h *Handler) CheckIn(c *gin.Context) {
+	var body branchEntity.CheckinRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+
+	result, err := h.branchSvc.CheckIn(c.Request.Context(), c.GetString(middleware.ContextUserKey), body.BranchID)
+	httpHelper.Render(c, http.StatusCreated, result, err, errStatus)
+}
+
+// GetCheckins lists the check-ins of ?date=YYYY-MM-DD, defaulting to today,
+// of one branch by ?branch_id=
+func (h *Handler) GetCheckins(c *gin.Context) {
+	var branchID int64
+	if raw := c.Query("branch_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			httpHelper.BadRequest(c)
+			return
+		}
+		branchID = id
+	}
+
+	result, err := h.branchSvc.ListCheckins(c.Request.Context(), branchEntity.CheckinFilter{
+		BranchID: middleware.ScopedBranch(c, branchID),
+		Date:     c.Query("date"),
+	})
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// SetHomeBranch moves a member to another home branch
+func (h *Handler) SetHomeBranch(c *gin.Context) {
+	var body branchEntity.HomeBranch
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+
+	result, err := h.branchSvc.SetHomeBranch(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// errStatus is the status of the errors the handlers know besides not
+// found and invalid
+var errStatus = map[error]int{
+	branchEntity.ErrNoActiveSubscription: http.StatusForbidden,
+	branchEntity.ErrNoBranchAccess:       http.StatusForbidden,
+	branchEntity.ErrDuplicateBranch:      http.StatusConflict,
+}
@@ -0,0 +1,159 @@
+package catalogue
+
+import (
+	"context"
+	httpHelper "gold-gym-be/internal/delivery/http"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	jaegerLog "gold-gym-be/pkg/log"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+)
+
+type IcatalogueSvc interface {
+	CreateCategory(ctx context.Context, category goldStockEntity.Category) (goldStockEntity.Category, error)
+	ListCategories(ctx context.Context) ([]goldStockEntity.Category, error)
+	CreateProduct(ctx context.Context, product goldStockEntity.Product) (goldStockEntity.Product, error)
+	ListProducts(ctx context.Context, filter goldStockEntity.CatalogueFilter) ([]goldStockEntity.Product, error)
+	GetProduct(ctx context.Context, code string) (goldStockEntity.Product, error)
+	SetVariant(ctx context.Context, variant goldStockEntity.Variant) (goldStockEntity.Product, error)
+	RemoveVariant(ctx context.Context, code, stockcode string) (string, error)
+	UploadProductImage(ctx context.Context, code, contentType string, size int64, body io.Reader) (goldStockEntity.Product, error)
+	AddBarcode(ctx context.Context, barcode goldStockEntity.Barcode) (goldStockEntity.Barcode, error)
+	RemoveBarcode(ctx context.Context, barcode string) (string, error)
+	ScanBarcode(ctx context.Context, barcode string) (goldStockEntity.ScanResult, error)
+}
+
+type Handler struct {
+	catalogueSvc IcatalogueSvc
+	tracer       opentracing.Tracer
+	logger       jaegerLog.Factory
+}
+
+// New for bridging product handler initialization
+func New(cs IcatalogueSvc, tracer opentracing.Tracer, logger jaegerLog.Factory) *Handler {
+	return &Handler{
+		catalogueSvc: cs,
+		tracer:       tracer,
+		logger:       logger,
+	}
+}
+
+func (h *Handler) CreateCategory(c *gin.Context) {
+	var body goldStockEntity.Category
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+
+	result, err := h.catalogueSvc.CreateCategory(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusCreated, result, err, errStatus)
+}
+
+func (h *Handler) GetCategories(c *gin.Context) {
+	result, err := h.catalogueSvc.ListCategories(c.Request.Context())
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) CreateProduct(c *gin.Context) {
+	var body goldStockEntity.Product
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+
+	result, err := h.catalogueSvc.CreateProduct(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusCreated, result, err, errStatus)
+}
+
+// GetProducts searches the catalogue by ?category_id= and/or ?q= (name)
+func (h *Handler) GetProducts(c *gin.Context) {
+	var filter goldStockEntity.CatalogueFilter
+	if raw := c.Query("category_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			httpHelper.BadRequest(c)
+			return
+		}
+		filter.CategoryID = id
+	}
+	filter.Query = c.Query("q")
+
+	result, err := h.catalogueSvc.ListProducts(c.Request.Context(), filter)
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) GetProduct(c *gin.Context) {
+	result, err := h.catalogueSvc.GetProduct(c.Request.Context(), c.Param("code"))
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// SetVariant links a stock code to the product with its flavour and size
+func (h *Handler) SetVariant(c *gin.Context) {
+	var body goldStockEntity.Variant
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+	body.ProductCode = c.Param("code")
+
+	result, err := h.catalogueSvc.SetVariant(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) RemoveVariant(c *gin.Context) {
+	result, err := h.catalogueSvc.RemoveVariant(c.Request.Context(), c.Param("code"), c.Param("stockcode"))
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// UploadImage takes a multipart "image" file and makes it the product image
+func (h *Handler) UploadImage(c *gin.Context) {
+	header, err := c.FormFile("image")
+	if err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+	defer file.Close()
+
+	result, err := h.catalogueSvc.UploadProductImage(c.Request.Context(), c.Param("code"), header.Header.Get("Content-Type"), header.Size, file)
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) AddBarcode(c *gin.Context) {
+	var body goldStockEntity.Barcode
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+
+	result, err := h.catalogueSvc.AddBarcode(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusCreated, result, err, errStatus)
+}
+
+func (h *Handler) RemoveBarcode(c *gin.Context) {
+	result, err := h.catalogueSvc.RemoveBarcode(c.Request.Context(), c.Param("barcode"))
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// Scan resolves a scanned barcode for the POS
+func (h *Handler) Scan(c *gin.Context) {
+	result, err := h.catalogueSvc.ScanBarcode(c.Request.Context(), c.Param("barcode"))
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// errStatus is the status of the errors the handlers know besides not
+// found and invalid
+var errStatus = map[error]int{
+	goldStockEntity.ErrDuplicateCategory:    http.StatusConflict,
+	goldStockEntity.ErrDuplicateProduct:     http.StatusConflict,
+	goldStockEntity.ErrDuplicateBarcode:     http.StatusConflict,
+	goldStockEntity.ErrStorageNotConfigured: http.StatusServiceUnavailable,
+}
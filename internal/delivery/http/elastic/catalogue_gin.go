@@ -0,0 +1,97 @@
+package elastic
+
+import (
+	"net/http"
+	"strconv"
+
+	"gold-gym-be/internal/delivery/http/middleware"
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SuggestStock handles GET /gold-gym/v2/elastic/stock
+// Query params:
+//
+//	?query=<typed so far>[&size=<n>]
+//
+// Returns the stock variants matching by name, product name, code or
+// barcode, best match first, for the POS autocomplete.
+func (h *Handler) SuggestStock(c *gin.Context) {
+	search := elasticEntity.StockSearch{Query: c.Query("query")}
+	if v := c.Query("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size must be a number"})
+			return
+		}
+		search.Size = size
+	}
+
+	docs, err := h.elasticSvc.SuggestStock(c.Request.Context(), search)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     docs,
+		"metadata": nil,
+	})
+}
+
+// GetSalesReport handles GET /gold-gym/v2/elastic/sales-report
+// Query params:
+//
+//	?from=YYYY-MM-DD&to=YYYY-MM-DD
+//	  [&branch_id=<id>][&salesperson=<name>][&top=<n>]
+//
+// Returns the top sellers, the revenue per day and per salesperson of the
+// period, to included. Branch scoped users only see their own branch.
+func (h *Handler) GetSalesReport(c *gin.Context) {
+	filter := elasticEntity.SalesReportFilter{
+		From:        c.Query("from"),
+		To:          c.Query("to"),
+		Salesperson: c.Query("salesperson"),
+	}
+
+	var branchID int64
+	if v := c.Query("branch_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "branch_id must be a number"})
+			return
+		}
+		branchID = id
+	}
+	if v := c.Query("top"); v != "" {
+		top, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "top must be a number"})
+			return
+		}
+		filter.Top = top
+	}
+	filter.BranchID = middleware.ScopedBranch(c, branchID)
+
+	report, err := h.elasticSvc.SalesReport(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     report,
+		"metadata": nil,
+	})
+}
+
+// errorStatus is 400 for a rejected request and 500 otherwise
+func errorStatus(err error) int {
+	if errors.Cause(err) == entity.ErrInvalid {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -1,9 +1,15 @@
 package elastic
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"gold-gym-be/internal/delivery/http/middleware"
 	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,37 +17,41 @@ import (
 // GetElasticGin handles GET /gold-gym/v2/elastic
 // Query params:
 //
-//	?type=search&index=<index>&query=<query>
-//	?type=getbyid&index=<index>&id=<id>
+//	?type=search[&query=<query>]
+//	  [&status=<status>,...][&package=<package>,...][&branch_id=<id>]
+//	  [&expires_from=YYYY-MM-DD][&expires_to=YYYY-MM-DD]
+//	  [&sort=score|name|id|expiry, "-" prefix for descending]
+//	  [&from=<n>&size=<n> | &after=<next of the previous page>&size=<n>]
+//	?type=getbyid&id=<id>
+//
+// Both read the member alias. Search without query lists the members
+// matching the filters. A caller limited to their home branch only finds its
+// members, whatever branch_id says.
 func (h *Handler) GetElasticGin(c *gin.Context) {
 	ctx := c.Request.Context()
 	types := c.Query("type")
-	index := c.Query("index")
 
-	if index == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "index is required"})
+	if _, ok := c.GetQuery("index"); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errIndexParam})
 		return
 	}
 
 	switch types {
 	case "search":
-		query := c.Query("query")
-		if query == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "query is required for search"})
+		search, err := memberSearch(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		docs, err := h.elasticSvc.SearchUsers(ctx, index, query)
+		result, err := h.elasticSvc.SearchUsers(ctx, search)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"data": elasticEntity.SearchResult{
-				Total: len(docs),
-				Hits:  docs,
-			},
+			"data":     result,
 			"metadata": nil,
 		})
 
@@ -52,11 +62,15 @@ func (h *Handler) GetElasticGin(c *gin.Context) {
 			return
 		}
 
-		doc, err := h.elasticSvc.GetUserByID(ctx, index, id)
+		doc, err := h.elasticSvc.GetUserByID(ctx, id)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if middleware.ScopedBranch(c, doc.GoldBranchID) != doc.GoldBranchID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+			return
+		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"data":     doc,
@@ -67,3 +81,57 @@ func (h *Handler) GetElasticGin(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be 'search' or 'getbyid'"})
 	}
 }
+
+// memberSearch reads the search query params. Lists are comma separated or
+// repeated, expires_to includes the whole day and branch_id is scoped to the
+// caller.
+func memberSearch(c *gin.Context) (elasticEntity.MemberSearch, error) {
+	search := elasticEntity.MemberSearch{
+		Query:    c.Query("query"),
+		Status:   listParam(c, "status"),
+		Packages: listParam(c, "package"),
+		Sort:     c.Query("sort"),
+		After:    c.Query("after"),
+	}
+
+	var err error
+	for name, dst := range map[string]*int{"from": &search.From, "size": &search.Size} {
+		if v := c.Query(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				return search, fmt.Errorf("%s must be a number", name)
+			}
+		}
+	}
+	var branchID int64
+	if v := c.Query("branch_id"); v != "" {
+		if branchID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return search, errors.New("branch_id must be a number")
+		}
+	}
+	search.BranchID = middleware.ScopedBranch(c, branchID)
+	if v := c.Query("expires_from"); v != "" {
+		if search.ExpiresFrom, err = time.ParseInLocation(dateLayout, v, time.Local); err != nil {
+			return search, errors.New("expires_from must be YYYY-MM-DD")
+		}
+	}
+	if v := c.Query("expires_to"); v != "" {
+		to, err := time.ParseInLocation(dateLayout, v, time.Local)
+		if err != nil {
+			return search, errors.New("expires_to must be YYYY-MM-DD")
+		}
+		search.ExpiresTo = to.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return search, nil
+}
+
+func listParam(c *gin.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryArray(name) {
+		for _, v := range strings.Split(param, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
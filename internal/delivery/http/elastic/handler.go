@@ -9,11 +9,20 @@ import (
 	jaegerLog "gold-gym-be/pkg/log"
 )
 
+// dateLayout is the format of date query params
+const dateLayout = "2006-01-02"
+
+// errIndexParam is returned to callers still sending an index, the indices
+// are created and named by the service
+const errIndexParam = "index is managed by the service and cannot be chosen"
+
 // IelasticSvc defines the service layer methods used by this handler
 type IelasticSvc interface {
-	IndexUser(ctx context.Context, index string, doc elasticEntity.UserDocument) (string, error)
-	SearchUsers(ctx context.Context, index string, query string) ([]elasticEntity.UserDocument, error)
-	GetUserByID(ctx context.Context, index string, id string) (elasticEntity.UserDocument, error)
+	IndexUser(ctx context.Context, doc elasticEntity.UserDocument) (string, error)
+	SearchUsers(ctx context.Context, search elasticEntity.MemberSearch) (elasticEntity.SearchResult, error)
+	GetUserByID(ctx context.Context, id string) (elasticEntity.UserDocument, error)
+	SuggestStock(ctx context.Context, search elasticEntity.StockSearch) ([]elasticEntity.StockDocument, error)
+	SalesReport(ctx context.Context, filter elasticEntity.SalesReportFilter) (elasticEntity.SalesReport, error)
 }
 
 // Handler holds the elastic service dependency
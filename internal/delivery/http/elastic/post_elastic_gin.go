@@ -11,16 +11,15 @@ import (
 // PostElasticGin handles POST /gold-gym/v2/elastic
 // Query params:
 //
-//	?type=index&index=<index>
+//	?type=index
 //
-// Body: JSON UserDocument
+// Body: JSON UserDocument, indexed into the member alias
 func (h *Handler) PostElasticGin(c *gin.Context) {
 	ctx := c.Request.Context()
 	types := c.Query("type")
-	index := c.Query("index")
 
-	if index == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "index is required"})
+	if _, ok := c.GetQuery("index"); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errIndexParam})
 		return
 	}
 
@@ -32,7 +31,7 @@ func (h *Handler) PostElasticGin(c *gin.Context) {
 			return
 		}
 
-		docID, err := h.elasticSvc.IndexUser(ctx, index, doc)
+		docID, err := h.elasticSvc.IndexUser(ctx, doc)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
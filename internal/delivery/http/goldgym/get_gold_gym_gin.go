@@ -3,6 +3,7 @@ package goldgym
 import (
 	"bytes"
 	"errors"
+	"gold-gym-be/internal/delivery/http/middleware"
 	"gold-gym-be/internal/entity"
 	"gold-gym-be/pkg/response"
 	"image"
@@ -76,11 +77,25 @@ func (h *Handler) GetGoldGymGin(c *gin.Context) {
 		// stock -----------------------------------------------------------------------------------------------
 		log.Printf("testDelivery %+v", result)
 	case "getallstock":
+		branchID, _ := strconv.ParseInt(c.Query("branch_id"), 10, 64)
+		if branchID = middleware.ScopedBranch(c, branchID); branchID != 0 {
+			result, err = h.goldgymSvcStock.GetBranchStock(ctx, branchID)
+			break
+		}
 		result, err = h.goldgymSvcStock.GetAllStockHeader(ctx)
 		// stock -----------------------------------------------------------------------------------------------
 		// log.Printf("testDelivery %+v", result)
 	case "getallstockredis":
 		result, err = h.goldgymSvcStock.GetAllStockHeaderToRedis(ctx)
+	case "reorderlist":
+		result, err = h.goldgymSvcStock.GetReorderList(ctx)
+	case "nearexpiry":
+		days, _ := strconv.Atoi(c.Query("days"))
+		branchID, _ := strconv.ParseInt(c.Query("branch_id"), 10, 64)
+		result, err = h.goldgymSvcStock.GetNearExpiryReport(ctx, days, middleware.ScopedBranch(c, branchID))
+	case "stockcard":
+		branchID, _ := strconv.ParseInt(c.Query("branch_id"), 10, 64)
+		result, err = h.goldgymSvcStock.GetStockCard(ctx, c.Query("stockcode"), c.Query("from"), c.Query("to"), middleware.ScopedBranch(c, branchID))
 	case "getfromfirebase":
 		result, err = h.goldgymSvcStock.GetFromFirebase(ctx, c.Query("userid"))
 	case "getimages":
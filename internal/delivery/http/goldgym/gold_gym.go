@@ -44,8 +44,17 @@ type IgoldgymSvcStock interface {
 	// LoginUser(ctx context.Context, _user, _password string, _host string) (auth.Token, map[string]interface{}, error)
 	GetOneStockProduct(ctx context.Context, stockcode string, stocknmame string, stockid string) (goldStockEntity.GetOneStock, error)
 	InsertStockSales(ctx context.Context, stock goldStockEntity.InsertStockData) (string, error)
+	RecordMovement(ctx context.Context, movement goldStockEntity.StockMovement) (string, error)
+	GetStockCard(ctx context.Context, stockcode, from, to string, branchID int64) (goldStockEntity.StockCard, error)
+	RebuildStockQty(ctx context.Context, stockcode string) (int64, error)
+	SetReorderPoint(ctx context.Context, reorder goldStockEntity.ReorderPoint) (string, error)
+	GetReorderList(ctx context.Context) ([]goldStockEntity.ReorderSuggestion, error)
+	GetNearExpiryReport(ctx context.Context, days int, branchID int64) (goldStockEntity.NearExpiryReport, error)
+	WriteOffExpiredBatches(ctx context.Context) (int, error)
 	GetAllStockHeader(ctx context.Context) ([]goldStockEntity.GetOneStock, error)
 	GetAllStockHeaderToRedis(ctx context.Context) ([]goldStockEntity.GetOneStock, error)
+	GetBranchStock(ctx context.Context, branchID int64) ([]goldStockEntity.GetOneStock, error)
+	TransferStock(ctx context.Context, transfer goldStockEntity.StockTransfer) (string, error)
 	GetFromFirebase(ctx context.Context, userID string) (*firebaseEntity.User, error)
 	CreateUser(ctx context.Context, user firebaseEntity.User) (string, error)
 }
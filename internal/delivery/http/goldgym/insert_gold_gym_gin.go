@@ -2,6 +2,7 @@ package goldgym
 
 import (
 	"fmt"
+	"gold-gym-be/internal/delivery/http/middleware"
 	"gold-gym-be/internal/entity/firebase"
 	goldEntity "gold-gym-be/internal/entity/goldgym"
 	goldStockEntity "gold-gym-be/internal/entity/stock"
@@ -42,6 +43,8 @@ func (h *Handler) InsertGoldGymGin(c *gin.Context) {
 		insertUserFirebase       firebase.User
 		insertgoldsubsuserdetail goldEntity.SubscriptionDetail
 		insertstock              goldStockEntity.InsertStockData
+		stockmovement            goldStockEntity.StockMovement
+		stocktransfer            goldStockEntity.StockTransfer
 		// header                   http.Header
 		// testings                 goldEntity.Testings
 	)
@@ -120,7 +123,7 @@ func (h *Handler) InsertGoldGymGin(c *gin.Context) {
 	case "insertstock":
 		// body, _ := ioutil.ReadAll(c.Request.Body)
 		// json.Unmarshal(body, &insertstock)
-		if err := c.ShouldBindJSON(&insertgoldsubsuser); err != nil {
+		if err := c.ShouldBindJSON(&insertstock); err != nil {
 			c.JSON(400, gin.H{"error": err.Error()})
 			return
 		}
@@ -128,6 +131,32 @@ func (h *Handler) InsertGoldGymGin(c *gin.Context) {
 		if err != nil {
 			log.Println("err", err)
 		}
+	case "stockmovement":
+		if err := c.ShouldBindJSON(&stockmovement); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		// the movement is booked to the authenticated caller, not the body
+		stockmovement.MovementBy = c.GetString(middleware.ContextUserKey)
+		result, err = h.goldgymSvcStock.RecordMovement(ctx, stockmovement)
+		if err != nil {
+			log.Println("err", err)
+		}
+	case "stocktransfer":
+		if err := c.ShouldBindJSON(&stocktransfer); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		// a caller limited to their home branch only sends stock out of it
+		if middleware.ScopedBranch(c, stocktransfer.FromBranchID) != stocktransfer.FromBranchID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "transfers only out of your own branch"})
+			return
+		}
+		stocktransfer.TransferBy = c.GetString(middleware.ContextUserKey)
+		result, err = h.goldgymSvcStock.TransferStock(ctx, stocktransfer)
+		if err != nil {
+			log.Println("err", err)
+		}
 		// case "":
 	case "uploadimages":
 
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	httpHelper "gold-gym-be/internal/delivery/http"
 	goldEntity "gold-gym-be/internal/entity/goldgym"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
 	"gold-gym-be/pkg/response"
 	"io/ioutil"
 	"log"
@@ -36,6 +37,7 @@ func (h *Handler) UpdateGoldGymGin(c *gin.Context) {
 		updatenama         goldEntity.UpdateNama
 		updatekartu        goldEntity.UpdateKartu
 		logout             goldEntity.Logout
+		reorderpoint       goldStockEntity.ReorderPoint
 	)
 	// defer resp.RenderJSON(w, r)
 	ctx := c.Request.Context()
@@ -116,6 +118,16 @@ func (h *Handler) UpdateGoldGymGin(c *gin.Context) {
 		}
 	case "updatepaymentsubscription":
 		result, err, resp = h.goldgymSvc.UpdatePayment(ctx, c.Request.FormValue("otp"), c.Request.FormValue("email"))
+	// stock -----------------------------------------------------------------------------------------------
+	case "rebuildstock":
+		// empty stockcode rebuilds every stock_qty from the ledger
+		result, err = h.goldgymSvcStock.RebuildStockQty(ctx, c.Request.FormValue("stockcode"))
+	case "reorderpoint":
+		body, _ := ioutil.ReadAll(c.Request.Body)
+		json.Unmarshal(body, &reorderpoint)
+		result, err = h.goldgymSvcStock.SetReorderPoint(ctx, reorderpoint)
+	case "writeoffexpired":
+		result, err = h.goldgymSvcStock.WriteOffExpiredBatches(ctx)
 		// 	// case "":
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"gold-gym-be/internal/delivery/http/middleware"
+	partnerEntity "gold-gym-be/internal/entity/partner"
 	"gold-gym-be/pkg/response"
 	"log"
 	"net/http"
@@ -88,6 +89,7 @@ func (s *Server) Handler() *gin.Engine {
 	// metrics + access log
 	r.Use(middleware.PrometheusMetrics())
 	r.Use(middleware.AccessLogger())
+	r.Use(middleware.RateLimit(s.RateLimiter))
 
 	// timeout
 	r.Use(middleware.Timeout(5 * time.Second))
@@ -116,6 +118,8 @@ func (s *Server) Handler() *gin.Engine {
 	// r.GET("/", defaultHandler)
 	r.GET("", defaultHandler)
 	r.GET("/healthz", s.Health.Check)
+	r.GET("/readyz", s.Health.Ready)
+	r.GET("/.well-known/jwks.json", s.Auth.JWKS)
 
 	// Tambahan Prefix di depan API endpoint
 	router := r.Group("/gold-gym")
@@ -123,21 +127,97 @@ func (s *Server) Handler() *gin.Engine {
 	// Routes
 	goldgym := router.Group("/v2/userdata")
 	{
-		// Define the routes for GoldGym
-		goldgym.GET("", s.Goldgym.GetGoldGymGin)                                      // GET
-		goldgym.POST("", s.Middleware.CheckUniqueRequest, s.Goldgym.InsertGoldGymGin) // POST
-		goldgym.PUT("", s.Goldgym.UpdateGoldGymGin)                                   // PUT
-		goldgym.DELETE("", s.Goldgym.DeleteGoldGymGin)                                // DELETE
+		// Define the routes for GoldGym, the stock ledger types of POST book
+		// to the caller and need a token
+		goldgym.GET("", s.Middleware.BranchScope, s.Goldgym.GetGoldGymGin)                                                                                // GET
+		goldgym.POST("", s.Middleware.RequireAuthForTypes("stockmovement", "stocktransfer"), s.Middleware.CheckUniqueRequest, s.Goldgym.InsertGoldGymGin) // POST
+		goldgym.PUT("", s.Goldgym.UpdateGoldGymGin)                                                                                                       // PUT
+		goldgym.DELETE("", s.Goldgym.DeleteGoldGymGin)                                                                                                    // DELETE
 
 		// Auth routes
 		goldgym.POST("/login", s.Auth.LoginUser) // POST
+
+		// Social login (OIDC) routes
+		goldgym.GET("/oidc/:provider/authorize", s.Auth.OIDCAuthorize)                   // GET
+		goldgym.GET("/oidc/:provider/callback", s.Auth.OIDCCallback)                     // GET
+		goldgym.GET("/oidc/identities", s.Middleware.RequireAuth, s.Auth.OIDCIdentities) // GET
+		goldgym.POST("/oidc/:provider/link", s.Middleware.RequireAuth, s.Auth.OIDCLink)  // POST
+		goldgym.DELETE("/oidc/:provider", s.Middleware.RequireAuth, s.Auth.OIDCUnlink)   // DELETE
+	}
+
+	// Partner (B2B) routes, SNAP-style signatures
+	partner := router.Group("/partner/v1.0")
+	{
+		partner.POST("/access-token/b2b", s.Partner.AccessToken)                                                        // POST
+		partner.POST("/members", s.Partner.Authenticate(partnerEntity.ServiceCodeEnrollMember), s.Partner.EnrollMember) // POST
+	}
+
+	// Front desk (POS) sales routes, the salesperson is the caller
+	sales := router.Group("/v2/sales", s.Middleware.RequireAuth)
+	{
+		sales.POST("", s.Middleware.CheckUniqueRequest, s.Sales.CreateSale) // POST
+		sales.GET("", s.Sales.GetSales)                                     // GET: ?date=, ?salesperson= and/or ?branch_id=
+		sales.GET("/:id", s.Sales.GetReceipt)                               // GET
+	}
+
+	// Supplier and purchase order routes, POs are created and received by
+	// the caller
+	purchasing := router.Group("/v2/purchasing", s.Middleware.RequireAuth)
+	{
+		purchasing.POST("/suppliers", s.Purchasing.CreateSupplier)                                                 // POST
+		purchasing.GET("/suppliers", s.Purchasing.GetSuppliers)                                                    // GET
+		purchasing.POST("/orders", s.Middleware.CheckUniqueRequest, s.Purchasing.CreatePurchaseOrder)              // POST
+		purchasing.GET("/orders", s.Purchasing.GetPurchaseOrders)                                                  // GET: ?supplier_id=, ?branch_id= and/or ?status=
+		purchasing.GET("/orders/:id", s.Purchasing.GetPurchaseOrder)                                               // GET
+		purchasing.POST("/orders/:id/receive", s.Middleware.CheckUniqueRequest, s.Purchasing.ReceivePurchaseOrder) // POST
+		purchasing.POST("/orders/:id/cancel", s.Purchasing.CancelPurchaseOrder)                                    // POST
+		purchasing.GET("/outstanding", s.Purchasing.GetOutstanding)                                                // GET: ?supplier_id= and/or ?branch_id=
+	}
+
+	// Branch and member check-in routes, branches and home branches are
+	// managed by callers with access to every branch
+	branch := router.Group("/v2/branches")
+	{
+		branch.POST("", s.Middleware.RequireAuth, s.Middleware.RequireAllBranches, s.Branch.CreateBranch)         // POST
+		branch.GET("", s.Branch.GetBranches)                                                                      // GET
+		branch.PUT("/members", s.Middleware.RequireAuth, s.Middleware.RequireAllBranches, s.Branch.SetHomeBranch) // PUT: home branch of a member
+		branch.POST("/checkins", s.Middleware.RequireAuth, s.Branch.CheckIn)                                      // POST
+		branch.GET("/checkins", s.Middleware.BranchScope, s.Branch.GetCheckins)                                   // GET: ?date= and/or ?branch_id=
+	}
+
+	// Product catalogue routes, reads and barcode scans are public, changes
+	// need a token
+	catalogue := router.Group("/v2/catalogue")
+	{
+		catalogue.POST("/categories", s.Middleware.RequireAuth, s.Catalogue.CreateCategory)                          // POST
+		catalogue.GET("/categories", s.Catalogue.GetCategories)                                                      // GET
+		catalogue.POST("/products", s.Middleware.RequireAuth, s.Catalogue.CreateProduct)                             // POST
+		catalogue.GET("/products", s.Catalogue.GetProducts)                                                          // GET: ?category_id= and/or ?q=
+		catalogue.GET("/products/:code", s.Catalogue.GetProduct)                                                     // GET
+		catalogue.PUT("/products/:code/variants", s.Middleware.RequireAuth, s.Catalogue.SetVariant)                  // PUT
+		catalogue.DELETE("/products/:code/variants/:stockcode", s.Middleware.RequireAuth, s.Catalogue.RemoveVariant) // DELETE
+		catalogue.POST("/products/:code/image", s.Middleware.RequireAuth, s.Catalogue.UploadImage)                   // POST: multipart "image"
+		catalogue.POST("/barcodes", s.Middleware.RequireAuth, s.Catalogue.AddBarcode)                                // POST
+		catalogue.DELETE("/barcodes/:barcode", s.Middleware.RequireAuth, s.Catalogue.RemoveBarcode)                  // DELETE
+		catalogue.GET("/scan/:barcode", s.Catalogue.Scan)                                                            // GET
+	}
+
+	// Finance report routes, JSON or ?format=csv. Costs and margins are
+	// company wide, so they are for callers with access to every branch
+	report := router.Group("/v2/reports", s.Middleware.RequireAuth, s.Middleware.RequireAllBranches)
+	{
+		report.GET("/valuation", s.Report.GetValuation) // GET: ?method=
+		report.GET("/cogs", s.Report.GetCOGS)           // GET: ?from= ?to= ?method=
+		report.GET("/margin", s.Report.GetMargin)       // GET: ?from= ?to= ?method=
 	}
 
-	// Elastic routes
+	// Elastic routes, member search is scoped to the caller's branch
 	elastic := router.Group("/v2/elastic")
 	{
-		elastic.GET("", s.Elastic.GetElasticGin)   // GET: search or getbyid
-		elastic.POST("", s.Elastic.PostElasticGin) // POST: index document
+		elastic.GET("", s.Middleware.BranchScope, s.Elastic.GetElasticGin)               // GET: search or getbyid
+		elastic.POST("", s.Middleware.RequireAuth, s.Elastic.PostElasticGin)             // POST: index document
+		elastic.GET("/stock", s.Middleware.BranchScope, s.Elastic.SuggestStock)          // GET: ?query= ?size=, POS autocomplete
+		elastic.GET("/sales-report", s.Middleware.BranchScope, s.Elastic.GetSalesReport) // GET: ?from= ?to= ?branch_id= ?salesperson= ?top=
 	}
 
 	// Swagger route
@@ -154,6 +234,7 @@ func (s *Server) EchoHandler() *echo.Echo {
 
 	e.Use(echoMiddleware.Logger())
 	e.Use(echoMiddleware.Recover())
+	e.Use(middleware.RateLimitEcho(s.RateLimiter))
 
 	echoGym := e.Group("/echo-gym")
 	echoUserdata := echoGym.Group("/v2/userdata")
@@ -171,6 +252,7 @@ func (s *Server) MuxHandler() *mux.Router {
 	r := mux.NewRouter()
 	// Jika tidak ditemukan, jangan diubah.
 	r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	r.Use(middleware.RateLimitMux(s.RateLimiter))
 	// Health Check
 	r.HandleFunc("", defaultHandlerMux).Methods("GET")
 	r.HandleFunc("/", defaultHandlerMux).Methods("GET")
@@ -202,6 +284,7 @@ func (s *Server) BeegoHandler() *beegoWeb.HttpServer {
 
 	app.Cfg.WebConfig.AutoRender = false
 	app.Cfg.Log.AccessLogs = false
+	app.InsertFilter("*", beegoWeb.BeforeExec, middleware.RateLimitBeego(s.RateLimiter))
 
 	app.Get("/beego-gym/v2/userdata", s.BeegoGoldGym.GetGoldGymBeego)
 	app.Post("/beego-gym/v2/userdata", s.BeegoGoldGym.InsertGoldGymBeego)
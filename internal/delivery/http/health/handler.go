@@ -7,12 +7,19 @@ import (
 	"gorm.io/gorm"
 )
 
+// Probe is a dependency that must be ready before traffic is sent, such as
+// the Kafka consumers
+type Probe interface {
+	Ready() error
+}
+
 type Handler struct {
-	db *gorm.DB
+	db     *gorm.DB
+	probes []Probe
 }
 
-func New(db *gorm.DB) *Handler {
-	return &Handler{db: db}
+func New(db *gorm.DB, probes ...Probe) *Handler {
+	return &Handler{db: db, probes: probes}
 }
 
 func (h *Handler) Check(c *gin.Context) {
@@ -29,3 +36,24 @@ func (h *Handler) Check(c *gin.Context) {
 
 	c.JSON(200, gin.H{"status": "ok"})
 }
+
+// Ready is Check plus every probe
+func (h *Handler) Ready(c *gin.Context) {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "db error"})
+		return
+	}
+	if err := sqlDB.PingContext(c.Request.Context()); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "db down"})
+		return
+	}
+
+	for _, probe := range h.probes {
+		if err := probe.Ready(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
+			return
+		}
+	}
+	c.JSON(200, gin.H{"status": "ready"})
+}
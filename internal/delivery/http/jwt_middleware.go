@@ -2,14 +2,10 @@ package http
 
 import (
 	"context"
-	"fmt"
 	"gold-gym-be/internal/entity"
 	"gold-gym-be/pkg/response"
 	"net/http"
-	"os"
 	"strings"
-
-	"github.com/dgrijalva/jwt-go"
 )
 
 func (s *Server) JWTMiddleware(next http.Handler) http.Handler {
@@ -49,54 +45,15 @@ func (s *Server) JWTMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		jwtToken, err := jwt.Parse(token[1], func(_token *jwt.Token) (interface{}, error) {
-			if method, ok := _token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("signing method invalid - a")
-			} else if method != jwt.SigningMethodHS256 {
-				return nil, fmt.Errorf("signing method invalid - b")
-			}
-
-			return []byte(os.Getenv("TOKEN_SECRET")), nil
-		})
+		// tokens are verified against our published signing keys (see /.well-known/jwks.json)
+		claims, err := s.Tokens.ParseAccessToken(r.Context(), token[1])
 		if err != nil {
 			resp := &response.Response{}
 			defer resp.RenderJSON(w, r)
 
 			resp.Error = response.Error{
 				Status: false,
-				Msg:    err.Error(),
-				Code:   500,
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(500)
-
-			return
-		}
-
-		claims, ok := jwtToken.Claims.(jwt.MapClaims)
-		if !ok {
-			resp := &response.Response{}
-			defer resp.RenderJSON(w, r)
-
-			resp.Error = response.Error{
-				Status: false,
-				Msg:    "Invalid token: unsupported token type",
-				Code:   401,
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(401)
-
-			return
-		}
-		if !jwtToken.Valid {
-			resp := &response.Response{}
-			defer resp.RenderJSON(w, r)
-
-			resp.Error = response.Error{
-				Status: false,
-				Msg:    "Invalid token: unsupported token type",
+				Msg:    "Invalid token",
 				Code:   401,
 			}
 
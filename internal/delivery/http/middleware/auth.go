@@ -2,31 +2,66 @@ package middleware
 
 import (
 	"fmt"
+	"gold-gym-be/pkg/response"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func (h *Handler) CheckUniqueRequest(c *gin.Context) {
+// ContextUserKey is the gin context key holding the authenticated member email
+const ContextUserKey = "user"
 
-	// err := h.service.ValidateHeaders(c.Request.Header)
-	// if err != nil {
+// RequireAuth rejects requests without a valid access token issued by LoginUser
+// and exposes the member email to handlers under ContextUserKey, and the
+// branch claims as BranchScope does
+func (h *Handler) RequireAuth(c *gin.Context) {
+	resp := response.Response{}
 
-	// 	var appErr *apperror.AppError
-	// 	if errors.As(err, &appErr) {
-	// 		c.AbortWithStatusJSON(400, gin.H{
-	// 			"responseCode":    appErr.Code,
-	// 			"responseMessage": appErr.Message,
-	// 		})
-	// 		return
-	// 	}
+	accessToken, ok := bearerToken(c)
+	if !ok {
+		resp.SetError(fmt.Errorf("Invalid token: unsupported token type"), http.StatusUnauthorized)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
 
-	// 	c.AbortWithStatusJSON(500, gin.H{
-	// 		"responseCode":    "5000000",
-	// 		"responseMessage": "Internal error",
-	// 	})
-	// 	return
-	// }
-	fmt.Println("Middleware: CheckUniqueRequest")
+	claims, err := h.goldgymSvc.ParseAccessToken(c.Request.Context(), accessToken)
+	if err != nil {
+		resp.SetError(fmt.Errorf("Invalid token"), http.StatusUnauthorized)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
 
+	user, _ := claims["user"].(string)
+	if user == "" {
+		resp.SetError(fmt.Errorf("Invalid token: missing user"), http.StatusUnauthorized)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	c.Set(ContextUserKey, user)
+	setBranch(c, claims)
 	c.Next()
 }
+
+// RequireAuthForTypes runs RequireAuth for the listed values of the type
+// query only, on routes where public and member-only types share a path
+func (h *Handler) RequireAuthForTypes(types ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, t := range types {
+			if c.Query("type") == t {
+				h.RequireAuth(c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, accessToken, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || accessToken == "" {
+		return "", false
+	}
+	return accessToken, true
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fmt"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	"gold-gym-be/pkg/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys holding the home branch of the caller and whether the
+// caller is limited to it (branchEntity.AccessHome) or not
+const (
+	ContextBranchKey       = "branch_id"
+	ContextBranchAccessKey = "branch_access"
+)
+
+// BranchScope reads the branch claims of the access token for ScopedBranch.
+// Requests without a valid token are rejected, unscoped they would see every
+// branch.
+func (h *Handler) BranchScope(c *gin.Context) {
+	resp := response.Response{}
+	accessToken, ok := bearerToken(c)
+	if !ok {
+		resp.SetError(fmt.Errorf("Invalid token: unsupported token type"), http.StatusUnauthorized)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+	claims, err := h.goldgymSvc.ParseAccessToken(c.Request.Context(), accessToken)
+	if err != nil {
+		resp.SetError(fmt.Errorf("Invalid token"), http.StatusUnauthorized)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	setBranch(c, claims)
+	c.Next()
+}
+
+// RequireAllBranches rejects callers limited to their home branch, or whose
+// token carries no branch access, from routes that manage every branch. It
+// runs after RequireAuth.
+func (h *Handler) RequireAllBranches(c *gin.Context) {
+	if c.GetString(ContextBranchAccessKey) != branchEntity.AccessAll {
+		resp := response.Response{}
+		resp.SetError(fmt.Errorf("Forbidden: access to all branches required"), http.StatusForbidden)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+	c.Next()
+}
+
+// ScopedBranch is the branch a request works on. A caller limited to their
+// home branch always gets it, anyone else gets requested; for a list zero
+// means every branch.
+func ScopedBranch(c *gin.Context, requested int64) int64 {
+	if c.GetString(ContextBranchAccessKey) == branchEntity.AccessHome {
+		if home := c.GetInt64(ContextBranchKey); home != 0 {
+			return home
+		}
+	}
+	return requested
+}
+
+// setBranch copies the branch claims, JSON numbers come out of the token as
+// float64. Tokens issued before branches existed carry neither and stay
+// unscoped.
+func setBranch(c *gin.Context, claims map[string]interface{}) {
+	if id, ok := claims["branch_id"].(float64); ok && id > 0 {
+		c.Set(ContextBranchKey, int64(id))
+	}
+	if access, ok := claims["branch_access"].(string); ok {
+		c.Set(ContextBranchAccessKey, access)
+	}
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTokens memetakan access token ke claims, token lain tidak valid
+type fakeTokens map[string]map[string]interface{}
+
+func (f fakeTokens) ParseAccessToken(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	if claims, ok := f[accessToken]; ok {
+		return claims, nil
+	}
+	return nil, errors.New("invalid token")
+}
+
+func TestBranchScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := New(nil, fakeTokens{
+		"home":   {"user": "budi@test.com", "branch_id": float64(2), "branch_access": "home"},
+		"all":    {"user": "sari@test.com", "branch_id": float64(2), "branch_access": "all"},
+		"legacy": {"user": "lama@test.com"},
+	}, nil, nil, jaegerLog.Factory{})
+
+	r := gin.New()
+	r.GET("/sales", h.BranchScope, func(c *gin.Context) {
+		requested, _ := strconv.ParseInt(c.Query("branch_id"), 10, 64)
+		c.String(http.StatusOK, strconv.FormatInt(ScopedBranch(c, requested), 10))
+	})
+
+	tests := []struct {
+		name   string
+		auth   string
+		query  string
+		status int
+		branch string
+	}{
+		{"tanpa token ditolak", "", "", http.StatusUnauthorized, ""},
+		{"tanpa token memilih cabang ditolak", "", "branch_id=3", http.StatusUnauthorized, ""},
+		{"akses rumah selalu cabang rumah", "Bearer home", "branch_id=3", http.StatusOK, "2"},
+		{"akses rumah tanpa pilihan", "Bearer home", "", http.StatusOK, "2"},
+		{"akses semua memilih cabang", "Bearer all", "branch_id=3", http.StatusOK, "3"},
+		{"akses semua semua cabang", "Bearer all", "", http.StatusOK, "0"},
+		{"token lama tanpa klaim cabang", "Bearer legacy", "branch_id=3", http.StatusOK, "3"},
+		{"token tidak valid", "Bearer rusak", "", http.StatusUnauthorized, ""},
+		{"skema bukan bearer", "Basic home", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/sales?"+tt.query, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.branch, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAllBranches(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := New(nil, fakeTokens{
+		"home":   {"user": "budi@test.com", "branch_id": float64(2), "branch_access": "home"},
+		"all":    {"user": "sari@test.com", "branch_id": float64(2), "branch_access": "all"},
+		"legacy": {"user": "lama@test.com"},
+	}, nil, nil, jaegerLog.Factory{})
+
+	r := gin.New()
+	r.POST("/branches", h.RequireAuth, h.RequireAllBranches, func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name   string
+		auth   string
+		status int
+	}{
+		{"akses semua cabang boleh", "Bearer all", http.StatusCreated},
+		{"akses rumah ditolak", "Bearer home", http.StatusForbidden},
+		{"token lama tanpa klaim cabang ditolak", "Bearer legacy", http.StatusForbidden},
+		{"tanpa token", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/branches", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestRequireAuthForTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := New(nil, fakeTokens{
+		"home": {"user": "budi@test.com", "branch_id": float64(2), "branch_access": "home"},
+	}, nil, nil, jaegerLog.Factory{})
+
+	r := gin.New()
+	r.POST("/userdata", h.RequireAuthForTypes("stockmovement"), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextUserKey))
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		auth   string
+		status int
+		user   string
+	}{
+		{"tipe publik tanpa token", "type=insertuser", "", http.StatusOK, ""},
+		{"tipe stok tanpa token ditolak", "type=stockmovement", "", http.StatusUnauthorized, ""},
+		{"tipe stok dengan token", "type=stockmovement", "Bearer home", http.StatusOK, "budi@test.com"},
+		{"tipe stok token tidak valid", "type=stockmovement", "Bearer rusak", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/userdata?"+tt.query, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.user, w.Body.String())
+			}
+		})
+	}
+}
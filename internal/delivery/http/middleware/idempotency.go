@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	idemEntity "gold-gym-be/internal/entity/idempotency"
+	"gold-gym-be/own-pkg/crypto"
+	"gold-gym-be/pkg/response"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader is sent by clients that may retry a write
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response served from the idempotency store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// captureWriter tees the response body so it can be stored for replays
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// CheckUniqueRequest makes writes retry-safe with the Idempotency-Key header.
+// The first response for a key is stored and replayed for retries, a retry
+// while the first request is still running gets 409, and reusing a key with a
+// different payload gets 422. Requests without the header pass through.
+// Responses with a 5xx status are not stored so the client can retry them.
+func (h *Handler) CheckUniqueRequest(c *gin.Context) {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	resp := response.Response{}
+	if len(key) > maxIdempotencyKeyLength {
+		resp.SetError(errors.New("Idempotency-Key is too long"), http.StatusBadRequest)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		resp.SetError(errors.New("failed to read request body"), http.StatusBadRequest)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	// the query string is part of the payload because the operation lives in type=
+	fingerprint := crypto.SHA256Hash(c.Request.Method + "\n" + c.Request.URL.RawQuery + "\n" + string(body))
+	scopedKey := c.Request.Method + ":" + c.FullPath() + ":" + key
+
+	ctx := c.Request.Context()
+	reservation, err := h.middlewareSvc.BeginIdempotentRequest(ctx, scopedKey, fingerprint)
+	if err != nil {
+		// fail open: an unavailable store must not block sign-ups
+		log.Printf("[ERROR] %s %s - idempotency store unavailable: %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+		c.Next()
+		return
+	}
+
+	switch reservation.Outcome {
+	case idemEntity.OutcomeMismatch:
+		resp.SetError(errors.New("Idempotency-Key was already used with a different request"), http.StatusUnprocessableEntity)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	case idemEntity.OutcomeInProgress:
+		resp.SetError(errors.New("a request with this Idempotency-Key is still being processed"), http.StatusConflict)
+		c.AbortWithStatusJSON(resp.StatusCode, resp)
+		return
+	case idemEntity.OutcomeReplay:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(reservation.Record.ResponseStatus, reservation.Record.ContentType, reservation.Record.ResponseBody)
+		c.Abort()
+		return
+	}
+
+	writer := &captureWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+
+	// the request context may already be cancelled by the timeout middleware
+	storeCtx := context.WithoutCancel(ctx)
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		if err := h.middlewareSvc.ReleaseIdempotentRequest(storeCtx, scopedKey, reservation.Record); err != nil {
+			log.Printf("[ERROR] %s %s - release idempotency key: %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+		}
+	}()
+
+	c.Next()
+
+	if writer.Status() >= http.StatusInternalServerError {
+		return
+	}
+	err = h.middlewareSvc.CompleteIdempotentRequest(storeCtx, scopedKey, reservation.Record, idemEntity.Response{
+		Status:      writer.Status(),
+		ContentType: writer.Header().Get("Content-Type"),
+		Body:        writer.body.Bytes(),
+	})
+	if err != nil {
+		log.Printf("[ERROR] %s %s - store idempotent response: %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+		return
+	}
+	completed = true
+}
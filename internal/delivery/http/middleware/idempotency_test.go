@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	idempotencyData "gold-gym-be/internal/data/idempotency"
+	middlewareService "gold-gym-be/internal/service/middleware"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIdempotentRouter memasang CheckUniqueRequest di depan handler yang
+// menghitung berapa kali benar-benar dieksekusi.
+func newIdempotentRouter(t *testing.T, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	svc := middlewareService.New(idempotencyData.New(rdb, nil, jaegerLog.Factory{}), time.Hour, 10*time.Second, nil, jaegerLog.Factory{})
+	h := New(svc, nil, nil, nil, jaegerLog.Factory{})
+
+	r := gin.New()
+	r.POST("/gold-gym/v2/userdata", h.CheckUniqueRequest, handler)
+	return r
+}
+
+func post(r http.Handler, key, query, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/gold-gym/v2/userdata?"+query, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCheckUniqueRequestReplay(t *testing.T) {
+	var calls int32
+	r := newIdempotentRouter(t, func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.JSON(http.StatusOK, gin.H{"data": "Sukses", "call": n})
+	})
+
+	first := post(r, "key-1", "type=insertuser", `{"gold_email":"budi@test.com"}`)
+	require.Equal(t, http.StatusOK, first.Code)
+
+	retry := post(r, "key-1", "type=insertuser", `{"gold_email":"budi@test.com"}`)
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// no key means no protection
+	post(r, "", "type=insertuser", `{"gold_email":"budi@test.com"}`)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestCheckUniqueRequestPayloadMismatch(t *testing.T) {
+	r := newIdempotentRouter(t, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "Sukses"})
+	})
+
+	require.Equal(t, http.StatusOK, post(r, "key-1", "type=insertuser", `{"gold_email":"budi@test.com"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(r, "key-1", "type=insertuser", `{"gold_email":"sari@test.com"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, post(r, "key-1", "type=insertsubsuser", `{"gold_email":"budi@test.com"}`).Code)
+}
+
+func TestCheckUniqueRequestConcurrent(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	r := newIdempotentRouter(t, func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		c.JSON(http.StatusOK, gin.H{"data": "Sukses"})
+	})
+
+	const n = 5
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- post(r, "key-1", "type=insertuser", `{}`).Code
+		}()
+	}
+
+	// wait until the winner is inside the handler and the rest were turned away
+	require.Eventually(t, func() bool { return len(codes) == n-1 }, 5*time.Second, 10*time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	assert.Equal(t, 1, counts[http.StatusOK])
+	assert.Equal(t, n-1, counts[http.StatusConflict])
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestCheckUniqueRequestServerErrorIsRetryable(t *testing.T) {
+	var calls int32
+	r := newIdempotentRouter(t, func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": "Sukses"})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, post(r, "key-1", "type=insertuser", `{}`).Code)
+	assert.Equal(t, http.StatusOK, post(r, "key-1", "type=insertuser", `{}`).Code)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
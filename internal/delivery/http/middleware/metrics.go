@@ -22,6 +22,11 @@ var (
 		Help:    "Duration of HTTP requests in seconds.",
 		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
 	}, []string{"method", "path", "status", "environment"})
+
+	httpRateLimitRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_rate_limit_rejections_total",
+		Help: "Total number of HTTP requests rejected by the rate limiter.",
+	}, []string{"method", "path", "rule", "environment"})
 )
 
 var environment = detectEnvironment()
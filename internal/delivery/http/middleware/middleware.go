@@ -1,15 +1,21 @@
 package middleware
 
 import (
+	"context"
+	idemEntity "gold-gym-be/internal/entity/idempotency"
 	jaegerLog "gold-gym-be/pkg/log"
 
 	"github.com/opentracing/opentracing-go"
 )
 
 type ImiddlewareSvc interface {
+	BeginIdempotentRequest(ctx context.Context, key, fingerprint string) (idemEntity.Reservation, error)
+	CompleteIdempotentRequest(ctx context.Context, key string, processing idemEntity.Record, resp idemEntity.Response) error
+	ReleaseIdempotentRequest(ctx context.Context, key string, processing idemEntity.Record) error
 }
 
 type IgoldgymSvc interface {
+	ParseAccessToken(ctx context.Context, accessToken string) (map[string]interface{}, error)
 }
 
 type IgoldgymSvcStock interface {
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+
+	"gold-gym-be/pkg/ratelimit"
+	"gold-gym-be/pkg/response"
+
+	beegoCtx "github.com/beego/beego/v2/server/web/context"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/mux"
+	"github.com/labstack/echo/v4"
+)
+
+var errRateLimited = errors.New("too many requests, please retry later")
+
+// check runs the enforcer and records rejections. A nil enforcer disables
+// rate limiting.
+func check(r *http.Request, enforcer *ratelimit.Enforcer, path, clientIP string) ratelimit.Decision {
+	if enforcer == nil {
+		return ratelimit.Decision{Result: ratelimit.Result{Allowed: true}}
+	}
+
+	d := enforcer.Check(r.Context(), ratelimit.Request{
+		Method:        r.Method,
+		Path:          path,
+		Type:          r.URL.Query().Get("type"),
+		IP:            clientIP,
+		Authorization: r.Header.Get("Authorization"),
+	})
+	if d.Error != nil {
+		// fail open: an unavailable counter store must not take the API down
+		log.Printf("[ERROR] %s %s - rate limiter unavailable: %s\n", r.Method, r.URL.Path, d.Error.Error())
+	}
+	if !d.Allowed {
+		httpRateLimitRejectionsTotal.WithLabelValues(r.Method, path, d.Rule.Name, environment).Inc()
+	}
+	return d
+}
+
+func rejection() response.Response {
+	resp := response.Response{}
+	resp.SetError(errRateLimited, http.StatusTooManyRequests)
+	return resp
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// RateLimit limits Gin requests per route and type= operation
+func RateLimit(enforcer *ratelimit.Enforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+
+		d := check(c.Request, enforcer, path, c.ClientIP())
+		for k, v := range d.Headers() {
+			c.Header(k, v)
+		}
+		if !d.Allowed {
+			resp := rejection()
+			c.AbortWithStatusJSON(resp.StatusCode, resp)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitEcho is RateLimit for the Echo server
+func RateLimitEcho(enforcer *ratelimit.Enforcer) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			d := check(c.Request(), enforcer, c.Path(), c.RealIP())
+			for k, v := range d.Headers() {
+				c.Response().Header().Set(k, v)
+			}
+			if !d.Allowed {
+				resp := rejection()
+				return c.JSON(resp.StatusCode, resp)
+			}
+			return next(c)
+		}
+	}
+}
+
+// RateLimitMux is RateLimit for the gorilla/mux server
+func RateLimitMux(enforcer *ratelimit.Enforcer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					path = tpl
+				}
+			}
+
+			d := check(r, enforcer, path, remoteIP(r))
+			for k, v := range d.Headers() {
+				w.Header().Set(k, v)
+			}
+			if !d.Allowed {
+				resp := rejection()
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(resp.StatusCode)
+				json.NewEncoder(w).Encode(resp)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitBeego is RateLimit for the Beego server. Register it at
+// BeforeExec so the matched router pattern is available.
+func RateLimitBeego(enforcer *ratelimit.Enforcer) func(ctx *beegoCtx.Context) {
+	return func(ctx *beegoCtx.Context) {
+		path, _ := ctx.Input.GetData("RouterPattern").(string)
+		if path == "" {
+			path = ctx.Input.URL()
+		}
+
+		d := check(ctx.Request, enforcer, path, ctx.Input.IP())
+		for k, v := range d.Headers() {
+			ctx.Output.Header(k, v)
+		}
+		if !d.Allowed {
+			resp := rejection()
+			ctx.Output.SetStatus(resp.StatusCode)
+			ctx.Output.JSON(resp, false, false)
+		}
+	}
+}
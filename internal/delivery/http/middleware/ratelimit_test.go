@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gold-gym-be/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitGin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	enforcer := ratelimit.NewEnforcer(ratelimit.NewMemory(),
+		ratelimit.Rule{Limit: 100},
+		ratelimit.Rule{Name: "otp", Method: http.MethodPut, Type: "updateotp", Limit: 2, Window: 10 * time.Minute, Algorithm: ratelimit.AlgorithmTokenBucket},
+	)
+
+	r := gin.New()
+	r.Use(RateLimit(enforcer))
+	r.PUT("/gold-gym/v2/userdata", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": "Berhasil"})
+	})
+
+	put := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/gold-gym/v2/userdata?"+query, nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	rejected := testutil.ToFloat64(httpRateLimitRejectionsTotal.WithLabelValues(http.MethodPut, "/gold-gym/v2/userdata", "otp", environment))
+
+	first := put("type=updateotp")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, put("type=updateotp").Code)
+
+	w := put("type=updateotp")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "300", w.Header().Get("Retry-After"))
+	assert.Equal(t, rejected+1, testutil.ToFloat64(httpRateLimitRejectionsTotal.WithLabelValues(http.MethodPut, "/gold-gym/v2/userdata", "otp", environment)))
+
+	// other operations on the same route use the default rule
+	w = put("type=updatenama")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(RateLimit(nil))
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
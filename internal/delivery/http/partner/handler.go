@@ -0,0 +1,174 @@
+package partner
+
+import (
+	"bytes"
+	"context"
+	"gold-gym-be/internal/entity"
+	partnerEntity "gold-gym-be/internal/entity/partner"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+)
+
+// ContextPartnerKey is the gin context key holding the authenticated partner
+const ContextPartnerKey = "partner"
+
+type IpartnerSvc interface {
+	IssueAccessToken(ctx context.Context, clientKey, timestamp, signature string) (partnerEntity.AccessToken, error)
+	VerifyRequest(ctx context.Context, req partnerEntity.SignedRequest) (partnerEntity.Partner, error)
+	EnrollMember(ctx context.Context, partner partnerEntity.Partner, req partnerEntity.EnrollMember) (partnerEntity.Enrollment, error)
+}
+
+type Handler struct {
+	partnerSvc IpartnerSvc
+	tracer     opentracing.Tracer
+	logger     jaegerLog.Factory
+}
+
+// New for bridging product handler initialization
+func New(ps IpartnerSvc, tracer opentracing.Tracer, logger jaegerLog.Factory) *Handler {
+	return &Handler{
+		partnerSvc: ps,
+		tracer:     tracer,
+		logger:     logger,
+	}
+}
+
+// AccessToken issues a B2B access token. The partner signs
+// "X-CLIENT-KEY|X-TIMESTAMP" with its private key and sends it as X-SIGNATURE.
+func (h *Handler) AccessToken(c *gin.Context) {
+	code := partnerEntity.ServiceCodeAccessToken
+
+	var body partnerEntity.AccessTokenRequest
+	if err := c.ShouldBindJSON(&body); err != nil || body.GrantType == "" {
+		renderResult(c, http.StatusBadRequest, code, "02", "Invalid Mandatory Field grantType")
+		return
+	}
+	if body.GrantType != "client_credentials" {
+		renderResult(c, http.StatusBadRequest, code, "01", "Invalid Field Format grantType")
+		return
+	}
+	for _, header := range []string{"X-TIMESTAMP", "X-CLIENT-KEY", "X-SIGNATURE"} {
+		if c.GetHeader(header) == "" {
+			renderResult(c, http.StatusBadRequest, code, "02", "Invalid Mandatory Field "+header)
+			return
+		}
+	}
+
+	result, err := h.partnerSvc.IssueAccessToken(c.Request.Context(), c.GetHeader("X-CLIENT-KEY"), c.GetHeader("X-TIMESTAMP"), c.GetHeader("X-SIGNATURE"))
+	if err != nil {
+		renderError(c, code, err)
+		return
+	}
+
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL.Path)
+	c.JSON(http.StatusOK, result)
+}
+
+// Authenticate verifies the access token and the per-request HMAC signature
+// and exposes the partner to handlers under ContextPartnerKey
+func (h *Handler) Authenticate(serviceCode string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, accessToken, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || accessToken == "" {
+			renderResult(c, http.StatusUnauthorized, serviceCode, "01", "Invalid Token (B2B)")
+			c.Abort()
+			return
+		}
+		for _, header := range []string{"X-TIMESTAMP", "X-SIGNATURE", "X-PARTNER-ID", "X-EXTERNAL-ID"} {
+			if c.GetHeader(header) == "" {
+				renderResult(c, http.StatusBadRequest, serviceCode, "02", "Invalid Mandatory Field "+header)
+				c.Abort()
+				return
+			}
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			renderResult(c, http.StatusBadRequest, serviceCode, "00", "Bad Request")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		partner, err := h.partnerSvc.VerifyRequest(c.Request.Context(), partnerEntity.SignedRequest{
+			PartnerID:   c.GetHeader("X-PARTNER-ID"),
+			ExternalID:  c.GetHeader("X-EXTERNAL-ID"),
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.RequestURI(),
+			AccessToken: accessToken,
+			Timestamp:   c.GetHeader("X-TIMESTAMP"),
+			Signature:   c.GetHeader("X-SIGNATURE"),
+			Body:        string(body),
+		})
+		if err != nil {
+			renderError(c, serviceCode, err)
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextPartnerKey, partner)
+		c.Next()
+	}
+}
+
+// EnrollMember registers an employee of the authenticated partner as a member
+func (h *Handler) EnrollMember(c *gin.Context) {
+	code := partnerEntity.ServiceCodeEnrollMember
+
+	var body partnerEntity.EnrollMember
+	if err := c.ShouldBindJSON(&body); err != nil {
+		renderResult(c, http.StatusBadRequest, code, "01", "Invalid Field Format")
+		return
+	}
+	if body.Email == "" || body.Name == "" {
+		renderResult(c, http.StatusBadRequest, code, "02", "Invalid Mandatory Field email/name")
+		return
+	}
+
+	partner, _ := c.MustGet(ContextPartnerKey).(partnerEntity.Partner)
+	result, err := h.partnerSvc.EnrollMember(c.Request.Context(), partner, body)
+	if err != nil {
+		renderError(c, code, err)
+		return
+	}
+
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL.Path)
+	c.JSON(http.StatusOK, result)
+}
+
+// renderResult writes a SNAP response: HTTP status + service code + case code
+func renderResult(c *gin.Context, status int, serviceCode, caseCode, message string) {
+	c.JSON(status, gin.H{
+		"responseCode":    strconv.Itoa(status) + serviceCode + caseCode,
+		"responseMessage": message,
+	})
+}
+
+func renderError(c *gin.Context, serviceCode string, err error) {
+	log.Printf("[ERROR] %s %s - %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+
+	switch errors.Cause(err) {
+	case partnerEntity.ErrUnknownClient:
+		renderResult(c, http.StatusUnauthorized, serviceCode, "00", "Unauthorized. Unknown client")
+	case partnerEntity.ErrInvalidTimestamp:
+		renderResult(c, http.StatusBadRequest, serviceCode, "01", "Invalid Field Format X-TIMESTAMP")
+	case partnerEntity.ErrInvalidSignature:
+		renderResult(c, http.StatusUnauthorized, serviceCode, "00", "Unauthorized. Signature")
+	case partnerEntity.ErrInvalidToken:
+		renderResult(c, http.StatusUnauthorized, serviceCode, "01", "Invalid Token (B2B)")
+	case partnerEntity.ErrDuplicateExternalID:
+		renderResult(c, http.StatusConflict, serviceCode, "00", "Conflict")
+	case entity.ErrInvalid:
+		renderResult(c, http.StatusBadRequest, serviceCode, "01", "Invalid Field Format")
+	default:
+		renderResult(c, http.StatusInternalServerError, serviceCode, "00", "General Error")
+	}
+}
@@ -0,0 +1,149 @@
+package purchasing
+
+import (
+	"context"
+	httpHelper "gold-gym-be/internal/delivery/http"
+	"gold-gym-be/internal/delivery/http/middleware"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	jaegerLog "gold-gym-be/pkg/log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+)
+
+type IpurchasingSvc interface {
+	CreateSupplier(ctx context.Context, supplier goldStockEntity.Supplier) (goldStockEntity.Supplier, error)
+	ListSuppliers(ctx context.Context) ([]goldStockEntity.Supplier, error)
+	CreatePurchaseOrder(ctx context.Context, req goldStockEntity.CreatePurchaseOrder) (goldStockEntity.PurchaseOrder, error)
+	ListPurchaseOrders(ctx context.Context, filter goldStockEntity.POFilter) ([]goldStockEntity.PurchaseOrder, error)
+	GetPurchaseOrder(ctx context.Context, poID string) (goldStockEntity.PurchaseOrder, error)
+	ReceivePurchaseOrder(ctx context.Context, req goldStockEntity.ReceivePurchaseOrder) (goldStockEntity.PurchaseOrder, error)
+	CancelPurchaseOrder(ctx context.Context, poID string) (string, error)
+	GetOutstandingPurchaseOrders(ctx context.Context, supplierID, branchID int64) (goldStockEntity.OutstandingPOReport, error)
+}
+
+type Handler struct {
+	purchasingSvc IpurchasingSvc
+	tracer        opentracing.Tracer
+	logger        jaegerLog.Factory
+}
+
+// New for bridging product handler initialization
+func New(ps IpurchasingSvc, tracer opentracing.Tracer, logger jaegerLog.Factory) *Handler {
+	return &Handler{
+		purchasingSvc: ps,
+		tracer:        tracer,
+		logger:        logger,
+	}
+}
+
+func (h *Handler) CreateSupplier(c *gin.Context) {
+	var body goldStockEntity.Supplier
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+
+	result, err := h.purchasingSvc.CreateSupplier(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusCreated, result, err, errStatus)
+}
+
+func (h *Handler) GetSuppliers(c *gin.Context) {
+	result, err := h.purchasingSvc.ListSuppliers(c.Request.Context())
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) CreatePurchaseOrder(c *gin.Context) {
+	var body goldStockEntity.CreatePurchaseOrder
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+	body.CreatedBy = c.GetString(middleware.ContextUserKey)
+	body.BranchID = middleware.ScopedBranch(c, body.BranchID)
+
+	result, err := h.purchasingSvc.CreatePurchaseOrder(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusCreated, result, err, errStatus)
+}
+
+// GetPurchaseOrders lists POs by ?supplier_id=, ?branch_id= and/or ?status=
+func (h *Handler) GetPurchaseOrders(c *gin.Context) {
+	supplierID, ok := h.queryID(c, "supplier_id")
+	if !ok {
+		return
+	}
+	branchID, ok := h.queryID(c, "branch_id")
+	if !ok {
+		return
+	}
+
+	result, err := h.purchasingSvc.ListPurchaseOrders(c.Request.Context(), goldStockEntity.POFilter{
+		SupplierID: supplierID,
+		BranchID:   middleware.ScopedBranch(c, branchID),
+		Status:     c.Query("status"),
+	})
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) GetPurchaseOrder(c *gin.Context) {
+	result, err := h.purchasingSvc.GetPurchaseOrder(c.Request.Context(), c.Param("id"))
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// ReceivePurchaseOrder books a full or partial delivery of the PO into stock
+func (h *Handler) ReceivePurchaseOrder(c *gin.Context) {
+	var body goldStockEntity.ReceivePurchaseOrder
+	if err := c.ShouldBindJSON(&body); err != nil {
+		httpHelper.BadRequest(c)
+		return
+	}
+	body.POID = c.Param("id")
+	body.ReceivedBy = c.GetString(middleware.ContextUserKey)
+
+	result, err := h.purchasingSvc.ReceivePurchaseOrder(c.Request.Context(), body)
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) CancelPurchaseOrder(c *gin.Context) {
+	result, err := h.purchasingSvc.CancelPurchaseOrder(c.Request.Context(), c.Param("id"))
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+// GetOutstanding reports undelivered PO lines, optionally by ?supplier_id=
+// and/or ?branch_id=
+func (h *Handler) GetOutstanding(c *gin.Context) {
+	supplierID, ok := h.queryID(c, "supplier_id")
+	if !ok {
+		return
+	}
+	branchID, ok := h.queryID(c, "branch_id")
+	if !ok {
+		return
+	}
+
+	result, err := h.purchasingSvc.GetOutstandingPurchaseOrders(c.Request.Context(), supplierID, middleware.ScopedBranch(c, branchID))
+	httpHelper.Render(c, http.StatusOK, result, err, errStatus)
+}
+
+func (h *Handler) queryID(c *gin.Context, key string) (int64, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		httpHelper.BadRequest(c)
+		return 0, false
+	}
+	return id, true
+}
+
+// errStatus is the status of the errors the handlers know besides not
+// found and invalid
+var errStatus = map[error]int{
+	goldStockEntity.ErrDuplicateSupplier: http.StatusConflict,
+	goldStockEntity.ErrPOClosed:          http.StatusConflict,
+	goldStockEntity.ErrOverReceive:       http.StatusConflict,
+}
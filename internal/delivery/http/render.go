@@ -0,0 +1,47 @@
+package http
+
+import (
+	"gold-gym-be/internal/entity"
+	"gold-gym-be/pkg/errors"
+	"gold-gym-be/pkg/response"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BadRequest writes the response to a request that does not bind
+func BadRequest(c *gin.Context) {
+	resp := response.Response{}
+	resp.SetError(errors.New("invalid request"), http.StatusBadRequest)
+	c.JSON(resp.StatusCode, resp)
+}
+
+// Render writes result with status, or err when it is not nil.
+// entity.ErrNotFound is a 404 and entity.ErrInvalid a 400, statuses maps
+// the other errors a handler knows to their status; anything else is a 500
+// that does not leak err.
+func Render(c *gin.Context, status int, result interface{}, err error, statuses map[error]int) {
+	resp := response.Response{}
+	if err != nil {
+		log.Printf("[ERROR] %s %s - %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+
+		cause := errors.Cause(err)
+		switch code, ok := statuses[cause]; {
+		case ok:
+			resp.SetError(err, code)
+		case cause == entity.ErrNotFound:
+			resp.SetError(err, http.StatusNotFound)
+		case cause == entity.ErrInvalid:
+			resp.SetError(err, http.StatusBadRequest)
+		default:
+			resp.SetError(entity.ErrInternal, http.StatusInternalServerError)
+		}
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(status, resp)
+}
@@ -0,0 +1,170 @@
+package report
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"gold-gym-be/internal/entity"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"gold-gym-be/pkg/response"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+)
+
+type IvaluationSvc interface {
+	GetInventoryValuation(ctx context.Context, method string) (goldStockEntity.InventoryValuation, error)
+}
+
+type IcogsSvc interface {
+	GetCOGSReport(ctx context.Context, filter salesEntity.ReportFilter) (salesEntity.COGSReport, error)
+	GetMarginReport(ctx context.Context, filter salesEntity.ReportFilter) (salesEntity.MarginReport, error)
+}
+
+type Handler struct {
+	valuationSvc  IvaluationSvc
+	cogsSvc       IcogsSvc
+	defaultMethod string
+	tracer        opentracing.Tracer
+	logger        jaegerLog.Factory
+}
+
+// New for bridging product handler initialization. defaultMethod is the
+// valuation method used when a request does not pass ?method=.
+func New(vs IvaluationSvc, cs IcogsSvc, defaultMethod string, tracer opentracing.Tracer, logger jaegerLog.Factory) *Handler {
+	return &Handler{
+		valuationSvc:  vs,
+		cogsSvc:       cs,
+		defaultMethod: defaultMethod,
+		tracer:        tracer,
+		logger:        logger,
+	}
+}
+
+// GetValuation values the stock on hand, ?method=average|fifo, ?format=csv
+func (h *Handler) GetValuation(c *gin.Context) {
+	result, err := h.valuationSvc.GetInventoryValuation(c.Request.Context(), h.method(c))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		records := [][]string{{"stock_code", "stock_name", "stock_qty", "unit_cost", "value"}}
+		for _, line := range result.Lines {
+			records = append(records, []string{line.StockCode, line.StockName, strconv.Itoa(line.StockQty), line.UnitCost.String(), line.Value.String()})
+		}
+		records = append(records, []string{"TOTAL", "", strconv.Itoa(result.TotalQty), "", result.TotalValue.String()})
+		h.renderCSV(c, fmt.Sprintf("valuation_%s_%s.csv", result.Method, result.AsOf), records)
+		return
+	}
+	h.render(c, result)
+}
+
+// GetCOGS lists cost of goods sold per sale, ?from= ?to= ?method= ?format=csv
+func (h *Handler) GetCOGS(c *gin.Context) {
+	result, err := h.cogsSvc.GetCOGSReport(c.Request.Context(), h.filter(c))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		records := [][]string{{"sale_id", "sale_transdate", "sale_transtime", "sale_salesperson", "revenue", "cogs", "gross_margin", "margin_pct"}}
+		for _, sale := range result.Sales {
+			records = append(records, []string{sale.SaleID, sale.SaleTransdate, sale.SaleTransTime, sale.SaleSalesperson,
+				sale.Revenue.String(), sale.COGS.String(), sale.GrossMargin.String(), percent(sale.MarginPct)})
+		}
+		records = append(records, totalRecord(4, result.ReportTotals))
+		h.renderCSV(c, fmt.Sprintf("cogs_%s_%s_%s.csv", result.Method, result.From, result.To), records)
+		return
+	}
+	h.render(c, result)
+}
+
+// GetMargin reports gross margin per product, ?from= ?to= ?method= ?format=csv
+func (h *Handler) GetMargin(c *gin.Context) {
+	result, err := h.cogsSvc.GetMarginReport(c.Request.Context(), h.filter(c))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		records := [][]string{{"stock_code", "stock_name", "qty", "revenue", "cogs", "gross_margin", "margin_pct"}}
+		for _, product := range result.Products {
+			records = append(records, []string{product.StockCode, product.StockName, strconv.Itoa(product.Qty),
+				product.Revenue.String(), product.COGS.String(), product.GrossMargin.String(), percent(product.MarginPct)})
+		}
+		records = append(records, totalRecord(3, result.ReportTotals))
+		h.renderCSV(c, fmt.Sprintf("margin_%s_%s_%s.csv", result.Method, result.From, result.To), records)
+		return
+	}
+	h.render(c, result)
+}
+
+func (h *Handler) method(c *gin.Context) string {
+	if method := c.Query("method"); method != "" {
+		return method
+	}
+	return h.defaultMethod
+}
+
+func (h *Handler) filter(c *gin.Context) salesEntity.ReportFilter {
+	return salesEntity.ReportFilter{
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+		Method: h.method(c),
+	}
+}
+
+// totalRecord is the TOTAL row of a CSV, padded so the totals line up under
+// the revenue column
+func totalRecord(pad int, totals salesEntity.ReportTotals) []string {
+	record := make([]string, pad, pad+4)
+	record[0] = "TOTAL"
+	return append(record, totals.Revenue.String(), totals.COGS.String(), totals.GrossMargin.String(), percent(totals.MarginPct))
+}
+
+func percent(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func (h *Handler) render(c *gin.Context, result interface{}) {
+	resp := response.Response{}
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) renderCSV(c *gin.Context, filename string, records [][]string) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(records); err != nil {
+		log.Printf("[ERROR] %s %s - %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+		return
+	}
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+}
+
+func (h *Handler) renderError(c *gin.Context, err error) {
+	resp := response.Response{}
+	log.Printf("[ERROR] %s %s - %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+
+	switch errors.Cause(err) {
+	case entity.ErrInvalid:
+		resp.SetError(err, http.StatusBadRequest)
+	default:
+		resp.SetError(entity.ErrInternal, http.StatusInternalServerError)
+	}
+	c.JSON(resp.StatusCode, resp)
+}
@@ -0,0 +1,131 @@
+package sales
+
+import (
+	"context"
+	"gold-gym-be/internal/delivery/http/middleware"
+	"gold-gym-be/internal/entity"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+	"gold-gym-be/pkg/response"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opentracing/opentracing-go"
+)
+
+type IsalesSvc interface {
+	CreateSale(ctx context.Context, req salesEntity.CreateSale) (salesEntity.Receipt, error)
+	ListSales(ctx context.Context, filter salesEntity.SalesFilter) ([]salesEntity.Receipt, error)
+	GetReceipt(ctx context.Context, saleID string) (salesEntity.Receipt, error)
+}
+
+type Handler struct {
+	salesSvc IsalesSvc
+	tracer   opentracing.Tracer
+	logger   jaegerLog.Factory
+}
+
+// New for bridging product handler initialization
+func New(ss IsalesSvc, tracer opentracing.Tracer, logger jaegerLog.Factory) *Handler {
+	return &Handler{
+		salesSvc: ss,
+		tracer:   tracer,
+		logger:   logger,
+	}
+}
+
+// CreateSale checks out a front desk sale by the authenticated caller and
+// returns the receipt
+func (h *Handler) CreateSale(c *gin.Context) {
+	resp := response.Response{}
+
+	var body salesEntity.CreateSale
+	if err := c.ShouldBindJSON(&body); err != nil {
+		resp.SetError(errors.New("invalid request body"), http.StatusBadRequest)
+		c.JSON(resp.StatusCode, resp)
+		return
+	}
+	body.Salesperson = c.GetString(middleware.ContextUserKey)
+	body.BranchID = middleware.ScopedBranch(c, body.BranchID)
+
+	result, err := h.salesSvc.CreateSale(c.Request.Context(), body)
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetSales lists receipts by ?date=YYYY-MM-DD and/or ?salesperson=,
+// defaulting to today, of one branch by ?branch_id=
+func (h *Handler) GetSales(c *gin.Context) {
+	resp := response.Response{}
+
+	var branchID int64
+	if raw := c.Query("branch_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			resp.SetError(errors.New("invalid branch_id"), http.StatusBadRequest)
+			c.JSON(resp.StatusCode, resp)
+			return
+		}
+		branchID = id
+	}
+
+	result, err := h.salesSvc.ListSales(c.Request.Context(), salesEntity.SalesFilter{
+		BranchID:    middleware.ScopedBranch(c, branchID),
+		Date:        c.Query("date"),
+		Salesperson: c.Query("salesperson"),
+	})
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetReceipt returns a single sale of the caller's branch for reprinting
+func (h *Handler) GetReceipt(c *gin.Context) {
+	resp := response.Response{}
+
+	result, err := h.salesSvc.GetReceipt(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	// a sale of another branch is not there for a caller limited to their own
+	if middleware.ScopedBranch(c, result.SaleBranchID) != result.SaleBranchID {
+		h.renderError(c, entity.ErrNotFound)
+		return
+	}
+
+	resp.Data = result
+	log.Printf("[INFO] %s %s\n", c.Request.Method, c.Request.URL)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) renderError(c *gin.Context, err error) {
+	resp := response.Response{}
+	log.Printf("[ERROR] %s %s - %s\n", c.Request.Method, c.Request.URL.Path, err.Error())
+
+	switch errors.Cause(err) {
+	case entity.ErrNotFound:
+		resp.SetError(err, http.StatusNotFound)
+	case entity.ErrInvalid, salesEntity.ErrUnknownStock, salesEntity.ErrInsufficientPayment:
+		resp.SetError(err, http.StatusBadRequest)
+	case salesEntity.ErrInsufficientStock:
+		resp.SetError(err, http.StatusConflict)
+	default:
+		resp.SetError(entity.ErrInternal, http.StatusInternalServerError)
+	}
+	c.JSON(resp.StatusCode, resp)
+}
@@ -7,13 +7,14 @@ import (
 	"gold-gym-be/internal/config"
 	"gold-gym-be/pkg/grace"
 	jaegerLog "gold-gym-be/pkg/log"
+	"gold-gym-be/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/cors"
 
-	beegoCtx "github.com/beego/beego/v2/server/web/context"
 	beegoWeb "github.com/beego/beego/v2/server/web"
+	beegoCtx "github.com/beego/beego/v2/server/web/context"
 )
 
 // GoldGymHandler ...
@@ -47,14 +48,32 @@ type GoldGymHandler interface {
 type AuthHandler interface {
 	// LoginUser(w http.ResponseWriter, r *http.Request)
 	LoginUser(c *gin.Context)
+
+	OIDCAuthorize(c *gin.Context)
+	OIDCCallback(c *gin.Context)
+	OIDCLink(c *gin.Context)
+	OIDCUnlink(c *gin.Context)
+	OIDCIdentities(c *gin.Context)
+
+	JWKS(c *gin.Context)
+}
+
+// TokenVerifier checks access tokens issued by LoginUser
+type TokenVerifier interface {
+	ParseAccessToken(ctx context.Context, accessToken string) (map[string]interface{}, error)
 }
 
 type MiddlewareHandler interface {
 	CheckUniqueRequest(c *gin.Context)
+	RequireAuth(c *gin.Context)
+	RequireAuthForTypes(types ...string) gin.HandlerFunc
+	RequireAllBranches(c *gin.Context)
+	BranchScope(c *gin.Context)
 }
 
 type HealthHandler interface {
 	Check(c *gin.Context)
+	Ready(c *gin.Context)
 }
 
 type EchoGoldGymHandler interface {
@@ -78,9 +97,68 @@ type BeegoGoldGymHandler interface {
 	DeleteGoldGymBeego(ctx *beegoCtx.Context)
 }
 
+// PartnerHandler serves the SNAP-style B2B partner API
+type PartnerHandler interface {
+	AccessToken(c *gin.Context)
+	Authenticate(serviceCode string) gin.HandlerFunc
+	EnrollMember(c *gin.Context)
+}
+
+// SalesHandler serves front desk (POS) sales
+type SalesHandler interface {
+	CreateSale(c *gin.Context)
+	GetSales(c *gin.Context)
+	GetReceipt(c *gin.Context)
+}
+
+// PurchasingHandler serves suppliers and purchase orders
+type PurchasingHandler interface {
+	CreateSupplier(c *gin.Context)
+	GetSuppliers(c *gin.Context)
+	CreatePurchaseOrder(c *gin.Context)
+	GetPurchaseOrders(c *gin.Context)
+	GetPurchaseOrder(c *gin.Context)
+	ReceivePurchaseOrder(c *gin.Context)
+	CancelPurchaseOrder(c *gin.Context)
+	GetOutstanding(c *gin.Context)
+}
+
+// BranchHandler serves branches and member check-ins
+type BranchHandler interface {
+	CreateBranch(c *gin.Context)
+	GetBranches(c *gin.Context)
+	CheckIn(c *gin.Context)
+	GetCheckins(c *gin.Context)
+	SetHomeBranch(c *gin.Context)
+}
+
+// CatalogueHandler serves product categories, variants, barcodes and images
+type CatalogueHandler interface {
+	CreateCategory(c *gin.Context)
+	GetCategories(c *gin.Context)
+	CreateProduct(c *gin.Context)
+	GetProducts(c *gin.Context)
+	GetProduct(c *gin.Context)
+	SetVariant(c *gin.Context)
+	RemoveVariant(c *gin.Context)
+	UploadImage(c *gin.Context)
+	AddBarcode(c *gin.Context)
+	RemoveBarcode(c *gin.Context)
+	Scan(c *gin.Context)
+}
+
+// ReportHandler serves the finance reports
+type ReportHandler interface {
+	GetValuation(c *gin.Context)
+	GetCOGS(c *gin.Context)
+	GetMargin(c *gin.Context)
+}
+
 type ElasticHandler interface {
 	GetElasticGin(c *gin.Context)
 	PostElasticGin(c *gin.Context)
+	SuggestStock(c *gin.Context)
+	GetSalesReport(c *gin.Context)
 }
 
 // Server ...
@@ -92,6 +170,14 @@ type Server struct {
 	MuxGoldGym   MuxGoldGymHandler
 	BeegoGoldGym BeegoGoldGymHandler
 	Elastic      ElasticHandler
+	Partner      PartnerHandler
+	Sales        SalesHandler
+	Purchasing   PurchasingHandler
+	Branch       BranchHandler
+	Catalogue    CatalogueHandler
+	Report       ReportHandler
+	Tokens       TokenVerifier
+	RateLimiter  *ratelimit.Enforcer
 
 	engine     *gin.Engine
 	echoEngine *echo.Echo
@@ -0,0 +1,83 @@
+package branch
+
+import (
+	"errors"
+	"time"
+)
+
+// MainBranchID is the original site. Rows written before branches existed
+// belong to it, and writes that do not name a branch go to it.
+const MainBranchID int64 = 1
+
+// Branch access of a member, set per subscription package
+const (
+	// AccessHome lets a member in at their home branch only
+	AccessHome = "home"
+	// AccessAll lets a member in at every branch
+	AccessAll = "all"
+)
+
+var (
+	ErrDuplicateBranch      = errors.New("branch code already exists")
+	ErrNoActiveSubscription = errors.New("member has no active subscription")
+	ErrNoBranchAccess       = errors.New("member has no access to this branch")
+)
+
+type Branch struct {
+	BranchID        int64     `gorm:"column:branch_id;primaryKey;autoIncrement" json:"branch_id"`
+	BranchCode      string    `gorm:"column:branch_code" json:"branch_code"`
+	BranchName      string    `gorm:"column:branch_name" json:"branch_name"`
+	BranchAddress   string    `gorm:"column:branch_address" json:"branch_address"`
+	BranchPhone     string    `gorm:"column:branch_phone" json:"branch_phone"`
+	BranchActive    bool      `gorm:"column:branch_active" json:"branch_active"`
+	BranchCreatedAt time.Time `gorm:"column:branch_created_at;->" json:"branch_created_at"`
+}
+
+// MemberAccess is what a check-in needs to know about a member. Access is
+// AccessAll when any active subscription is on an all-branch package.
+type MemberAccess struct {
+	GoldID       int    `gorm:"column:gold_id" json:"gold_id"`
+	GoldEmail    string `gorm:"column:gold_email" json:"gold_email"`
+	HomeBranchID int64  `gorm:"column:gold_branch_id" json:"home_branch_id"`
+	Access       string `gorm:"column:branch_access" json:"branch_access"`
+	Active       bool   `gorm:"column:active" json:"active"`
+}
+
+// CanEnter reports whether the member may check in at branchID
+func (m MemberAccess) CanEnter(branchID int64) bool {
+	return m.Access == AccessAll || m.HomeBranchID == branchID
+}
+
+// Checkin is one member visit at the front desk of a branch
+type Checkin struct {
+	CheckinID int64     `gorm:"column:checkin_id;primaryKey;autoIncrement" json:"checkin_id"`
+	GoldID    int       `gorm:"column:gold_id" json:"gold_id"`
+	BranchID  int64     `gorm:"column:branch_id" json:"branch_id"`
+	CheckinAt time.Time `gorm:"column:checkin_at" json:"checkin_at"`
+}
+
+// CheckinRequest is the check-in body, the member comes from the access token
+type CheckinRequest struct {
+	BranchID int64 `json:"branch_id"`
+}
+
+// HomeBranch moves a member to another home branch
+type HomeBranch struct {
+	GoldID   int   `json:"gold_id"`
+	BranchID int64 `json:"branch_id"`
+}
+
+// CheckinFilter selects check-ins by branch and day (YYYY-MM-DD), zero
+// fields match all
+type CheckinFilter struct {
+	BranchID int64
+	Date     string
+}
+
+func (Branch) TableName() string {
+	return "branch"
+}
+
+func (Checkin) TableName() string {
+	return "member_checkin"
+}
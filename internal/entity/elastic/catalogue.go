@@ -0,0 +1,137 @@
+package elastic
+
+import (
+	"gold-gym-be/internal/entity"
+)
+
+// Aliases of the stock and sales documents when none are configured
+const (
+	DefaultStockIndex = "gold-stock"
+	DefaultSalesIndex = "gold-sales"
+)
+
+// Template versions of the stock and sales indices, see
+// MemberTemplateVersion
+const (
+	StockTemplateVersion = 1
+	SalesTemplateVersion = 1
+)
+
+// Indices are the aliases the service reads and writes through, empty ones
+// take their default
+type Indices struct {
+	Members string
+	Stock   string
+	Sales   string
+}
+
+// WithDefaults fills the empty aliases of i
+func (i Indices) WithDefaults() Indices {
+	if i.Members == "" {
+		i.Members = DefaultMemberIndex
+	}
+	if i.Stock == "" {
+		i.Stock = DefaultStockIndex
+	}
+	if i.Sales == "" {
+		i.Sales = DefaultSalesIndex
+	}
+	return i
+}
+
+// StockDocument is a stock variant as the POS looks it up, with the product
+// and category it belongs to and the barcodes printed on it
+type StockDocument struct {
+	StockCode    string       `gorm:"column:stock_code" json:"stock_code"`
+	StockName    string       `gorm:"column:stock_name" json:"stock_name"`
+	StockPack    string       `gorm:"column:stock_pack" json:"stock_pack"`
+	StockQTY     int          `gorm:"column:stock_qty" json:"stock_qty"`
+	StockPrice   entity.Money `gorm:"column:stock_price" json:"stock_price"`
+	ProductCode  string       `gorm:"column:product_code" json:"product_code,omitempty"`
+	ProductName  string       `gorm:"column:product_name" json:"product_name,omitempty"`
+	CategoryName string       `gorm:"column:category_name" json:"category_name,omitempty"`
+	Flavour      string       `gorm:"column:flavour" json:"flavour,omitempty"`
+	Size         string       `gorm:"column:size" json:"size,omitempty"`
+	Barcodes     []string     `gorm:"-" json:"barcodes,omitempty"`
+	IndexedAt    string       `gorm:"-" json:"indexed_at"`
+}
+
+// SaleDocument is a completed sale. SoldAt is "2006-01-02 15:04:05" in
+// server local time, like the sale_transdate and sale_transtime it is made
+// of.
+type SaleDocument struct {
+	SaleID      string             `json:"sale_id"`
+	BranchID    int64              `json:"branch_id"`
+	Salesperson string             `json:"salesperson"`
+	SoldAt      string             `json:"sold_at"`
+	Total       entity.Money       `json:"total"`
+	Lines       []SaleDocumentLine `json:"lines"`
+	IndexedAt   string             `json:"indexed_at"`
+}
+
+// SaleDocumentLine is one stock code of a sale, Amount is Qty * Price
+type SaleDocumentLine struct {
+	StockCode string       `json:"stock_code"`
+	StockName string       `json:"stock_name"`
+	Qty       int          `json:"qty"`
+	Price     entity.Money `json:"price"`
+	Amount    entity.Money `json:"amount"`
+}
+
+// Stock suggestion sizes
+const (
+	DefaultSuggestSize = 10
+	MaxSuggestSize     = 50
+)
+
+// StockSearch looks stock up by the start of its name, product name, stock
+// code or barcode, as typed at the POS
+type StockSearch struct {
+	Query string
+	Size  int
+}
+
+// Top seller list sizes
+const (
+	DefaultTopSellers = 10
+	MaxTopSellers     = 100
+)
+
+// SalesReportFilter selects the sales of a report. From and To are
+// YYYY-MM-DD and both included; a zero BranchID or an empty Salesperson
+// matches all.
+type SalesReportFilter struct {
+	From        string
+	To          string
+	BranchID    int64
+	Salesperson string
+	Top         int
+}
+
+// SalesReport aggregates the sales of a SalesReportFilter. Daily has a
+// bucket for every day of the period, days without sales included.
+type SalesReport struct {
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Sales          int             `json:"sales"`
+	Revenue        entity.Money    `json:"revenue"`
+	TopSellers     []TopSeller     `json:"top_sellers"`
+	Daily          []RevenueBucket `json:"daily"`
+	PerSalesperson []RevenueBucket `json:"per_salesperson"`
+}
+
+// TopSeller is a stock code with the units sold and their revenue
+type TopSeller struct {
+	StockCode string       `json:"stock_code"`
+	StockName string       `json:"stock_name"`
+	Qty       int          `json:"qty"`
+	Revenue   entity.Money `json:"revenue"`
+}
+
+// RevenueBucket is the number of sales and their revenue of one day or one
+// salesperson
+type RevenueBucket struct {
+	Key     string       `json:"key"`
+	Sales   int          `json:"sales"`
+	Revenue entity.Money `json:"revenue"`
+}
@@ -1,16 +1,168 @@
 package elastic
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// DefaultMemberIndex is the member alias when none is configured
+const DefaultMemberIndex = "gold-members"
+
+// MemberTemplateVersion is the version of the member index template. Raise
+// it with every change of the template, indices built from an older version
+// are rebuilt at startup.
+const MemberTemplateVersion = 2
+
 // UserDocument — dokumen yang di-index ke ES
 type UserDocument struct {
-	GoldId      int    `json:"gold_id"`
-	GoldEmail   string `json:"gold_email"`
-	GoldNama    string `json:"gold_nama"`
-	GoldNomorHp string `json:"gold_nomorhp"`
-	IndexedAt   string `json:"indexed_at"`
+	GoldId       int    `gorm:"column:gold_id" json:"gold_id"`
+	GoldEmail    string `gorm:"column:gold_email" json:"gold_email"`
+	GoldNama     string `gorm:"column:gold_nama" json:"gold_nama"`
+	GoldNomorHp  string `gorm:"column:gold_nomorhp" json:"gold_nomorhp"`
+	GoldBranchID int64  `gorm:"column:gold_branch_id" json:"gold_branch_id,omitempty"`
+	// PhoneNormalized is GoldNomorHp as digits with the 62 country code,
+	// set when the document is indexed
+	PhoneNormalized string `gorm:"-" json:"gold_nomorhp_normalized,omitempty"`
+	// The subscription fields summarise subscription_detail. A data_peserta
+	// change event does not carry them, they are left out so an upsert
+	// keeps the stored ones.
+	SubscriptionStatus   []string `gorm:"-" json:"subscription_status,omitempty"`
+	SubscriptionPackages []string `gorm:"-" json:"subscription_packages,omitempty"`
+	// SubscriptionEnd is the latest end date, "2006-01-02 15:04:05"
+	SubscriptionEnd string `gorm:"-" json:"subscription_end,omitempty"`
+	IndexedAt       string `gorm:"-" json:"indexed_at"`
 }
 
-// SearchResult — response dari ES search
+// MemberHit is a member found by a search with the matched fragments of its
+// fields
+type MemberHit struct {
+	UserDocument
+	Highlight map[string][]string `json:"highlight,omitempty"`
+}
+
+// SearchResult — response dari ES search. Next is the cursor of the page
+// after this one, empty on the last page.
 type SearchResult struct {
-	Total int            `json:"total"`
-	Hits  []UserDocument `json:"hits"`
+	Total int         `json:"total"`
+	Hits  []MemberHit `json:"hits"`
+	Next  string      `json:"next,omitempty"`
+}
+
+// Member search sort keys, prefixed with "-" for descending order
+const (
+	SortScore  = "score"
+	SortName   = "name"
+	SortID     = "id"
+	SortExpiry = "expiry"
+)
+
+// Member search page sizes
+const (
+	DefaultSearchSize = 20
+	MaxSearchSize     = 100
+	// MaxSearchWindow is how deep from and size may reach, further pages
+	// need After
+	MaxSearchWindow = 10000
+)
+
+// MemberSearch is a member search. Query is matched fuzzily and by prefix
+// against name, email and phone; an empty Query lists the members matching
+// the filters. The expiry range filters SubscriptionEnd, a zero bound is
+// open. A page is From/Size, or Size members after the cursor After of the
+// previous page.
+type MemberSearch struct {
+	Query       string
+	Status      []string
+	Packages    []string
+	BranchID    int64
+	ExpiresFrom time.Time
+	ExpiresTo   time.Time
+	Sort        string
+	From        int
+	Size        int
+	After       string
+}
+
+// NormalizePhone reduces a phone number to its digits with the Indonesian
+// country code, so 0812-3456, +62 812 3456, 628123456 and 8123456 are equal
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	switch {
+	case strings.HasPrefix(digits, "0"):
+		digits = "62" + digits[1:]
+	case strings.HasPrefix(digits, "8"):
+		digits = "62" + digits
+	}
+	return digits
+}
+
+// ErrBadCursor is returned for an After that is not a cursor of a search
+var ErrBadCursor = errors.New("invalid search cursor")
+
+// EncodeCursor turns the sort values of the last member of a page into the
+// After of the next page
+func EncodeCursor(sort json.RawMessage) string {
+	if len(sort) == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(sort)
+}
+
+// DecodeCursor returns the sort values EncodeCursor was given
+func DecodeCursor(cursor string) (json.RawMessage, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil || len(values) == 0 {
+		return nil, ErrBadCursor
+	}
+	return raw, nil
+}
+
+// ValidSort reports whether sort is empty or a sort key, optionally
+// prefixed with "-"
+func ValidSort(sort string) bool {
+	switch strings.TrimPrefix(sort, "-") {
+	case "", SortScore, SortName, SortID, SortExpiry:
+		return sort != "-"
+	}
+	return false
+}
+
+// BulkAction is one line pair of a bulk request: an upsert of Doc, or the
+// delete of the document with Doc.GoldId. Replace indexes Doc whole
+// instead, dropping fields it leaves empty.
+type BulkAction struct {
+	Delete  bool
+	Replace bool
+	Doc     UserDocument
+}
+
+// ReindexResult reports a full member reindex
+type ReindexResult struct {
+	Index     string   `json:"index"`
+	Documents int      `json:"documents"`
+	Removed   []string `json:"removed"`
+}
+
+// MigrationResult reports the startup migration of an alias. Index is the
+// index the alias points to afterwards, Reindexed is set when it was rebuilt
+// for it.
+type MigrationResult struct {
+	Alias           string         `json:"alias"`
+	TemplateVersion int            `json:"template_version"`
+	Index           string         `json:"index"`
+	Reindexed       bool           `json:"reindexed"`
+	Reindex         *ReindexResult `json:"reindex,omitempty"`
 }
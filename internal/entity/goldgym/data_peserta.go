@@ -1,6 +1,7 @@
 package goldgym
 
 import (
+	"gold-gym-be/internal/entity"
 	"gopkg.in/guregu/null.v3/zero"
 )
 
@@ -47,6 +48,7 @@ type GetGoldUserss struct {
 	GoldLastLogin           string      `gorm:"column:gold_last_login" db:"gold_last_login" json:"gold_last_login"`
 	GoldLastLoginHost       string      `gorm:"column:gold_last_login_host" db:"gold_last_login_host" json:"gold_last_login_host"`
 	GoldForceChangePassword int         `gorm:"column:gold_force_change_password" db:"gold_force_change_password" json:"gold_force_change_password"`
+	GoldBranchID            int64       `gorm:"column:gold_branch_id" db:"gold_branch_id" json:"gold_branch_id"`
 }
 
 type LoginUser struct {
@@ -91,19 +93,19 @@ type Logout struct {
 }
 
 type GetSubsWithUser struct {
-	GoldId              int         `gorm:"column:gold_id" db:"gold_id" json:"gold_id"`
-	GoldMenuId          zero.String `gorm:"column:gold_menuid" db:"gold_menuid" json:"gold_menuid"`
-	GoldEmail           string      `gorm:"column:gold_email" db:"gold_email" json:"gold_email"`
-	GoldNama            string      `gorm:"column:gold_nama" db:"gold_nama" json:"gold_nama"`
-	GoldNomorHp         string      `gorm:"column:gold_nomorhp" db:"gold_nomorhp" json:"gold_nomorhp"`
-	GoldExpireddate     string      `gorm:"column:gold_expireddate" db:"gold_expireddate" json:"gold_expireddate"`
-	GoldNamaPaket       zero.String `gorm:"column:gold_namapaket" db:"gold_namapaket" json:"gold_namapaket"`
-	GoldNamaLayanan     zero.String `gorm:"column:gold_namalayanan" db:"gold_namalayanan" json:"gold_namalayanan"`
-	GoldHarga           zero.Float  `gorm:"column:gold_harga" db:"gold_harga" json:"gold_harga"`
-	GoldListLatihan     zero.String `gorm:"column:gold_listlatihan" db:"gold_listlatihan" json:"gold_listlatihan"`
-	GoldJumlahpertemuan zero.Int    `gorm:"column:gold_jumlahpertemuan" db:"gold_jumlahpertemuan" json:"gold_jumlahpertemuan"`
-	GoldDurasi          zero.Int    `gorm:"column:gold_durasi" db:"gold_durasi" json:"gold_durasi"`
-	GoldStatuslangganan zero.String `gorm:"column:gold_statuslangganan" db:"gold_statuslangganan" json:"gold_statuslangganan"`
+	GoldId              int          `gorm:"column:gold_id" db:"gold_id" json:"gold_id"`
+	GoldMenuId          zero.String  `gorm:"column:gold_menuid" db:"gold_menuid" json:"gold_menuid"`
+	GoldEmail           string       `gorm:"column:gold_email" db:"gold_email" json:"gold_email"`
+	GoldNama            string       `gorm:"column:gold_nama" db:"gold_nama" json:"gold_nama"`
+	GoldNomorHp         string       `gorm:"column:gold_nomorhp" db:"gold_nomorhp" json:"gold_nomorhp"`
+	GoldExpireddate     string       `gorm:"column:gold_expireddate" db:"gold_expireddate" json:"gold_expireddate"`
+	GoldNamaPaket       zero.String  `gorm:"column:gold_namapaket" db:"gold_namapaket" json:"gold_namapaket"`
+	GoldNamaLayanan     zero.String  `gorm:"column:gold_namalayanan" db:"gold_namalayanan" json:"gold_namalayanan"`
+	GoldHarga           entity.Money `gorm:"column:gold_harga" db:"gold_harga" json:"gold_harga"`
+	GoldListLatihan     zero.String  `gorm:"column:gold_listlatihan" db:"gold_listlatihan" json:"gold_listlatihan"`
+	GoldJumlahpertemuan zero.Int     `gorm:"column:gold_jumlahpertemuan" db:"gold_jumlahpertemuan" json:"gold_jumlahpertemuan"`
+	GoldDurasi          zero.Int     `gorm:"column:gold_durasi" db:"gold_durasi" json:"gold_durasi"`
+	GoldStatuslangganan zero.String  `gorm:"column:gold_statuslangganan" db:"gold_statuslangganan" json:"gold_statuslangganan"`
 }
 
 type GetValidationGoldOTP struct {
@@ -0,0 +1,53 @@
+package goldgym
+
+import "time"
+
+// MemberIdentity links an external OIDC identity (provider + subject) to a member
+type MemberIdentity struct {
+	GoldId           int       `gorm:"column:gold_id" db:"gold_id" json:"gold_id"`
+	IdentityProvider string    `gorm:"column:identity_provider" db:"identity_provider" json:"identity_provider"`
+	IdentitySubject  string    `gorm:"column:identity_subject" db:"identity_subject" json:"identity_subject"`
+	IdentityEmail    string    `gorm:"column:identity_email" db:"identity_email" json:"identity_email"`
+	IdentityLinkedAt time.Time `gorm:"column:identity_linked_at" db:"identity_linked_at" json:"identity_linked_at"`
+}
+
+// OIDCLoginState is the server-side half of an authorization request, keyed by
+// the state parameter. GoldId is filled when an existing member starts a link flow.
+type OIDCLoginState struct {
+	StateID           string    `gorm:"column:state_id;primaryKey" db:"state_id" json:"state_id"`
+	StateProvider     string    `gorm:"column:state_provider" db:"state_provider" json:"state_provider"`
+	StateCodeVerifier string    `gorm:"column:state_code_verifier" db:"state_code_verifier" json:"-"`
+	StateNonce        string    `gorm:"column:state_nonce" db:"state_nonce" json:"-"`
+	GoldId            int       `gorm:"column:gold_id" db:"gold_id" json:"gold_id"`
+	StateExpiresAt    time.Time `gorm:"column:state_expires_at" db:"state_expires_at" json:"state_expires_at"`
+}
+
+// OIDCMember is the data_peserta row created without a password chosen by the
+// member, for a first-time social login or a partner enrolment
+type OIDCMember struct {
+	GoldId                  int    `gorm:"column:gold_id;primaryKey;autoIncrement" db:"gold_id" json:"gold_id"`
+	GoldEmail               string `gorm:"column:gold_email" db:"gold_email" json:"gold_email"`
+	GoldPassword            string `gorm:"column:gold_password" db:"gold_password" json:"-"`
+	GoldNama                string `gorm:"column:gold_nama" db:"gold_nama" json:"gold_nama"`
+	GoldNomorHp             string `gorm:"column:gold_nomorhp" db:"gold_nomorhp" json:"gold_nomorhp"`
+	GoldValidasiYN          string `gorm:"column:gold_validasiyn" db:"gold_validasiyn" json:"gold_validasiyn"`
+	GoldForceChangePassword int    `gorm:"column:gold_force_change_password" db:"gold_force_change_password" json:"gold_force_change_password"`
+}
+
+// OIDCAuthorize is returned to the client to start the redirect to the provider
+type OIDCAuthorize struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+func (MemberIdentity) TableName() string {
+	return "member_identity"
+}
+
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_state"
+}
+
+func (OIDCMember) TableName() string {
+	return "data_peserta"
+}
@@ -0,0 +1,46 @@
+package idempotency
+
+import "time"
+
+const (
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+)
+
+// Outcome tells the middleware what to do with a request carrying an Idempotency-Key
+type Outcome int
+
+const (
+	// OutcomeNew means the key was reserved and the request should be executed
+	OutcomeNew Outcome = iota
+	// OutcomeReplay means a stored response exists and must be replayed
+	OutcomeReplay
+	// OutcomeInProgress means another request with the same key is still running
+	OutcomeInProgress
+	// OutcomeMismatch means the key was used before with a different payload
+	OutcomeMismatch
+)
+
+// Record is what we keep in Redis per key
+type Record struct {
+	Status         string    `json:"status"`
+	Fingerprint    string    `json:"fingerprint"`
+	Token          string    `json:"token"`
+	ResponseStatus int       `json:"response_status,omitempty"`
+	ContentType    string    `json:"content_type,omitempty"`
+	ResponseBody   []byte    `json:"response_body,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Reservation is the result of trying to claim a key
+type Reservation struct {
+	Outcome Outcome
+	Record  Record
+}
+
+// Response is the captured response of the first request for a key
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
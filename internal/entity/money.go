@@ -0,0 +1,172 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is a rupiah amount held as a whole number of sen (1/100 rupiah), the
+// precision of the DECIMAL(15, 2) money columns. Sums and products of Money
+// are exact; wherever a result falls between two sen (parsing more than two
+// decimals, converting a float, dividing) it is rounded half away from zero,
+// so 0.005 becomes 0.01 and -0.005 becomes -0.01.
+//
+// Money marshals to a JSON number with two decimals and accepts a JSON number,
+// a numeric string or null (zero). In SQL it is written as a decimal string
+// and read from DECIMAL, integer or float columns; NULL reads as zero.
+type Money int64
+
+// Sen is the number of Money units in one rupiah
+const Sen = 100
+
+// NewMoney returns a whole rupiah amount
+func NewMoney(rupiah int64) Money {
+	return Money(rupiah * Sen)
+}
+
+// MoneyFromFloat converts a float amount in rupiah, rounding to the sen
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * Sen))
+}
+
+// ParseMoney parses a decimal amount in rupiah such as "25000", "7500.5" or
+// "-12.345" without going through a float
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || strings.ContainsAny(s, "/eE") {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+	r.Mul(r, big.NewRat(Sen, 1))
+	m, exact := roundRat(r)
+	if !exact {
+		return 0, fmt.Errorf("money amount %q out of range", s)
+	}
+	return m, nil
+}
+
+// Mul multiplies by a quantity
+func (m Money) Mul(qty int) Money {
+	return m * Money(qty)
+}
+
+// MulRatio returns m * num / den rounded to the sen, e.g. the cost of part of
+// a lot. It panics when den is zero.
+func (m Money) MulRatio(num, den int64) Money {
+	r := new(big.Rat).SetFrac(new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(num)), big.NewInt(den))
+	res, _ := roundRat(r)
+	return res
+}
+
+// Div divides by n rounded to the sen, e.g. a unit price from a total. It
+// panics when n is zero.
+func (m Money) Div(n int64) Money {
+	return m.MulRatio(1, n)
+}
+
+// Percent returns m as a percentage of total rounded to two decimals, zero
+// when total is zero
+func (m Money) Percent(total Money) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(m)/float64(total)*100*100) / 100
+}
+
+// Float64 is the amount in rupiah, for interfaces that only carry floats
+func (m Money) Float64() float64 {
+	return float64(m) / Sen
+}
+
+// String formats the amount with two decimals, e.g. "25000.50"
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+	}
+	u := uint64(v)
+	if v < 0 {
+		u = uint64(-v)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, u/Sen, u%Sen)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		*m = 0
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value implements driver.Valuer
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		return m.Scan(string(v))
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case int64:
+		*m = NewMoney(v)
+	case float64:
+		*m = MoneyFromFloat(v)
+	case float32:
+		*m = MoneyFromFloat(float64(v))
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	return nil
+}
+
+// GormDataType keeps gorm migrations on DECIMAL
+func (Money) GormDataType() string {
+	return "decimal(15,2)"
+}
+
+// roundRat rounds r half away from zero, reporting false when it does not fit
+func roundRat(r *big.Rat) (Money, bool) {
+	num, den := new(big.Int).Set(r.Num()), r.Denom()
+	neg := num.Sign() < 0
+	num.Abs(num)
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if neg {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return 0, false
+	}
+	return Money(q.Int64()), true
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{"25000", 2500000, false},
+		{"7500.5", 750050, false},
+		{"0.1", 10, false},
+		{"12.345", 1235, false}, // setengah dibulatkan menjauhi nol
+		{"12.344", 1234, false},
+		{"-0.005", -1, false},
+		{" 100.00 ", 10000, false},
+		{"1e3", 0, true},
+		{"1/3", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// float 0.1 + 0.2 tidak pernah tepat 0.3, Money selalu tepat
+	assert.Equal(t, MoneyFromFloat(0.3), MoneyFromFloat(0.1)+MoneyFromFloat(0.2))
+	assert.Equal(t, Money(750050*3), Money(750050).Mul(3))
+
+	assert.Equal(t, Money(3333), NewMoney(100).Div(3))
+	assert.Equal(t, Money(6667), NewMoney(200).Div(3))
+	assert.Equal(t, Money(-6667), NewMoney(-200).Div(3))
+	assert.Equal(t, Money(2166667), NewMoney(65000).MulRatio(1, 3))
+
+	assert.Equal(t, 21.74, NewMoney(12500).Percent(NewMoney(57500)))
+	assert.Equal(t, 0.0, NewMoney(1).Percent(0))
+	assert.Equal(t, 7500.5, Money(750050).Float64())
+	assert.Equal(t, "-12.05", Money(-1205).String())
+	assert.Equal(t, "0.00", Money(0).String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		Price Money `json:"price"`
+		Total Money `json:"total"`
+		Tip   Money `json:"tip"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"price": 7500.5, "total": "82500.50", "tip": null}`), &v))
+	assert.Equal(t, Money(750050), v.Price)
+	assert.Equal(t, Money(8250050), v.Total)
+	assert.Equal(t, Money(0), v.Tip)
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price": 7500.50, "total": 82500.50, "tip": 0}`, string(b))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": true}`), &v))
+}
+
+func TestMoneySQL(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Money
+	}{
+		{[]byte("25000.50"), 2500050},
+		{"7500.00", 750000},
+		{int64(25000), 2500000},
+		{float64(7500.5), 750050},
+		{float32(0.1), 10},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		var m Money
+		require.NoError(t, m.Scan(tt.src))
+		assert.Equal(t, tt.want, m)
+	}
+
+	var m Money
+	assert.Error(t, m.Scan(true))
+
+	v, err := Money(2500050).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "25000.50", v)
+}
@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"gold-gym-be/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Domain event types
+const (
+	EventMemberRegistered = "MemberRegistered"
+	EventSubscriptionPaid = "SubscriptionPaid"
+	EventStockLow         = "StockLow"
+	EventSaleCompleted    = "SaleCompleted"
+)
+
+// Aggregate types, events of one aggregate are published in order
+const (
+	AggregateMember = "member"
+	AggregateStock  = "stock"
+	AggregateSale   = "sale"
+)
+
+// EnvelopeVersion is the version of the Envelope layout itself, the payload
+// is versioned by its schema id
+const EnvelopeVersion = 1
+
+// SchemaIDs names the payload schema of every event type. A breaking change
+// to a payload gets a new schema id, consumers pick the decoder by it.
+var SchemaIDs = map[string]string{
+	EventMemberRegistered: "goldgym.member.registered.v1",
+	EventSubscriptionPaid: "goldgym.subscription.paid.v1",
+	EventStockLow:         "goldgym.stock.low.v1",
+	EventSaleCompleted:    "goldgym.sale.completed.v1",
+}
+
+// Envelope is the JSON value of every published event
+type Envelope struct {
+	Version       int             `json:"envelope_version"`
+	SchemaID      string          `json:"schema_id"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Event is a row of outbox_event
+type Event struct {
+	OutboxID      int64      `gorm:"column:outbox_id;primaryKey;autoIncrement" json:"outbox_id"`
+	EventID       string     `gorm:"column:outbox_event_id" json:"event_id"`
+	EventType     string     `gorm:"column:outbox_event_type" json:"event_type"`
+	AggregateType string     `gorm:"column:outbox_aggregate_type" json:"aggregate_type"`
+	AggregateID   string     `gorm:"column:outbox_aggregate_id" json:"aggregate_id"`
+	Envelope      []byte     `gorm:"column:outbox_envelope" json:"-"`
+	CreatedAt     time.Time  `gorm:"column:outbox_created_at" json:"created_at"`
+	PublishedAt   *time.Time `gorm:"column:outbox_published_at" json:"published_at"`
+	Attempts      int        `gorm:"column:outbox_attempts" json:"attempts"`
+	LastError     string     `gorm:"column:outbox_last_error" json:"last_error"`
+}
+
+// TableName ...
+func (Event) TableName() string {
+	return "outbox_event"
+}
+
+// Key is the Kafka message key, it sends all events of one aggregate to the
+// same partition
+func (e Event) Key() string {
+	return e.AggregateType + ":" + e.AggregateID
+}
+
+// NewEvent wraps payload in an envelope ready to be written to the outbox
+func NewEvent(eventType, aggregateType, aggregateID string, payload interface{}, occurredAt time.Time) (Event, error) {
+	schemaID, ok := SchemaIDs[eventType]
+	if !ok || aggregateID == "" {
+		return Event{}, entity.ErrInvalid
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	envelope := Envelope{
+		Version:       EnvelopeVersion,
+		SchemaID:      schemaID,
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		OccurredAt:    occurredAt.UTC(),
+		Payload:       raw,
+	}
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		EventID:       envelope.EventID,
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Envelope:      value,
+		CreatedAt:     occurredAt,
+	}, nil
+}
+
+// PublishErrors is returned by a publisher that wrote part of a batch, the
+// error of events[i] is at index i and nil when it was written
+type PublishErrors []error
+
+func (e PublishErrors) Error() string {
+	failed := 0
+	for _, err := range e {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d events not published", failed, len(e))
+}
+
+// MemberRegistered is published when a member is created, by signing up or
+// through a social login or enrollment
+type MemberRegistered struct {
+	GoldID int    `json:"gold_id"`
+	Email  string `json:"gold_email"`
+	Nama   string `json:"gold_nama"`
+}
+
+// SubscriptionPaid is published when the payment of a member's subscription
+// is confirmed
+type SubscriptionPaid struct {
+	GoldID     int          `json:"gold_id"`
+	Email      string       `json:"gold_email"`
+	TotalHarga entity.Money `json:"gold_totalharga"`
+	PaidAt     time.Time    `json:"paid_at"`
+}
+
+// StockLow is published when a product falls to or below its reorder point
+type StockLow struct {
+	StockCode    string `json:"stock_code"`
+	StockName    string `json:"stock_name"`
+	StockQty     int    `json:"stock_qty"`
+	ReorderPoint int    `json:"reorder_point"`
+	SuggestedQty int    `json:"suggested_qty"`
+}
+
+// SaleCompleted is published for every front desk sale
+type SaleCompleted struct {
+	SaleID      string         `json:"sale_id"`
+	BranchID    int64          `json:"branch_id"`
+	Salesperson string         `json:"salesperson"`
+	Total       entity.Money   `json:"total"`
+	Lines       []SaleLineSold `json:"lines"`
+	CompletedAt time.Time      `json:"completed_at"`
+}
+
+// SaleLineSold is one product of a SaleCompleted
+type SaleLineSold struct {
+	StockCode string       `json:"stock_code"`
+	Qty       int          `json:"qty"`
+	Price     entity.Money `json:"price"`
+}
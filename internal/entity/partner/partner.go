@@ -0,0 +1,94 @@
+package partner
+
+import (
+	"errors"
+	"time"
+)
+
+// SNAP response codes are HTTP status + service code + case code
+const (
+	ServiceCodeAccessToken  = "73"
+	ServiceCodeEnrollMember = "90"
+)
+
+var (
+	ErrUnknownClient       = errors.New("unknown client")
+	ErrInvalidTimestamp    = errors.New("invalid timestamp")
+	ErrInvalidSignature    = errors.New("invalid signature")
+	ErrInvalidToken        = errors.New("invalid token (B2B)")
+	ErrDuplicateExternalID = errors.New("duplicate X-EXTERNAL-ID")
+)
+
+// Partner is a corporate client registered for the partner API. PublicKey
+// (base64 of a PEM or DER RSA key) verifies the asymmetric X-SIGNATURE of the
+// access token request and ClientSecret keys the HMAC-SHA512 signature of
+// every other request.
+type Partner struct {
+	PartnerID           int       `gorm:"column:partner_id;primaryKey;autoIncrement" db:"partner_id" json:"partner_id"`
+	PartnerClientKey    string    `gorm:"column:partner_client_key" db:"partner_client_key" json:"partner_client_key"`
+	PartnerName         string    `gorm:"column:partner_name" db:"partner_name" json:"partner_name"`
+	PartnerPublicKey    string    `gorm:"column:partner_public_key" db:"partner_public_key" json:"-"`
+	PartnerClientSecret string    `gorm:"column:partner_client_secret" db:"partner_client_secret" json:"-"`
+	PartnerActiveYN     string    `gorm:"column:partner_active_yn" db:"partner_active_yn" json:"partner_active_yn"`
+	PartnerCreatedAt    time.Time `gorm:"column:partner_created_at" db:"partner_created_at" json:"partner_created_at"`
+}
+
+// PartnerMember records which partner enrolled a member
+type PartnerMember struct {
+	PartnerID  int       `gorm:"column:partner_id" db:"partner_id" json:"partner_id"`
+	GoldId     int       `gorm:"column:gold_id" db:"gold_id" json:"gold_id"`
+	EmployeeID string    `gorm:"column:employee_id" db:"employee_id" json:"employee_id"`
+	EnrolledAt time.Time `gorm:"column:enrolled_at" db:"enrolled_at" json:"enrolled_at"`
+}
+
+// AccessTokenRequest is the body of the B2B access token request
+type AccessTokenRequest struct {
+	GrantType string `json:"grantType"`
+}
+
+// AccessToken is the SNAP access token response
+type AccessToken struct {
+	ResponseCode    string `json:"responseCode"`
+	ResponseMessage string `json:"responseMessage"`
+	AccessToken     string `json:"accessToken"`
+	TokenType       string `json:"tokenType"`
+	ExpiresIn       string `json:"expiresIn"`
+}
+
+// SignedRequest carries the headers and body a partner signed
+type SignedRequest struct {
+	PartnerID   string // X-PARTNER-ID, the client key
+	ExternalID  string // X-EXTERNAL-ID, unique per partner per day
+	Method      string
+	Path        string // relative URL including the query string
+	AccessToken string
+	Timestamp   string
+	Signature   string
+	Body        string
+}
+
+// EnrollMember enrols one employee of the partner as a member
+type EnrollMember struct {
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	PhoneNo    string `json:"phoneNo"`
+	EmployeeID string `json:"employeeId"`
+}
+
+// Enrollment is the SNAP response to EnrollMember. Status is "created" for a
+// new member or "existing" when the email was already registered.
+type Enrollment struct {
+	ResponseCode    string `json:"responseCode"`
+	ResponseMessage string `json:"responseMessage"`
+	MemberID        int    `json:"memberId"`
+	Email           string `json:"email"`
+	Status          string `json:"status"`
+}
+
+func (Partner) TableName() string {
+	return "partner_client"
+}
+
+func (PartnerMember) TableName() string {
+	return "partner_member"
+}
@@ -0,0 +1,142 @@
+package replication
+
+import (
+	"errors"
+	"strings"
+)
+
+// Debezium change event operations
+const (
+	OpCreate = "c"
+	OpUpdate = "u"
+	OpDelete = "d"
+	// OpRead is a row of the initial snapshot, applied like a create
+	OpRead = "r"
+)
+
+// Conflict rules, deciding whether an event overwrites the target row
+const (
+	// ConflictLastWriterWins applies an event only when its Version column is
+	// not older than the one of the target row
+	ConflictLastWriterWins = "last_writer_wins"
+	// ConflictSourceWins always applies the event, the source is the system
+	// of record for the table
+	ConflictSourceWins = "source_wins"
+)
+
+// Outcome of applying one event
+const (
+	Applied = "applied"
+	// Skipped: the target already has a newer version, or a delete found
+	// nothing left to delete
+	Skipped = "skipped"
+	// Echo: the event is a replicated write coming back to the database it
+	// was replicated from
+	Echo = "echo"
+)
+
+// Databases kept in sync
+const (
+	Local = "local"
+	Prod  = "prod"
+)
+
+// Sync directions of a table
+const (
+	DirectionBoth        = "both"
+	DirectionLocalToProd = "local_to_prod"
+	DirectionProdToLocal = "prod_to_local"
+	DirectionNone        = "none"
+)
+
+// SyncOriginColumn tags the rows written by replication with the database
+// the change came from and a token unique to the write, as "local#<uuid>".
+// A trigger clears it on every other write, so a change made by the
+// application is never mistaken for a replicated one.
+const SyncOriginColumn = "sync_origin"
+
+var (
+	ErrMissingKey   = errors.New("change event is missing a key column")
+	ErrUnknownOp    = errors.New("unknown change event operation")
+	ErrBadIdentity  = errors.New("invalid column name in change event")
+	ErrBadTable     = errors.New("unknown replicated table")
+	ErrBadDirection = errors.New("unknown sync direction")
+)
+
+// Table describes how the rows of one table are replicated
+type Table struct {
+	Name string
+	// Keys identify a row on both sides. They need not be the primary key,
+	// any unique key works.
+	Keys []string
+	// Skip lists columns the target assigns itself, such as an
+	// AUTO_INCREMENT id when Keys is another unique key, or keeps itself,
+	// such as a quantity derived from its own ledger
+	Skip []string
+	// Timestamps lists DATETIME columns, which Debezium sends as epoch
+	// milliseconds of the wall clock
+	Timestamps []string
+	// Conflict is ConflictLastWriterWins or ConflictSourceWins
+	Conflict string
+	// Version is the column compared by ConflictLastWriterWins
+	Version string
+	// Direction is one of the Direction constants
+	Direction string
+}
+
+// Into reports whether changes of the table are replicated into target,
+// Local or Prod
+func (t Table) Into(target string) bool {
+	switch t.Direction {
+	case DirectionBoth:
+		return true
+	case DirectionLocalToProd:
+		return target == Prod
+	case DirectionProdToLocal:
+		return target == Local
+	}
+	return false
+}
+
+// Origin is the database a tag of SyncOriginColumn names, empty for a row
+// the application wrote
+func Origin(tag interface{}) string {
+	s, _ := tag.(string)
+	origin, _, found := strings.Cut(s, "#")
+	if !found {
+		return ""
+	}
+	return origin
+}
+
+// ValidDirection ...
+func ValidDirection(direction string) bool {
+	switch direction {
+	case DirectionBoth, DirectionLocalToProd, DirectionProdToLocal, DirectionNone:
+		return true
+	}
+	return false
+}
+
+// Drift kinds found by reconciliation
+const (
+	MissingInLocal = "missing_in_local"
+	MissingInProd  = "missing_in_prod"
+	Mismatch       = "mismatch"
+)
+
+// Drift is one row that differs between local and prod. Key holds the
+// values of the table keys joined by "|".
+type Drift struct {
+	Table string `json:"table"`
+	Key   string `json:"key"`
+	Kind  string `json:"kind"`
+}
+
+// Reconciliation compares the rows of one table on both databases
+type Reconciliation struct {
+	Table     string  `json:"table"`
+	LocalRows int     `json:"local_rows"`
+	ProdRows  int     `json:"prod_rows"`
+	Drift     []Drift `json:"drift"`
+}
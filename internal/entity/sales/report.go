@@ -0,0 +1,63 @@
+package goldgym
+
+import "gold-gym-be/internal/entity"
+
+// ReportFilter selects the sales of a period, From and To are YYYY-MM-DD and
+// inclusive. Method is a stock valuation method, empty means the default.
+type ReportFilter struct {
+	From   string
+	To     string
+	Method string
+}
+
+// SaleCOGS is the revenue and cost of goods sold of one sale. CostAvg and
+// CostFIFO are the stored costs, COGS is the one of the requested method.
+type SaleCOGS struct {
+	SaleID          string       `json:"sale_id"`
+	SaleTransdate   string       `json:"sale_transdate"`
+	SaleTransTime   string       `json:"sale_transtime"`
+	SaleSalesperson string       `json:"sale_salesperson"`
+	Revenue         entity.Money `json:"revenue"`
+	CostAvg         entity.Money `json:"-"`
+	CostFIFO        entity.Money `json:"-"`
+	COGS            entity.Money `gorm:"-" json:"cogs"`
+	GrossMargin     entity.Money `gorm:"-" json:"gross_margin"`
+	MarginPct       float64      `gorm:"-" json:"margin_pct"`
+}
+
+// ProductMargin is the gross margin of one stock code over a period
+type ProductMargin struct {
+	StockCode   string       `json:"stock_code"`
+	StockName   string       `json:"stock_name"`
+	Qty         int          `json:"qty"`
+	Revenue     entity.Money `json:"revenue"`
+	CostAvg     entity.Money `json:"-"`
+	CostFIFO    entity.Money `json:"-"`
+	COGS        entity.Money `gorm:"-" json:"cogs"`
+	GrossMargin entity.Money `gorm:"-" json:"gross_margin"`
+	MarginPct   float64      `gorm:"-" json:"margin_pct"`
+}
+
+// ReportTotals sums a report, MarginPct is GrossMargin / Revenue x 100
+type ReportTotals struct {
+	Revenue     entity.Money `json:"revenue"`
+	COGS        entity.Money `json:"cogs"`
+	GrossMargin entity.Money `json:"gross_margin"`
+	MarginPct   float64      `json:"margin_pct"`
+}
+
+type COGSReport struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Method string `json:"method"`
+	ReportTotals
+	Sales []SaleCOGS `json:"sales"`
+}
+
+type MarginReport struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Method string `json:"method"`
+	ReportTotals
+	Products []ProductMargin `json:"products"`
+}
@@ -1,22 +1,73 @@
 package goldgym
 
+import (
+	"errors"
+	"gold-gym-be/internal/entity"
+)
+
+var (
+	ErrUnknownStock        = errors.New("unknown stock code")
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrInsufficientPayment = errors.New("payment is less than the transaction total")
+)
+
 type SalesHeader struct {
-	SaleID           string  `db:"sale_id" json:"sale_id"`
-	SaleTransdate    string  `db:"sale_transdate" json:"sale_transdate"`
-	SaleTransTime    string  `db:"sale_transtime" json:"sale_transtime"`
-	SaleTranstotal   float64 `db:"sale_transtotal" json:"sale_transtotal"`
-	SaleTranspayment float64 `db:"sale_transpayment" json:"sale_transpayment"`
-	SaleTranschange  float64 `db:"sale_transchange" json:"sale_transchange"`
-	SaleSalesperson  string  `db:"sale_salesperson" json:"sale_salesperson"`
+	SaleID           string       `gorm:"column:sale_id;primaryKey" db:"sale_id" json:"sale_id"`
+	SaleTransdate    string       `gorm:"column:sale_transdate" db:"sale_transdate" json:"sale_transdate"`
+	SaleTransTime    string       `gorm:"column:sale_transtime" db:"sale_transtime" json:"sale_transtime"`
+	SaleTranstotal   entity.Money `gorm:"column:sale_transtotal" db:"sale_transtotal" json:"sale_transtotal"`
+	SaleTranspayment entity.Money `gorm:"column:sale_transpayment" db:"sale_transpayment" json:"sale_transpayment"`
+	SaleTranschange  entity.Money `gorm:"column:sale_transchange" db:"sale_transchange" json:"sale_transchange"`
+	SaleSalesperson  string       `gorm:"column:sale_salesperson" db:"sale_salesperson" json:"sale_salesperson"`
+	SaleBranchID     int64        `gorm:"column:sale_branch_id" db:"sale_branch_id" json:"sale_branch_id"`
 }
 
 type SalesDetail struct {
-	SaleID         string `db:"sale_id" json:"sale_id"`
-	SaleStockID    string `db:"sale_stockid" json:"sale_stockid"`
-	SaleStockcode  string `db:"sale_stockcode" json:"sale_stockcode"`
-	SaleStockname  string `db:"sale_stockname" json:"sale_stockname"`
-	SaleQty        string `db:"sale_qty" json:"sale_qty"`
-	SaleSalesprice string `db:"sale_salesprice" json:"sale_salesprice"`
-	SalePack      string `db:"sale_pack" json:"sale_pack"`
-	SaleLastupdate      string `db:"sale_lastupdate" json:"sale_lastupdate"`
+	SaleID         string       `gorm:"column:sale_id;primaryKey" db:"sale_id" json:"sale_id"`
+	SaleStockID    string       `gorm:"column:sale_stockid" db:"sale_stockid" json:"sale_stockid"`
+	SaleStockcode  string       `gorm:"column:sale_stockcode;primaryKey" db:"sale_stockcode" json:"sale_stockcode"`
+	SaleStockname  string       `gorm:"column:sale_stockname" db:"sale_stockname" json:"sale_stockname"`
+	SaleQty        int          `gorm:"column:sale_qty" db:"sale_qty" json:"sale_qty"`
+	SaleSalesprice entity.Money `gorm:"column:sale_salesprice" db:"sale_salesprice" json:"sale_salesprice"`
+	SalePack       string       `gorm:"column:sale_pack" db:"sale_pack" json:"sale_pack"`
+	SaleCostAvg    entity.Money `gorm:"column:sale_cost_avg" db:"sale_cost_avg" json:"-"`
+	SaleCostFIFO   entity.Money `gorm:"column:sale_cost_fifo" db:"sale_cost_fifo" json:"-"`
+	SaleLastupdate string       `gorm:"column:sale_lastupdate;->" db:"sale_lastupdate" json:"sale_lastupdate"`
+}
+
+// SaleLine is one item rung up at the front desk
+type SaleLine struct {
+	StockCode string `json:"stock_code"`
+	Qty       int    `json:"qty"`
+}
+
+// CreateSale is the POS request body. Salesperson is the authenticated
+// caller, it is not read from the body.
+type CreateSale struct {
+	Salesperson string       `json:"-"`
+	BranchID    int64        `json:"branch_id"`
+	Payment     entity.Money `json:"payment"`
+	Lines       []SaleLine   `json:"lines"`
+}
+
+// Receipt is a sale with its lines, returned after checkout and when listing
+type Receipt struct {
+	SalesHeader
+	Details []SalesDetail `json:"details"`
+}
+
+// SalesFilter selects sales by day, salesperson and/or branch, empty fields
+// match all
+type SalesFilter struct {
+	Date        string
+	Salesperson string
+	BranchID    int64
+}
+
+func (SalesHeader) TableName() string {
+	return "sales_header"
+}
+
+func (SalesDetail) TableName() string {
+	return "sales_detail"
 }
@@ -0,0 +1,84 @@
+package goldgym
+
+import (
+	"gold-gym-be/internal/entity"
+	"time"
+)
+
+// Batch usage types
+const (
+	UsageSale     = "sale"
+	UsageExpired  = "expired"
+	UsageTransfer = "transfer"
+)
+
+// StockBatch is one received lot of a stock code. BatchQty is what is left of
+// it; BatchExpiry is YYYY-MM-DD or empty when the product does not expire.
+type StockBatch struct {
+	BatchID          int64        `gorm:"column:batch_id;primaryKey;autoIncrement" json:"batch_id"`
+	StockCode        string       `gorm:"column:stock_code" json:"stock_code"`
+	BatchBranchID    int64        `gorm:"column:batch_branch_id" json:"batch_branch_id"`
+	BatchLot         string       `gorm:"column:batch_lot" json:"batch_lot"`
+	BatchExpiry      string       `gorm:"column:batch_expiry" json:"batch_expiry"`
+	BatchQtyReceived int          `gorm:"column:batch_qty_received" json:"batch_qty_received"`
+	BatchQty         int          `gorm:"column:batch_qty" json:"batch_qty"`
+	BatchUnitCost    entity.Money `gorm:"column:batch_unit_cost" json:"batch_unit_cost"`
+	BatchRef         string       `gorm:"column:batch_ref" json:"batch_ref"`
+	BatchReceivedAt  time.Time    `gorm:"column:batch_received_at" json:"batch_received_at"`
+}
+
+// StockBatchUsage records which batch a sale, write-off or transfer took
+// units from
+type StockBatchUsage struct {
+	UsageID   int64     `gorm:"column:usage_id;primaryKey;autoIncrement" json:"usage_id"`
+	BatchID   int64     `gorm:"column:batch_id" json:"batch_id"`
+	StockCode string    `gorm:"column:stock_code" json:"stock_code"`
+	UsageType string    `gorm:"column:usage_type" json:"usage_type"`
+	UsageQty  int       `gorm:"column:usage_qty" json:"usage_qty"`
+	UsageRef  string    `gorm:"column:usage_ref" json:"usage_ref"`
+	UsageAt   time.Time `gorm:"column:usage_at" json:"usage_at"`
+}
+
+// StockCostLayer is the cost of the units of one receipt line not yet sold
+// or written off. Outflows take their cost from the oldest layers first
+// (FIFO), whichever batch the units physically came from.
+type StockCostLayer struct {
+	LayerID          int64        `gorm:"column:layer_id;primaryKey;autoIncrement" json:"layer_id"`
+	StockCode        string       `gorm:"column:stock_code" json:"stock_code"`
+	LayerQtyReceived int          `gorm:"column:layer_qty_received" json:"layer_qty_received"`
+	LayerQty         int          `gorm:"column:layer_qty" json:"layer_qty"`
+	LayerUnitCost    entity.Money `gorm:"column:layer_unit_cost" json:"layer_unit_cost"`
+	LayerRef         string       `gorm:"column:layer_ref" json:"layer_ref"`
+	LayerReceivedAt  time.Time    `gorm:"column:layer_received_at" json:"layer_received_at"`
+}
+
+// NearExpiryLine is a batch with stock left that expires within the report
+// window, or already has
+type NearExpiryLine struct {
+	StockBatch
+	StockName string       `gorm:"column:stock_name" json:"stock_name"`
+	DaysLeft  int          `gorm:"-" json:"days_left"`
+	Expired   bool         `gorm:"-" json:"expired"`
+	Value     entity.Money `gorm:"-" json:"value"`
+}
+
+type NearExpiryReport struct {
+	AsOf       string           `json:"as_of"`
+	Days       int              `json:"days"`
+	ExpiredQty int              `json:"expired_qty"`
+	NearQty    int              `json:"near_qty"`
+	TotalValue entity.Money     `json:"total_value"`
+	Lines      []NearExpiryLine `json:"lines"`
+}
+
+func (StockBatch) TableName() string {
+	return "stock_batch"
+}
+
+func (StockBatchUsage) TableName() string {
+	return "stock_batch_usage"
+}
+
+func (StockCostLayer) TableName() string {
+	return "stock_cost_layer"
+}
@@ -0,0 +1,80 @@
+package goldgym
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrDuplicateCategory is returned when category_name is already taken
+	ErrDuplicateCategory = errors.New("category already exists")
+	// ErrDuplicateProduct is returned when product_code is already taken
+	ErrDuplicateProduct = errors.New("product code already exists")
+	// ErrDuplicateBarcode is returned when a barcode is already assigned to a
+	// stock code
+	ErrDuplicateBarcode = errors.New("barcode is already assigned")
+	// ErrStorageNotConfigured is returned when uploading an image without a
+	// Firebase storage client
+	ErrStorageNotConfigured = errors.New("image storage is not configured")
+)
+
+type Category struct {
+	CategoryID        int64     `gorm:"column:category_id;primaryKey;autoIncrement" json:"category_id"`
+	CategoryName      string    `gorm:"column:category_name" json:"category_name"`
+	CategoryCreatedAt time.Time `gorm:"column:category_created_at;->" json:"category_created_at"`
+}
+
+// Product is the parent of one or more stock variants. Variants is filled
+// when reading the catalogue.
+type Product struct {
+	ProductCode        string        `gorm:"column:product_code;primaryKey" json:"product_code"`
+	ProductName        string        `gorm:"column:product_name" json:"product_name"`
+	ProductCategoryID  int64         `gorm:"column:product_category_id" json:"category_id"`
+	CategoryName       string        `gorm:"column:category_name;->" json:"category_name"`
+	ProductDescription string        `gorm:"column:product_description" json:"product_description"`
+	ProductImageURL    string        `gorm:"column:product_image_url" json:"product_image_url"`
+	ProductCreatedAt   time.Time     `gorm:"column:product_created_at;->" json:"product_created_at"`
+	Variants           []GetOneStock `gorm:"-" json:"variants"`
+}
+
+// Variant links an existing stock code to a product as one of its
+// flavours/sizes
+type Variant struct {
+	ProductCode string `json:"-"`
+	StockCode   string `json:"stock_code"`
+	Flavour     string `json:"flavour"`
+	Size        string `json:"size"`
+}
+
+type Barcode struct {
+	Barcode          string    `gorm:"column:barcode;primaryKey" json:"barcode"`
+	StockCode        string    `gorm:"column:stock_code" json:"stock_code"`
+	BarcodeCreatedAt time.Time `gorm:"column:barcode_created_at;->" json:"barcode_created_at"`
+}
+
+// ScanResult is what a barcode scan resolves to: the variant to sell and,
+// when it has one, its product
+type ScanResult struct {
+	Barcode string      `json:"barcode"`
+	Stock   GetOneStock `json:"stock"`
+	Product *Product    `json:"product,omitempty"`
+}
+
+// CatalogueFilter selects products by category and/or a name search, zero
+// values match all
+type CatalogueFilter struct {
+	CategoryID int64
+	Query      string
+}
+
+func (Category) TableName() string {
+	return "product_category"
+}
+
+func (Product) TableName() string {
+	return "product"
+}
+
+func (Barcode) TableName() string {
+	return "stock_barcode"
+}
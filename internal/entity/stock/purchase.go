@@ -0,0 +1,154 @@
+package goldgym
+
+import (
+	"errors"
+	"gold-gym-be/internal/entity"
+	"time"
+)
+
+var (
+	// ErrDuplicateSupplier is returned when supplier_code is already taken
+	ErrDuplicateSupplier = errors.New("supplier code already exists")
+	// ErrPOClosed is returned when receiving or cancelling a purchase order
+	// that is already fully received or cancelled
+	ErrPOClosed = errors.New("purchase order is closed")
+	// ErrOverReceive is returned when a receipt is more than what is still
+	// outstanding on the purchase order line
+	ErrOverReceive = errors.New("received qty exceeds the outstanding qty")
+)
+
+// Purchase order statuses
+const (
+	POStatusOpen      = "open"
+	POStatusPartial   = "partial"
+	POStatusReceived  = "received"
+	POStatusCancelled = "cancelled"
+)
+
+type Supplier struct {
+	SupplierID        int64     `gorm:"column:supplier_id;primaryKey;autoIncrement" json:"supplier_id"`
+	SupplierCode      string    `gorm:"column:supplier_code" json:"supplier_code"`
+	SupplierName      string    `gorm:"column:supplier_name" json:"supplier_name"`
+	SupplierPhone     string    `gorm:"column:supplier_phone" json:"supplier_phone"`
+	SupplierEmail     string    `gorm:"column:supplier_email" json:"supplier_email"`
+	SupplierAddress   string    `gorm:"column:supplier_address" json:"supplier_address"`
+	SupplierActive    bool      `gorm:"column:supplier_active" json:"supplier_active"`
+	SupplierCreatedAt time.Time `gorm:"column:supplier_created_at;->" json:"supplier_created_at"`
+}
+
+// PurchaseOrder is a PO header. PODate and POExpectedDate are YYYY-MM-DD.
+type PurchaseOrder struct {
+	POID           string              `gorm:"column:po_id;primaryKey" json:"po_id"`
+	POSupplierID   int64               `gorm:"column:po_supplier_id" json:"supplier_id"`
+	POBranchID     int64               `gorm:"column:po_branch_id" json:"branch_id"`
+	POStatus       string              `gorm:"column:po_status" json:"po_status"`
+	PODate         string              `gorm:"column:po_date" json:"po_date"`
+	POExpectedDate string              `gorm:"column:po_expected_date" json:"po_expected_date"`
+	PONote         string              `gorm:"column:po_note" json:"po_note"`
+	POTotal        entity.Money        `gorm:"column:po_total" json:"po_total"`
+	POCreatedBy    string              `gorm:"column:po_created_by" json:"po_created_by"`
+	POCreatedAt    time.Time           `gorm:"column:po_created_at" json:"po_created_at"`
+	Lines          []PurchaseOrderLine `gorm:"-" json:"lines"`
+}
+
+// PurchaseOrderLine is one stock code on a PO, at most one line per code
+type PurchaseOrderLine struct {
+	LineID      int64        `gorm:"column:pol_id;primaryKey;autoIncrement" json:"line_id"`
+	POID        string       `gorm:"column:po_id" json:"po_id"`
+	StockCode   string       `gorm:"column:stock_code" json:"stock_code"`
+	QtyOrdered  int          `gorm:"column:pol_qty_ordered" json:"qty_ordered"`
+	QtyReceived int          `gorm:"column:pol_qty_received" json:"qty_received"`
+	UnitCost    entity.Money `gorm:"column:pol_unit_cost" json:"unit_cost"`
+}
+
+// POReceipt records one receiving of one PO line, at the cost actually billed
+type POReceipt struct {
+	ReceiptID       int64        `gorm:"column:receipt_id;primaryKey;autoIncrement" json:"receipt_id"`
+	POID            string       `gorm:"column:po_id" json:"po_id"`
+	StockCode       string       `gorm:"column:stock_code" json:"stock_code"`
+	ReceiptQty      int          `gorm:"column:receipt_qty" json:"receipt_qty"`
+	ReceiptUnitCost entity.Money `gorm:"column:receipt_unit_cost" json:"receipt_unit_cost"`
+	ReceiptLot      string       `gorm:"column:receipt_lot" json:"receipt_lot"`
+	ReceiptExpiry   string       `gorm:"column:receipt_expiry" json:"receipt_expiry"`
+	ReceiptNote     string       `gorm:"column:receipt_note" json:"receipt_note"`
+	ReceiptBy       string       `gorm:"column:receipt_by" json:"receipt_by"`
+	ReceiptAt       time.Time    `gorm:"column:receipt_at" json:"receipt_at"`
+}
+
+// CreatePurchaseOrder is the body of a new PO. CreatedBy is the
+// authenticated caller, it is not read from the body.
+type CreatePurchaseOrder struct {
+	SupplierID   int64           `json:"supplier_id"`
+	BranchID     int64           `json:"branch_id"`
+	ExpectedDate string          `json:"expected_date"`
+	Note         string          `json:"note"`
+	CreatedBy    string          `json:"-"`
+	Lines        []POLineRequest `json:"lines"`
+}
+
+// POLineRequest is a line of a new PO or of a receipt. Lot and ExpiryDate
+// (YYYY-MM-DD) are only read when receiving.
+type POLineRequest struct {
+	StockCode  string       `json:"stock_code"`
+	Qty        int          `json:"qty"`
+	UnitCost   entity.Money `json:"unit_cost"`
+	Lot        string       `json:"lot"`
+	ExpiryDate string       `json:"expiry_date"`
+}
+
+// ReceivePurchaseOrder receives some or all of a PO. A zero UnitCost on a
+// line means the cost agreed on the PO. Every line becomes a stock batch, so
+// one stock code may be received in several lots. ReceivedBy is the
+// authenticated caller, it is not read from the body.
+type ReceivePurchaseOrder struct {
+	POID       string          `json:"-"`
+	ReceivedBy string          `json:"-"`
+	Note       string          `json:"note"`
+	Lines      []POLineRequest `json:"lines"`
+}
+
+type POFilter struct {
+	SupplierID int64
+	BranchID   int64
+	Status     string
+}
+
+// OutstandingPOLine is a PO line not yet fully received
+type OutstandingPOLine struct {
+	POID             string       `json:"po_id"`
+	SupplierCode     string       `json:"supplier_code"`
+	SupplierName     string       `json:"supplier_name"`
+	PODate           string       `json:"po_date"`
+	POExpectedDate   string       `json:"po_expected_date"`
+	POStatus         string       `json:"po_status"`
+	StockCode        string       `json:"stock_code"`
+	QtyOrdered       int          `json:"qty_ordered"`
+	QtyReceived      int          `json:"qty_received"`
+	QtyOutstanding   int          `json:"qty_outstanding"`
+	UnitCost         entity.Money `json:"unit_cost"`
+	OutstandingValue entity.Money `json:"outstanding_value"`
+	Overdue          bool         `gorm:"-" json:"overdue"`
+}
+
+type OutstandingPOReport struct {
+	AsOf         string              `json:"as_of"`
+	TotalValue   entity.Money        `json:"total_value"`
+	OverdueLines int                 `json:"overdue_lines"`
+	Lines        []OutstandingPOLine `json:"lines"`
+}
+
+func (Supplier) TableName() string {
+	return "supplier"
+}
+
+func (PurchaseOrder) TableName() string {
+	return "purchase_order"
+}
+
+func (PurchaseOrderLine) TableName() string {
+	return "purchase_order_line"
+}
+
+func (POReceipt) TableName() string {
+	return "po_receipt"
+}
@@ -1,26 +1,148 @@
 package goldgym
 
+import (
+	"errors"
+	"gold-gym-be/internal/entity"
+	"time"
+)
+
+// ErrNegativeStock is returned when an adjustment would take stock_qty below zero
+var ErrNegativeStock = errors.New("stock quantity cannot go negative")
+
+// Movement types in the stock ledger
+const (
+	MovementRestock    = "restock"
+	MovementSale       = "sale"
+	MovementAdjustment = "adjustment"
+	MovementWriteOff   = "writeoff"
+	MovementTransfer   = "transfer"
+	MovementReturn     = "return"
+)
+
 type GetOneStock struct {
-	StockID         string  `db:"stock_id" json:"stock_id"`
-	StockCode       string  `db:"stock_code" json:"stock_code"`
-	StockName       string  `db:"stock_name" json:"stock_name"`
-	StockPack       string  `db:"stock_pack" json:"stock_pack"`
-	StockQTY        int     `db:"stock_qty" json:"stock_qty"`
-	StockPrice      float32 `db:"stock_price" json:"stock_price"`
-	StockLastUpdate string  `db:"stock_last_update" json:"stock_last_update"`
-	StockUpdateBy   string  `db:"stock_update_by" json:"stock_update_by"`
+	StockID         string       `db:"stock_id" json:"stock_id"`
+	StockCode       string       `db:"stock_code" json:"stock_code"`
+	StockName       string       `db:"stock_name" json:"stock_name"`
+	StockPack       string       `db:"stock_pack" json:"stock_pack"`
+	StockQTY        int          `db:"stock_qty" json:"stock_qty"`
+	StockPrice      entity.Money `db:"stock_price" json:"stock_price"`
+	StockLastUpdate string       `db:"stock_last_update" json:"stock_last_update"`
+	StockUpdateBy   string       `db:"stock_update_by" json:"stock_update_by"`
+	// StockCostPrice is the weighted average cost of the units on hand
+	StockCostPrice entity.Money `db:"stock_cost_price" json:"stock_cost_price"`
+
+	StockReorderPoint int `db:"stock_reorder_point" json:"stock_reorder_point"`
+	StockReorderQty   int `db:"stock_reorder_qty" json:"stock_reorder_qty"`
+	// StockSuggestedOrder is computed, not stored: how much to buy now
+	StockSuggestedOrder int `gorm:"-" db:"-" json:"stock_suggested_order"`
+	// StockBatches are the lots still in stock, filled by getonestock only
+	StockBatches []StockBatch `gorm:"-" db:"-" json:"stock_batches,omitempty"`
+
+	// catalogue: the product this stock row is a variant of, if any
+	StockProductCode string   `db:"stock_product_code" json:"stock_product_code"`
+	StockFlavour     string   `db:"stock_flavour" json:"stock_flavour"`
+	StockSize        string   `db:"stock_size" json:"stock_size"`
+	StockBarcodes    []string `gorm:"-" db:"-" json:"stock_barcodes,omitempty"`
 }
 
 type InsertStock struct {
-	StockID       string  `db:"stock_id" json:"stock_id"`
-	StockCode     string  `db:"stock_code" json:"stock_code"`
-	StockName     string  `db:"stock_name" json:"stock_name"`
-	StockPack     string  `db:"stock_pack" json:"stock_pack"`
-	StockQTY      int     `db:"stock_qty" json:"stock_qty"`
-	StockPrice    float32 `db:"stock_price" json:"stock_price"`
-	StockUpdateBy string  `db:"stock_update_by" json:"stock_update_by"`
+	StockID       string       `db:"stock_id" json:"stock_id"`
+	StockCode     string       `db:"stock_code" json:"stock_code"`
+	StockName     string       `db:"stock_name" json:"stock_name"`
+	StockPack     string       `db:"stock_pack" json:"stock_pack"`
+	StockQTY      int          `db:"stock_qty" json:"stock_qty"`
+	StockPrice    entity.Money `db:"stock_price" json:"stock_price"`
+	StockUpdateBy string       `db:"stock_update_by" json:"stock_update_by"`
+	// StockBranchID is the branch the restock arrives at
+	StockBranchID int64 `db:"-" json:"stock_branch_id"`
 }
 
 type InsertStockData struct {
 	StockData InsertStock `json:"data"`
 }
+
+// StockMovement is one row of the stock ledger. MovementQty is signed, the sum
+// of all movements of a stock_code is its stock_qty, and the sum per
+// MovementBranchID what that branch has on hand.
+type StockMovement struct {
+	MovementID       int64     `gorm:"column:movement_id;primaryKey;autoIncrement" db:"movement_id" json:"movement_id"`
+	StockCode        string    `gorm:"column:stock_code" db:"stock_code" json:"stock_code"`
+	MovementBranchID int64     `gorm:"column:movement_branch_id" db:"movement_branch_id" json:"movement_branch_id"`
+	MovementType     string    `gorm:"column:movement_type" db:"movement_type" json:"movement_type"`
+	MovementQty      int       `gorm:"column:movement_qty" db:"movement_qty" json:"movement_qty"`
+	MovementReason   string    `gorm:"column:movement_reason" db:"movement_reason" json:"movement_reason"`
+	MovementRef      string    `gorm:"column:movement_ref" db:"movement_ref" json:"movement_ref"`
+	MovementBy       string    `gorm:"column:movement_by" db:"movement_by" json:"movement_by"`
+	MovementAt       time.Time `gorm:"column:movement_at" db:"movement_at" json:"movement_at"`
+}
+
+// StockTransfer moves stock from one branch to another. The batches go with
+// it, first expiry first, so the receiving branch sells the same lots.
+// TransferBy is the authenticated caller, it is not read from the body.
+type StockTransfer struct {
+	StockCode    string `json:"stock_code"`
+	FromBranchID int64  `json:"from_branch_id"`
+	ToBranchID   int64  `json:"to_branch_id"`
+	Qty          int    `json:"qty"`
+	Reason       string `json:"reason"`
+	TransferBy   string `json:"-"`
+}
+
+// StockCardLine is a movement with the balance after it
+type StockCardLine struct {
+	StockMovement
+	Balance int `json:"balance"`
+}
+
+// StockCard is the movement report of one stock_code over a date range, of
+// one branch when BranchID is not zero
+type StockCard struct {
+	StockCode  string          `json:"stock_code"`
+	BranchID   int64           `json:"branch_id"`
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	OpeningQty int             `json:"opening_qty"`
+	InQty      int             `json:"in_qty"`
+	OutQty     int             `json:"out_qty"`
+	ClosingQty int             `json:"closing_qty"`
+	Lines      []StockCardLine `json:"lines"`
+}
+
+// ReorderPoint sets when (stock_qty at or below ReorderPoint) and how much
+// (ReorderQty) to reorder. A zero ReorderPoint turns the alert off.
+type ReorderPoint struct {
+	StockCode    string `json:"stock_code"`
+	ReorderPoint int    `json:"stock_reorder_point"`
+	ReorderQty   int    `json:"stock_reorder_qty"`
+}
+
+// ReorderSuggestion is one line of the suggested purchase list
+type ReorderSuggestion struct {
+	StockCode    string `json:"stock_code"`
+	StockName    string `json:"stock_name"`
+	StockPack    string `json:"stock_pack"`
+	StockQTY     int    `json:"stock_qty"`
+	ReorderPoint int    `json:"stock_reorder_point"`
+	SuggestedQty int    `json:"suggested_qty"`
+}
+
+// SuggestedOrder is how much of s to buy now, zero while stock is above the
+// reorder point. It orders ReorderQty (the reorder point when unset), or
+// enough to get back to the point if that is more.
+func (s GetOneStock) SuggestedOrder() int {
+	if s.StockReorderPoint <= 0 || s.StockQTY > s.StockReorderPoint {
+		return 0
+	}
+	qty := s.StockReorderQty
+	if qty <= 0 {
+		qty = s.StockReorderPoint
+	}
+	if shortfall := s.StockReorderPoint - s.StockQTY; shortfall > qty {
+		qty = shortfall
+	}
+	return qty
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movement"
+}
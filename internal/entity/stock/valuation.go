@@ -0,0 +1,35 @@
+package goldgym
+
+import "gold-gym-be/internal/entity"
+
+// Inventory valuation methods
+const (
+	// ValuationAverage values stock at the weighted-average cost kept in
+	// stock.stock_cost_price
+	ValuationAverage = "average"
+	// ValuationFIFO values stock at the cost of the receipt layers not yet
+	// sold or written off, oldest layers being used up first
+	ValuationFIFO = "fifo"
+)
+
+// ValidValuationMethod reports whether method is one of the valuation methods
+func ValidValuationMethod(method string) bool {
+	return method == ValuationAverage || method == ValuationFIFO
+}
+
+// InventoryValuationLine is the value of one stock code on hand
+type InventoryValuationLine struct {
+	StockCode string       `json:"stock_code"`
+	StockName string       `json:"stock_name"`
+	StockQty  int          `json:"stock_qty"`
+	UnitCost  entity.Money `json:"unit_cost"`
+	Value     entity.Money `json:"value"`
+}
+
+type InventoryValuation struct {
+	AsOf       string                   `json:"as_of"`
+	Method     string                   `json:"method"`
+	TotalQty   int                      `json:"total_qty"`
+	TotalValue entity.Money             `json:"total_value"`
+	Lines      []InventoryValuationLine `json:"lines"`
+}
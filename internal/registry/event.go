@@ -0,0 +1,268 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const datetimeLayout = "2006-01-02 15:04:05"
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// Event adalah event CDC yang sudah di-decode ke struct baris tabelnya.
+// Before kosong untuk create dan snapshot, After kosong untuk delete.
+type Event[T any] struct {
+	Op     string
+	Before *T
+	After  *T
+}
+
+// Snapshot true untuk baris hasil snapshot awal (op=r)
+func (e Event[T]) Snapshot() bool {
+	return e.Op == "r"
+}
+
+// Row mengembalikan baris terbaru: After, atau Before untuk delete
+func (e Event[T]) Row() *T {
+	if e.After != nil {
+		return e.After
+	}
+	return e.Before
+}
+
+// Register mendaftarkan handler bertipe untuk table. Kolom dicocokkan ke
+// field lewat tag db, lalu gorm column, lalu json.
+func Register[T any](r *Registry, table string, handler func(ctx context.Context, ev Event[T]) error) {
+	r.Handle(table, func(ctx context.Context, op string, after, before map[string]interface{}) error {
+		ev := Event[T]{Op: op}
+		var err error
+		if ev.After, err = decodeRow[T](after); err != nil {
+			return fmt.Errorf("decode %s after: %w", table, err)
+		}
+		if ev.Before, err = decodeRow[T](before); err != nil {
+			return fmt.Errorf("decode %s before: %w", table, err)
+		}
+		return handler(ctx, ev)
+	})
+}
+
+func decodeRow[T any](row map[string]interface{}) (*T, error) {
+	if row == nil {
+		return nil, nil
+	}
+	dst := new(T)
+	if err := DecodeRow(row, dst); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// DecodeRow mengisi struct yang ditunjuk dst dari satu baris event CDC.
+// Kolom tanpa field diabaikan.
+func DecodeRow(row map[string]interface{}, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode row: %T is not a pointer to a struct", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		column := columnName(field)
+		if column == "" {
+			continue
+		}
+		value, ok := row[column]
+		if !ok {
+			continue
+		}
+		if err := assign(v.Field(i), value); err != nil {
+			return fmt.Errorf("column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+func columnName(field reflect.StructField) string {
+	if tag := field.Tag.Get("db"); tag != "" {
+		return strings.Split(tag, ",")[0]
+	}
+	for _, part := range strings.Split(field.Tag.Get("gorm"), ";") {
+		if strings.HasPrefix(part, "column:") {
+			return strings.TrimPrefix(part, "column:")
+		}
+		if part == "-" {
+			return ""
+		}
+	}
+	if tag := field.Tag.Get("json"); tag != "" {
+		name := strings.Split(tag, ",")[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	return ""
+}
+
+// assign converts a decoded column value (int64, float64, string, bool,
+// []byte, time.Time or nil) to the type of field
+func assign(field reflect.Value, value interface{}) error {
+	if field.CanAddr() && field.Addr().Type().Implements(scannerType) && field.Type() != timeType {
+		return field.Addr().Interface().(sql.Scanner).Scan(scannable(value))
+	}
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Type() == timeType {
+		switch v := value.(type) {
+		case time.Time:
+			field.Set(reflect.ValueOf(v))
+			return nil
+		case string:
+			parsed, err := parseTime(v)
+			if err != nil {
+				return err
+			}
+			field.Set(reflect.ValueOf(parsed))
+			return nil
+		}
+		return fmt.Errorf("cannot assign %T to time.Time", value)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text(value))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("cannot assign %v to %s", value, field.Type())
+		}
+		field.SetUint(uint64(n))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(value)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+		return nil
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			field.SetBool(v)
+			return nil
+		case int64:
+			field.SetBool(v != 0)
+			return nil
+		}
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := assign(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	return fmt.Errorf("cannot assign %T to %s", value, field.Type())
+}
+
+// scannable gives a sql.Scanner the value a database driver would: times of
+// day and decimals stay strings, JSON numbers become int64 or float64
+func scannable(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case int:
+		return int64(v)
+	}
+	return value
+}
+
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(datetimeLayout)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+func toInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+	case json.Number:
+		return v.Int64()
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("cannot assign %v to an integer", value)
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("cannot assign %v to a float", value)
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, datetimeLayout, "2006-01-02 15:04:05.999999", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time", s)
+}
@@ -107,9 +107,16 @@ package registry
 
 import (
 	"context"
+	"fmt"
 	"log"
 
+	replicationData "gold-gym-be/internal/data/replication"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	replicationEntity "gold-gym-be/internal/entity/replication"
 	"gold-gym-be/internal/resources"
+	"gold-gym-be/pkg/errors"
+
+	"gorm.io/gorm"
 )
 
 // HandlerFunc adalah tipe fungsi handler CDC untuk setiap tabel
@@ -120,11 +127,116 @@ type Registry struct {
 	handlers map[string]HandlerFunc
 }
 
-// New membuat Registry baru dengan resource yang dibutuhkan
+// Database tujuan sebuah registry
+const (
+	// TargetProd menerima event dari local
+	TargetProd = replicationEntity.Prod
+	// TargetLocal menerima event dari prod
+	TargetLocal = replicationEntity.Local
+)
+
+// New membuat Registry baru dengan resource yang dibutuhkan. Event dari
+// local ditulis ke DBProd, perubahan data_peserta juga ke index member ES.
+// Nil bila DBProd belum dibuka.
 func New(res *resources.BootResources) *Registry {
-	return &Registry{
-		handlers: GetRegistry(res),
+	r, _ := ForTarget(res, TargetProd, Tables)
+	return r
+}
+
+// ForTarget membuat Registry yang menulis event ke database target, hanya
+// untuk tabel yang arah sync-nya menuju target. Index member ES dibangun
+// dari local, jadi hanya diperbarui oleh registry prod yang menerima
+// perubahan local.
+func ForTarget(res *resources.BootResources, target string, tables []replicationEntity.Table) (*Registry, error) {
+	var (
+		db     *gorm.DB
+		source string
+	)
+	switch target {
+	case TargetProd:
+		db, source = res.DBProd, TargetLocal
+	case TargetLocal:
+		db, source = res.DBLocal, TargetProd
+	default:
+		return nil, fmt.Errorf("registry: unknown target %q", target)
+	}
+	if db == nil {
+		return nil, fmt.Errorf("registry: no database for target %q", target)
 	}
+
+	var synced []replicationEntity.Table
+	for _, table := range tables {
+		if table.Into(target) {
+			synced = append(synced, table)
+		}
+	}
+
+	r := &Registry{handlers: map[string]HandlerFunc{}}
+	for table, handler := range Handlers(replicationData.New(db, target, source), synced) {
+		r.Handle(table, handler)
+	}
+	if target == TargetProd && res.SearchIndex != nil {
+		Register(r, "data_peserta", func(ctx context.Context, ev Event[elasticEntity.UserDocument]) error {
+			row := ev.Row()
+			if row == nil {
+				return nil
+			}
+			return res.SearchIndex.ProjectMember(ctx, ev.Op, *row)
+		})
+		// status, paket dan masa berlaku di dokumen member diambil dari
+		// subscription_detail, jadi dokumennya dibangun ulang dari MySQL
+		Register(r, "subscription_detail", func(ctx context.Context, ev Event[subscriptionKey]) error {
+			row := ev.Row()
+			if row == nil || row.GoldID == 0 {
+				return nil
+			}
+			return res.SearchIndex.RefreshMember(ctx, row.GoldID)
+		})
+		// dokumen stok menggabungkan produk, kategori dan barcode, jadi
+		// dibangun ulang dari MySQL setelah baris stok direplikasi
+		Register(r, "stock", func(ctx context.Context, ev Event[stockKey]) error {
+			row := ev.Row()
+			if row == nil || row.StockCode == "" {
+				return nil
+			}
+			return res.SearchIndex.RefreshStock(ctx, row.StockCode)
+		})
+		// penjualan tidak dihapus, hanya yang baru dan yang berubah di-index
+		Register(r, "sales_header", func(ctx context.Context, ev Event[saleKey]) error {
+			row := ev.Row()
+			if ev.Op == replicationEntity.OpDelete || row == nil || row.SaleID == "" {
+				return nil
+			}
+			return res.SearchIndex.RefreshSale(ctx, row.SaleID)
+		})
+	}
+	return r, nil
+}
+
+// subscriptionKey adalah kolom subscription_detail yang dibutuhkan untuk
+// memperbarui dokumen member
+type subscriptionKey struct {
+	GoldID int `db:"gold_id"`
+}
+
+// stockKey adalah kolom stock yang dibutuhkan untuk memperbarui dokumen stok
+type stockKey struct {
+	StockCode string `db:"stock_code"`
+}
+
+// saleKey adalah kolom sales_header yang dibutuhkan untuk meng-index
+// penjualan
+type saleKey struct {
+	SaleID string `db:"sale_id"`
+}
+
+// Handle mendaftarkan handler untuk table. Handler kedua dan seterusnya
+// untuk tabel yang sama dijalankan setelahnya lewat Chain.
+func (r *Registry) Handle(table string, handler HandlerFunc) {
+	if existing, ok := r.handlers[table]; ok {
+		handler = Chain(existing, handler)
+	}
+	r.handlers[table] = handler
 }
 
 // GetHandler mencari handler berdasarkan nama tabel
@@ -133,151 +245,124 @@ func (r *Registry) GetHandler(table string) (HandlerFunc, bool) {
 	return h, ok
 }
 
-// GetRegistry daftar semua table CDC → handler function
-func GetRegistry(res *resources.BootResources) map[string]HandlerFunc {
-	return map[string]HandlerFunc{
-		"users":        handleUsers(res),
-		"goldgym":      handleGoldGym(res),
-		"data_peserta": handlePeserta(res), // contoh tambahan
-	}
+// Tables daftar tabel yang direplikasi beserta arah sync dan aturan
+// konfliknya. data_peserta dan subscription diubah di kedua sisi, jadi
+// disinkronkan dua arah dan yang terakhir diubah yang menang; detail
+// langganan dan stok hanya diubah di local, jadi hanya dikirim ke prod dan
+// local selalu menang. stock_id diberikan oleh prod sendiri, baris stok
+// dicocokkan lewat stock_code. stock_qty tidak ikut dikirim: jumlahnya
+// dijaga ledger pergerakan stok di masing-masing sisi, jadi tidak ditimpa
+// oleh salinan dari local.
+var Tables = []replicationEntity.Table{
+	{
+		Name:       "data_peserta",
+		Keys:       []string{"gold_id"},
+		Timestamps: []string{"gold_updated_at"},
+		Conflict:   replicationEntity.ConflictLastWriterWins,
+		Version:    "gold_updated_at",
+		Direction:  replicationEntity.DirectionBoth,
+	},
+	{
+		Name:       "subscription",
+		Keys:       []string{"gold_id"},
+		Timestamps: []string{"gold_lastupdate"},
+		Conflict:   replicationEntity.ConflictLastWriterWins,
+		Version:    "gold_lastupdate",
+		Direction:  replicationEntity.DirectionBoth,
+	},
+	{
+		Name:       "subscription_detail",
+		Keys:       []string{"gold_id", "gold_menuid"},
+		Timestamps: []string{"gold_startdate", "gold_enddate"},
+		Conflict:   replicationEntity.ConflictSourceWins,
+		Direction:  replicationEntity.DirectionLocalToProd,
+	},
+	{
+		Name:      "stock",
+		Keys:      []string{"stock_code"},
+		Skip:      []string{"stock_id", "stock_qty"},
+		Conflict:  replicationEntity.ConflictSourceWins,
+		Direction: replicationEntity.DirectionLocalToProd,
+	},
 }
 
-// ===================== HANDLERS =====================
-
-// handler untuk tabel users
-func handleUsers(res *resources.BootResources) HandlerFunc {
-	return func(ctx context.Context, op string, after, before map[string]interface{}) error {
-		switch op {
-		case "c":
-			var err error
-			// log.Println("[CDC] users insert event", after)
-
-			// var user goldgym.GetGoldUsers
-			// raw, _ := json.Marshal(after)
-			// if err := json.Unmarshal(raw, &user); err != nil {
-			// 	return err
-			// }
-
-			// // pake service production
-			// _, err := res.GoldSvcProd.InsertGoldUser(ctx, user)
-			return err
-
-		case "u":
-			log.Println("[CDC] users update event", after)
-			// var user goldgym.GetGoldUsers
-			// raw, _ := json.Marshal(after)
-			// if err := json.Unmarshal(raw, &user); err != nil {
-			// 	return err
-			// }
-			// _, err := res.GoldSvcProd.UpdateGoldUser(ctx, user)
-			var err error
-			return err
-
-		case "d":
-			log.Println("[CDC] users delete event", before)
-			// var user goldgym.GetGoldUsers
-			// raw, _ := json.Marshal(before)
-			// if err := json.Unmarshal(raw, &user); err != nil {
-			// 	return err
-			// }
-			// return res.GoldSvcProd.DeleteGoldUser(ctx, user.ID)
-			var err error
-			return err
+// Configure mengembalikan salinan tables dengan arah sync dari directions
+// (nama tabel → arah). Tabel yang tidak disebut tetap memakai arah
+// bawaannya.
+func Configure(tables []replicationEntity.Table, directions map[string]string) ([]replicationEntity.Table, error) {
+	configured := make([]replicationEntity.Table, len(tables))
+	copy(configured, tables)
 
-		default:
-			log.Printf("[CDC] users unknown op=%s", op)
-			return nil
+	known := make(map[string]int, len(tables))
+	for i, table := range configured {
+		known[table.Name] = i
+	}
+	for name, direction := range directions {
+		i, ok := known[name]
+		if !ok {
+			return nil, errors.Wrap(replicationEntity.ErrBadTable, name)
+		}
+		if !replicationEntity.ValidDirection(direction) {
+			return nil, errors.Wrap(replicationEntity.ErrBadDirection, name+": "+direction)
 		}
+		configured[i].Direction = direction
 	}
+	return configured, nil
 }
 
-// handler untuk tabel goldgym
-func handleGoldGym(res *resources.BootResources) HandlerFunc {
-	return func(ctx context.Context, op string, after, before map[string]interface{}) error {
-		switch op {
-		case "c":
-			var err error
-			// log.Println("[CDC] goldgym insert event", after)
-
-			// var g goldgym.GetGoldUsers
-			// raw, _ := json.Marshal(after)
-			// if err := json.Unmarshal(raw, &g); err != nil {
-			// 	return err
-			// }
-
-			// _, err := res.GoldSvcLocal.InsertGoldUser(ctx, g)
-			return err
-
-		case "u":
-			log.Println("[CDC] goldgym update event", after)
-			// sama pola nya
-			return nil
+// Applier menulis satu event CDC ke database tujuan
+type Applier interface {
+	Apply(ctx context.Context, table replicationEntity.Table, op string, after, before map[string]interface{}) (string, error)
+}
 
-		case "d":
-			log.Println("[CDC] goldgym delete event", before)
-			return nil
+// GetRegistry daftar semua table CDC → handler function
+func GetRegistry(res *resources.BootResources) map[string]HandlerFunc {
+	r, err := ForTarget(res, TargetProd, Tables)
+	if err != nil {
+		return map[string]HandlerFunc{}
+	}
+	return r.handlers
+}
 
-		default:
-			log.Printf("[CDC] goldgym unknown op=%s", op)
-			return nil
+// Chain menjalankan handler berurutan dan berhenti di error pertama. Saat
+// event diulang, handler yang sudah berhasil ikut diulang, jadi semuanya
+// harus idempotent.
+func Chain(handlers ...HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, op string, after, before map[string]interface{}) error {
+		for _, handler := range handlers {
+			if err := handler(ctx, op, after, before); err != nil {
+				return err
+			}
 		}
+		return nil
+	}
+}
+
+// Handlers membuat handler untuk setiap tabel yang menulis lewat target
+func Handlers(target Applier, tables []replicationEntity.Table) map[string]HandlerFunc {
+	handlers := make(map[string]HandlerFunc, len(tables))
+	for _, table := range tables {
+		handlers[table.Name] = replicate(target, table)
 	}
+	return handlers
 }
 
-// handler untuk tabel data_peserta
-func handlePeserta(res *resources.BootResources) HandlerFunc {
+// ===================== HANDLERS =====================
+
+// replicate menerapkan create, update, snapshot dan delete ke target. Event
+// yang dikirim ulang atau datang terlambat aman diterapkan lagi, yang kalah
+// aturan konflik hanya dilewati.
+func replicate(target Applier, table replicationEntity.Table) HandlerFunc {
 	return func(ctx context.Context, op string, after, before map[string]interface{}) error {
-		var (
-		// user goldgym.GetGoldUsers
-		// keys []string
-		)
-
-		switch op {
-		case "c":
-			// log.Println("[CDC] peserta insert event", after)
-			// log.Println("[CDC] peserta insert event Two", after["gold_cvv"])
-			// // for key := range after {
-			// // 	keys = append(keys, key)
-			// // }
-			// // fmt.Println("test-keys", keys)
-
-			// if v, ok := after["gold_expireddate"]; ok {
-			// 	switch t := v.(type) {
-			// 	case float64:
-			// 		// Konversi milisecond → detik → time.Time
-			// 		tm := time.UnixMilli(int64(t))
-			// 		after["gold_expireddate"] = tm.Format("20060102") // hasil: "20230928"
-			// 	}
-			// }
-
-			// // 1. Marshal map ke JSON
-			// jsonData, err := json.Marshal(after)
-			// if err != nil {
-			// 	log.Println("Error marshal map:", err)
-			// 	return nil
-			// }
-			// // 2. Unmarshal ke struct
-			// err = json.Unmarshal(jsonData, &user)
-			// if err != nil {
-			// 	log.Println("Error unmarshal ke struct:", err)
-			// 	return nil
-			// }
-
-			// _, err = res.GoldSvcProd.InsertGoldUser(ctx, user)
-			// // TODO: mapping ke entity peserta → panggil service peserta
-			return nil
-
-		case "u":
-			log.Println("[CDC] peserta update event", after)
-			return nil
-
-		case "d":
-			log.Println("[CDC] peserta delete event", before)
-			return nil
-
-		default:
-			log.Printf("[CDC] peserta unknown op=%s", op)
-			return nil
+		outcome, err := target.Apply(ctx, table, op, after, before)
+		if err != nil {
+			return err
+		}
+		// echo dari penulisan replikasi sendiri wajar terjadi di setiap
+		// perubahan dua arah, tidak perlu dicatat
+		if outcome == replicationEntity.Skipped {
+			log.Printf("[CDC] %s op=%s skipped, target is newer or already gone", table.Name, op)
 		}
+		return nil
 	}
 }
@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	replicationEntity "gold-gym-be/internal/entity/replication"
+	"gold-gym-be/internal/resources"
+	pkgErrors "gold-gym-be/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3/zero"
+	"gorm.io/gorm"
+)
+
+// fakeApplier mencatat event yang diterapkan dan mengembalikan hasil tetap
+type fakeApplier struct {
+	outcome string
+	err     error
+	applied []string
+}
+
+func (f *fakeApplier) Apply(ctx context.Context, table replicationEntity.Table, op string, after, before map[string]interface{}) (string, error) {
+	f.applied = append(f.applied, table.Name+":"+op)
+	return f.outcome, f.err
+}
+
+func TestHandlers(t *testing.T) {
+	target := &fakeApplier{outcome: replicationEntity.Applied}
+	handlers := Handlers(target, Tables)
+
+	for _, table := range []string{"data_peserta", "subscription", "subscription_detail", "stock"} {
+		h, ok := handlers[table]
+		require.True(t, ok, table)
+		require.NoError(t, h(context.Background(), "u", map[string]interface{}{}, nil))
+	}
+	assert.Equal(t, []string{"data_peserta:u", "subscription:u", "subscription_detail:u", "stock:u"}, target.applied)
+
+	// event yang kalah aturan konflik bukan error
+	target.outcome = replicationEntity.Skipped
+	assert.NoError(t, handlers["stock"](context.Background(), "d", nil, map[string]interface{}{}))
+
+	target.err = errors.New("db down")
+	assert.Error(t, handlers["stock"](context.Background(), "c", map[string]interface{}{}, nil))
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	step := func(name string, err error) HandlerFunc {
+		return func(ctx context.Context, op string, after, before map[string]interface{}) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	require.NoError(t, Chain(step("replikasi", nil), step("index", nil))(context.Background(), "u", nil, nil))
+	assert.Equal(t, []string{"replikasi", "index"}, calls)
+
+	// berhenti di error pertama
+	calls = nil
+	assert.Error(t, Chain(step("replikasi", errors.New("db down")), step("index", nil))(context.Background(), "u", nil, nil))
+	assert.Equal(t, []string{"replikasi"}, calls)
+}
+
+type pesertaRow struct {
+	GoldID        int          `db:"gold_id"`
+	GoldNama      string       `gorm:"column:gold_nama"`
+	GoldUpdatedAt string       `json:"gold_updated_at"`
+	GoldLahir     time.Time    `db:"gold_tanggal_lahir"`
+	GoldHarga     entity.Money `db:"gold_harga"`
+	GoldOTP       zero.String  `db:"gold_otp"`
+	GoldAktif     bool         `db:"gold_aktif"`
+	GoldBerat     float64      `db:"gold_berat"`
+	GoldCabang    *int64       `db:"gold_branch_id"`
+	Abaikan       string       `gorm:"-"`
+}
+
+func TestRegister(t *testing.T) {
+	updated := time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+	r := &Registry{handlers: map[string]HandlerFunc{}}
+
+	var got []Event[pesertaRow]
+	Register(r, "data_peserta", func(ctx context.Context, ev Event[pesertaRow]) error {
+		got = append(got, ev)
+		return nil
+	})
+	h, ok := r.GetHandler("data_peserta")
+	require.True(t, ok)
+
+	after := map[string]interface{}{
+		"gold_id":            int64(7),
+		"gold_nama":          "Budi",
+		"gold_updated_at":    updated,
+		"gold_tanggal_lahir": "2000-01-02",
+		"gold_harga":         "25000.00",
+		"gold_otp":           "1234",
+		"gold_aktif":         int64(1),
+		"gold_berat":         "70.5",
+		"gold_branch_id":     int64(2),
+		"kolom_baru":         "tidak ada field-nya",
+	}
+	require.NoError(t, h(context.Background(), "r", after, nil))
+	require.Len(t, got, 1)
+	ev := got[0]
+	assert.True(t, ev.Snapshot())
+	assert.Nil(t, ev.Before)
+	assert.Same(t, ev.After, ev.Row())
+	assert.Equal(t, pesertaRow{
+		GoldID:        7,
+		GoldNama:      "Budi",
+		GoldUpdatedAt: "2026-10-19 09:30:00",
+		GoldLahir:     time.Date(2000, 1, 2, 0, 0, 0, 0, time.Local),
+		GoldHarga:     entity.NewMoney(25000),
+		GoldOTP:       zero.StringFrom("1234"),
+		GoldAktif:     true,
+		GoldBerat:     70.5,
+		GoldCabang:    func() *int64 { n := int64(2); return &n }(),
+	}, *ev.After)
+
+	// delete hanya membawa before
+	require.NoError(t, h(context.Background(), "d", nil, map[string]interface{}{"gold_id": int64(7), "gold_otp": nil}))
+	assert.Nil(t, got[1].After)
+	assert.Equal(t, 7, got[1].Row().GoldID)
+	assert.False(t, got[1].Row().GoldOTP.Valid)
+
+	// tipe yang tidak cocok menjadi error, bukan panic
+	assert.Error(t, h(context.Background(), "u", map[string]interface{}{"gold_id": "tujuh"}, nil))
+}
+
+func TestHandleChainsSameTable(t *testing.T) {
+	r := &Registry{handlers: map[string]HandlerFunc{}}
+	var calls []string
+	r.Handle("stock", func(ctx context.Context, op string, after, before map[string]interface{}) error {
+		calls = append(calls, "replikasi")
+		return nil
+	})
+	Register(r, "stock", func(ctx context.Context, ev Event[struct {
+		StockCode string `db:"stock_code"`
+	}]) error {
+		calls = append(calls, "typed "+ev.After.StockCode)
+		return nil
+	})
+
+	h, _ := r.GetHandler("stock")
+	require.NoError(t, h(context.Background(), "c", map[string]interface{}{"stock_code": "BRG-01"}, nil))
+	assert.Equal(t, []string{"replikasi", "typed BRG-01"}, calls)
+}
+
+func TestForTarget(t *testing.T) {
+	// target tanpa database ditolak, begitu juga target yang tidak dikenal
+	_, err := ForTarget(&resources.BootResources{}, TargetProd, Tables)
+	assert.EqualError(t, err, `registry: no database for target "prod"`)
+	_, err = ForTarget(&resources.BootResources{DBLocal: &gorm.DB{}}, "staging", Tables)
+	assert.EqualError(t, err, `registry: unknown target "staging"`)
+
+	// hanya tabel yang arah sync-nya menuju local
+	r, err := ForTarget(&resources.BootResources{DBLocal: &gorm.DB{}}, TargetLocal, Tables)
+	require.NoError(t, err)
+	for table, want := range map[string]bool{"data_peserta": true, "subscription": true, "subscription_detail": false, "stock": false} {
+		_, ok := r.GetHandler(table)
+		assert.Equal(t, want, ok, table)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	tables, err := Configure(Tables, map[string]string{
+		"stock":        replicationEntity.DirectionBoth,
+		"subscription": replicationEntity.DirectionNone,
+	})
+	require.NoError(t, err)
+	directions := map[string]string{}
+	for _, table := range tables {
+		directions[table.Name] = table.Direction
+	}
+	assert.Equal(t, map[string]string{
+		"data_peserta":        replicationEntity.DirectionBoth,
+		"subscription":        replicationEntity.DirectionNone,
+		"subscription_detail": replicationEntity.DirectionLocalToProd,
+		"stock":               replicationEntity.DirectionBoth,
+	}, directions)
+	// Tables sendiri tidak berubah
+	assert.Equal(t, replicationEntity.DirectionLocalToProd, Tables[3].Direction)
+
+	_, err = Configure(Tables, map[string]string{"users": replicationEntity.DirectionBoth})
+	assert.Equal(t, replicationEntity.ErrBadTable, pkgErrors.Cause(err))
+	_, err = Configure(Tables, map[string]string{"stock": "sideways"})
+	assert.Equal(t, replicationEntity.ErrBadDirection, pkgErrors.Cause(err))
+}
@@ -1,6 +1,7 @@
 package resources
 
 import (
+	elasticService "gold-gym-be/internal/service/elastic"
 	"gold-gym-be/internal/service/goldgym" // sesuaikan path
 
 	"github.com/go-redis/redis/v8"
@@ -16,6 +17,9 @@ type BootResources struct {
 	Redis        *redis.Client
 	GoldSvcLocal goldgym.Service
 	GoldSvcProd  goldgym.Service
-	Tracer       opentracing.Tracer
-	Logger       *zap.Logger
+	// SearchIndex keeps the ES member, stock and sales indices in sync, nil
+	// leaves them alone
+	SearchIndex *elasticService.Service
+	Tracer      opentracing.Tracer
+	Logger      *zap.Logger
 }
@@ -0,0 +1,42 @@
+package branch
+
+import (
+	"context"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	jaegerLog "gold-gym-be/pkg/log"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Data ...
+// Masukkan function dari package data ke dalam interface ini
+type Data interface {
+	InsertBranch(ctx context.Context, branch branchEntity.Branch) (int64, error)
+	GetBranches(ctx context.Context) ([]branchEntity.Branch, error)
+	GetBranchByID(ctx context.Context, branchID int64) (branchEntity.Branch, error)
+	GetBranchByCode(ctx context.Context, code string) (branchEntity.Branch, error)
+	GetMemberAccess(ctx context.Context, email string) (branchEntity.MemberAccess, error)
+	UpdateMemberBranch(ctx context.Context, goldID int, branchID int64) error
+	InsertCheckin(ctx context.Context, checkin branchEntity.Checkin) (int64, error)
+	GetCheckins(ctx context.Context, filter branchEntity.CheckinFilter) ([]branchEntity.Checkin, error)
+}
+
+// Service ...
+type Service struct {
+	branch Data
+	now    func() time.Time
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(branchData Data, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
+	return Service{
+		branch: branchData,
+		now:    time.Now,
+		tracer: tracer,
+		logger: logger,
+	}
+}
@@ -0,0 +1,134 @@
+package branch
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	"gold-gym-be/pkg/errors"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// CreateBranch opens a new branch, branch_code must be unique
+func (s Service) CreateBranch(ctx context.Context, branch branchEntity.Branch) (branchEntity.Branch, error) {
+	branch.BranchCode = strings.ToUpper(strings.TrimSpace(branch.BranchCode))
+	branch.BranchName = strings.TrimSpace(branch.BranchName)
+	if branch.BranchCode == "" || branch.BranchName == "" {
+		return branch, errors.Wrap(entity.ErrInvalid, "branch_code and branch_name are required")
+	}
+
+	_, err := s.branch.GetBranchByCode(ctx, branch.BranchCode)
+	if err == nil {
+		return branch, errors.Wrap(branchEntity.ErrDuplicateBranch, branch.BranchCode)
+	}
+	if errors.Cause(err) != entity.ErrNotFound {
+		return branch, errors.Wrap(err, "[SERVICE][CreateBranch]")
+	}
+
+	branch.BranchID = 0
+	branch.BranchActive = true
+	branch.BranchID, err = s.branch.InsertBranch(ctx, branch)
+	if err != nil {
+		return branch, errors.Wrap(err, "[SERVICE][CreateBranch]")
+	}
+	return branch, nil
+}
+
+func (s Service) ListBranches(ctx context.Context) ([]branchEntity.Branch, error) {
+	branches, err := s.branch.GetBranches(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][ListBranches]")
+	}
+	return branches, nil
+}
+
+// CheckIn lets the member with email in at branchID, the main branch when it
+// is zero. The member needs a running subscription, and one on an all-branch
+// package to enter anywhere but their home branch.
+func (s Service) CheckIn(ctx context.Context, email string, branchID int64) (branchEntity.Checkin, error) {
+	var checkin branchEntity.Checkin
+
+	branchID, err := s.openBranch(ctx, branchID)
+	if err != nil {
+		return checkin, errors.Wrap(err, "[SERVICE][CheckIn]")
+	}
+	member, err := s.branch.GetMemberAccess(ctx, email)
+	if err != nil {
+		return checkin, errors.Wrap(err, "[SERVICE][CheckIn]")
+	}
+	if !member.Active {
+		return checkin, errors.Wrap(branchEntity.ErrNoActiveSubscription, email)
+	}
+	if !member.CanEnter(branchID) {
+		return checkin, errors.Wrap(branchEntity.ErrNoBranchAccess, email)
+	}
+
+	checkin = branchEntity.Checkin{
+		GoldID:    member.GoldID,
+		BranchID:  branchID,
+		CheckinAt: s.now(),
+	}
+	checkin.CheckinID, err = s.branch.InsertCheckin(ctx, checkin)
+	if err != nil {
+		return checkin, errors.Wrap(err, "[SERVICE][CheckIn]")
+	}
+	return checkin, nil
+}
+
+// ListCheckins returns the check-ins of one day, today when no date is
+// given, of one branch when BranchID is not zero
+func (s Service) ListCheckins(ctx context.Context, filter branchEntity.CheckinFilter) ([]branchEntity.Checkin, error) {
+	if filter.Date == "" {
+		filter.Date = s.now().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, filter.Date); err != nil {
+		return nil, errors.Wrap(entity.ErrInvalid, "date must be YYYY-MM-DD")
+	}
+
+	checkins, err := s.branch.GetCheckins(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][ListCheckins]")
+	}
+	return checkins, nil
+}
+
+// SetHomeBranch moves a member to another open branch
+func (s Service) SetHomeBranch(ctx context.Context, home branchEntity.HomeBranch) (string, error) {
+	var result string
+
+	if home.GoldID <= 0 || home.BranchID <= 0 {
+		result = "Gagal"
+		return result, errors.Wrap(entity.ErrInvalid, "gold_id and branch_id are required")
+	}
+	if _, err := s.openBranch(ctx, home.BranchID); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[SERVICE][SetHomeBranch]")
+	}
+	if err := s.branch.UpdateMemberBranch(ctx, home.GoldID, home.BranchID); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[SERVICE][SetHomeBranch]")
+	}
+	result = "Berhasil"
+	return result, nil
+}
+
+// openBranch resolves branchID, the main branch when zero, and refuses an
+// unknown or closed branch as invalid input
+func (s Service) openBranch(ctx context.Context, branchID int64) (int64, error) {
+	if branchID == 0 {
+		branchID = branchEntity.MainBranchID
+	}
+	branch, err := s.branch.GetBranchByID(ctx, branchID)
+	if err != nil {
+		if errors.Cause(err) == entity.ErrNotFound {
+			return 0, errors.Wrap(entity.ErrInvalid, "unknown branch_id")
+		}
+		return 0, err
+	}
+	if !branch.BranchActive {
+		return 0, errors.Wrap(entity.ErrInvalid, "branch is closed")
+	}
+	return branchID, nil
+}
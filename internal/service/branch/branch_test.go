@@ -0,0 +1,194 @@
+package branch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	pkgErrors "gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeData menyimpan cabang, member dan check-in di memory
+type fakeData struct {
+	branches map[int64]branchEntity.Branch
+	members  map[string]branchEntity.MemberAccess
+	checkins []branchEntity.Checkin
+}
+
+func (f *fakeData) InsertBranch(ctx context.Context, branch branchEntity.Branch) (int64, error) {
+	branch.BranchID = int64(len(f.branches) + 1)
+	f.branches[branch.BranchID] = branch
+	return branch.BranchID, nil
+}
+
+func (f *fakeData) GetBranches(ctx context.Context) ([]branchEntity.Branch, error) {
+	var branches []branchEntity.Branch
+	for id := int64(1); id <= int64(len(f.branches)); id++ {
+		branches = append(branches, f.branches[id])
+	}
+	return branches, nil
+}
+
+func (f *fakeData) GetBranchByID(ctx context.Context, branchID int64) (branchEntity.Branch, error) {
+	if branch, ok := f.branches[branchID]; ok {
+		return branch, nil
+	}
+	return branchEntity.Branch{}, pkgErrors.Wrap(entity.ErrNotFound, "branch")
+}
+
+func (f *fakeData) GetBranchByCode(ctx context.Context, code string) (branchEntity.Branch, error) {
+	for _, branch := range f.branches {
+		if branch.BranchCode == code {
+			return branch, nil
+		}
+	}
+	return branchEntity.Branch{}, pkgErrors.Wrap(entity.ErrNotFound, "branch")
+}
+
+func (f *fakeData) GetMemberAccess(ctx context.Context, email string) (branchEntity.MemberAccess, error) {
+	if member, ok := f.members[email]; ok {
+		return member, nil
+	}
+	return branchEntity.MemberAccess{}, pkgErrors.Wrap(entity.ErrNotFound, email)
+}
+
+func (f *fakeData) UpdateMemberBranch(ctx context.Context, goldID int, branchID int64) error {
+	for email, member := range f.members {
+		if member.GoldID == goldID {
+			member.HomeBranchID = branchID
+			f.members[email] = member
+			return nil
+		}
+	}
+	return pkgErrors.Wrap(entity.ErrNotFound, "member")
+}
+
+func (f *fakeData) InsertCheckin(ctx context.Context, checkin branchEntity.Checkin) (int64, error) {
+	checkin.CheckinID = int64(len(f.checkins) + 1)
+	f.checkins = append(f.checkins, checkin)
+	return checkin.CheckinID, nil
+}
+
+func (f *fakeData) GetCheckins(ctx context.Context, filter branchEntity.CheckinFilter) ([]branchEntity.Checkin, error) {
+	var checkins []branchEntity.Checkin
+	for _, checkin := range f.checkins {
+		if filter.BranchID != 0 && checkin.BranchID != filter.BranchID {
+			continue
+		}
+		if checkin.CheckinAt.Format(dateLayout) == filter.Date {
+			checkins = append(checkins, checkin)
+		}
+	}
+	return checkins, nil
+}
+
+func newTestService() (Service, *fakeData) {
+	data := &fakeData{
+		branches: map[int64]branchEntity.Branch{
+			1: {BranchID: 1, BranchCode: "MAIN", BranchName: "Gold Gym", BranchActive: true},
+			2: {BranchID: 2, BranchCode: "NORTH", BranchName: "Gold Gym North", BranchActive: true},
+			3: {BranchID: 3, BranchCode: "OLD", BranchName: "Gold Gym Lama"},
+		},
+		members: map[string]branchEntity.MemberAccess{
+			"home@mail.com":    {GoldID: 1, GoldEmail: "home@mail.com", HomeBranchID: 1, Access: branchEntity.AccessHome, Active: true},
+			"all@mail.com":     {GoldID: 2, GoldEmail: "all@mail.com", HomeBranchID: 1, Access: branchEntity.AccessAll, Active: true},
+			"expired@mail.com": {GoldID: 3, GoldEmail: "expired@mail.com", HomeBranchID: 1, Access: branchEntity.AccessHome},
+		},
+	}
+	svc := New(data, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 6, 15, 0, 0, time.Local) }
+	return svc, data
+}
+
+func TestCreateBranch(t *testing.T) {
+	svc, _ := newTestService()
+	ctx := context.Background()
+
+	branch, err := svc.CreateBranch(ctx, branchEntity.Branch{BranchCode: " south ", BranchName: "Gold Gym South"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), branch.BranchID)
+	assert.Equal(t, "SOUTH", branch.BranchCode)
+	assert.True(t, branch.BranchActive)
+
+	_, err = svc.CreateBranch(ctx, branchEntity.Branch{BranchCode: "north", BranchName: "Dobel"})
+	assert.Equal(t, branchEntity.ErrDuplicateBranch, pkgErrors.Cause(err))
+
+	_, err = svc.CreateBranch(ctx, branchEntity.Branch{BranchCode: "EAST"})
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+
+	branches, err := svc.ListBranches(ctx)
+	require.NoError(t, err)
+	assert.Len(t, branches, 4)
+}
+
+func TestCheckIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		branchID int64
+		want     error
+	}{
+		{"cabang rumah", "home@mail.com", 1, nil},
+		{"tanpa cabang berarti cabang utama", "home@mail.com", 0, nil},
+		{"paket semua cabang", "all@mail.com", 2, nil},
+		{"paket cabang rumah di cabang lain", "home@mail.com", 2, branchEntity.ErrNoBranchAccess},
+		{"langganan habis", "expired@mail.com", 1, branchEntity.ErrNoActiveSubscription},
+		{"cabang tutup", "all@mail.com", 3, entity.ErrInvalid},
+		{"cabang tidak dikenal", "all@mail.com", 9, entity.ErrInvalid},
+		{"member tidak dikenal", "nope@mail.com", 1, entity.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, data := newTestService()
+			checkin, err := svc.CheckIn(context.Background(), tt.email, tt.branchID)
+			if tt.want != nil {
+				assert.Equal(t, tt.want, pkgErrors.Cause(err))
+				assert.Empty(t, data.checkins)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), checkin.CheckinID)
+			assert.Equal(t, data.members[tt.email].GoldID, checkin.GoldID)
+			assert.NotZero(t, checkin.BranchID)
+		})
+	}
+}
+
+func TestListCheckinsAndSetHomeBranch(t *testing.T) {
+	svc, data := newTestService()
+	ctx := context.Background()
+
+	// pindah cabang rumah, lalu boleh masuk di cabang baru
+	result, err := svc.SetHomeBranch(ctx, branchEntity.HomeBranch{GoldID: 1, BranchID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "Berhasil", result)
+	assert.Equal(t, int64(2), data.members["home@mail.com"].HomeBranchID)
+
+	_, err = svc.CheckIn(ctx, "home@mail.com", 2)
+	require.NoError(t, err)
+	_, err = svc.CheckIn(ctx, "all@mail.com", 1)
+	require.NoError(t, err)
+
+	checkins, err := svc.ListCheckins(ctx, branchEntity.CheckinFilter{BranchID: 2})
+	require.NoError(t, err)
+	require.Len(t, checkins, 1)
+	assert.Equal(t, 1, checkins[0].GoldID)
+
+	checkins, err = svc.ListCheckins(ctx, branchEntity.CheckinFilter{Date: "2026-10-19"})
+	require.NoError(t, err)
+	assert.Len(t, checkins, 2)
+
+	_, err = svc.ListCheckins(ctx, branchEntity.CheckinFilter{Date: "19-10-2026"})
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+
+	_, err = svc.SetHomeBranch(ctx, branchEntity.HomeBranch{GoldID: 1, BranchID: 3})
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+	_, err = svc.SetHomeBranch(ctx, branchEntity.HomeBranch{GoldID: 99, BranchID: 2})
+	assert.Equal(t, entity.ErrNotFound, pkgErrors.Cause(err))
+}
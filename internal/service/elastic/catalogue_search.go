@@ -0,0 +1,74 @@
+package elastic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
+)
+
+// maxReportDays bounds the period of a sales report, it has a daily bucket
+// for every day of it
+const maxReportDays = 366
+
+// reportDateLayout is the format of the sales report period bounds
+const reportDateLayout = "2006-01-02"
+
+// SuggestStock returns the stock variants the POS can offer for what was
+// typed so far: name, product name and code by prefix, barcodes exactly.
+// Size defaults to DefaultSuggestSize.
+func (s *Service) SuggestStock(ctx context.Context, search elasticEntity.StockSearch) ([]elasticEntity.StockDocument, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Size == 0 {
+		search.Size = elasticEntity.DefaultSuggestSize
+	}
+	switch {
+	case search.Query == "":
+		return nil, errors.Wrap(entity.ErrInvalid, "query is required")
+	case search.Size < 0 || search.Size > elasticEntity.MaxSuggestSize:
+		return nil, errors.Wrap(entity.ErrInvalid, fmt.Sprintf("size must be between 1 and %d", elasticEntity.MaxSuggestSize))
+	}
+
+	docs, err := s.elastic.SuggestStock(ctx, s.indices.Stock, search)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][SuggestStock]")
+	}
+	return docs, nil
+}
+
+// SalesReport aggregates the completed sales of a period: top sellers by
+// units sold, revenue per day and revenue per salesperson. Top defaults to
+// DefaultTopSellers and the period spans at most maxReportDays.
+func (s *Service) SalesReport(ctx context.Context, filter elasticEntity.SalesReportFilter) (elasticEntity.SalesReport, error) {
+	filter.Salesperson = strings.TrimSpace(filter.Salesperson)
+	if filter.Top == 0 {
+		filter.Top = elasticEntity.DefaultTopSellers
+	}
+
+	from, err := time.Parse(reportDateLayout, filter.From)
+	if err != nil {
+		return elasticEntity.SalesReport{}, errors.Wrap(entity.ErrInvalid, "from must be YYYY-MM-DD")
+	}
+	to, err := time.Parse(reportDateLayout, filter.To)
+	if err != nil {
+		return elasticEntity.SalesReport{}, errors.Wrap(entity.ErrInvalid, "to must be YYYY-MM-DD")
+	}
+	switch {
+	case to.Before(from):
+		return elasticEntity.SalesReport{}, errors.Wrap(entity.ErrInvalid, "to is before from")
+	case to.Sub(from) >= maxReportDays*24*time.Hour:
+		return elasticEntity.SalesReport{}, errors.Wrap(entity.ErrInvalid, fmt.Sprintf("the period spans more than %d days", maxReportDays))
+	case filter.Top < 0 || filter.Top > elasticEntity.MaxTopSellers:
+		return elasticEntity.SalesReport{}, errors.Wrap(entity.ErrInvalid, fmt.Sprintf("top must be between 1 and %d", elasticEntity.MaxTopSellers))
+	}
+
+	report, err := s.elastic.SalesReport(ctx, s.indices.Sales, filter)
+	if err != nil {
+		return report, errors.Wrap(err, "[SERVICE][SalesReport]")
+	}
+	return report, nil
+}
@@ -0,0 +1,91 @@
+package elastic
+
+import (
+	"context"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	pkgErrors "gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogueES mencatat pencarian stok dan laporan terakhir yang diteruskan
+// ke data layer
+type catalogueES struct {
+	RepoData
+	index  string
+	search elasticEntity.StockSearch
+	filter elasticEntity.SalesReportFilter
+}
+
+func (f *catalogueES) SuggestStock(ctx context.Context, index string, search elasticEntity.StockSearch) ([]elasticEntity.StockDocument, error) {
+	f.index, f.search = index, search
+	return []elasticEntity.StockDocument{{StockCode: "WHEY-1"}}, nil
+}
+
+func (f *catalogueES) SalesReport(ctx context.Context, index string, filter elasticEntity.SalesReportFilter) (elasticEntity.SalesReport, error) {
+	f.index, f.filter = index, filter
+	return elasticEntity.SalesReport{From: filter.From, To: filter.To, Sales: 3}, nil
+}
+
+func TestSuggestStock(t *testing.T) {
+	es := &catalogueES{}
+	svc := New(es, nil, nil, nil, elasticEntity.Indices{Stock: "pos-stock"}, nil, jaegerLog.Factory{})
+
+	docs, err := svc.SuggestStock(context.Background(), elasticEntity.StockSearch{Query: "  whey "})
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+	assert.Equal(t, "pos-stock", es.index)
+	assert.Equal(t, "whey", es.search.Query)
+	assert.Equal(t, elasticEntity.DefaultSuggestSize, es.search.Size)
+
+	for _, search := range []elasticEntity.StockSearch{
+		{Query: " "},
+		{Query: "whey", Size: elasticEntity.MaxSuggestSize + 1},
+		{Query: "whey", Size: -1},
+	} {
+		_, err := svc.SuggestStock(context.Background(), search)
+		assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err), "%+v", search)
+	}
+}
+
+func TestSalesReport(t *testing.T) {
+	es := &catalogueES{}
+	svc := New(es, nil, nil, nil, elasticEntity.Indices{}, nil, jaegerLog.Factory{})
+
+	report, err := svc.SalesReport(context.Background(), elasticEntity.SalesReportFilter{From: "2026-10-01", To: "2026-10-19", Salesperson: " andi "})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Sales)
+	assert.Equal(t, elasticEntity.DefaultSalesIndex, es.index)
+	assert.Equal(t, elasticEntity.DefaultTopSellers, es.filter.Top)
+	assert.Equal(t, "andi", es.filter.Salesperson)
+
+	// satu tahun kabisat penuh masih boleh
+	_, err = svc.SalesReport(context.Background(), elasticEntity.SalesReportFilter{From: "2028-01-01", To: "2028-12-31"})
+	require.NoError(t, err)
+}
+
+func TestSalesReportInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter elasticEntity.SalesReportFilter
+	}{
+		{"tanpa from", elasticEntity.SalesReportFilter{To: "2026-10-19"}},
+		{"to bukan tanggal", elasticEntity.SalesReportFilter{From: "2026-10-01", To: "19-10-2026"}},
+		{"periode terbalik", elasticEntity.SalesReportFilter{From: "2026-10-19", To: "2026-10-01"}},
+		{"periode terlalu panjang", elasticEntity.SalesReportFilter{From: "2025-01-01", To: "2026-10-19"}},
+		{"top terlalu besar", elasticEntity.SalesReportFilter{From: "2026-10-01", To: "2026-10-19", Top: elasticEntity.MaxTopSellers + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := &catalogueES{}
+			_, err := New(es, nil, nil, nil, elasticEntity.Indices{}, nil, jaegerLog.Factory{}).SalesReport(context.Background(), tt.filter)
+			assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+			assert.Empty(t, es.index, "ES tidak dipanggil")
+		})
+	}
+}
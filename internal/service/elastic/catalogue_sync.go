@@ -0,0 +1,115 @@
+package elastic
+
+import (
+	"context"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
+)
+
+// RefreshStock indexes the stock variant with code again from MySQL after a
+// change of its row, removing it when it is gone
+func (s *Service) RefreshStock(ctx context.Context, code string) error {
+	var (
+		docs    []elasticEntity.StockDocument
+		deleted []string
+	)
+	doc, err := s.stock.GetStockDocument(ctx, code)
+	switch {
+	case errors.Cause(err) == entity.ErrNotFound:
+		deleted = []string{code}
+	case err != nil:
+		return errors.Wrap(err, "[SERVICE][RefreshStock]")
+	default:
+		docs = []elasticEntity.StockDocument{doc}
+	}
+
+	if err := s.elastic.IndexStock(ctx, s.indices.Stock, docs, deleted); err != nil {
+		return errors.Wrap(err, "[SERVICE][RefreshStock]")
+	}
+	return nil
+}
+
+// RefreshSale indexes the sale with saleID from MySQL once it is completed.
+// A sale that is not there is ignored, sales are never deleted.
+func (s *Service) RefreshSale(ctx context.Context, saleID string) error {
+	doc, err := s.sales.GetSaleDocument(ctx, saleID)
+	switch {
+	case errors.Cause(err) == entity.ErrNotFound:
+		return nil
+	case err != nil:
+		return errors.Wrap(err, "[SERVICE][RefreshSale]")
+	}
+	if err := s.elastic.IndexSales(ctx, s.indices.Sales, []elasticEntity.SaleDocument{doc}); err != nil {
+		return errors.Wrap(err, "[SERVICE][RefreshSale]")
+	}
+	return nil
+}
+
+// ReindexStock rebuilds the stock index from MySQL without downtime, see
+// ReindexMembers
+func (s *Service) ReindexStock(ctx context.Context) (elasticEntity.ReindexResult, error) {
+	result, err := s.rebuild(ctx, s.stockAlias())
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][ReindexStock]")
+	}
+	return result, nil
+}
+
+// ReindexSales rebuilds the sales index from MySQL without downtime, see
+// ReindexMembers
+func (s *Service) ReindexSales(ctx context.Context) (elasticEntity.ReindexResult, error) {
+	result, err := s.rebuild(ctx, s.salesAlias())
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][ReindexSales]")
+	}
+	return result, nil
+}
+
+// copyStock bulk indexes the stock variants, the ones updated since then
+// when since is set, into index and returns how many were copied
+func (s *Service) copyStock(ctx context.Context, index string, since time.Time) (int, error) {
+	var (
+		copied    int
+		afterCode string
+	)
+	for {
+		docs, err := s.stock.GetStockDocuments(ctx, afterCode, reindexBatch, since)
+		if err != nil {
+			return copied, err
+		}
+		if len(docs) == 0 {
+			return copied, nil
+		}
+		if err := s.elastic.IndexStock(ctx, index, docs, nil); err != nil {
+			return copied, err
+		}
+		copied += len(docs)
+		afterCode = docs[len(docs)-1].StockCode
+	}
+}
+
+// copySales bulk indexes the sales, the ones sold since then when since is
+// set, into index and returns how many were copied
+func (s *Service) copySales(ctx context.Context, index string, since time.Time) (int, error) {
+	var (
+		copied  int
+		afterID string
+	)
+	for {
+		docs, err := s.sales.GetSaleDocuments(ctx, afterID, reindexBatch, since)
+		if err != nil {
+			return copied, err
+		}
+		if len(docs) == 0 {
+			return copied, nil
+		}
+		if err := s.elastic.IndexSales(ctx, index, docs); err != nil {
+			return copied, err
+		}
+		copied += len(docs)
+		afterID = docs[len(docs)-1].SaleID
+	}
+}
@@ -0,0 +1,196 @@
+package elastic
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	pkgErrors "gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeES) PutStockTemplate(ctx context.Context, alias string) error {
+	f.templates[alias] = elasticEntity.StockTemplateVersion
+	return nil
+}
+
+func (f *fakeES) PutSalesTemplate(ctx context.Context, alias string) error {
+	f.templates[alias] = elasticEntity.SalesTemplateVersion
+	return nil
+}
+
+func (f *fakeES) IndexStock(ctx context.Context, index string, docs []elasticEntity.StockDocument, deleted []string) error {
+	f.requests++
+	if f.bulkErr != nil {
+		return f.bulkErr
+	}
+	stored, ok := f.stock[f.resolve(index)]
+	if !ok {
+		stored = map[string]elasticEntity.StockDocument{}
+		f.stock[f.resolve(index)] = stored
+	}
+	for _, doc := range docs {
+		stored[doc.StockCode] = doc
+	}
+	for _, code := range deleted {
+		delete(stored, code)
+	}
+	return nil
+}
+
+func (f *fakeES) IndexSales(ctx context.Context, index string, docs []elasticEntity.SaleDocument) error {
+	f.requests++
+	if f.bulkErr != nil {
+		return f.bulkErr
+	}
+	stored, ok := f.sales[f.resolve(index)]
+	if !ok {
+		stored = map[string]elasticEntity.SaleDocument{}
+		f.sales[f.resolve(index)] = stored
+	}
+	for _, doc := range docs {
+		stored[doc.SaleID] = doc
+	}
+	return nil
+}
+
+// fakeStock menyajikan stok berurutan stock_code
+type fakeStock struct {
+	docs []elasticEntity.StockDocument
+}
+
+func (f *fakeStock) GetStockDocuments(ctx context.Context, afterCode string, limit int, since time.Time) ([]elasticEntity.StockDocument, error) {
+	var page []elasticEntity.StockDocument
+	for _, doc := range f.docs {
+		if doc.StockCode <= afterCode || !since.IsZero() {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, doc)
+	}
+	return page, nil
+}
+
+func (f *fakeStock) GetStockDocument(ctx context.Context, code string) (elasticEntity.StockDocument, error) {
+	for _, doc := range f.docs {
+		if doc.StockCode == code {
+			return doc, nil
+		}
+	}
+	return elasticEntity.StockDocument{}, pkgErrors.Wrap(entity.ErrNotFound, "stock")
+}
+
+// fakeSales menyajikan penjualan berurutan sale_id
+type fakeSales struct {
+	docs []elasticEntity.SaleDocument
+}
+
+func (f *fakeSales) GetSaleDocuments(ctx context.Context, afterID string, limit int, since time.Time) ([]elasticEntity.SaleDocument, error) {
+	var page []elasticEntity.SaleDocument
+	for _, doc := range f.docs {
+		if doc.SaleID <= afterID || !since.IsZero() {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, doc)
+	}
+	return page, nil
+}
+
+func (f *fakeSales) GetSaleDocument(ctx context.Context, saleID string) (elasticEntity.SaleDocument, error) {
+	for _, doc := range f.docs {
+		if doc.SaleID == saleID {
+			return doc, nil
+		}
+	}
+	return elasticEntity.SaleDocument{}, pkgErrors.Wrap(entity.ErrNotFound, "sale")
+}
+
+func newCatalogueService(es *fakeES, stock *fakeStock, sales *fakeSales) *Service {
+	svc := New(es, &fakeMembers{}, stock, sales, elasticEntity.Indices{}, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC) }
+	return svc
+}
+
+func TestRefreshStock(t *testing.T) {
+	es := newFakeES()
+	es.stock["gold-stock"] = map[string]elasticEntity.StockDocument{"OLD": {StockCode: "OLD"}}
+	stock := &fakeStock{docs: []elasticEntity.StockDocument{{StockCode: "WHEY-1", StockName: "Whey Coklat", Barcodes: []string{"899123"}}}}
+	svc := newCatalogueService(es, stock, &fakeSales{})
+
+	require.NoError(t, svc.RefreshStock(context.Background(), "WHEY-1"))
+	assert.Equal(t, []string{"899123"}, es.stock["gold-stock"]["WHEY-1"].Barcodes)
+
+	// stok yang sudah tidak ada dihapus dari index
+	require.NoError(t, svc.RefreshStock(context.Background(), "OLD"))
+	assert.NotContains(t, es.stock["gold-stock"], "OLD")
+}
+
+func TestRefreshSale(t *testing.T) {
+	es := newFakeES()
+	sales := &fakeSales{docs: []elasticEntity.SaleDocument{{SaleID: "S-1", Total: entity.NewMoney(50000)}}}
+	svc := newCatalogueService(es, &fakeStock{}, sales)
+
+	require.NoError(t, svc.RefreshSale(context.Background(), "S-1"))
+	assert.Equal(t, entity.NewMoney(50000), es.sales["gold-sales"]["S-1"].Total)
+
+	// penjualan yang belum ada dilewati
+	es.requests = 0
+	require.NoError(t, svc.RefreshSale(context.Background(), "S-2"))
+	assert.Zero(t, es.requests)
+}
+
+func TestReindexStock(t *testing.T) {
+	stock := &fakeStock{}
+	for _, code := range []string{"A", "B", "C"} {
+		stock.docs = append(stock.docs, elasticEntity.StockDocument{StockCode: code})
+	}
+	es := newFakeES()
+	es.aliases["gold-stock"] = []string{"gold-stock-old"}
+	es.indices["gold-stock-old"] = map[int]elasticEntity.UserDocument{}
+
+	result, err := newCatalogueService(es, stock, &fakeSales{}).ReindexStock(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "gold-stock-20261019093000", result.Index)
+	assert.Equal(t, 3, result.Documents)
+	assert.Equal(t, []string{"gold-stock-old"}, result.Removed)
+	assert.Equal(t, []string{result.Index}, es.aliases["gold-stock"])
+	assert.Len(t, es.stock[result.Index], 3)
+	assert.Equal(t, elasticEntity.StockTemplateVersion, es.templates["gold-stock"])
+}
+
+func TestMigrateIndices(t *testing.T) {
+	es := newFakeES()
+	// index member sudah terbaru, stok dan penjualan belum ada
+	es.templates["gold-members"] = elasticEntity.MemberTemplateVersion
+	es.aliases["gold-members"] = []string{"gold-members-1"}
+	es.indices["gold-members-1"] = map[int]elasticEntity.UserDocument{}
+	es.versions["gold-members-1"] = elasticEntity.MemberTemplateVersion
+
+	stock := &fakeStock{docs: []elasticEntity.StockDocument{{StockCode: "A"}}}
+	sales := &fakeSales{docs: []elasticEntity.SaleDocument{{SaleID: "S-1"}, {SaleID: "S-2"}}}
+	results, err := newCatalogueService(es, stock, sales).MigrateIndices(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	var reindexed []string
+	for _, result := range results {
+		if result.Reindexed {
+			reindexed = append(reindexed, result.Alias)
+		}
+	}
+	sort.Strings(reindexed)
+	assert.Equal(t, []string{"gold-sales", "gold-stock"}, reindexed)
+	assert.Len(t, es.sales[es.aliases["gold-sales"][0]], 2)
+	assert.Len(t, es.stock[es.aliases["gold-stock"][0]], 1)
+}
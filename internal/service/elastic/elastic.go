@@ -2,6 +2,8 @@ package elastic
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	elasticEntity "gold-gym-be/internal/entity/elastic"
 
@@ -12,22 +14,78 @@ import (
 // RepoData defines the data layer interface consumed by this service
 type RepoData interface {
 	IndexDocument(ctx context.Context, index string, doc elasticEntity.UserDocument) (string, error)
-	SearchDocuments(ctx context.Context, index string, query string) ([]elasticEntity.UserDocument, error)
+	SearchMembers(ctx context.Context, index string, search elasticEntity.MemberSearch) (elasticEntity.SearchResult, error)
 	GetDocumentByID(ctx context.Context, index string, id string) (elasticEntity.UserDocument, error)
+	Bulk(ctx context.Context, index string, actions []elasticEntity.BulkAction) error
+
+	IndexStock(ctx context.Context, index string, docs []elasticEntity.StockDocument, deleted []string) error
+	IndexSales(ctx context.Context, index string, docs []elasticEntity.SaleDocument) error
+	SuggestStock(ctx context.Context, index string, search elasticEntity.StockSearch) ([]elasticEntity.StockDocument, error)
+	SalesReport(ctx context.Context, index string, filter elasticEntity.SalesReportFilter) (elasticEntity.SalesReport, error)
+
+	PutMemberTemplate(ctx context.Context, alias string) error
+	PutStockTemplate(ctx context.Context, alias string) error
+	PutSalesTemplate(ctx context.Context, alias string) error
+	TemplateVersion(ctx context.Context, alias string) (int, error)
+	IndexVersions(ctx context.Context, alias string) (map[string]int, error)
+	CreateIndex(ctx context.Context, index string) error
+	AliasIndices(ctx context.Context, alias string) ([]string, bool, error)
+	SwapAlias(ctx context.Context, alias, index string, old []string, concrete bool) error
+	DeleteIndices(ctx context.Context, indices []string) error
+}
+
+// MemberData reads the members a reindex rebuilds the index from
+type MemberData interface {
+	GetMemberDocuments(ctx context.Context, afterID, limit int, since time.Time) ([]elasticEntity.UserDocument, error)
+	GetMemberDocument(ctx context.Context, goldID int) (elasticEntity.UserDocument, error)
+}
+
+// StockData reads the stock variants the stock index is built from
+type StockData interface {
+	GetStockDocuments(ctx context.Context, afterCode string, limit int, since time.Time) ([]elasticEntity.StockDocument, error)
+	GetStockDocument(ctx context.Context, code string) (elasticEntity.StockDocument, error)
+}
+
+// SalesData reads the completed sales the sales index is built from
+type SalesData interface {
+	GetSaleDocuments(ctx context.Context, afterID string, limit int, since time.Time) ([]elasticEntity.SaleDocument, error)
+	GetSaleDocument(ctx context.Context, saleID string) (elasticEntity.SaleDocument, error)
 }
 
 // Service holds the data layer dependency
 type Service struct {
 	elastic RepoData
+	members MemberData
+	stock   StockData
+	sales   SalesData
+	indices elasticEntity.Indices
 	tracer  opentracing.Tracer
 	logger  jaegerLog.Factory
+	now     func() time.Time
+
+	// member actions waiting for the next bulk request, see ProjectMember.
+	// flushMu keeps the flushes in order.
+	memberMu       sync.Mutex
+	flushMu        sync.Mutex
+	memberActions  []elasticEntity.BulkAction
+	memberTimer    *time.Timer
+	memberFailures int
+	// tombstones are the members deleted while ReindexMembers copies, nil
+	// when no copy runs
+	tombstones map[int]bool
 }
 
-// New creates a new elastic Service
-func New(elasticData RepoData, tracer opentracing.Tracer, logger jaegerLog.Factory) *Service {
+// New creates a new elastic Service. indices are the aliases of the
+// member, stock and sales documents, empty ones take their default.
+func New(elasticData RepoData, memberData MemberData, stockData StockData, salesData SalesData, indices elasticEntity.Indices, tracer opentracing.Tracer, logger jaegerLog.Factory) *Service {
 	return &Service{
 		elastic: elasticData,
+		members: memberData,
+		stock:   stockData,
+		sales:   salesData,
+		indices: indices.WithDefaults(),
 		tracer:  tracer,
 		logger:  logger,
+		now:     time.Now,
 	}
 }
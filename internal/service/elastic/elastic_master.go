@@ -2,21 +2,53 @@ package elastic
 
 import (
 	"context"
+	"fmt"
 
+	"gold-gym-be/internal/entity"
 	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
 )
 
-// IndexUser indexes a user document into Elasticsearch
-func (s *Service) IndexUser(ctx context.Context, index string, doc elasticEntity.UserDocument) (string, error) {
-	return s.elastic.IndexDocument(ctx, index, doc)
+// IndexUser indexes a user document into the member alias
+func (s *Service) IndexUser(ctx context.Context, doc elasticEntity.UserDocument) (string, error) {
+	return s.elastic.IndexDocument(ctx, s.indices.Members, doc)
 }
 
-// SearchUsers performs a full-text search across user documents
-func (s *Service) SearchUsers(ctx context.Context, index string, query string) ([]elasticEntity.UserDocument, error) {
-	return s.elastic.SearchDocuments(ctx, index, query)
+// SearchUsers searches the members behind the member alias. Size defaults to DefaultSearchSize; From and After cannot be
+// combined and From/Size cannot reach past MaxSearchWindow.
+func (s *Service) SearchUsers(ctx context.Context, search elasticEntity.MemberSearch) (elasticEntity.SearchResult, error) {
+	if search.Size == 0 {
+		search.Size = elasticEntity.DefaultSearchSize
+	}
+
+	switch {
+	case search.Size < 0 || search.Size > elasticEntity.MaxSearchSize:
+		return elasticEntity.SearchResult{}, errors.Wrap(entity.ErrInvalid, fmt.Sprintf("size must be between 1 and %d", elasticEntity.MaxSearchSize))
+	case search.From < 0:
+		return elasticEntity.SearchResult{}, errors.Wrap(entity.ErrInvalid, "from must not be negative")
+	case search.From > 0 && search.After != "":
+		return elasticEntity.SearchResult{}, errors.Wrap(entity.ErrInvalid, "from and after cannot be combined")
+	case search.From+search.Size > elasticEntity.MaxSearchWindow:
+		return elasticEntity.SearchResult{}, errors.Wrap(entity.ErrInvalid, fmt.Sprintf("from + size must not exceed %d, page with after instead", elasticEntity.MaxSearchWindow))
+	case !elasticEntity.ValidSort(search.Sort):
+		return elasticEntity.SearchResult{}, errors.Wrap(entity.ErrInvalid, "unknown sort "+search.Sort)
+	case !search.ExpiresFrom.IsZero() && !search.ExpiresTo.IsZero() && search.ExpiresTo.Before(search.ExpiresFrom):
+		return elasticEntity.SearchResult{}, errors.Wrap(entity.ErrInvalid, "expires_to is before expires_from")
+	}
+	if search.After != "" {
+		if _, err := elasticEntity.DecodeCursor(search.After); err != nil {
+			return elasticEntity.SearchResult{}, errors.Wrap(entity.ErrInvalid, err.Error())
+		}
+	}
+
+	result, err := s.elastic.SearchMembers(ctx, s.indices.Members, search)
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][SearchUsers]")
+	}
+	return result, nil
 }
 
-// GetUserByID retrieves a single user document by ID
-func (s *Service) GetUserByID(ctx context.Context, index string, id string) (elasticEntity.UserDocument, error) {
-	return s.elastic.GetDocumentByID(ctx, index, id)
+// GetUserByID retrieves a single member document by ID
+func (s *Service) GetUserByID(ctx context.Context, id string) (elasticEntity.UserDocument, error) {
+	return s.elastic.GetDocumentByID(ctx, s.indices.Members, id)
 }
@@ -0,0 +1,69 @@
+package elastic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	pkgErrors "gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchES mencatat pencarian terakhir yang diteruskan ke data layer
+type searchES struct {
+	RepoData
+	index  string
+	search elasticEntity.MemberSearch
+}
+
+func (f *searchES) SearchMembers(ctx context.Context, index string, search elasticEntity.MemberSearch) (elasticEntity.SearchResult, error) {
+	f.index, f.search = index, search
+	return elasticEntity.SearchResult{Total: 1}, nil
+}
+
+func TestSearchUsers(t *testing.T) {
+	es := &searchES{}
+	svc := New(es, &fakeMembers{}, nil, nil, elasticEntity.Indices{}, nil, jaegerLog.Factory{})
+
+	// selalu memakai alias member, size memakai default
+	result, err := svc.SearchUsers(context.Background(), elasticEntity.MemberSearch{Query: "budi"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, elasticEntity.DefaultMemberIndex, es.index)
+	assert.Equal(t, elasticEntity.DefaultSearchSize, es.search.Size)
+
+	cursor := elasticEntity.EncodeCursor([]byte(`["budi",7]`))
+	_, err = svc.SearchUsers(context.Background(), elasticEntity.MemberSearch{After: cursor, Sort: "-name"})
+	require.NoError(t, err)
+	assert.Equal(t, cursor, es.search.After)
+}
+
+func TestSearchUsersInvalid(t *testing.T) {
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name   string
+		search elasticEntity.MemberSearch
+	}{
+		{"size terlalu besar", elasticEntity.MemberSearch{Size: elasticEntity.MaxSearchSize + 1}},
+		{"from negatif", elasticEntity.MemberSearch{From: -1}},
+		{"from dan after", elasticEntity.MemberSearch{From: 20, After: elasticEntity.EncodeCursor([]byte(`[7]`))}},
+		{"melewati jendela from", elasticEntity.MemberSearch{From: elasticEntity.MaxSearchWindow}},
+		{"sort tidak dikenal", elasticEntity.MemberSearch{Sort: "gold_email"}},
+		{"sort hanya tanda minus", elasticEntity.MemberSearch{Sort: "-"}},
+		{"cursor rusak", elasticEntity.MemberSearch{After: "bukan-cursor"}},
+		{"rentang expiry terbalik", elasticEntity.MemberSearch{ExpiresFrom: day, ExpiresTo: day.AddDate(0, 0, -1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := &searchES{}
+			_, err := New(es, &fakeMembers{}, nil, nil, elasticEntity.Indices{}, nil, jaegerLog.Factory{}).SearchUsers(context.Background(), tt.search)
+			assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+			assert.Empty(t, es.index, "ES tidak dipanggil")
+		})
+	}
+}
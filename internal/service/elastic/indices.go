@@ -0,0 +1,178 @@
+package elastic
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
+)
+
+// managedIndex is an alias whose indices the service creates from a
+// versioned template and rebuilds from MySQL. copy bulk indexes the rows,
+// only the ones changed since then when since is set, into index and
+// returns how many it copied.
+type managedIndex struct {
+	alias   string
+	version int
+	put     func(ctx context.Context, alias string) error
+	copy    func(ctx context.Context, index string, since time.Time) (int, error)
+}
+
+func (s *Service) memberAlias() managedIndex {
+	return managedIndex{
+		alias:   s.indices.Members,
+		version: elasticEntity.MemberTemplateVersion,
+		put:     s.elastic.PutMemberTemplate,
+		copy:    s.copyMembers,
+	}
+}
+
+func (s *Service) stockAlias() managedIndex {
+	return managedIndex{
+		alias:   s.indices.Stock,
+		version: elasticEntity.StockTemplateVersion,
+		put:     s.elastic.PutStockTemplate,
+		copy:    s.copyStock,
+	}
+}
+
+func (s *Service) salesAlias() managedIndex {
+	return managedIndex{
+		alias:   s.indices.Sales,
+		version: elasticEntity.SalesTemplateVersion,
+		put:     s.elastic.PutSalesTemplate,
+		copy:    s.copySales,
+	}
+}
+
+// MigrateIndices migrates the member, stock and sales aliases, see
+// MigrateMemberIndex. A failing alias does not stop the others, the first
+// error is returned with the results of all of them.
+func (s *Service) MigrateIndices(ctx context.Context) ([]elasticEntity.MigrationResult, error) {
+	var (
+		results  []elasticEntity.MigrationResult
+		firstErr error
+	)
+	for _, idx := range []managedIndex{s.memberAlias(), s.stockAlias(), s.salesAlias()} {
+		result, err := s.migrate(ctx, idx)
+		if err != nil && firstErr == nil {
+			firstErr = errors.Wrap(err, "[SERVICE][MigrateIndices] "+idx.alias)
+		}
+		results = append(results, result)
+	}
+	return results, firstErr
+}
+
+// migrate installs or upgrades the template of idx and rebuilds it when the
+// alias does not exist yet, is a concrete index created before the service
+// owned its indices, or points to an index built from an older template. A
+// template newer than this build, stored by a newer deployment, is left
+// alone and nothing is rebuilt.
+func (s *Service) migrate(ctx context.Context, idx managedIndex) (elasticEntity.MigrationResult, error) {
+	result := elasticEntity.MigrationResult{Alias: idx.alias, TemplateVersion: idx.version}
+
+	stored, err := s.ensureTemplate(ctx, idx)
+	if err != nil {
+		return result, err
+	}
+	if stored > idx.version {
+		result.TemplateVersion = stored
+		return result, nil
+	}
+
+	indices, concrete, err := s.elastic.AliasIndices(ctx, idx.alias)
+	if err != nil {
+		return result, err
+	}
+	outdated := concrete || len(indices) == 0
+	if !outdated {
+		versions, err := s.elastic.IndexVersions(ctx, idx.alias)
+		if err != nil {
+			return result, err
+		}
+		for _, index := range indices {
+			if versions[index] < idx.version {
+				outdated = true
+			}
+		}
+	}
+	if !outdated {
+		result.Index = indices[0]
+		return result, nil
+	}
+
+	reindex, err := s.rebuild(ctx, idx)
+	if err != nil {
+		return result, err
+	}
+	result.Index = reindex.Index
+	result.Reindexed = true
+	result.Reindex = &reindex
+	return result, nil
+}
+
+// ensureTemplate installs the template of idx unless the stored one is as
+// new or newer, and returns the version that was stored before
+func (s *Service) ensureTemplate(ctx context.Context, idx managedIndex) (int, error) {
+	stored, err := s.elastic.TemplateVersion(ctx, idx.alias)
+	if err != nil {
+		return 0, err
+	}
+	if stored >= idx.version {
+		return stored, nil
+	}
+	return stored, idx.put(ctx, idx.alias)
+}
+
+// rebuild rebuilds idx from MySQL without downtime: the template is put in
+// place, the rows are copied into a new index, the alias is swapped over in
+// one request and the replaced indices are dropped. Rows changed while the
+// copy ran are copied once more through the alias afterwards.
+func (s *Service) rebuild(ctx context.Context, idx managedIndex) (elasticEntity.ReindexResult, error) {
+	started := s.now()
+	result := elasticEntity.ReindexResult{
+		Index: fmt.Sprintf("%s-%s", idx.alias, started.UTC().Format("20060102150405")),
+	}
+
+	if _, err := s.ensureTemplate(ctx, idx); err != nil {
+		return result, err
+	}
+	if err := s.elastic.CreateIndex(ctx, result.Index); err != nil {
+		return result, err
+	}
+	n, err := idx.copy(ctx, result.Index, time.Time{})
+	if err != nil {
+		s.dropUnused(ctx, result.Index)
+		return result, err
+	}
+	result.Documents = n
+
+	old, concrete, err := s.elastic.AliasIndices(ctx, idx.alias)
+	if err != nil {
+		s.dropUnused(ctx, result.Index)
+		return result, err
+	}
+	if err := s.elastic.SwapAlias(ctx, idx.alias, result.Index, old, concrete); err != nil {
+		s.dropUnused(ctx, result.Index)
+		return result, err
+	}
+
+	if _, err := idx.copy(ctx, idx.alias, started); err != nil {
+		return result, errors.Wrap(err, "catch up")
+	}
+	if err := s.elastic.DeleteIndices(ctx, old); err != nil {
+		return result, errors.Wrap(err, "drop replaced indices")
+	}
+	result.Removed = old
+	return result, nil
+}
+
+// dropUnused removes the index of a rebuild that did not go live
+func (s *Service) dropUnused(ctx context.Context, index string) {
+	if err := s.elastic.DeleteIndices(ctx, []string{index}); err != nil {
+		log.Printf("[ERROR] [SERVICE][rebuild] drop %s: %v", index, err)
+	}
+}
@@ -0,0 +1,226 @@
+package elastic
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	"gold-gym-be/pkg/errors"
+)
+
+const (
+	// reindexBatch is the number of members read and bulk indexed at a time
+	reindexBatch = 500
+	// memberFlushSize is how many buffered member actions make a bulk request
+	memberFlushSize = 200
+	// memberFlushInterval is how long a member action waits in the buffer at
+	// most
+	memberFlushInterval = time.Second
+	// memberFlushTimeout bounds a flush started by the timer
+	memberFlushTimeout = 10 * time.Second
+	// memberFlushAttempts is how many flushes in a row may fail before the
+	// buffered actions are dropped, ReindexMembers brings them back
+	memberFlushAttempts = 5
+)
+
+// ProjectMember applies a data_peserta change event to the member index:
+// creates, updates and snapshot reads upsert doc, deletes remove it. The
+// change is buffered, see bufferMember.
+func (s *Service) ProjectMember(ctx context.Context, op string, doc elasticEntity.UserDocument) error {
+	action := elasticEntity.BulkAction{Doc: doc}
+	switch op {
+	case "c", "u", "r":
+	case "d":
+		action.Delete = true
+	default:
+		return errors.Wrap(entity.ErrInvalid, "unknown op "+op)
+	}
+	if doc.GoldId == 0 {
+		return errors.Wrap(entity.ErrInvalid, "change event has no gold_id")
+	}
+
+	if err := s.bufferMember(ctx, action); err != nil {
+		return errors.Wrap(err, "[SERVICE][ProjectMember]")
+	}
+	return nil
+}
+
+// RefreshMember indexes the member with goldID again from MySQL, after a
+// change of one of its subscriptions. The document is replaced whole so
+// subscription fields that no longer apply are dropped; a member that is
+// gone is removed. The action is buffered behind the member's pending
+// changes, see bufferMember.
+func (s *Service) RefreshMember(ctx context.Context, goldID int) error {
+	action := elasticEntity.BulkAction{Replace: true}
+	doc, err := s.members.GetMemberDocument(ctx, goldID)
+	switch {
+	case errors.Cause(err) == entity.ErrNotFound:
+		action = elasticEntity.BulkAction{Delete: true, Doc: elasticEntity.UserDocument{GoldId: goldID}}
+	case err != nil:
+		return errors.Wrap(err, "[SERVICE][RefreshMember]")
+	default:
+		action.Doc = doc
+	}
+
+	if err := s.bufferMember(ctx, action); err != nil {
+		return errors.Wrap(err, "[SERVICE][RefreshMember]")
+	}
+	return nil
+}
+
+// bufferMember queues action for the member index. The queue is sent in one
+// bulk request, in order, once it holds memberFlushSize actions, by the
+// caller that filled it, or memberFlushInterval after its first action.
+// Buffered actions are not in the index yet when the event is committed: a
+// crash loses them and ReindexMembers restores them, FlushMembers sends
+// them on shutdown.
+func (s *Service) bufferMember(ctx context.Context, action elasticEntity.BulkAction) error {
+	s.memberMu.Lock()
+	s.memberActions = append(s.memberActions, action)
+	if action.Delete && s.tombstones != nil {
+		s.tombstones[action.Doc.GoldId] = true
+	}
+	full := len(s.memberActions) >= memberFlushSize
+	if !full && s.memberTimer == nil {
+		s.memberTimer = time.AfterFunc(memberFlushInterval, s.flushMembersLater)
+	}
+	s.memberMu.Unlock()
+
+	if full {
+		return s.FlushMembers(ctx)
+	}
+	return nil
+}
+
+// FlushMembers sends the buffered member actions in one bulk request. When
+// it fails they stay buffered ahead of the newer ones and go with the next
+// flush; after memberFlushAttempts failures in a row they are dropped.
+func (s *Service) FlushMembers(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.memberMu.Lock()
+	actions := s.memberActions
+	s.memberActions = nil
+	if s.memberTimer != nil {
+		s.memberTimer.Stop()
+		s.memberTimer = nil
+	}
+	s.memberMu.Unlock()
+	if len(actions) == 0 {
+		return nil
+	}
+
+	err := s.elastic.Bulk(ctx, s.indices.Members, actions)
+
+	s.memberMu.Lock()
+	defer s.memberMu.Unlock()
+	if err == nil {
+		s.memberFailures = 0
+		return nil
+	}
+	s.memberFailures++
+	if s.memberFailures >= memberFlushAttempts {
+		log.Printf("[ERROR] [SERVICE][FlushMembers] dropped %d member action(s) after %d failed flushes, reindex the members to restore them", len(actions), s.memberFailures)
+		s.memberFailures = 0
+	} else {
+		s.memberActions = append(actions, s.memberActions...)
+		if s.memberTimer == nil {
+			s.memberTimer = time.AfterFunc(memberFlushInterval, s.flushMembersLater)
+		}
+	}
+	return errors.Wrap(err, "[SERVICE][FlushMembers]")
+}
+
+// flushMembersLater is the timer flush of bufferMember
+func (s *Service) flushMembersLater() {
+	ctx, cancel := context.WithTimeout(context.Background(), memberFlushTimeout)
+	defer cancel()
+	if err := s.FlushMembers(ctx); err != nil {
+		log.Printf("[ERROR] %v", err)
+	}
+}
+
+// MigrateMemberIndex brings the member alias up to the current template at
+// startup. The template is installed or upgraded first; the members are then
+// reindexed when the alias does not exist yet, is a concrete index created
+// before the service owned its indices, or points to an index built from an
+// older template. A template newer than this build, stored by a newer
+// deployment, is left alone and nothing is rebuilt.
+func (s *Service) MigrateMemberIndex(ctx context.Context) (elasticEntity.MigrationResult, error) {
+	result, err := s.migrate(ctx, s.memberAlias())
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][MigrateMemberIndex]")
+	}
+	return result, nil
+}
+
+// ReindexMembers rebuilds the member index from MySQL without downtime: the
+// member template is put in place, the members are copied into a new index,
+// the alias is swapped over in one request and the replaced indices are
+// dropped. Members updated while the copy ran are copied once more through
+// the alias afterwards.
+func (s *Service) ReindexMembers(ctx context.Context) (elasticEntity.ReindexResult, error) {
+	result, err := s.rebuild(ctx, s.memberAlias())
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][ReindexMembers]")
+	}
+	return result, nil
+}
+
+// copyMembers bulk indexes the members, the ones updated since then when
+// since is set, into index and returns how many were copied. A full copy
+// starts recording the members deleted meanwhile, which the catch-up copy
+// since then removes again: the copy may have read them before the delete
+// reached the old index.
+func (s *Service) copyMembers(ctx context.Context, index string, since time.Time) (int, error) {
+	s.memberMu.Lock()
+	if since.IsZero() {
+		s.tombstones = map[int]bool{}
+	}
+	s.memberMu.Unlock()
+
+	var copied, afterID int
+	for {
+		docs, err := s.members.GetMemberDocuments(ctx, afterID, reindexBatch, since)
+		if err != nil {
+			return copied, err
+		}
+		if len(docs) == 0 {
+			if since.IsZero() {
+				return copied, nil
+			}
+			return copied, s.applyTombstones(ctx, index)
+		}
+
+		actions := make([]elasticEntity.BulkAction, len(docs))
+		for i, doc := range docs {
+			actions[i] = elasticEntity.BulkAction{Doc: doc}
+		}
+		if err := s.elastic.Bulk(ctx, index, actions); err != nil {
+			return copied, err
+		}
+		copied += len(docs)
+		afterID = docs[len(docs)-1].GoldId
+	}
+}
+
+// applyTombstones removes the members deleted since the full copy started
+// from index and stops recording them
+func (s *Service) applyTombstones(ctx context.Context, index string) error {
+	s.memberMu.Lock()
+	tombstones := s.tombstones
+	s.tombstones = nil
+	s.memberMu.Unlock()
+	if len(tombstones) == 0 {
+		return nil
+	}
+
+	actions := make([]elasticEntity.BulkAction, 0, len(tombstones))
+	for goldID := range tombstones {
+		actions = append(actions, elasticEntity.BulkAction{Delete: true, Doc: elasticEntity.UserDocument{GoldId: goldID}})
+	}
+	return s.elastic.Bulk(ctx, index, actions)
+}
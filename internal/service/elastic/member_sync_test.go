@@ -0,0 +1,387 @@
+package elastic
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	elasticEntity "gold-gym-be/internal/entity/elastic"
+	pkgErrors "gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeES menyimpan index, alias dan dokumen di memory. indices berisi
+// dokumen member, stock dan sales dokumen stok dan penjualan per index.
+// templates adalah versi template yang tersimpan per alias, versions versi
+// template tiap index.
+type fakeES struct {
+	RepoData
+	indices   map[string]map[int]elasticEntity.UserDocument
+	stock     map[string]map[string]elasticEntity.StockDocument
+	sales     map[string]map[string]elasticEntity.SaleDocument
+	aliases   map[string][]string
+	templates map[string]int
+	versions  map[string]int
+	bulkErr   error
+	requests  int
+}
+
+func newFakeES() *fakeES {
+	return &fakeES{
+		indices:   map[string]map[int]elasticEntity.UserDocument{},
+		stock:     map[string]map[string]elasticEntity.StockDocument{},
+		sales:     map[string]map[string]elasticEntity.SaleDocument{},
+		aliases:   map[string][]string{},
+		templates: map[string]int{},
+		versions:  map[string]int{},
+	}
+}
+
+func (f *fakeES) resolve(index string) string {
+	if targets, ok := f.aliases[index]; ok && len(targets) == 1 {
+		return targets[0]
+	}
+	return index
+}
+
+func (f *fakeES) Bulk(ctx context.Context, index string, actions []elasticEntity.BulkAction) error {
+	f.requests++
+	if f.bulkErr != nil {
+		return f.bulkErr
+	}
+	docs, ok := f.indices[f.resolve(index)]
+	if !ok {
+		docs = map[int]elasticEntity.UserDocument{}
+		f.indices[f.resolve(index)] = docs
+	}
+	for _, action := range actions {
+		if action.Delete {
+			delete(docs, action.Doc.GoldId)
+			continue
+		}
+		docs[action.Doc.GoldId] = action.Doc
+	}
+	return nil
+}
+
+func (f *fakeES) PutMemberTemplate(ctx context.Context, alias string) error {
+	f.templates[alias] = elasticEntity.MemberTemplateVersion
+	return nil
+}
+
+func (f *fakeES) TemplateVersion(ctx context.Context, alias string) (int, error) {
+	return f.templates[alias], nil
+}
+
+func (f *fakeES) IndexVersions(ctx context.Context, alias string) (map[string]int, error) {
+	versions := map[string]int{}
+	for _, index := range f.aliases[alias] {
+		versions[index] = f.versions[index]
+	}
+	return versions, nil
+}
+
+func (f *fakeES) CreateIndex(ctx context.Context, index string) error {
+	f.indices[index] = map[int]elasticEntity.UserDocument{}
+	f.versions[index] = f.templates[strings.TrimRight(index, "-0123456789")]
+	return nil
+}
+
+func (f *fakeES) AliasIndices(ctx context.Context, alias string) ([]string, bool, error) {
+	if targets, ok := f.aliases[alias]; ok {
+		return targets, false, nil
+	}
+	_, concrete := f.indices[alias]
+	return nil, concrete, nil
+}
+
+func (f *fakeES) SwapAlias(ctx context.Context, alias, index string, old []string, concrete bool) error {
+	if concrete {
+		delete(f.indices, alias)
+	}
+	f.aliases[alias] = []string{index}
+	return nil
+}
+
+func (f *fakeES) DeleteIndices(ctx context.Context, indices []string) error {
+	for _, index := range indices {
+		delete(f.indices, index)
+	}
+	return nil
+}
+
+// fakeMembers menyajikan member berurutan gold_id, updated menandai member
+// yang berubah selama reindex. during dijalankan sekali setelah halaman
+// pertama dibaca, seolah terjadi di tengah salinan.
+type fakeMembers struct {
+	docs    []elasticEntity.UserDocument
+	updated map[int]bool
+	during  func()
+}
+
+func (f *fakeMembers) GetMemberDocuments(ctx context.Context, afterID, limit int, since time.Time) ([]elasticEntity.UserDocument, error) {
+	var page []elasticEntity.UserDocument
+	for _, doc := range f.docs {
+		if doc.GoldId <= afterID || (!since.IsZero() && !f.updated[doc.GoldId]) {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, doc)
+	}
+	if f.during != nil {
+		during := f.during
+		f.during = nil
+		during()
+	}
+	return page, nil
+}
+
+func (f *fakeMembers) GetMemberDocument(ctx context.Context, goldID int) (elasticEntity.UserDocument, error) {
+	for _, doc := range f.docs {
+		if doc.GoldId == goldID {
+			return doc, nil
+		}
+	}
+	return elasticEntity.UserDocument{}, pkgErrors.Wrap(entity.ErrNotFound, "member")
+}
+
+func newTestService(es *fakeES, members *fakeMembers) *Service {
+	svc := New(es, members, nil, nil, elasticEntity.Indices{}, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC) }
+	return svc
+}
+
+func TestProjectMember(t *testing.T) {
+	es := newFakeES()
+	es.aliases["gold-members"] = []string{"gold-members-1"}
+	svc := newTestService(es, &fakeMembers{})
+	ctx := context.Background()
+
+	// perubahan ditampung dulu lalu dikirim berurutan dalam satu bulk
+	doc := elasticEntity.UserDocument{GoldId: 7, GoldNama: "Budi", GoldEmail: "budi@test.com"}
+	require.NoError(t, svc.ProjectMember(ctx, "c", doc))
+	doc.GoldNama = "Budi S"
+	require.NoError(t, svc.ProjectMember(ctx, "u", doc))
+	assert.Zero(t, es.requests)
+	require.NoError(t, svc.FlushMembers(ctx))
+	assert.Equal(t, 1, es.requests)
+	assert.Equal(t, "Budi S", es.indices["gold-members-1"][7].GoldNama)
+
+	require.NoError(t, svc.ProjectMember(ctx, "d", doc))
+	require.NoError(t, svc.FlushMembers(ctx))
+	assert.Empty(t, es.indices["gold-members-1"])
+
+	err := svc.ProjectMember(ctx, "c", elasticEntity.UserDocument{GoldNama: "Tanpa ID"})
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+
+	err = svc.ProjectMember(ctx, "t", doc)
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+
+	// bulk yang gagal menyimpan perubahannya untuk flush berikutnya
+	es.bulkErr = errors.New("es down")
+	require.NoError(t, svc.ProjectMember(ctx, "u", doc))
+	assert.Error(t, svc.FlushMembers(ctx))
+	es.bulkErr = nil
+	require.NoError(t, svc.FlushMembers(ctx))
+	assert.Equal(t, "Budi S", es.indices["gold-members-1"][7].GoldNama)
+}
+
+func TestProjectMemberFlushesFullBuffer(t *testing.T) {
+	es := newFakeES()
+	es.aliases["gold-members"] = []string{"gold-members-1"}
+	svc := newTestService(es, &fakeMembers{})
+	ctx := context.Background()
+
+	for id := 1; id <= memberFlushSize; id++ {
+		require.NoError(t, svc.ProjectMember(ctx, "r", elasticEntity.UserDocument{GoldId: id}))
+	}
+	assert.Equal(t, 1, es.requests)
+	assert.Len(t, es.indices["gold-members-1"], memberFlushSize)
+
+	// buffer penuh yang gagal dikirim dikembalikan ke consumer
+	es.bulkErr = errors.New("es down")
+	for id := 1; id < memberFlushSize; id++ {
+		require.NoError(t, svc.ProjectMember(ctx, "u", elasticEntity.UserDocument{GoldId: id}))
+	}
+	assert.Error(t, svc.ProjectMember(ctx, "u", elasticEntity.UserDocument{GoldId: memberFlushSize}))
+
+	// setelah memberFlushAttempts kali gagal berturut-turut buffer dibuang
+	for i := 1; i < memberFlushAttempts; i++ {
+		assert.Error(t, svc.FlushMembers(ctx))
+	}
+	es.bulkErr = nil
+	requests := es.requests
+	require.NoError(t, svc.FlushMembers(ctx))
+	assert.Equal(t, requests, es.requests)
+}
+
+func TestReindexMembers(t *testing.T) {
+	members := &fakeMembers{updated: map[int]bool{}}
+	for id := 1; id <= reindexBatch+2; id++ {
+		members.docs = append(members.docs, elasticEntity.UserDocument{GoldId: id, GoldNama: "Member"})
+	}
+
+	t.Run("index lama tanpa alias", func(t *testing.T) {
+		es := newFakeES()
+		es.indices["gold-members"] = map[int]elasticEntity.UserDocument{1: {GoldId: 1}}
+		members.updated[2] = true
+
+		result, err := newTestService(es, members).ReindexMembers(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "gold-members-20261019093000", result.Index)
+		assert.Equal(t, reindexBatch+2, result.Documents)
+		assert.Empty(t, result.Removed)
+		assert.Equal(t, []string{result.Index}, es.aliases["gold-members"])
+		assert.Len(t, es.indices[result.Index], reindexBatch+2)
+		// dua batch salinan dan satu batch susulan
+		assert.Equal(t, 3, es.requests)
+	})
+
+	t.Run("alias pindah dan index lama dihapus", func(t *testing.T) {
+		es := newFakeES()
+		es.indices["gold-members-old"] = map[int]elasticEntity.UserDocument{}
+		es.aliases["gold-members"] = []string{"gold-members-old"}
+
+		result, err := newTestService(es, members).ReindexMembers(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gold-members-old"}, result.Removed)
+
+		var indices []string
+		for index := range es.indices {
+			indices = append(indices, index)
+		}
+		sort.Strings(indices)
+		assert.Equal(t, []string{result.Index}, indices)
+	})
+
+	t.Run("member yang dihapus selama salinan dihapus lagi", func(t *testing.T) {
+		es := newFakeES()
+		es.indices["gold-members-old"] = map[int]elasticEntity.UserDocument{}
+		es.aliases["gold-members"] = []string{"gold-members-old"}
+		members := &fakeMembers{updated: map[int]bool{}}
+		for id := 1; id <= 3; id++ {
+			members.docs = append(members.docs, elasticEntity.UserDocument{GoldId: id, GoldNama: "Member"})
+		}
+		svc := newTestService(es, members)
+		// member 2 sudah terbaca salinan, delete-nya masuk ke index lama
+		members.during = func() {
+			members.docs = append(members.docs[:1], members.docs[2:]...)
+			require.NoError(t, svc.ProjectMember(context.Background(), "d", elasticEntity.UserDocument{GoldId: 2}))
+			require.NoError(t, svc.FlushMembers(context.Background()))
+		}
+
+		result, err := svc.ReindexMembers(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, es.indices[result.Index], 1)
+		assert.NotContains(t, es.indices[result.Index], 2)
+		assert.Contains(t, es.indices[result.Index], 3)
+	})
+
+	t.Run("gagal menyalin tidak memindah alias", func(t *testing.T) {
+		es := newFakeES()
+		es.aliases["gold-members"] = []string{"gold-members-old"}
+		es.indices["gold-members-old"] = map[int]elasticEntity.UserDocument{}
+		es.bulkErr = errors.New("es down")
+
+		result, err := newTestService(es, members).ReindexMembers(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, []string{"gold-members-old"}, es.aliases["gold-members"])
+		assert.NotContains(t, es.indices, result.Index)
+	})
+}
+
+func TestMigrateMemberIndex(t *testing.T) {
+	members := &fakeMembers{docs: []elasticEntity.UserDocument{{GoldId: 1, GoldNama: "Budi"}}}
+	current := elasticEntity.MemberTemplateVersion
+
+	t.Run("alias belum ada", func(t *testing.T) {
+		es := newFakeES()
+
+		result, err := newTestService(es, members).MigrateMemberIndex(context.Background())
+		require.NoError(t, err)
+		assert.True(t, result.Reindexed)
+		assert.Equal(t, current, es.templates["gold-members"])
+		assert.Equal(t, []string{result.Index}, es.aliases["gold-members"])
+		assert.Equal(t, current, es.versions[result.Index])
+		assert.Len(t, es.indices[result.Index], 1)
+	})
+
+	t.Run("index konkret lama", func(t *testing.T) {
+		es := newFakeES()
+		es.indices["gold-members"] = map[int]elasticEntity.UserDocument{}
+
+		result, err := newTestService(es, members).MigrateMemberIndex(context.Background())
+		require.NoError(t, err)
+		assert.True(t, result.Reindexed)
+		assert.Equal(t, []string{result.Index}, es.aliases["gold-members"])
+	})
+
+	t.Run("index dari template lama dibangun ulang", func(t *testing.T) {
+		es := newFakeES()
+		es.templates["gold-members"] = current - 1
+		es.indices["gold-members-old"] = map[int]elasticEntity.UserDocument{}
+		es.aliases["gold-members"] = []string{"gold-members-old"}
+		es.versions["gold-members-old"] = current - 1
+
+		result, err := newTestService(es, members).MigrateMemberIndex(context.Background())
+		require.NoError(t, err)
+		assert.True(t, result.Reindexed)
+		assert.Equal(t, current, es.templates["gold-members"])
+		assert.Equal(t, []string{"gold-members-old"}, result.Reindex.Removed)
+	})
+
+	t.Run("sudah terbaru", func(t *testing.T) {
+		es := newFakeES()
+		es.templates["gold-members"] = current
+		es.indices["gold-members-1"] = map[int]elasticEntity.UserDocument{}
+		es.aliases["gold-members"] = []string{"gold-members-1"}
+		es.versions["gold-members-1"] = current
+
+		result, err := newTestService(es, members).MigrateMemberIndex(context.Background())
+		require.NoError(t, err)
+		assert.False(t, result.Reindexed)
+		assert.Equal(t, "gold-members-1", result.Index)
+		assert.Zero(t, es.requests)
+	})
+
+	t.Run("template lebih baru tidak diturunkan", func(t *testing.T) {
+		es := newFakeES()
+		es.templates["gold-members"] = current + 1
+
+		result, err := newTestService(es, members).MigrateMemberIndex(context.Background())
+		require.NoError(t, err)
+		assert.False(t, result.Reindexed)
+		assert.Equal(t, current+1, result.TemplateVersion)
+		assert.Equal(t, current+1, es.templates["gold-members"])
+		assert.Empty(t, es.aliases)
+	})
+}
+
+func TestRefreshMember(t *testing.T) {
+	es := newFakeES()
+	es.indices["gold-members"] = map[int]elasticEntity.UserDocument{
+		8: {GoldId: 8, GoldNama: "Sari"},
+	}
+	members := &fakeMembers{docs: []elasticEntity.UserDocument{
+		{GoldId: 7, GoldNama: "Budi", SubscriptionStatus: []string{"aktif"}, SubscriptionEnd: "2026-12-31 00:00:00"},
+	}}
+	svc := newTestService(es, members)
+
+	require.NoError(t, svc.RefreshMember(context.Background(), 7))
+	require.NoError(t, svc.FlushMembers(context.Background()))
+	assert.Equal(t, []string{"aktif"}, es.indices["gold-members"][7].SubscriptionStatus)
+
+	// member yang sudah tidak ada dihapus dari index
+	require.NoError(t, svc.RefreshMember(context.Background(), 8))
+	require.NoError(t, svc.FlushMembers(context.Background()))
+	assert.NotContains(t, es.indices["gold-members"], 8)
+}
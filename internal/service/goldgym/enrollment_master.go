@@ -0,0 +1,31 @@
+package goldgym
+
+import (
+	"context"
+	goldEntity "gold-gym-be/internal/entity/goldgym"
+	"gold-gym-be/pkg/errors"
+)
+
+// EnrollPartnerMember returns the member registered with email, creating it
+// when it does not exist yet. Enrolled members confirm their email through the
+// OTP flow when they set their password.
+func (s Service) EnrollPartnerMember(ctx context.Context, email, nama, nomorHp string) (int, bool, error) {
+	user, err := s.goldgym.GetGoldUserByEmail(ctx, email)
+	if err == nil {
+		return user.GoldId, false, nil
+	}
+	if !isRecordNotFound(err) {
+		return 0, false, errors.Wrap(err, "[SERVICE][EnrollPartnerMember][GetGoldUserByEmail]")
+	}
+
+	goldID, err := s.insertMemberWithoutPassword(ctx, goldEntity.OIDCMember{
+		GoldEmail:      email,
+		GoldNama:       nama,
+		GoldNomorHp:    nomorHp,
+		GoldValidasiYN: "N",
+	})
+	if err != nil {
+		return 0, false, errors.Wrap(err, "[SERVICE][EnrollPartnerMember]")
+	}
+	return goldID, true, nil
+}
@@ -0,0 +1,56 @@
+package goldgym
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	goldEntity "gold-gym-be/internal/entity/goldgym"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrollPartnerMember(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("existing member is reused", func(t *testing.T) {
+		_, repo := newIdentityRepo(goldEntity.GetGoldUserss{GoldId: 1, GoldEmail: "budi@corp.com"})
+		svc := newTestService(repo)
+
+		id, created, err := svc.EnrollPartnerMember(ctx, "budi@corp.com", "Budi", "0812")
+		require.NoError(t, err)
+		assert.Equal(t, 1, id)
+		assert.False(t, created)
+	})
+
+	t.Run("new member must set a password", func(t *testing.T) {
+		var inserted goldEntity.OIDCMember
+		_, repo := newIdentityRepo()
+		insert := repo.InsertGoldUserOIDCFn
+		repo.InsertGoldUserOIDCFn = func(ctx context.Context, m goldEntity.OIDCMember) (int, error) {
+			inserted = m
+			return insert(ctx, m)
+		}
+		svc := newTestService(repo)
+
+		id, created, err := svc.EnrollPartnerMember(ctx, "sari@corp.com", "Sari", "0813")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NotZero(t, id)
+		assert.Equal(t, "0813", inserted.GoldNomorHp)
+		assert.Equal(t, "N", inserted.GoldValidasiYN)
+		assert.Equal(t, 1, inserted.GoldForceChangePassword)
+		assert.NotEmpty(t, inserted.GoldPassword)
+	})
+
+	t.Run("lookup failure", func(t *testing.T) {
+		svc := newTestService(&mockRepo{
+			GetGoldUserByEmailFn: func(context.Context, string) (goldEntity.GetGoldUserss, error) {
+				return goldEntity.GetGoldUserss{}, errors.New("connection refused")
+			},
+		})
+		_, _, err := svc.EnrollPartnerMember(ctx, "sari@corp.com", "Sari", "")
+		assert.Error(t, err)
+	})
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"gold-gym-be/internal/entity"
+	"gold-gym-be/pkg/jwtkeys"
 	jaegerLog "gold-gym-be/pkg/log"
+	"gold-gym-be/pkg/oidc"
 
 	goldEntity "gold-gym-be/internal/entity/goldgym"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
 
 	"github.com/opentracing/opentracing-go"
 	// "go.opentelemetry.io/otel/trace"
@@ -43,34 +46,56 @@ type RepoData interface {
 	GetSubscriptionHeader(ctx context.Context, id int) (goldEntity.SubscriptionHeader, error)
 	UpdateValidasiPaymentHeader(ctx context.Context, updatePayment goldEntity.UpdatePayment) error
 	UpdateValidasiPaymentDetail(ctx context.Context, updatePayment goldEntity.UpdatePayment) error
+	ConfirmPayment(ctx context.Context, updatePayment goldEntity.UpdatePayment, event outboxEntity.Event) error
 	GetSubscriptionHeaderTotalHarga(ctx context.Context, id int) (goldEntity.SubscriptionHeaderPayment, error)
 	GetPasswordByUser(ctx context.Context, _user string) (string, error)
 	UpdateLastLogin(ctx context.Context, _user goldEntity.GetGoldUserss) error
+	GetMemberBranchAccess(ctx context.Context, goldID int) (string, error)
 
 	//testings
 	UploadTestingImages(ctx context.Context, testing goldEntity.Testings) (string, error)
 	GetTestingImages(ctx context.Context, id int) ([]byte, error)
 
 	GetGoldUserByID(ctx context.Context, id string) (goldEntity.GetGoldUserss, error)
+
+	//social login
+	GetMemberIdentity(ctx context.Context, provider, subject string) (goldEntity.MemberIdentity, error)
+	GetMemberIdentities(ctx context.Context, goldID int) ([]goldEntity.MemberIdentity, error)
+	InsertMemberIdentity(ctx context.Context, identity goldEntity.MemberIdentity) error
+	DeleteMemberIdentity(ctx context.Context, goldID int, provider string) error
+	InsertOIDCLoginState(ctx context.Context, state goldEntity.OIDCLoginState) error
+	ConsumeOIDCLoginState(ctx context.Context, stateID string) (goldEntity.OIDCLoginState, error)
+	InsertGoldUserOIDC(ctx context.Context, member goldEntity.OIDCMember) (int, error)
+}
+
+// IdentityProvider is an external OpenID Connect provider (Google, ...)
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier string) (oidc.Token, error)
+	VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (oidc.Claims, error)
 }
 
 // Service ...
 // Tambahkan variable sesuai banyak data layer yang dibutuhkan
 type Service struct {
-	goldgym RepoData
-	tracer  opentracing.Tracer
+	goldgym   RepoData
+	providers map[string]IdentityProvider
+	tokenKeys *jwtkeys.KeySet
+	tracer    opentracing.Tracer
 	// tracer trace.Tracer
 	logger jaegerLog.Factory
 }
 
 // New ...
 // Tambahkan parameter sesuai banyak data layer yang dibutuhkan
-func New(goldgymData RepoData, tracer opentracing.Tracer, logger jaegerLog.Factory) *Service {
+func New(goldgymData RepoData, identityProviders map[string]IdentityProvider, tokenKeys *jwtkeys.KeySet, tracer opentracing.Tracer, logger jaegerLog.Factory) *Service {
 	// Assign variable dari parameter ke object
 	return &Service{
-		goldgym: goldgymData,
-		tracer:  tracer,
-		logger:  logger,
+		goldgym:   goldgymData,
+		providers: identityProviders,
+		tokenKeys: tokenKeys,
+		tracer:    tracer,
+		logger:    logger,
 	}
 }
 
@@ -3,9 +3,13 @@ package goldgym
 import (
 	"context"
 	"fmt"
+	"gold-gym-be/internal/entity"
 	"gold-gym-be/internal/entity/auth/v2"
+	branchEntity "gold-gym-be/internal/entity/branch"
 	goldEntity "gold-gym-be/internal/entity/goldgym"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
 	"gold-gym-be/pkg/errors"
+	"gold-gym-be/pkg/jwtkeys"
 	"gold-gym-be/pkg/response"
 	"log"
 	"math"
@@ -336,8 +340,6 @@ func (s Service) InsertGoldUser(ctx context.Context, user goldEntity.GetGoldUser
 
 var (
 	jwtApplicationName = "GOLD-GYM-BE"
-	jwtSigningMethod   = jwt.SigningMethodHS256
-	jwtSecret          = []byte("a7fecfed-14c8-4f54-84a7-e43fe9cf1823")
 )
 
 func GenerateNumber(length int) (string, error) {
@@ -355,6 +357,77 @@ func GenerateNumber(length int) (string, error) {
 	return string(buffer), nil
 }
 
+// issueToken signs our access token for an authenticated member and records the login
+func (s Service) issueToken(ctx context.Context, user goldEntity.GetGoldUserss, _host string) (auth.Token, error) {
+	var token auth.Token
+
+	t := time.Now()
+	d := 12 * time.Hour
+	e := t.Add(d)
+
+	// The branch claims scope what the member sees, see middleware.ScopedBranch
+	access, err := s.goldgym.GetMemberBranchAccess(ctx, user.GoldId)
+	if err != nil {
+		return token, errors.Wrap(err, "[SERVICE][issueToken]")
+	}
+	if access != branchEntity.AccessAll {
+		access = branchEntity.AccessHome
+	}
+	branchID := user.GoldBranchID
+	if branchID == 0 {
+		branchID = branchEntity.MainBranchID
+	}
+
+	// Signed with the active key; the kid header tells verifiers which JWKS entry to use
+	accessToken, err := s.tokenKeys.Sign(jwt.MapClaims{
+		"iss":           jwtApplicationName,
+		"sub":           user.GoldEmail,
+		"user":          user.GoldEmail,
+		"branch_id":     branchID,
+		"branch_access": access,
+		"nbf":           t.Unix(),
+		"iat":           t.Unix(),
+		"exp":           e.Unix(),
+	})
+	if err != nil {
+		return token, errors.Wrap(err, "[SERVICE][issueToken]")
+	}
+
+	user.GoldLastLoginHost = _host
+	err = s.goldgym.UpdateLastLogin(ctx, user)
+	if err != nil {
+		return token, errors.Wrap(err, "[SERVICE][issueToken]")
+	}
+
+	token = auth.Token{
+		AccessToken:         accessToken,
+		ExpiresIn:           e.Unix() - t.Unix(),
+		ExpiresAt:           e.Unix(),
+		TokenType:           "Bearer",
+		ForceChangePassword: user.GoldForceChangePassword,
+	}
+	return token, nil
+}
+
+// ParseAccessToken verifies one of our access tokens and returns its claims
+func (s Service) ParseAccessToken(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	parsed, err := s.tokenKeys.Parse(accessToken)
+	if err != nil {
+		return nil, errors.Wrap(entity.ErrUnauthorized, "[SERVICE][ParseAccessToken] "+err.Error())
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !parsed.Valid || claims["iss"] != jwtApplicationName {
+		return nil, errors.Wrap(entity.ErrUnauthorized, "[SERVICE][ParseAccessToken]")
+	}
+	return claims, nil
+}
+
+// GetJWKS returns the public keys verifiers need to check our access tokens
+func (s Service) GetJWKS(ctx context.Context) jwtkeys.JSONWebKeySet {
+	return s.tokenKeys.JWKS()
+}
+
 // func (s Service) LoginUser(ctx context.Context, user goldEntity.LogUser) (interface{}, goldEntity.LoginUser, error) {
 func (s Service) LoginUser(ctx context.Context, _user, _password string, _host string) (auth.Token, map[string]interface{}, error) {
 	var (
@@ -392,46 +465,10 @@ func (s Service) LoginUser(ctx context.Context, _user, _password string, _host s
 	}
 	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK2")
 
-	t := time.Now()
-	d := 12 * time.Hour
-	e := t.Add(d)
-	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK3")
-
-	// Set Header Token
-	sign := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"iss":  jwtApplicationName,
-		"sub":  _user,
-		"user": _user,
-		"nbf":  t.Unix(),
-		"iat":  t.Unix(),
-		"exp":  e.Unix(),
-	})
-	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK4")
-
-	// Set Secret Key Token
-	accessToken, err := sign.SignedString(jwtSecret)
+	token, err = s.issueToken(ctx, user, _host)
 	if err != nil {
 		return token, metadata, errors.Wrap(err, "[SERVICE][Login]")
 	}
-	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK5")
-	log.Println("testHOST", _host)
-	user.GoldLastLoginHost = _host
-	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK6")
-	err = s.goldgym.UpdateLastLogin(ctx, user)
-	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK7")
-	if err != nil {
-		return token, metadata, errors.Wrap(err, "[SERVICE][Login]")
-	}
-	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK8")
-
-	token = auth.Token{
-		AccessToken:         accessToken,
-		ExpiresIn:           e.Unix() - t.Unix(),
-		ExpiresAt:           e.Unix(),
-		TokenType:           "Bearer",
-		ForceChangePassword: user.GoldForceChangePassword,
-	}
-	log.Println("MASSSSSSSSSSSSOOOOOOOOOOOOOOOOOKKKKKKKKKKKKKK9")
 
 	metadata["username"] = user.GoldNama
 	log.Println("metadata", metadata)
@@ -539,7 +576,7 @@ func (s Service) InsertSubscriptionUser(ctx context.Context, subs goldEntity.Ins
 		err              error
 		detailData       goldEntity.SubscriptionDetail
 		insertDetailData []goldEntity.SubscriptionDetail
-		totalHarga       float64
+		totalHarga       entity.Money
 	)
 
 	header, err := s.goldgym.GetAllSubscription(ctx)
@@ -1099,8 +1136,21 @@ func (s Service) UpdatePayment(ctx context.Context, otp string, email string) (s
 		if nowHourMinuteConv <= otpHourMinuteConv+5.0 {
 			log.Println("false-Time")
 			updatePayment.GoldID = header.GoldId
-			err = s.goldgym.UpdateValidasiPaymentHeader(ctx, updatePayment)
-			err = s.goldgym.UpdateValidasiPaymentDetail(ctx, updatePayment)
+			paid := outboxEntity.SubscriptionPaid{
+				GoldID:     header.GoldId,
+				Email:      header.GoldEmail,
+				TotalHarga: subs.GoldTotalharga,
+				PaidAt:     now,
+			}
+			event, err := outboxEntity.NewEvent(outboxEntity.EventSubscriptionPaid, outboxEntity.AggregateMember, strconv.Itoa(header.GoldId), paid, now)
+			if err != nil {
+				return "Error", errors.Wrap(err, "[Service][UpdatePayment]"), resp
+			}
+			if err := s.goldgym.ConfirmPayment(ctx, updatePayment, event); err != nil {
+				resp.StatusCode = 501
+				resp.Error.Status = true
+				return "Error", errors.Wrap(err, "[Service][UpdatePayment]"), resp
+			}
 			result = "OTP true"
 		}
 
@@ -0,0 +1,299 @@
+package goldgym
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	"gold-gym-be/internal/entity/auth/v2"
+	goldEntity "gold-gym-be/internal/entity/goldgym"
+	"gold-gym-be/pkg/errors"
+	"gold-gym-be/pkg/oidc"
+	"strconv"
+	"time"
+
+	"github.com/raja/argon2pw"
+)
+
+// oidcStateTTL is how long a member has to finish the provider consent screen
+const oidcStateTTL = 10 * time.Minute
+
+func isRecordNotFound(err error) bool {
+	return err != nil && err.Error() == "record not found"
+}
+
+// BeginOIDCLogin starts a social login for the given provider
+func (s Service) BeginOIDCLogin(ctx context.Context, provider string) (goldEntity.OIDCAuthorize, error) {
+	return s.beginOIDC(ctx, provider, 0)
+}
+
+// BeginOIDCLink starts linking a provider identity to an already signed-in member
+func (s Service) BeginOIDCLink(ctx context.Context, provider string, email string) (goldEntity.OIDCAuthorize, error) {
+	user, err := s.goldgym.GetGoldUserByEmail(ctx, email)
+	if err != nil {
+		if isRecordNotFound(err) {
+			return goldEntity.OIDCAuthorize{}, errors.Wrap(entity.ErrNotFound, "[SERVICE][BeginOIDCLink] member")
+		}
+		return goldEntity.OIDCAuthorize{}, errors.Wrap(err, "[SERVICE][BeginOIDCLink]")
+	}
+	return s.beginOIDC(ctx, provider, user.GoldId)
+}
+
+func (s Service) beginOIDC(ctx context.Context, provider string, goldID int) (goldEntity.OIDCAuthorize, error) {
+	var result goldEntity.OIDCAuthorize
+
+	idp, ok := s.providers[provider]
+	if !ok {
+		return result, errors.Wrap(entity.ErrNotFound, "[SERVICE][beginOIDC] provider "+provider)
+	}
+
+	stateID, err := oidc.RandomString(24)
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][beginOIDC]")
+	}
+	nonce, err := oidc.RandomString(24)
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][beginOIDC]")
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][beginOIDC]")
+	}
+
+	err = s.goldgym.InsertOIDCLoginState(ctx, goldEntity.OIDCLoginState{
+		StateID:           stateID,
+		StateProvider:     provider,
+		StateCodeVerifier: verifier,
+		StateNonce:        nonce,
+		GoldId:            goldID,
+		StateExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][beginOIDC][InsertOIDCLoginState]")
+	}
+
+	authURL, err := idp.AuthCodeURL(ctx, stateID, nonce, oidc.CodeChallengeS256(verifier))
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][beginOIDC][AuthCodeURL]")
+	}
+
+	result = goldEntity.OIDCAuthorize{
+		AuthorizationURL: authURL,
+		State:            stateID,
+	}
+	return result, nil
+}
+
+// CompleteOIDCLogin redeems the provider callback. The external identity is
+// resolved to a member by an existing link, then by verified email, and a new
+// member is created as a last resort. Link flows attach the identity to the
+// member who started them. Either way our normal access token is returned.
+func (s Service) CompleteOIDCLogin(ctx context.Context, provider, stateID, code, _host string) (auth.Token, map[string]interface{}, error) {
+	var (
+		token    auth.Token
+		metadata = make(map[string]interface{})
+	)
+
+	idp, ok := s.providers[provider]
+	if !ok {
+		return token, metadata, errors.Wrap(entity.ErrNotFound, "[SERVICE][CompleteOIDCLogin] provider "+provider)
+	}
+
+	state, err := s.goldgym.ConsumeOIDCLoginState(ctx, stateID)
+	if err != nil {
+		if isRecordNotFound(err) {
+			return token, metadata, errors.Wrap(entity.ErrUnauthorized, "[SERVICE][CompleteOIDCLogin] unknown or expired state")
+		}
+		return token, metadata, errors.Wrap(err, "[SERVICE][CompleteOIDCLogin][ConsumeOIDCLoginState]")
+	}
+	if state.StateProvider != provider {
+		return token, metadata, errors.Wrap(entity.ErrUnauthorized, "[SERVICE][CompleteOIDCLogin] state issued for another provider")
+	}
+
+	tokens, err := idp.Exchange(ctx, code, state.StateCodeVerifier)
+	if err != nil {
+		return token, metadata, errors.Wrap(entity.ErrUnauthorized, "[SERVICE][CompleteOIDCLogin][Exchange] "+err.Error())
+	}
+	claims, err := idp.VerifyIDToken(ctx, tokens.IDToken, state.StateNonce)
+	if err != nil {
+		return token, metadata, errors.Wrap(entity.ErrUnauthorized, "[SERVICE][CompleteOIDCLogin][VerifyIDToken] "+err.Error())
+	}
+
+	var (
+		user   goldEntity.GetGoldUserss
+		action string
+	)
+	if state.GoldId != 0 {
+		user, err = s.linkIdentity(ctx, provider, state.GoldId, claims)
+		action = "linked"
+	} else {
+		user, action, err = s.resolveIdentity(ctx, provider, claims)
+	}
+	if err != nil {
+		return token, metadata, errors.Wrap(err, "[SERVICE][CompleteOIDCLogin]")
+	}
+
+	token, err = s.issueToken(ctx, user, _host)
+	if err != nil {
+		return token, metadata, errors.Wrap(err, "[SERVICE][CompleteOIDCLogin]")
+	}
+
+	metadata["username"] = user.GoldNama
+	metadata["provider"] = provider
+	metadata["action"] = action
+	return token, metadata, nil
+}
+
+func (s Service) linkIdentity(ctx context.Context, provider string, goldID int, claims oidc.Claims) (goldEntity.GetGoldUserss, error) {
+	existing, err := s.goldgym.GetMemberIdentity(ctx, provider, claims.Subject)
+	if err != nil && !isRecordNotFound(err) {
+		return goldEntity.GetGoldUserss{}, errors.Wrap(err, "[SERVICE][linkIdentity][GetMemberIdentity]")
+	}
+	if err == nil && existing.GoldId != goldID {
+		return goldEntity.GetGoldUserss{}, errors.Wrap(entity.ErrInvalid, "[SERVICE][linkIdentity] identity already linked to another member")
+	}
+	if err != nil {
+		err = s.goldgym.InsertMemberIdentity(ctx, goldEntity.MemberIdentity{
+			GoldId:           goldID,
+			IdentityProvider: provider,
+			IdentitySubject:  claims.Subject,
+			IdentityEmail:    claims.Email,
+			IdentityLinkedAt: time.Now(),
+		})
+		if err != nil {
+			return goldEntity.GetGoldUserss{}, errors.Wrap(err, "[SERVICE][linkIdentity][InsertMemberIdentity]")
+		}
+	}
+
+	user, err := s.goldgym.GetGoldUserByID(ctx, strconv.Itoa(goldID))
+	if err != nil {
+		return user, errors.Wrap(err, "[SERVICE][linkIdentity][GetGoldUserByID]")
+	}
+	return user, nil
+}
+
+func (s Service) resolveIdentity(ctx context.Context, provider string, claims oidc.Claims) (goldEntity.GetGoldUserss, string, error) {
+	var user goldEntity.GetGoldUserss
+
+	existing, err := s.goldgym.GetMemberIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		user, err = s.goldgym.GetGoldUserByID(ctx, strconv.Itoa(existing.GoldId))
+		if err != nil {
+			return user, "", errors.Wrap(err, "[SERVICE][resolveIdentity][GetGoldUserByID]")
+		}
+		return user, "login", nil
+	}
+	if !isRecordNotFound(err) {
+		return user, "", errors.Wrap(err, "[SERVICE][resolveIdentity][GetMemberIdentity]")
+	}
+
+	// Matching on an unverified email would let anyone claim a member account
+	if claims.Email == "" || !claims.EmailVerified {
+		return user, "", errors.Wrap(entity.ErrUnauthorized, "[SERVICE][resolveIdentity] provider email is not verified")
+	}
+
+	action := "linked"
+	user, err = s.goldgym.GetGoldUserByEmail(ctx, claims.Email)
+	if err != nil {
+		if !isRecordNotFound(err) {
+			return user, "", errors.Wrap(err, "[SERVICE][resolveIdentity][GetGoldUserByEmail]")
+		}
+
+		nama := claims.Name
+		if nama == "" {
+			nama = claims.Email
+		}
+		goldID, err := s.insertMemberWithoutPassword(ctx, goldEntity.OIDCMember{
+			GoldEmail:      claims.Email,
+			GoldNama:       nama,
+			GoldValidasiYN: "Y",
+		})
+		if err != nil {
+			return user, "", errors.Wrap(err, "[SERVICE][resolveIdentity]")
+		}
+		user = goldEntity.GetGoldUserss{
+			GoldId:                  goldID,
+			GoldEmail:               claims.Email,
+			GoldNama:                nama,
+			GoldValidasiYN:          "Y",
+			GoldForceChangePassword: 1,
+		}
+		action = "created"
+	}
+
+	err = s.goldgym.InsertMemberIdentity(ctx, goldEntity.MemberIdentity{
+		GoldId:           user.GoldId,
+		IdentityProvider: provider,
+		IdentitySubject:  claims.Subject,
+		IdentityEmail:    claims.Email,
+		IdentityLinkedAt: time.Now(),
+	})
+	if err != nil {
+		return user, "", errors.Wrap(err, "[SERVICE][resolveIdentity][InsertMemberIdentity]")
+	}
+	return user, action, nil
+}
+
+// insertMemberWithoutPassword creates a member who never chose a password.
+// They get an unguessable one and are asked to set a real one on first login.
+func (s Service) insertMemberWithoutPassword(ctx context.Context, member goldEntity.OIDCMember) (int, error) {
+	secret, err := oidc.RandomString(32)
+	if err != nil {
+		return 0, errors.Wrap(err, "[SERVICE][insertMemberWithoutPassword]")
+	}
+	member.GoldPassword, err = argon2pw.GenerateSaltedHash(secret)
+	if err != nil {
+		return 0, errors.Wrap(err, "[SERVICE][insertMemberWithoutPassword]")
+	}
+	member.GoldForceChangePassword = 1
+
+	goldID, err := s.goldgym.InsertGoldUserOIDC(ctx, member)
+	if err != nil {
+		return 0, errors.Wrap(err, "[SERVICE][insertMemberWithoutPassword][InsertGoldUserOIDC]")
+	}
+	return goldID, nil
+}
+
+// GetLinkedIdentities lists the external identities linked to a member
+func (s Service) GetLinkedIdentities(ctx context.Context, email string) ([]goldEntity.MemberIdentity, error) {
+	user, err := s.goldgym.GetGoldUserByEmail(ctx, email)
+	if err != nil {
+		if isRecordNotFound(err) {
+			return nil, errors.Wrap(entity.ErrNotFound, "[SERVICE][GetLinkedIdentities] member")
+		}
+		return nil, errors.Wrap(err, "[SERVICE][GetLinkedIdentities]")
+	}
+
+	identities, err := s.goldgym.GetMemberIdentities(ctx, user.GoldId)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][GetLinkedIdentities]")
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity removes a provider link. A member created through social
+// login who never set a password cannot drop their last identity.
+func (s Service) UnlinkIdentity(ctx context.Context, email, provider string) (string, error) {
+	user, err := s.goldgym.GetGoldUserByEmail(ctx, email)
+	if err != nil {
+		if isRecordNotFound(err) {
+			return "Gagal", errors.Wrap(entity.ErrNotFound, "[SERVICE][UnlinkIdentity] member")
+		}
+		return "Gagal", errors.Wrap(err, "[SERVICE][UnlinkIdentity]")
+	}
+
+	identities, err := s.goldgym.GetMemberIdentities(ctx, user.GoldId)
+	if err != nil {
+		return "Gagal", errors.Wrap(err, "[SERVICE][UnlinkIdentity]")
+	}
+	if len(identities) <= 1 && user.GoldForceChangePassword == 1 {
+		return "Gagal", errors.Wrap(entity.ErrInvalid, "[SERVICE][UnlinkIdentity] set a password before removing the last linked identity")
+	}
+
+	err = s.goldgym.DeleteMemberIdentity(ctx, user.GoldId, provider)
+	if err != nil {
+		if isRecordNotFound(err) {
+			return "Gagal", errors.Wrap(entity.ErrNotFound, "[SERVICE][UnlinkIdentity] identity")
+		}
+		return "Gagal", errors.Wrap(err, "[SERVICE][UnlinkIdentity]")
+	}
+	return "Berhasil", nil
+}
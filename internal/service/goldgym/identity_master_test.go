@@ -0,0 +1,261 @@
+package goldgym
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	goldEntity "gold-gym-be/internal/entity/goldgym"
+	pkgErrors "gold-gym-be/pkg/errors"
+	"gold-gym-be/pkg/oidc"
+	"gold-gym-be/pkg/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errRecordNotFound = errors.New("record not found")
+
+// identityRepo menyimpan state, member dan identity di memory supaya alur OIDC
+// bisa dites end-to-end terhadap fake provider.
+type identityRepo struct {
+	states     map[string]goldEntity.OIDCLoginState
+	members    map[int]goldEntity.GetGoldUserss
+	identities []goldEntity.MemberIdentity
+}
+
+func newIdentityRepo(members ...goldEntity.GetGoldUserss) (*identityRepo, *mockRepo) {
+	r := &identityRepo{
+		states:  make(map[string]goldEntity.OIDCLoginState),
+		members: make(map[int]goldEntity.GetGoldUserss),
+	}
+	for _, m := range members {
+		r.members[m.GoldId] = m
+	}
+
+	return r, &mockRepo{
+		InsertOIDCLoginStateFn: func(_ context.Context, s goldEntity.OIDCLoginState) error {
+			r.states[s.StateID] = s
+			return nil
+		},
+		ConsumeOIDCLoginStateFn: func(_ context.Context, id string) (goldEntity.OIDCLoginState, error) {
+			s, ok := r.states[id]
+			if !ok {
+				return s, errRecordNotFound
+			}
+			delete(r.states, id)
+			return s, nil
+		},
+		GetGoldUserByEmailFn: func(_ context.Context, email string) (goldEntity.GetGoldUserss, error) {
+			for _, m := range r.members {
+				if m.GoldEmail == email {
+					return m, nil
+				}
+			}
+			return goldEntity.GetGoldUserss{}, errRecordNotFound
+		},
+		GetGoldUserByIDFn: func(_ context.Context, id string) (goldEntity.GetGoldUserss, error) {
+			n, _ := strconv.Atoi(id)
+			m, ok := r.members[n]
+			if !ok {
+				return m, errRecordNotFound
+			}
+			return m, nil
+		},
+		InsertGoldUserOIDCFn: func(_ context.Context, m goldEntity.OIDCMember) (int, error) {
+			id := len(r.members) + 100
+			r.members[id] = goldEntity.GetGoldUserss{GoldId: id, GoldEmail: m.GoldEmail, GoldNama: m.GoldNama, GoldForceChangePassword: m.GoldForceChangePassword}
+			return id, nil
+		},
+		GetMemberIdentityFn: func(_ context.Context, provider, subject string) (goldEntity.MemberIdentity, error) {
+			for _, i := range r.identities {
+				if i.IdentityProvider == provider && i.IdentitySubject == subject {
+					return i, nil
+				}
+			}
+			return goldEntity.MemberIdentity{}, errRecordNotFound
+		},
+		GetMemberIdentitiesFn: func(_ context.Context, goldID int) ([]goldEntity.MemberIdentity, error) {
+			var out []goldEntity.MemberIdentity
+			for _, i := range r.identities {
+				if i.GoldId == goldID {
+					out = append(out, i)
+				}
+			}
+			return out, nil
+		},
+		InsertMemberIdentityFn: func(_ context.Context, i goldEntity.MemberIdentity) error {
+			r.identities = append(r.identities, i)
+			return nil
+		},
+		DeleteMemberIdentityFn: func(_ context.Context, goldID int, provider string) error {
+			for n, i := range r.identities {
+				if i.GoldId == goldID && i.IdentityProvider == provider {
+					r.identities = append(r.identities[:n], r.identities[n+1:]...)
+					return nil
+				}
+			}
+			return errRecordNotFound
+		},
+	}
+}
+
+func newOIDCTestService(t *testing.T, repo RepoData) (*Service, *oidctest.Server) {
+	t.Helper()
+	srv := oidctest.NewServer("gold-gym", "s3cret")
+	t.Cleanup(srv.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       srv.Issuer(),
+		ClientID:     "gold-gym",
+		ClientSecret: "s3cret",
+		RedirectURL:  "http://localhost/callback",
+	}, nil)
+	return New(repo, map[string]IdentityProvider{"fake": provider}, testTokenKeys, nil, newTestLogger()), srv
+}
+
+// signIn menjalankan authorize -> consent -> callback terhadap fake provider
+func signIn(t *testing.T, svc *Service, srv *oidctest.Server, linkEmail string) (map[string]interface{}, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	var (
+		start goldEntity.OIDCAuthorize
+		err   error
+	)
+	if linkEmail != "" {
+		start, err = svc.BeginOIDCLink(ctx, "fake", linkEmail)
+	} else {
+		start, err = svc.BeginOIDCLogin(ctx, "fake")
+	}
+	require.NoError(t, err)
+
+	code, state, err := srv.Authorize(start.AuthorizationURL)
+	require.NoError(t, err)
+	require.Equal(t, start.State, state)
+
+	token, metadata, err := svc.CompleteOIDCLogin(ctx, "fake", state, code, "127.0.0.1")
+	if err == nil {
+		assert.Equal(t, "Bearer", token.TokenType)
+		claims, perr := svc.ParseAccessToken(ctx, token.AccessToken)
+		assert.NoError(t, perr)
+		assert.NotEmpty(t, claims["user"])
+	}
+	return metadata, err
+}
+
+func TestCompleteOIDCLogin(t *testing.T) {
+	budi := goldEntity.GetGoldUserss{GoldId: 1, GoldEmail: "budi@test.com", GoldNama: "Budi Santoso"}
+
+	tests := []struct {
+		name       string
+		user       oidctest.User
+		wantAction string
+		wantErr    error
+	}{
+		{
+			name:       "links existing member by verified email",
+			user:       oidctest.User{Subject: "g-1", Email: "budi@test.com", EmailVerified: true},
+			wantAction: "linked",
+		},
+		{
+			name:       "creates member for unknown email",
+			user:       oidctest.User{Subject: "g-2", Email: "sari@test.com", EmailVerified: true, Name: "Sari"},
+			wantAction: "created",
+		},
+		{
+			name:    "refuses unverified email",
+			user:    oidctest.User{Subject: "g-3", Email: "budi@test.com"},
+			wantErr: entity.ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, repo := newIdentityRepo(budi)
+			svc, srv := newOIDCTestService(t, repo)
+			srv.SetUser(tt.user)
+
+			metadata, err := signIn(t, svc, srv, "")
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, pkgErrors.Cause(err))
+				assert.Empty(t, store.identities)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAction, metadata["action"])
+			require.Len(t, store.identities, 1)
+			assert.Equal(t, tt.user.Subject, store.identities[0].IdentitySubject)
+
+			// second sign-in resolves through the stored link
+			metadata, err = signIn(t, svc, srv, "")
+			require.NoError(t, err)
+			assert.Equal(t, "login", metadata["action"])
+			assert.Len(t, store.identities, 1)
+		})
+	}
+}
+
+func TestCompleteOIDCLoginStateIsSingleUse(t *testing.T) {
+	_, repo := newIdentityRepo()
+	svc, srv := newOIDCTestService(t, repo)
+	srv.SetUser(oidctest.User{Subject: "g-1", Email: "sari@test.com", EmailVerified: true})
+
+	start, err := svc.BeginOIDCLogin(context.Background(), "fake")
+	require.NoError(t, err)
+	code, state, err := srv.Authorize(start.AuthorizationURL)
+	require.NoError(t, err)
+
+	_, _, err = svc.CompleteOIDCLogin(context.Background(), "fake", state, code, "127.0.0.1")
+	require.NoError(t, err)
+
+	_, _, err = svc.CompleteOIDCLogin(context.Background(), "fake", state, code, "127.0.0.1")
+	assert.Equal(t, entity.ErrUnauthorized, pkgErrors.Cause(err))
+}
+
+func TestLinkAndUnlinkIdentity(t *testing.T) {
+	budi := goldEntity.GetGoldUserss{GoldId: 1, GoldEmail: "budi@test.com", GoldNama: "Budi Santoso"}
+	sari := goldEntity.GetGoldUserss{GoldId: 2, GoldEmail: "sari@test.com", GoldNama: "Sari", GoldForceChangePassword: 1}
+
+	store, repo := newIdentityRepo(budi, sari)
+	svc, srv := newOIDCTestService(t, repo)
+	ctx := context.Background()
+
+	// the provider email does not need to match when a signed-in member links
+	srv.SetUser(oidctest.User{Subject: "g-1", Email: "budi.personal@gmail.com"})
+	metadata, err := signIn(t, svc, srv, "budi@test.com")
+	require.NoError(t, err)
+	assert.Equal(t, "linked", metadata["action"])
+
+	identities, err := svc.GetLinkedIdentities(ctx, "budi@test.com")
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, "fake", identities[0].IdentityProvider)
+
+	// the same external identity cannot be attached to a second member
+	_, err = signIn(t, svc, srv, "sari@test.com")
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+
+	result, err := svc.UnlinkIdentity(ctx, "budi@test.com", "fake")
+	require.NoError(t, err)
+	assert.Equal(t, "Berhasil", result)
+	assert.Empty(t, store.identities)
+
+	_, err = svc.UnlinkIdentity(ctx, "budi@test.com", "fake")
+	assert.Equal(t, entity.ErrNotFound, pkgErrors.Cause(err))
+
+	// a social-only member keeps at least one way to sign in
+	srv.SetUser(oidctest.User{Subject: "g-2", Email: "sari@test.com", EmailVerified: true})
+	_, err = signIn(t, svc, srv, "")
+	require.NoError(t, err)
+	_, err = svc.UnlinkIdentity(ctx, "sari@test.com", "fake")
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+}
+
+func TestBeginOIDCLoginUnknownProvider(t *testing.T) {
+	svc := newTestService(&mockRepo{})
+	_, err := svc.BeginOIDCLogin(context.Background(), "myspace")
+	assert.Equal(t, entity.ErrNotFound, pkgErrors.Cause(err))
+}
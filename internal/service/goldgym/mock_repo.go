@@ -4,6 +4,7 @@ import (
 	"context"
 
 	goldEntity "gold-gym-be/internal/entity/goldgym"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
 )
 
 // mockRepo mengimplementasikan interface RepoData untuk unit test.
@@ -43,12 +44,21 @@ type mockRepo struct {
 	GetSubscriptionHeaderFn           func(ctx context.Context, id int) (goldEntity.SubscriptionHeader, error)
 	UpdateValidasiPaymentHeaderFn     func(ctx context.Context, updatePayment goldEntity.UpdatePayment) error
 	UpdateValidasiPaymentDetailFn     func(ctx context.Context, updatePayment goldEntity.UpdatePayment) error
+	ConfirmPaymentFn                  func(ctx context.Context, updatePayment goldEntity.UpdatePayment, event outboxEntity.Event) error
 	GetSubscriptionHeaderTotalHargaFn func(ctx context.Context, id int) (goldEntity.SubscriptionHeaderPayment, error)
 	GetPasswordByUserFn               func(ctx context.Context, _user string) (string, error)
 	UpdateLastLoginFn                 func(ctx context.Context, _user goldEntity.GetGoldUserss) error
+	GetMemberBranchAccessFn           func(ctx context.Context, goldID int) (string, error)
 	UploadTestingImagesFn             func(ctx context.Context, testing goldEntity.Testings) (string, error)
 	GetTestingImagesFn                func(ctx context.Context, id int) ([]byte, error)
 	GetGoldUserByIDFn                 func(ctx context.Context, id string) (goldEntity.GetGoldUserss, error)
+	GetMemberIdentityFn               func(ctx context.Context, provider, subject string) (goldEntity.MemberIdentity, error)
+	GetMemberIdentitiesFn             func(ctx context.Context, goldID int) ([]goldEntity.MemberIdentity, error)
+	InsertMemberIdentityFn            func(ctx context.Context, identity goldEntity.MemberIdentity) error
+	DeleteMemberIdentityFn            func(ctx context.Context, goldID int, provider string) error
+	InsertOIDCLoginStateFn            func(ctx context.Context, state goldEntity.OIDCLoginState) error
+	ConsumeOIDCLoginStateFn           func(ctx context.Context, stateID string) (goldEntity.OIDCLoginState, error)
+	InsertGoldUserOIDCFn              func(ctx context.Context, member goldEntity.OIDCMember) (int, error)
 }
 
 func (m *mockRepo) GetGoldUser(ctx context.Context) ([]goldEntity.GetGoldUser, error) {
@@ -233,6 +243,13 @@ func (m *mockRepo) UpdateValidasiPaymentDetail(ctx context.Context, updatePaymen
 	return nil
 }
 
+func (m *mockRepo) ConfirmPayment(ctx context.Context, updatePayment goldEntity.UpdatePayment, event outboxEntity.Event) error {
+	if m.ConfirmPaymentFn != nil {
+		return m.ConfirmPaymentFn(ctx, updatePayment, event)
+	}
+	return nil
+}
+
 func (m *mockRepo) GetSubscriptionHeaderTotalHarga(ctx context.Context, id int) (goldEntity.SubscriptionHeaderPayment, error) {
 	if m.GetSubscriptionHeaderTotalHargaFn != nil {
 		return m.GetSubscriptionHeaderTotalHargaFn(ctx, id)
@@ -254,6 +271,13 @@ func (m *mockRepo) UpdateLastLogin(ctx context.Context, _user goldEntity.GetGold
 	return nil
 }
 
+func (m *mockRepo) GetMemberBranchAccess(ctx context.Context, goldID int) (string, error) {
+	if m.GetMemberBranchAccessFn != nil {
+		return m.GetMemberBranchAccessFn(ctx, goldID)
+	}
+	return "", nil
+}
+
 func (m *mockRepo) UploadTestingImages(ctx context.Context, testing goldEntity.Testings) (string, error) {
 	if m.UploadTestingImagesFn != nil {
 		return m.UploadTestingImagesFn(ctx, testing)
@@ -274,3 +298,52 @@ func (m *mockRepo) GetGoldUserByID(ctx context.Context, id string) (goldEntity.G
 	}
 	return goldEntity.GetGoldUserss{}, nil
 }
+
+func (m *mockRepo) GetMemberIdentity(ctx context.Context, provider, subject string) (goldEntity.MemberIdentity, error) {
+	if m.GetMemberIdentityFn != nil {
+		return m.GetMemberIdentityFn(ctx, provider, subject)
+	}
+	return goldEntity.MemberIdentity{}, nil
+}
+
+func (m *mockRepo) GetMemberIdentities(ctx context.Context, goldID int) ([]goldEntity.MemberIdentity, error) {
+	if m.GetMemberIdentitiesFn != nil {
+		return m.GetMemberIdentitiesFn(ctx, goldID)
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) InsertMemberIdentity(ctx context.Context, identity goldEntity.MemberIdentity) error {
+	if m.InsertMemberIdentityFn != nil {
+		return m.InsertMemberIdentityFn(ctx, identity)
+	}
+	return nil
+}
+
+func (m *mockRepo) DeleteMemberIdentity(ctx context.Context, goldID int, provider string) error {
+	if m.DeleteMemberIdentityFn != nil {
+		return m.DeleteMemberIdentityFn(ctx, goldID, provider)
+	}
+	return nil
+}
+
+func (m *mockRepo) InsertOIDCLoginState(ctx context.Context, state goldEntity.OIDCLoginState) error {
+	if m.InsertOIDCLoginStateFn != nil {
+		return m.InsertOIDCLoginStateFn(ctx, state)
+	}
+	return nil
+}
+
+func (m *mockRepo) ConsumeOIDCLoginState(ctx context.Context, stateID string) (goldEntity.OIDCLoginState, error) {
+	if m.ConsumeOIDCLoginStateFn != nil {
+		return m.ConsumeOIDCLoginStateFn(ctx, stateID)
+	}
+	return goldEntity.OIDCLoginState{}, nil
+}
+
+func (m *mockRepo) InsertGoldUserOIDC(ctx context.Context, member goldEntity.OIDCMember) (int, error) {
+	if m.InsertGoldUserOIDCFn != nil {
+		return m.InsertGoldUserOIDCFn(ctx, member)
+	}
+	return 0, nil
+}
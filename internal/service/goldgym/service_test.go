@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"gold-gym-be/internal/entity"
 	goldEntity "gold-gym-be/internal/entity/goldgym"
 
 	"github.com/raja/argon2pw"
@@ -161,8 +162,8 @@ func TestGetGoldUserByEmail(t *testing.T) {
 
 func TestGetAllSubscription(t *testing.T) {
 	subs := []goldEntity.Subscription{
-		{GoldNamaPaket: "Basic", GoldHarga: 100},
-		{GoldNamaPaket: "Premium", GoldHarga: 250},
+		{GoldNamaPaket: "Basic", GoldHarga: entity.NewMoney(100)},
+		{GoldNamaPaket: "Premium", GoldHarga: entity.NewMoney(250)},
 	}
 
 	tests := []struct {
@@ -331,6 +332,48 @@ func TestLoginUser(t *testing.T) {
 	}
 }
 
+// --- issueToken: klaim cabang ---
+
+func TestIssueTokenBranchClaims(t *testing.T) {
+	tests := []struct {
+		name       string
+		branchID   int64
+		access     string
+		accessErr  error
+		wantBranch float64
+		wantAccess string
+		wantErr    bool
+	}{
+		{name: "paket semua cabang", branchID: 2, access: "all", wantBranch: 2, wantAccess: "all"},
+		{name: "paket cabang asal", branchID: 2, access: "home", wantBranch: 2, wantAccess: "home"},
+		{name: "member lama tanpa cabang masuk cabang utama", access: "", wantBranch: 1, wantAccess: "home"},
+		{name: "gagal baca akses", branchID: 2, accessErr: errors.New("db error"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepo{
+				GetMemberBranchAccessFn: func(_ context.Context, goldID int) (string, error) {
+					assert.Equal(t, 7, goldID)
+					return tt.access, tt.accessErr
+				},
+			}
+			svc := newTestService(repo)
+			token, err := svc.issueToken(context.Background(), goldEntity.GetGoldUserss{GoldId: 7, GoldEmail: "budi@test.com", GoldBranchID: tt.branchID}, "127.0.0.1")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			claims, err := svc.ParseAccessToken(context.Background(), token.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBranch, claims["branch_id"])
+			assert.Equal(t, tt.wantAccess, claims["branch_access"])
+		})
+	}
+}
+
 // --- DeleteSubscriptionHeader ---
 
 func TestDeleteSubscriptionHeader(t *testing.T) {
@@ -809,8 +852,8 @@ func TestUpdateValidationOTP(t *testing.T) {
 
 func TestInsertSubscriptionUser(t *testing.T) {
 	products := []goldEntity.Subscription{
-		{GoldNamaPaket: "Basic", GoldNamaLayanan: "Gym", GoldHarga: 100, GoldJadwal: "Mon", GoldListLatihan: "Push", GoldJumlahpertemuan: 4, GoldDurasi: 30},
-		{GoldNamaPaket: "Premium", GoldNamaLayanan: "Gym+", GoldHarga: 200, GoldJadwal: "Tue", GoldListLatihan: "Pull", GoldJumlahpertemuan: 8, GoldDurasi: 60},
+		{GoldNamaPaket: "Basic", GoldNamaLayanan: "Gym", GoldHarga: entity.NewMoney(100), GoldJadwal: "Mon", GoldListLatihan: "Push", GoldJumlahpertemuan: 4, GoldDurasi: 30},
+		{GoldNamaPaket: "Premium", GoldNamaLayanan: "Gym+", GoldHarga: entity.NewMoney(200), GoldJadwal: "Tue", GoldListLatihan: "Pull", GoldJumlahpertemuan: 8, GoldDurasi: 60},
 	}
 	mockUser := goldEntity.GetGoldUserss{GoldId: 5, GoldEmail: "budi@test.com"}
 
@@ -908,7 +951,7 @@ func TestInsertSubscriptionUser(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "Berhasil", got)
 		assert.Len(t, capturedBulk, 2)
-		assert.Equal(t, float64(300), capturedHeader.GoldTotalharga) // 100 + 200
+		assert.Equal(t, entity.NewMoney(300), capturedHeader.GoldTotalharga) // 100 + 200
 	})
 
 	t.Run("email not found", func(t *testing.T) {
@@ -1006,7 +1049,7 @@ func TestInsertSubscriptionDetail(t *testing.T) {
 		svc := newTestService(&mockRepo{
 			GetOneSubscriptionFn: func(_ context.Context, _ int) (goldEntity.Subscription, error) {
 				return goldEntity.Subscription{
-					GoldNamaPaket: "Basic", GoldNamaLayanan: "Gym", GoldHarga: 100,
+					GoldNamaPaket: "Basic", GoldNamaLayanan: "Gym", GoldHarga: entity.NewMoney(100),
 					GoldJadwal: "Mon", GoldListLatihan: "Push", GoldJumlahpertemuan: 4, GoldDurasi: 30,
 				}, nil
 			},
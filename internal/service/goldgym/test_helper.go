@@ -1,6 +1,7 @@
 package goldgym
 
 import (
+	"gold-gym-be/pkg/jwtkeys"
 	jaegerLog "gold-gym-be/pkg/log"
 
 	"go.uber.org/zap"
@@ -11,6 +12,15 @@ func newTestLogger() jaegerLog.Factory {
 	return jaegerLog.NewFactory(logger)
 }
 
+// testTokenKeys dibuat sekali karena generate RSA key cukup lambat
+var testTokenKeys = func() *jwtkeys.KeySet {
+	ks, err := jwtkeys.Generate("test")
+	if err != nil {
+		panic(err)
+	}
+	return ks
+}()
+
 func newTestService(repo RepoData) *Service {
-	return New(repo, nil, newTestLogger())
+	return New(repo, nil, testTokenKeys, nil, newTestLogger())
 }
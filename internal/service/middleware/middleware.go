@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"gold-gym-be/internal/entity"
+	idemEntity "gold-gym-be/internal/entity/idempotency"
 	jaegerLog "gold-gym-be/pkg/log"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	// "go.opentelemetry.io/otel/trace"
@@ -13,25 +15,45 @@ import (
 // Data ...
 // Masukkan function dari package data ke dalam interface ini
 type DataMaster interface {
+	Reserve(ctx context.Context, key string, rec idemEntity.Record, lockTTL time.Duration) (idemEntity.Record, bool, error)
+	Complete(ctx context.Context, key string, processing, completed idemEntity.Record, ttl time.Duration) error
+	Release(ctx context.Context, key string, processing idemEntity.Record) error
 }
 
+const (
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultIdempotencyLockTTL = 30 * time.Second
+)
+
 // Service ...
 // Tambahkan variable sesuai banyak data layer yang dibutuhkan
 type Service struct {
-	goldgym DataMaster
-	tracer  opentracing.Tracer
+	idempotency DataMaster
+	ttl         time.Duration
+	lockTTL     time.Duration
+	tracer      opentracing.Tracer
 	// tracer trace.Tracer
 	logger jaegerLog.Factory
 }
 
 // New ...
 // Tambahkan parameter sesuai banyak data layer yang dibutuhkan
-func New(goldgymData DataMaster, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
+// ttl is how long a completed response is replayed, lockTTL bounds how long a
+// crashed request can keep its key busy; zero values use the defaults.
+func New(idempotencyData DataMaster, ttl, lockTTL time.Duration, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	if lockTTL <= 0 {
+		lockTTL = defaultIdempotencyLockTTL
+	}
 	// Assign variable dari parameter ke object
 	return Service{
-		goldgym: goldgymData,
-		tracer:  tracer,
-		logger:  logger,
+		idempotency: idempotencyData,
+		ttl:         ttl,
+		lockTTL:     lockTTL,
+		tracer:      tracer,
+		logger:      logger,
 	}
 }
 
@@ -1 +1,58 @@
-package middleware
\ No newline at end of file
+package middleware
+
+import (
+	"context"
+	idemEntity "gold-gym-be/internal/entity/idempotency"
+	"gold-gym-be/pkg/errors"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// BeginIdempotentRequest claims key for a request whose payload hashes to fingerprint
+func (s Service) BeginIdempotentRequest(ctx context.Context, key, fingerprint string) (idemEntity.Reservation, error) {
+	rec := idemEntity.Record{
+		Status:      idemEntity.StatusProcessing,
+		Fingerprint: fingerprint,
+		Token:       xid.New().String(),
+		CreatedAt:   time.Now(),
+	}
+
+	existing, reserved, err := s.idempotency.Reserve(ctx, key, rec, s.lockTTL)
+	if err != nil {
+		return idemEntity.Reservation{}, errors.Wrap(err, "[SERVICE][BeginIdempotentRequest]")
+	}
+
+	switch {
+	case reserved:
+		return idemEntity.Reservation{Outcome: idemEntity.OutcomeNew, Record: rec}, nil
+	case existing.Fingerprint != fingerprint:
+		return idemEntity.Reservation{Outcome: idemEntity.OutcomeMismatch, Record: existing}, nil
+	case existing.Status == idemEntity.StatusCompleted:
+		return idemEntity.Reservation{Outcome: idemEntity.OutcomeReplay, Record: existing}, nil
+	default:
+		return idemEntity.Reservation{Outcome: idemEntity.OutcomeInProgress, Record: existing}, nil
+	}
+}
+
+// CompleteIdempotentRequest stores the response so retries with the same key replay it
+func (s Service) CompleteIdempotentRequest(ctx context.Context, key string, processing idemEntity.Record, resp idemEntity.Response) error {
+	completed := processing
+	completed.Status = idemEntity.StatusCompleted
+	completed.ResponseStatus = resp.Status
+	completed.ContentType = resp.ContentType
+	completed.ResponseBody = resp.Body
+
+	if err := s.idempotency.Complete(ctx, key, processing, completed, s.ttl); err != nil {
+		return errors.Wrap(err, "[SERVICE][CompleteIdempotentRequest]")
+	}
+	return nil
+}
+
+// ReleaseIdempotentRequest forgets the key so a failed request can be retried
+func (s Service) ReleaseIdempotentRequest(ctx context.Context, key string, processing idemEntity.Record) error {
+	if err := s.idempotency.Release(ctx, key, processing); err != nil {
+		return errors.Wrap(err, "[SERVICE][ReleaseIdempotentRequest]")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package partner
+
+import (
+	"context"
+	partnerEntity "gold-gym-be/internal/entity/partner"
+	"gold-gym-be/pkg/jwtkeys"
+	jaegerLog "gold-gym-be/pkg/log"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	defaultTokenTTL      = 15 * time.Minute
+	defaultTimestampSkew = 5 * time.Minute
+	// X-EXTERNAL-ID must be unique per partner per day
+	externalIDTTL = 24 * time.Hour
+)
+
+// Data ...
+// Masukkan function dari package data ke dalam interface ini
+type Data interface {
+	GetPartnerByClientKey(ctx context.Context, clientKey string) (partnerEntity.Partner, error)
+	InsertPartnerMember(ctx context.Context, member partnerEntity.PartnerMember) error
+	ReserveExternalID(ctx context.Context, clientKey, externalID string, ttl time.Duration) (bool, error)
+}
+
+// MemberEnroller creates or looks up the member for an enrolled employee
+type MemberEnroller interface {
+	EnrollPartnerMember(ctx context.Context, email, nama, nomorHp string) (int, bool, error)
+}
+
+// Service ...
+type Service struct {
+	partner   Data
+	members   MemberEnroller
+	tokenKeys *jwtkeys.KeySet
+	tokenTTL  time.Duration
+	skew      time.Duration
+	now       func() time.Time
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+// tokenTTL and skew default to 15 minutes and 5 minutes when zero
+func New(partnerData Data, members MemberEnroller, tokenKeys *jwtkeys.KeySet, tokenTTL, skew time.Duration, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
+	if tokenTTL <= 0 {
+		tokenTTL = defaultTokenTTL
+	}
+	if skew <= 0 {
+		skew = defaultTimestampSkew
+	}
+	return Service{
+		partner:   partnerData,
+		members:   members,
+		tokenKeys: tokenKeys,
+		tokenTTL:  tokenTTL,
+		skew:      skew,
+		now:       time.Now,
+		tracer:    tracer,
+		logger:    logger,
+	}
+}
@@ -0,0 +1,201 @@
+package partner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gold-gym-be/internal/entity"
+	partnerEntity "gold-gym-be/internal/entity/partner"
+	"gold-gym-be/own-pkg/crypto"
+	"gold-gym-be/pkg/errors"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// tokenIssuer differs from the member token issuer so partner and member
+// tokens are never accepted in place of each other
+const tokenIssuer = "gold-gym-be/partner"
+
+// IssueAccessToken verifies X-SIGNATURE, the partner's SHA256withRSA signature
+// over "clientKey|timestamp", and returns a B2B access token
+func (s Service) IssueAccessToken(ctx context.Context, clientKey, timestamp, signature string) (partnerEntity.AccessToken, error) {
+	var token partnerEntity.AccessToken
+
+	partner, err := s.activePartner(ctx, clientKey)
+	if err != nil {
+		return token, errors.Wrap(err, "[SERVICE][IssueAccessToken]")
+	}
+	if err := s.checkTimestamp(timestamp); err != nil {
+		return token, errors.Wrap(err, "[SERVICE][IssueAccessToken]")
+	}
+
+	ok, err := crypto.RSAVerifySignature(partner.PartnerPublicKey, clientKey+"|"+timestamp, signature)
+	if err != nil || !ok {
+		return token, errors.Wrap(partnerEntity.ErrInvalidSignature, "[SERVICE][IssueAccessToken]")
+	}
+
+	t := s.now()
+	accessToken, err := s.tokenKeys.Sign(jwt.MapClaims{
+		"iss": tokenIssuer,
+		"sub": partner.PartnerClientKey,
+		"pid": partner.PartnerID,
+		"iat": t.Unix(),
+		"nbf": t.Unix(),
+		"exp": t.Add(s.tokenTTL).Unix(),
+	})
+	if err != nil {
+		return token, errors.Wrap(err, "[SERVICE][IssueAccessToken]")
+	}
+
+	token = partnerEntity.AccessToken{
+		ResponseCode:    "200" + partnerEntity.ServiceCodeAccessToken + "00",
+		ResponseMessage: "Successful",
+		AccessToken:     accessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       strconv.Itoa(int(s.tokenTTL.Seconds())),
+	}
+	return token, nil
+}
+
+// VerifyRequest authenticates a partner API call. X-SIGNATURE must be the
+// base64 HMAC-SHA512, keyed with the client secret, of
+// METHOD:PATH:ACCESS_TOKEN:hex(SHA256(minified body)):TIMESTAMP.
+func (s Service) VerifyRequest(ctx context.Context, req partnerEntity.SignedRequest) (partnerEntity.Partner, error) {
+	var partner partnerEntity.Partner
+
+	clientKey, err := s.TokenClient(req.AccessToken)
+	if err != nil {
+		return partner, errors.Wrap(err, "[SERVICE][VerifyRequest]")
+	}
+	if clientKey != req.PartnerID {
+		return partner, errors.Wrap(partnerEntity.ErrInvalidToken, "[SERVICE][VerifyRequest] X-PARTNER-ID does not match the token")
+	}
+
+	// checked on every call so deactivating a partner takes effect before its tokens expire
+	partner, err = s.activePartner(ctx, clientKey)
+	if err != nil {
+		return partner, errors.Wrap(err, "[SERVICE][VerifyRequest]")
+	}
+	if err := s.checkTimestamp(req.Timestamp); err != nil {
+		return partner, errors.Wrap(err, "[SERVICE][VerifyRequest]")
+	}
+
+	payload := crypto.BuildServiceSignaturePayload(req.Method, req.Path, req.AccessToken, minify(req.Body), req.Timestamp)
+	if !crypto.VerifyHMACSHA512(payload, partner.PartnerClientSecret, req.Signature) {
+		return partner, errors.Wrap(partnerEntity.ErrInvalidSignature, "[SERVICE][VerifyRequest]")
+	}
+
+	// only reserved once the signature holds, so forged calls cannot burn IDs
+	if req.ExternalID == "" {
+		return partner, errors.Wrap(entity.ErrInvalid, "[SERVICE][VerifyRequest] X-EXTERNAL-ID is required")
+	}
+	fresh, err := s.partner.ReserveExternalID(ctx, clientKey, req.ExternalID, externalIDTTL)
+	if err != nil {
+		return partner, errors.Wrap(err, "[SERVICE][VerifyRequest]")
+	}
+	if !fresh {
+		return partner, errors.Wrap(partnerEntity.ErrDuplicateExternalID, "[SERVICE][VerifyRequest]")
+	}
+	return partner, nil
+}
+
+// TokenClient returns the client key an access token was issued to. Only the
+// token is checked, VerifyRequest also checks the request signature.
+func (s Service) TokenClient(accessToken string) (string, error) {
+	parsed, err := s.tokenKeys.Parse(accessToken)
+	if err != nil {
+		return "", errors.Wrap(partnerEntity.ErrInvalidToken, err.Error())
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !parsed.Valid || claims["iss"] != tokenIssuer {
+		return "", partnerEntity.ErrInvalidToken
+	}
+	clientKey, _ := claims["sub"].(string)
+	if clientKey == "" {
+		return "", partnerEntity.ErrInvalidToken
+	}
+	return clientKey, nil
+}
+
+// EnrollMember registers an employee of partner as a member
+func (s Service) EnrollMember(ctx context.Context, partner partnerEntity.Partner, req partnerEntity.EnrollMember) (partnerEntity.Enrollment, error) {
+	var result partnerEntity.Enrollment
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return result, errors.Wrap(entity.ErrInvalid, "[SERVICE][EnrollMember] email")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return result, errors.Wrap(entity.ErrInvalid, "[SERVICE][EnrollMember] name")
+	}
+
+	goldID, created, err := s.members.EnrollPartnerMember(ctx, req.Email, req.Name, req.PhoneNo)
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][EnrollMember]")
+	}
+
+	err = s.partner.InsertPartnerMember(ctx, partnerEntity.PartnerMember{
+		PartnerID:  partner.PartnerID,
+		GoldId:     goldID,
+		EmployeeID: req.EmployeeID,
+		EnrolledAt: s.now(),
+	})
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][EnrollMember][InsertPartnerMember]")
+	}
+
+	result = partnerEntity.Enrollment{
+		ResponseCode:    "200" + partnerEntity.ServiceCodeEnrollMember + "00",
+		ResponseMessage: "Successful",
+		MemberID:        goldID,
+		Email:           req.Email,
+		Status:          "existing",
+	}
+	if created {
+		result.Status = "created"
+	}
+	return result, nil
+}
+
+func (s Service) activePartner(ctx context.Context, clientKey string) (partnerEntity.Partner, error) {
+	if clientKey == "" {
+		return partnerEntity.Partner{}, partnerEntity.ErrUnknownClient
+	}
+	partner, err := s.partner.GetPartnerByClientKey(ctx, clientKey)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return partner, partnerEntity.ErrUnknownClient
+		}
+		return partner, errors.Wrap(err, "[SERVICE][activePartner][GetPartnerByClientKey]")
+	}
+	if partner.PartnerActiveYN != "Y" {
+		return partner, partnerEntity.ErrUnknownClient
+	}
+	return partner, nil
+}
+
+// checkTimestamp accepts ISO-8601 X-TIMESTAMP values within the allowed skew
+func (s Service) checkTimestamp(timestamp string) error {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return partnerEntity.ErrInvalidTimestamp
+	}
+	diff := s.now().Sub(t)
+	if diff > s.skew || diff < -s.skew {
+		return partnerEntity.ErrInvalidTimestamp
+	}
+	return nil
+}
+
+// minify compacts a JSON body the way partners must before hashing it
+func minify(body string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(body)); err != nil {
+		return body
+	}
+	return buf.String()
+}
@@ -0,0 +1,221 @@
+package partner
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	partnerEntity "gold-gym-be/internal/entity/partner"
+	"gold-gym-be/own-pkg/crypto"
+	pkgErrors "gold-gym-be/pkg/errors"
+	"gold-gym-be/pkg/jwtkeys"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeData struct {
+	partners    map[string]partnerEntity.Partner
+	members     []partnerEntity.PartnerMember
+	externalIDs map[string]bool
+}
+
+func (f *fakeData) GetPartnerByClientKey(ctx context.Context, clientKey string) (partnerEntity.Partner, error) {
+	p, ok := f.partners[clientKey]
+	if !ok {
+		return p, errors.New("record not found")
+	}
+	return p, nil
+}
+
+func (f *fakeData) InsertPartnerMember(ctx context.Context, member partnerEntity.PartnerMember) error {
+	f.members = append(f.members, member)
+	return nil
+}
+
+func (f *fakeData) ReserveExternalID(ctx context.Context, clientKey, externalID string, ttl time.Duration) (bool, error) {
+	key := clientKey + ":" + externalID
+	if f.externalIDs[key] {
+		return false, nil
+	}
+	f.externalIDs[key] = true
+	return true, nil
+}
+
+type fakeMembers struct {
+	emails map[string]int
+}
+
+func (f *fakeMembers) EnrollPartnerMember(ctx context.Context, email, nama, nomorHp string) (int, bool, error) {
+	if id, ok := f.emails[email]; ok {
+		return id, false, nil
+	}
+	id := len(f.emails) + 100
+	f.emails[email] = id
+	return id, true, nil
+}
+
+// fixture menyiapkan satu partner aktif beserta private key-nya
+type fixture struct {
+	svc        Service
+	data       *fakeData
+	privateKey string
+	now        time.Time
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	data := &fakeData{
+		partners: map[string]partnerEntity.Partner{
+			"corp-a": {
+				PartnerID:           7,
+				PartnerClientKey:    "corp-a",
+				PartnerName:         "PT Corp A",
+				PartnerPublicKey:    base64.StdEncoding.EncodeToString(pubPEM),
+				PartnerClientSecret: "secret-a",
+				PartnerActiveYN:     "Y",
+			},
+		},
+		externalIDs: map[string]bool{},
+	}
+	keys, err := jwtkeys.Generate("test")
+	require.NoError(t, err)
+
+	// token expiry is checked against the wall clock, so stay close to it
+	now := time.Now().In(time.FixedZone("WIB", 7*3600)).Truncate(time.Second)
+	svc := New(data, &fakeMembers{emails: map[string]int{"budi@corp.com": 1}}, keys, 0, 0, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return now }
+
+	return fixture{svc: svc, data: data, privateKey: string(privPEM), now: now}
+}
+
+func (f fixture) accessToken(t *testing.T) string {
+	t.Helper()
+	ts := f.now.Format(time.RFC3339)
+	sig, err := crypto.RSASign(f.privateKey, "corp-a|"+ts)
+	require.NoError(t, err)
+	token, err := f.svc.IssueAccessToken(context.Background(), "corp-a", ts, sig)
+	require.NoError(t, err)
+	return token.AccessToken
+}
+
+func (f fixture) signed(token, externalID, body string) partnerEntity.SignedRequest {
+	ts := f.now.Format(time.RFC3339)
+	payload := crypto.BuildServiceSignaturePayload("POST", "/gold-gym/partner/v1.0/members", token, body, ts)
+	return partnerEntity.SignedRequest{
+		PartnerID:   "corp-a",
+		ExternalID:  externalID,
+		Method:      "POST",
+		Path:        "/gold-gym/partner/v1.0/members",
+		AccessToken: token,
+		Timestamp:   ts,
+		Signature:   crypto.HMACSHA512Base64(payload, "secret-a"),
+		Body:        body,
+	}
+}
+
+func TestIssueAccessToken(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	ts := f.now.Format(time.RFC3339)
+	sig, err := crypto.RSASign(f.privateKey, "corp-a|"+ts)
+	require.NoError(t, err)
+
+	token, err := f.svc.IssueAccessToken(ctx, "corp-a", ts, sig)
+	require.NoError(t, err)
+	assert.Equal(t, "2007300", token.ResponseCode)
+	assert.Equal(t, "900", token.ExpiresIn)
+	assert.NotEmpty(t, token.AccessToken)
+
+	tests := []struct {
+		name      string
+		clientKey string
+		timestamp string
+		signature string
+		want      error
+	}{
+		{"unknown client", "corp-x", ts, sig, partnerEntity.ErrUnknownClient},
+		{"signature over another timestamp", "corp-a", f.now.Add(time.Minute).Format(time.RFC3339), sig, partnerEntity.ErrInvalidSignature},
+		{"timestamp too old", "corp-a", f.now.Add(-10 * time.Minute).Format(time.RFC3339), sig, partnerEntity.ErrInvalidTimestamp},
+		{"timestamp not ISO-8601", "corp-a", "19-10-2026 09:00", sig, partnerEntity.ErrInvalidTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.svc.IssueAccessToken(ctx, tt.clientKey, tt.timestamp, tt.signature)
+			assert.Equal(t, tt.want, pkgErrors.Cause(err))
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	token := f.accessToken(t)
+	body := `{"email":"sari@corp.com","name":"Sari"}`
+
+	// the signature covers the minified body, so formatting does not matter
+	req := f.signed(token, "ext-1", body)
+	req.Body = "{\n  \"email\": \"sari@corp.com\",\n  \"name\": \"Sari\"\n}"
+	partner, err := f.svc.VerifyRequest(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 7, partner.PartnerID)
+
+	_, err = f.svc.VerifyRequest(ctx, f.signed(token, "ext-1", body))
+	assert.Equal(t, partnerEntity.ErrDuplicateExternalID, pkgErrors.Cause(err))
+
+	tampered := f.signed(token, "ext-2", body)
+	tampered.Body = `{"email":"eve@corp.com","name":"Sari"}`
+	_, err = f.svc.VerifyRequest(ctx, tampered)
+	assert.Equal(t, partnerEntity.ErrInvalidSignature, pkgErrors.Cause(err))
+
+	otherPartner := f.signed(token, "ext-3", body)
+	otherPartner.PartnerID = "corp-b"
+	_, err = f.svc.VerifyRequest(ctx, otherPartner)
+	assert.Equal(t, partnerEntity.ErrInvalidToken, pkgErrors.Cause(err))
+
+	_, err = f.svc.VerifyRequest(ctx, f.signed("not-a-token", "ext-4", body))
+	assert.Equal(t, partnerEntity.ErrInvalidToken, pkgErrors.Cause(err))
+
+	// deactivated partners are rejected even with an unexpired token
+	p := f.data.partners["corp-a"]
+	p.PartnerActiveYN = "N"
+	f.data.partners["corp-a"] = p
+	_, err = f.svc.VerifyRequest(ctx, f.signed(token, "ext-5", body))
+	assert.Equal(t, partnerEntity.ErrUnknownClient, pkgErrors.Cause(err))
+}
+
+func TestEnrollMember(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	partner := f.data.partners["corp-a"]
+
+	res, err := f.svc.EnrollMember(ctx, partner, partnerEntity.EnrollMember{Email: " Sari@Corp.com ", Name: "Sari", EmployeeID: "E-2"})
+	require.NoError(t, err)
+	assert.Equal(t, "2009000", res.ResponseCode)
+	assert.Equal(t, "created", res.Status)
+	assert.Equal(t, "sari@corp.com", res.Email)
+
+	res, err = f.svc.EnrollMember(ctx, partner, partnerEntity.EnrollMember{Email: "budi@corp.com", Name: "Budi", EmployeeID: "E-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "existing", res.Status)
+	assert.Equal(t, 1, res.MemberID)
+	require.Len(t, f.data.members, 2)
+	assert.Equal(t, 7, f.data.members[1].PartnerID)
+
+	_, err = f.svc.EnrollMember(ctx, partner, partnerEntity.EnrollMember{Email: "not-an-email", Name: "X"})
+	assert.Error(t, err)
+}
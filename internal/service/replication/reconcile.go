@@ -0,0 +1,126 @@
+package replication
+
+import (
+	"context"
+	replicationEntity "gold-gym-be/internal/entity/replication"
+	"gold-gym-be/pkg/errors"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// maxDrift caps the rows listed per table, the metric still counts all
+const maxDrift = 100
+
+var driftRows = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "replication_drift_rows",
+	Help: "Rows differing between local and prod at the last reconciliation.",
+}, []string{"table", "kind"})
+
+// Reconcile compares the row checksums of every synced table on local and
+// prod. Rows change while the two sides are read, so a row being replicated
+// right now may show up once and be gone at the next run; drift that stays
+// is what needs attention.
+func (s Service) Reconcile(ctx context.Context) ([]replicationEntity.Reconciliation, error) {
+	var results []replicationEntity.Reconciliation
+	for _, table := range s.tables {
+		if table.Direction == replicationEntity.DirectionNone {
+			continue
+		}
+		result, err := s.reconcile(ctx, table)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s Service) reconcile(ctx context.Context, table replicationEntity.Table) (replicationEntity.Reconciliation, error) {
+	result := replicationEntity.Reconciliation{Table: table.Name}
+
+	columns, err := s.columns(ctx, table)
+	if err != nil {
+		return result, err
+	}
+	local, err := s.local.Checksums(ctx, table, columns)
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][Reconcile] local")
+	}
+	prod, err := s.prod.Checksums(ctx, table, columns)
+	if err != nil {
+		return result, errors.Wrap(err, "[SERVICE][Reconcile] prod")
+	}
+	result.LocalRows, result.ProdRows = len(local), len(prod)
+
+	counts := map[string]int{}
+	add := func(key, kind string) {
+		counts[kind]++
+		if len(result.Drift) < maxDrift {
+			result.Drift = append(result.Drift, replicationEntity.Drift{Table: table.Name, Key: key, Kind: kind})
+		}
+	}
+	for _, key := range sortedKeys(local) {
+		sum, ok := prod[key]
+		switch {
+		case !ok:
+			add(key, replicationEntity.MissingInProd)
+		case sum != local[key]:
+			add(key, replicationEntity.Mismatch)
+		}
+	}
+	for _, key := range sortedKeys(prod) {
+		if _, ok := local[key]; !ok {
+			add(key, replicationEntity.MissingInLocal)
+		}
+	}
+
+	for _, kind := range []string{replicationEntity.MissingInLocal, replicationEntity.MissingInProd, replicationEntity.Mismatch} {
+		driftRows.WithLabelValues(table.Name, kind).Set(float64(counts[kind]))
+	}
+	return result, nil
+}
+
+// columns are the columns both sides have, without the ones each side
+// assigns itself and the sync tag, which always differ
+func (s Service) columns(ctx context.Context, table replicationEntity.Table) ([]string, error) {
+	local, err := s.local.Columns(ctx, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][Reconcile] local")
+	}
+	prod, err := s.prod.Columns(ctx, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][Reconcile] prod")
+	}
+
+	inProd := make(map[string]bool, len(prod))
+	for _, column := range prod {
+		inProd[column] = true
+	}
+	skip := map[string]bool{replicationEntity.SyncOriginColumn: true}
+	for _, column := range table.Skip {
+		skip[column] = true
+	}
+
+	var columns []string
+	for _, column := range local {
+		if inProd[column] && !skip[column] {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		return nil, errors.Wrap(replicationEntity.ErrBadTable, table.Name)
+	}
+	sort.Strings(columns)
+	return columns, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,37 @@
+package replication
+
+import (
+	"context"
+	replicationEntity "gold-gym-be/internal/entity/replication"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Data ...
+// Masukkan function dari package data ke dalam interface ini
+type Data interface {
+	Columns(ctx context.Context, table replicationEntity.Table) ([]string, error)
+	Checksums(ctx context.Context, table replicationEntity.Table, columns []string) (map[string]string, error)
+}
+
+// Service ...
+type Service struct {
+	local  Data
+	prod   Data
+	tables []replicationEntity.Table
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(local, prod Data, tables []replicationEntity.Table, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
+	return Service{
+		local:  local,
+		prod:   prod,
+		tables: tables,
+		tracer: tracer,
+		logger: logger,
+	}
+}
@@ -0,0 +1,114 @@
+package sales
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	"time"
+)
+
+// GetCOGSReport lists the cost of goods sold and gross margin of every sale
+// in the period
+func (s Service) GetCOGSReport(ctx context.Context, filter salesEntity.ReportFilter) (salesEntity.COGSReport, error) {
+	filter, err := s.reportFilter(filter)
+	if err != nil {
+		return salesEntity.COGSReport{}, errors.Wrap(err, "[SERVICE][GetCOGSReport]")
+	}
+
+	sales, err := s.sales.GetSaleCOGS(ctx, filter.From, filter.To)
+	if err != nil {
+		return salesEntity.COGSReport{}, errors.Wrap(err, "[SERVICE][GetCOGSReport]")
+	}
+
+	report := salesEntity.COGSReport{From: filter.From, To: filter.To, Method: filter.Method, Sales: sales}
+	if report.Sales == nil {
+		report.Sales = []salesEntity.SaleCOGS{}
+	}
+	for i := range report.Sales {
+		sale := &report.Sales[i]
+		sale.COGS = pickCost(filter.Method, sale.CostAvg, sale.CostFIFO)
+		sale.GrossMargin, sale.MarginPct = margin(sale.Revenue, sale.COGS)
+		report.Revenue += sale.Revenue
+		report.COGS += sale.COGS
+	}
+	report.ReportTotals = totals(report.Revenue, report.COGS)
+	return report, nil
+}
+
+// GetMarginReport sums the gross margin of the period per stock code
+func (s Service) GetMarginReport(ctx context.Context, filter salesEntity.ReportFilter) (salesEntity.MarginReport, error) {
+	filter, err := s.reportFilter(filter)
+	if err != nil {
+		return salesEntity.MarginReport{}, errors.Wrap(err, "[SERVICE][GetMarginReport]")
+	}
+
+	products, err := s.sales.GetProductMargins(ctx, filter.From, filter.To)
+	if err != nil {
+		return salesEntity.MarginReport{}, errors.Wrap(err, "[SERVICE][GetMarginReport]")
+	}
+
+	report := salesEntity.MarginReport{From: filter.From, To: filter.To, Method: filter.Method, Products: products}
+	if report.Products == nil {
+		report.Products = []salesEntity.ProductMargin{}
+	}
+	for i := range report.Products {
+		product := &report.Products[i]
+		product.COGS = pickCost(filter.Method, product.CostAvg, product.CostFIFO)
+		product.GrossMargin, product.MarginPct = margin(product.Revenue, product.COGS)
+		report.Revenue += product.Revenue
+		report.COGS += product.COGS
+	}
+	report.ReportTotals = totals(report.Revenue, report.COGS)
+	return report, nil
+}
+
+// reportFilter validates filter and fills the defaults: the current month up
+// to today and the weighted-average method
+func (s Service) reportFilter(filter salesEntity.ReportFilter) (salesEntity.ReportFilter, error) {
+	today := s.now()
+	if filter.From == "" {
+		filter.From = today.AddDate(0, 0, 1-today.Day()).Format(dateLayout)
+	}
+	if filter.To == "" {
+		filter.To = today.Format(dateLayout)
+	}
+	from, err := time.Parse(dateLayout, filter.From)
+	if err != nil {
+		return filter, errors.Wrap(entity.ErrInvalid, "from must be YYYY-MM-DD")
+	}
+	to, err := time.Parse(dateLayout, filter.To)
+	if err != nil {
+		return filter, errors.Wrap(entity.ErrInvalid, "to must be YYYY-MM-DD")
+	}
+	if to.Before(from) {
+		return filter, errors.Wrap(entity.ErrInvalid, "to is before from")
+	}
+
+	if filter.Method == "" {
+		filter.Method = goldStockEntity.ValuationAverage
+	}
+	if !goldStockEntity.ValidValuationMethod(filter.Method) {
+		return filter, errors.Wrap(entity.ErrInvalid, "method must be average or fifo")
+	}
+	return filter, nil
+}
+
+func pickCost(method string, avg, fifo entity.Money) entity.Money {
+	if method == goldStockEntity.ValuationFIFO {
+		return fifo
+	}
+	return avg
+}
+
+func margin(revenue, cogs entity.Money) (entity.Money, float64) {
+	gross := revenue - cogs
+	return gross, gross.Percent(revenue)
+}
+
+func totals(revenue, cogs entity.Money) salesEntity.ReportTotals {
+	t := salesEntity.ReportTotals{Revenue: revenue, COGS: cogs}
+	t.GrossMargin, t.MarginPct = margin(t.Revenue, t.COGS)
+	return t
+}
@@ -0,0 +1,148 @@
+package sales
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	pkgErrors "gold-gym-be/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeData) GetSaleCOGS(ctx context.Context, from, to string) ([]salesEntity.SaleCOGS, error) {
+	var sales []salesEntity.SaleCOGS
+	for _, h := range f.headers {
+		if h.SaleTransdate < from || h.SaleTransdate > to {
+			continue
+		}
+		sale := salesEntity.SaleCOGS{SaleID: h.SaleID, SaleTransdate: h.SaleTransdate, SaleTransTime: h.SaleTransTime, SaleSalesperson: h.SaleSalesperson}
+		for _, d := range f.details {
+			if d.SaleID == h.SaleID {
+				sale.Revenue += d.SaleSalesprice.Mul(d.SaleQty)
+				sale.CostAvg += d.SaleCostAvg
+				sale.CostFIFO += d.SaleCostFIFO
+			}
+		}
+		sales = append(sales, sale)
+	}
+	return sales, nil
+}
+
+func (f *fakeData) GetProductMargins(ctx context.Context, from, to string) ([]salesEntity.ProductMargin, error) {
+	dates := make(map[string]string, len(f.headers))
+	for _, h := range f.headers {
+		dates[h.SaleID] = h.SaleTransdate
+	}
+	byCode := make(map[string]*salesEntity.ProductMargin)
+	var codes []string
+	for _, d := range f.details {
+		if date := dates[d.SaleID]; date < from || date > to {
+			continue
+		}
+		product, ok := byCode[d.SaleStockcode]
+		if !ok {
+			product = &salesEntity.ProductMargin{StockCode: d.SaleStockcode, StockName: d.SaleStockname}
+			byCode[d.SaleStockcode] = product
+			codes = append(codes, d.SaleStockcode)
+		}
+		product.Qty += d.SaleQty
+		product.Revenue += d.SaleSalesprice.Mul(d.SaleQty)
+		product.CostAvg += d.SaleCostAvg
+		product.CostFIFO += d.SaleCostFIFO
+	}
+	sort.Strings(codes)
+	var products []salesEntity.ProductMargin
+	for _, code := range codes {
+		products = append(products, *byCode[code])
+	}
+	return products, nil
+}
+
+// seedCostedSales mengisi dua penjualan bulan ini dan satu bulan lalu dengan biaya average dan fifo
+func seedCostedSales(data *fakeData) {
+	data.headers = append(data.headers,
+		salesEntity.SalesHeader{SaleID: "SL1", SaleTransdate: "2026-10-02", SaleTransTime: "08:00:00", SaleSalesperson: "rina"},
+		salesEntity.SalesHeader{SaleID: "SL2", SaleTransdate: "2026-10-19", SaleTransTime: "09:00:00", SaleSalesperson: "budi"},
+		salesEntity.SalesHeader{SaleID: "SL0", SaleTransdate: "2026-09-30", SaleTransTime: "20:00:00", SaleSalesperson: "rina"},
+	)
+	data.details = append(data.details,
+		salesEntity.SalesDetail{SaleID: "SL1", SaleStockcode: "WHEY-1", SaleStockname: "Whey Protein Sachet", SaleQty: 2, SaleSalesprice: entity.NewMoney(25000), SaleCostAvg: entity.NewMoney(40000), SaleCostFIFO: entity.NewMoney(42000)},
+		salesEntity.SalesDetail{SaleID: "SL1", SaleStockcode: "ISO-1", SaleStockname: "Isotonic 500ml", SaleQty: 1, SaleSalesprice: entity.NewMoney(7500), SaleCostAvg: entity.NewMoney(5000), SaleCostFIFO: entity.NewMoney(4500)},
+		salesEntity.SalesDetail{SaleID: "SL2", SaleStockcode: "WHEY-1", SaleStockname: "Whey Protein Sachet", SaleQty: 1, SaleSalesprice: entity.NewMoney(25000), SaleCostAvg: entity.NewMoney(20000), SaleCostFIFO: entity.NewMoney(22000)},
+		salesEntity.SalesDetail{SaleID: "SL0", SaleStockcode: "WHEY-1", SaleStockname: "Whey Protein Sachet", SaleQty: 5, SaleSalesprice: entity.NewMoney(25000), SaleCostAvg: entity.NewMoney(100000), SaleCostFIFO: entity.NewMoney(100000)},
+	)
+}
+
+func TestCOGSReport(t *testing.T) {
+	svc, data := newTestService()
+	seedCostedSales(data)
+	ctx := context.Background()
+
+	// tanpa filter: bulan berjalan, metode average
+	report, err := svc.GetCOGSReport(ctx, salesEntity.ReportFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-01", report.From)
+	assert.Equal(t, "2026-10-19", report.To)
+	assert.Equal(t, "average", report.Method)
+	require.Len(t, report.Sales, 2)
+	assert.Equal(t, entity.NewMoney(57500), report.Sales[0].Revenue)
+	assert.Equal(t, entity.NewMoney(45000), report.Sales[0].COGS)
+	assert.Equal(t, entity.NewMoney(12500), report.Sales[0].GrossMargin)
+	assert.Equal(t, 21.74, report.Sales[0].MarginPct)
+	assert.Equal(t, entity.NewMoney(82500), report.Revenue)
+	assert.Equal(t, entity.NewMoney(65000), report.COGS)
+	assert.Equal(t, entity.NewMoney(17500), report.GrossMargin)
+	assert.Equal(t, 21.21, report.MarginPct)
+
+	report, err = svc.GetCOGSReport(ctx, salesEntity.ReportFilter{From: "2026-10-01", To: "2026-10-31", Method: "fifo"})
+	require.NoError(t, err)
+	assert.Equal(t, entity.NewMoney(46500), report.Sales[0].COGS)
+	assert.Equal(t, entity.NewMoney(68500), report.COGS)
+}
+
+func TestMarginReport(t *testing.T) {
+	svc, data := newTestService()
+	seedCostedSales(data)
+
+	report, err := svc.GetMarginReport(context.Background(), salesEntity.ReportFilter{From: "2026-09-01", To: "2026-10-31", Method: "fifo"})
+	require.NoError(t, err)
+	require.Len(t, report.Products, 2)
+	assert.Equal(t, "ISO-1", report.Products[0].StockCode)
+	assert.Equal(t, entity.NewMoney(3000), report.Products[0].GrossMargin)
+	assert.Equal(t, 40.0, report.Products[0].MarginPct)
+	whey := report.Products[1]
+	assert.Equal(t, 8, whey.Qty)
+	assert.Equal(t, entity.NewMoney(200000), whey.Revenue)
+	assert.Equal(t, entity.NewMoney(164000), whey.COGS)
+	assert.Equal(t, 18.0, whey.MarginPct)
+
+	// periode tanpa penjualan tetap mengembalikan list kosong
+	report, err = svc.GetMarginReport(context.Background(), salesEntity.ReportFilter{From: "2026-01-01", To: "2026-01-31"})
+	require.NoError(t, err)
+	assert.NotNil(t, report.Products)
+	assert.Empty(t, report.Products)
+	assert.Equal(t, 0.0, report.MarginPct)
+}
+
+func TestReportFilterRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter salesEntity.ReportFilter
+	}{
+		{"bad from", salesEntity.ReportFilter{From: "01-10-2026"}},
+		{"bad to", salesEntity.ReportFilter{To: "2026/10/31"}},
+		{"to before from", salesEntity.ReportFilter{From: "2026-10-10", To: "2026-10-01"}},
+		{"unknown method", salesEntity.ReportFilter{Method: "lifo"}},
+	}
+	svc, _ := newTestService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.GetCOGSReport(context.Background(), tt.filter)
+			assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package sales
+
+import (
+	"context"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	jaegerLog "gold-gym-be/pkg/log"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Data ...
+// Masukkan function dari package data ke dalam interface ini
+type Data interface {
+	GetStocksByCode(ctx context.Context, codes []string) ([]goldStockEntity.GetOneStock, error)
+	InsertSale(ctx context.Context, header salesEntity.SalesHeader, details []salesEntity.SalesDetail, movements []goldStockEntity.StockMovement, event outboxEntity.Event) error
+	GetSales(ctx context.Context, filter salesEntity.SalesFilter) ([]salesEntity.SalesHeader, error)
+	GetSaleByID(ctx context.Context, saleID string) (salesEntity.SalesHeader, error)
+	GetSaleDetails(ctx context.Context, saleIDs []string) ([]salesEntity.SalesDetail, error)
+	GetSaleCOGS(ctx context.Context, from, to string) ([]salesEntity.SaleCOGS, error)
+	GetProductMargins(ctx context.Context, from, to string) ([]salesEntity.ProductMargin, error)
+}
+
+// Service ...
+type Service struct {
+	sales Data
+	now   func() time.Time
+
+	tracer opentracing.Tracer
+	logger jaegerLog.Factory
+}
+
+// New ...
+func New(salesData Data, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
+	return Service{
+		sales:  salesData,
+		now:    time.Now,
+		tracer: tracer,
+		logger: logger,
+	}
+}
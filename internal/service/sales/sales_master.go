@@ -0,0 +1,225 @@
+package sales
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"gold-gym-be/internal/entity"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	timeLayout = "15:04:05"
+)
+
+// CreateSale prices the lines from the stock table, checks the payment covers
+// the total and stores the sale while decrementing stock at the selling
+// branch, the main branch when none is given. Lines with the same stock code
+// are merged.
+func (s Service) CreateSale(ctx context.Context, req salesEntity.CreateSale) (salesEntity.Receipt, error) {
+	var receipt salesEntity.Receipt
+
+	lines, err := mergeLines(req)
+	if err != nil {
+		return receipt, errors.Wrap(err, "[SERVICE][CreateSale]")
+	}
+
+	codes := make([]string, 0, len(lines))
+	for _, line := range lines {
+		codes = append(codes, line.StockCode)
+	}
+	stocks, err := s.sales.GetStocksByCode(ctx, codes)
+	if err != nil {
+		return receipt, errors.Wrap(err, "[SERVICE][CreateSale]")
+	}
+	byCode := make(map[string]goldStockEntity.GetOneStock, len(stocks))
+	for _, stock := range stocks {
+		byCode[stock.StockCode] = stock
+	}
+
+	t := s.now()
+	saleID, err := newSaleID(t)
+	if err != nil {
+		return receipt, errors.Wrap(err, "[SERVICE][CreateSale]")
+	}
+
+	var total entity.Money
+	details := make([]salesEntity.SalesDetail, 0, len(lines))
+	for _, line := range lines {
+		stock, ok := byCode[line.StockCode]
+		if !ok {
+			return receipt, errors.Wrap(salesEntity.ErrUnknownStock, line.StockCode)
+		}
+		if stock.StockQTY < line.Qty {
+			return receipt, errors.Wrap(salesEntity.ErrInsufficientStock, line.StockCode)
+		}
+		price := stock.StockPrice
+		total += price.Mul(line.Qty)
+		details = append(details, salesEntity.SalesDetail{
+			SaleID:         saleID,
+			SaleStockID:    stock.StockID,
+			SaleStockcode:  stock.StockCode,
+			SaleStockname:  stock.StockName,
+			SaleQty:        line.Qty,
+			SaleSalesprice: price,
+			SalePack:       stock.StockPack,
+		})
+	}
+	payment := req.Payment
+	if payment < total {
+		return receipt, errors.Wrap(salesEntity.ErrInsufficientPayment, "[SERVICE][CreateSale]")
+	}
+
+	branchID := req.BranchID
+	if branchID == 0 {
+		branchID = branchEntity.MainBranchID
+	}
+	header := salesEntity.SalesHeader{
+		SaleID:           saleID,
+		SaleBranchID:     branchID,
+		SaleTransdate:    t.Format(dateLayout),
+		SaleTransTime:    t.Format(timeLayout),
+		SaleTranstotal:   total,
+		SaleTranspayment: payment,
+		SaleTranschange:  payment - total,
+		SaleSalesperson:  strings.TrimSpace(req.Salesperson),
+	}
+
+	movements := make([]goldStockEntity.StockMovement, 0, len(details))
+	for _, detail := range details {
+		movements = append(movements, goldStockEntity.StockMovement{
+			StockCode:        detail.SaleStockcode,
+			MovementBranchID: branchID,
+			MovementType:     goldStockEntity.MovementSale,
+			MovementQty:      -detail.SaleQty,
+			MovementReason:   "front desk sale",
+			MovementRef:      saleID,
+			MovementBy:       header.SaleSalesperson,
+			MovementAt:       t,
+		})
+	}
+
+	sold := make([]outboxEntity.SaleLineSold, 0, len(details))
+	for _, detail := range details {
+		sold = append(sold, outboxEntity.SaleLineSold{StockCode: detail.SaleStockcode, Qty: detail.SaleQty, Price: detail.SaleSalesprice})
+	}
+	event, err := outboxEntity.NewEvent(outboxEntity.EventSaleCompleted, outboxEntity.AggregateSale, saleID, outboxEntity.SaleCompleted{
+		SaleID:      saleID,
+		BranchID:    branchID,
+		Salesperson: header.SaleSalesperson,
+		Total:       total,
+		Lines:       sold,
+		CompletedAt: t,
+	}, t)
+	if err != nil {
+		return receipt, errors.Wrap(err, "[SERVICE][CreateSale]")
+	}
+
+	// the stock check above is only a fast path, InsertSale re-checks
+	// atomically while decrementing
+	if err := s.sales.InsertSale(ctx, header, details, movements, event); err != nil {
+		return receipt, errors.Wrap(err, "[SERVICE][CreateSale]")
+	}
+
+	return salesEntity.Receipt{SalesHeader: header, Details: details}, nil
+}
+
+// ListSales returns the receipts for one day and/or one salesperson, of one
+// branch when BranchID is set. Without a day or salesperson it lists today's
+// sales.
+func (s Service) ListSales(ctx context.Context, filter salesEntity.SalesFilter) ([]salesEntity.Receipt, error) {
+	filter.Salesperson = strings.TrimSpace(filter.Salesperson)
+	if filter.Date == "" && filter.Salesperson == "" {
+		filter.Date = s.now().Format(dateLayout)
+	}
+	if filter.Date != "" {
+		if _, err := time.Parse(dateLayout, filter.Date); err != nil {
+			return nil, errors.Wrap(entity.ErrInvalid, "date must be YYYY-MM-DD")
+		}
+	}
+
+	headers, err := s.sales.GetSales(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][ListSales]")
+	}
+	receipts := make([]salesEntity.Receipt, 0, len(headers))
+	if len(headers) == 0 {
+		return receipts, nil
+	}
+
+	ids := make([]string, 0, len(headers))
+	for _, header := range headers {
+		ids = append(ids, header.SaleID)
+	}
+	details, err := s.sales.GetSaleDetails(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "[SERVICE][ListSales]")
+	}
+	bySale := make(map[string][]salesEntity.SalesDetail, len(headers))
+	for _, detail := range details {
+		bySale[detail.SaleID] = append(bySale[detail.SaleID], detail)
+	}
+
+	for _, header := range headers {
+		receipts = append(receipts, salesEntity.Receipt{SalesHeader: header, Details: bySale[header.SaleID]})
+	}
+	return receipts, nil
+}
+
+// GetReceipt reprints a single sale
+func (s Service) GetReceipt(ctx context.Context, saleID string) (salesEntity.Receipt, error) {
+	header, err := s.sales.GetSaleByID(ctx, saleID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return salesEntity.Receipt{}, errors.Wrap(entity.ErrNotFound, "[SERVICE][GetReceipt]")
+		}
+		return salesEntity.Receipt{}, errors.Wrap(err, "[SERVICE][GetReceipt]")
+	}
+
+	details, err := s.sales.GetSaleDetails(ctx, []string{saleID})
+	if err != nil {
+		return salesEntity.Receipt{}, errors.Wrap(err, "[SERVICE][GetReceipt]")
+	}
+	return salesEntity.Receipt{SalesHeader: header, Details: details}, nil
+}
+
+func mergeLines(req salesEntity.CreateSale) ([]salesEntity.SaleLine, error) {
+	if strings.TrimSpace(req.Salesperson) == "" {
+		return nil, errors.Wrap(entity.ErrInvalid, "salesperson is required")
+	}
+	if len(req.Lines) == 0 {
+		return nil, errors.Wrap(entity.ErrInvalid, "a sale needs at least one line")
+	}
+
+	lines := make([]salesEntity.SaleLine, 0, len(req.Lines))
+	index := make(map[string]int, len(req.Lines))
+	for _, line := range req.Lines {
+		line.StockCode = strings.TrimSpace(line.StockCode)
+		if line.StockCode == "" || line.Qty <= 0 {
+			return nil, errors.Wrap(entity.ErrInvalid, "every line needs a stock_code and a positive qty")
+		}
+		if i, ok := index[line.StockCode]; ok {
+			lines[i].Qty += line.Qty
+			continue
+		}
+		index[line.StockCode] = len(lines)
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// newSaleID is SL + transaction timestamp + random suffix, e.g. SL20261019093015a1b2c3
+func newSaleID(t time.Time) (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "SL" + t.Format("20060102150405") + hex.EncodeToString(b), nil
+}
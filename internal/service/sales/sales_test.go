@@ -0,0 +1,229 @@
+package sales
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	salesEntity "gold-gym-be/internal/entity/sales"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	pkgErrors "gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeData menyimpan stock dan sales di memory, InsertSale all-or-nothing seperti transaksi DB
+type fakeData struct {
+	stocks    map[string]goldStockEntity.GetOneStock
+	headers   []salesEntity.SalesHeader
+	details   []salesEntity.SalesDetail
+	movements []goldStockEntity.StockMovement
+	events    []outboxEntity.Event
+}
+
+func (f *fakeData) GetStocksByCode(ctx context.Context, codes []string) ([]goldStockEntity.GetOneStock, error) {
+	var stocks []goldStockEntity.GetOneStock
+	for _, code := range codes {
+		if stock, ok := f.stocks[code]; ok {
+			stocks = append(stocks, stock)
+		}
+	}
+	return stocks, nil
+}
+
+func (f *fakeData) InsertSale(ctx context.Context, header salesEntity.SalesHeader, details []salesEntity.SalesDetail, movements []goldStockEntity.StockMovement, event outboxEntity.Event) error {
+	for _, d := range details {
+		if f.stocks[d.SaleStockcode].StockQTY < d.SaleQty {
+			return pkgErrors.Wrap(salesEntity.ErrInsufficientStock, d.SaleStockcode)
+		}
+	}
+	for _, d := range details {
+		stock := f.stocks[d.SaleStockcode]
+		stock.StockQTY -= d.SaleQty
+		f.stocks[d.SaleStockcode] = stock
+	}
+	f.headers = append(f.headers, header)
+	f.details = append(f.details, details...)
+	f.movements = append(f.movements, movements...)
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeData) GetSales(ctx context.Context, filter salesEntity.SalesFilter) ([]salesEntity.SalesHeader, error) {
+	var headers []salesEntity.SalesHeader
+	for _, h := range f.headers {
+		if filter.BranchID != 0 && h.SaleBranchID != filter.BranchID {
+			continue
+		}
+		if (filter.Date == "" || h.SaleTransdate == filter.Date) && (filter.Salesperson == "" || h.SaleSalesperson == filter.Salesperson) {
+			headers = append(headers, h)
+		}
+	}
+	return headers, nil
+}
+
+func (f *fakeData) GetSaleByID(ctx context.Context, saleID string) (salesEntity.SalesHeader, error) {
+	for _, h := range f.headers {
+		if h.SaleID == saleID {
+			return h, nil
+		}
+	}
+	return salesEntity.SalesHeader{}, errors.New("record not found")
+}
+
+func (f *fakeData) GetSaleDetails(ctx context.Context, saleIDs []string) ([]salesEntity.SalesDetail, error) {
+	var details []salesEntity.SalesDetail
+	for _, d := range f.details {
+		for _, id := range saleIDs {
+			if d.SaleID == id {
+				details = append(details, d)
+			}
+		}
+	}
+	return details, nil
+}
+
+func newTestService() (Service, *fakeData) {
+	data := &fakeData{stocks: map[string]goldStockEntity.GetOneStock{
+		"WHEY-1": {StockID: "1", StockCode: "WHEY-1", StockName: "Whey Protein Sachet", StockPack: "sachet", StockQTY: 10, StockPrice: entity.NewMoney(25000)},
+		"ISO-1":  {StockID: "2", StockCode: "ISO-1", StockName: "Isotonic 500ml", StockPack: "botol", StockQTY: 2, StockPrice: entity.MoneyFromFloat(7500.5)},
+	}}
+	svc := New(data, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 15, 0, time.Local) }
+	return svc, data
+}
+
+func TestCreateSale(t *testing.T) {
+	svc, data := newTestService()
+	ctx := context.Background()
+
+	receipt, err := svc.CreateSale(ctx, salesEntity.CreateSale{
+		Salesperson: "rina",
+		Payment:     entity.NewMoney(100000),
+		Lines: []salesEntity.SaleLine{
+			{StockCode: "WHEY-1", Qty: 2},
+			{StockCode: "ISO-1", Qty: 1},
+			{StockCode: "WHEY-1", Qty: 1},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, entity.MoneyFromFloat(82500.5), receipt.SaleTranstotal)
+	assert.Equal(t, entity.MoneyFromFloat(17499.5), receipt.SaleTranschange)
+	assert.Equal(t, "2026-10-19", receipt.SaleTransdate)
+	assert.Equal(t, "09:30:15", receipt.SaleTransTime)
+	assert.Regexp(t, `^SL20261019093015[0-9a-f]{6}$`, receipt.SaleID)
+	require.Len(t, receipt.Details, 2)
+	assert.Equal(t, 3, receipt.Details[0].SaleQty)
+	assert.Equal(t, receipt.SaleID, receipt.Details[1].SaleID)
+
+	// stok berkurang sesuai qty
+	assert.Equal(t, 7, data.stocks["WHEY-1"].StockQTY)
+	assert.Equal(t, 1, data.stocks["ISO-1"].StockQTY)
+
+	// setiap baris penjualan tercatat di ledger
+	require.Len(t, data.movements, 2)
+	assert.Equal(t, goldStockEntity.MovementSale, data.movements[0].MovementType)
+	assert.Equal(t, -3, data.movements[0].MovementQty)
+	assert.Equal(t, receipt.SaleID, data.movements[0].MovementRef)
+	assert.Equal(t, "rina", data.movements[1].MovementBy)
+
+	// tanpa cabang penjualan masuk ke cabang utama
+	assert.Equal(t, int64(1), receipt.SaleBranchID)
+	assert.Equal(t, int64(1), data.movements[0].MovementBranchID)
+
+	// SaleCompleted disimpan bersama penjualan, dengan key per sale
+	require.Len(t, data.events, 1)
+	assert.Equal(t, "sale:"+receipt.SaleID, data.events[0].Key())
+	var envelope outboxEntity.Envelope
+	require.NoError(t, json.Unmarshal(data.events[0].Envelope, &envelope))
+	assert.Equal(t, outboxEntity.EventSaleCompleted, envelope.EventType)
+	assert.Equal(t, "goldgym.sale.completed.v1", envelope.SchemaID)
+	var completed outboxEntity.SaleCompleted
+	require.NoError(t, json.Unmarshal(envelope.Payload, &completed))
+	assert.Equal(t, receipt.SaleTranstotal, completed.Total)
+	assert.Equal(t, []outboxEntity.SaleLineSold{
+		{StockCode: "WHEY-1", Qty: 3, Price: receipt.Details[0].SaleSalesprice},
+		{StockCode: "ISO-1", Qty: 1, Price: receipt.Details[1].SaleSalesprice},
+	}, completed.Lines)
+
+	receipt, err = svc.CreateSale(ctx, salesEntity.CreateSale{
+		BranchID:    2,
+		Salesperson: "rina",
+		Payment:     entity.NewMoney(100000),
+		Lines:       []salesEntity.SaleLine{{StockCode: "WHEY-1", Qty: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), receipt.SaleBranchID)
+	assert.Equal(t, int64(2), data.movements[2].MovementBranchID)
+
+	receipts, err := svc.ListSales(ctx, salesEntity.SalesFilter{Date: "2026-10-19", BranchID: 2})
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	assert.Equal(t, receipt.SaleID, receipts[0].SaleID)
+}
+
+func TestCreateSaleRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		req  salesEntity.CreateSale
+		want error
+	}{
+		{"no salesperson", salesEntity.CreateSale{Payment: entity.NewMoney(1), Lines: []salesEntity.SaleLine{{StockCode: "WHEY-1", Qty: 1}}}, entity.ErrInvalid},
+		{"no lines", salesEntity.CreateSale{Salesperson: "rina", Payment: entity.NewMoney(1)}, entity.ErrInvalid},
+		{"zero qty", salesEntity.CreateSale{Salesperson: "rina", Payment: entity.NewMoney(1), Lines: []salesEntity.SaleLine{{StockCode: "WHEY-1"}}}, entity.ErrInvalid},
+		{"unknown stock", salesEntity.CreateSale{Salesperson: "rina", Payment: entity.NewMoney(1), Lines: []salesEntity.SaleLine{{StockCode: "NOPE", Qty: 1}}}, salesEntity.ErrUnknownStock},
+		{"not enough stock", salesEntity.CreateSale{Salesperson: "rina", Payment: entity.NewMoney(100000), Lines: []salesEntity.SaleLine{{StockCode: "ISO-1", Qty: 3}}}, salesEntity.ErrInsufficientStock},
+		{"payment too small", salesEntity.CreateSale{Salesperson: "rina", Payment: entity.NewMoney(25000), Lines: []salesEntity.SaleLine{{StockCode: "WHEY-1", Qty: 2}}}, salesEntity.ErrInsufficientPayment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, data := newTestService()
+			_, err := svc.CreateSale(context.Background(), tt.req)
+			assert.Equal(t, tt.want, pkgErrors.Cause(err))
+			assert.Empty(t, data.headers)
+			assert.Equal(t, 10, data.stocks["WHEY-1"].StockQTY)
+		})
+	}
+}
+
+func TestListSalesAndReceipt(t *testing.T) {
+	svc, _ := newTestService()
+	ctx := context.Background()
+
+	for _, person := range []string{"rina", "budi", "rina"} {
+		_, err := svc.CreateSale(ctx, salesEntity.CreateSale{Salesperson: person, Payment: entity.NewMoney(25000), Lines: []salesEntity.SaleLine{{StockCode: "WHEY-1", Qty: 1}}})
+		require.NoError(t, err)
+	}
+
+	// tanpa filter berarti penjualan hari ini
+	receipts, err := svc.ListSales(ctx, salesEntity.SalesFilter{})
+	require.NoError(t, err)
+	assert.Len(t, receipts, 3)
+
+	receipts, err = svc.ListSales(ctx, salesEntity.SalesFilter{Salesperson: "rina"})
+	require.NoError(t, err)
+	require.Len(t, receipts, 2)
+	assert.Len(t, receipts[0].Details, 1)
+
+	receipts, err = svc.ListSales(ctx, salesEntity.SalesFilter{Date: "2026-10-18"})
+	require.NoError(t, err)
+	assert.Empty(t, receipts)
+
+	_, err = svc.ListSales(ctx, salesEntity.SalesFilter{Date: "19-10-2026"})
+	assert.Equal(t, entity.ErrInvalid, pkgErrors.Cause(err))
+
+	all, _ := svc.ListSales(ctx, salesEntity.SalesFilter{})
+	receipt, err := svc.GetReceipt(ctx, all[1].SaleID)
+	require.NoError(t, err)
+	assert.Equal(t, "budi", receipt.SaleSalesperson)
+	assert.Len(t, receipt.Details, 1)
+
+	_, err = svc.GetReceipt(ctx, "SL-missing")
+	assert.Equal(t, entity.ErrNotFound, pkgErrors.Cause(err))
+}
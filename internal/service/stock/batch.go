@@ -0,0 +1,89 @@
+package goldgym
+
+import (
+	"context"
+	"fmt"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	"log"
+	"strconv"
+	"time"
+)
+
+// defaultNearExpiryDays is the near-expiry window when none is asked for
+const defaultNearExpiryDays = 30
+
+// GetNearExpiryReport lists the batches with stock left that expire within
+// days from today, expired ones first, of one branch when branchID is not
+// zero. Value is the remaining quantity at the batch cost.
+func (s Service) GetNearExpiryReport(ctx context.Context, days int, branchID int64) (goldStockEntity.NearExpiryReport, error) {
+	if days <= 0 {
+		days = defaultNearExpiryDays
+	}
+	now := s.now()
+	today, _ := time.ParseInLocation(dateLayout, now.Format(dateLayout), time.Local)
+	report := goldStockEntity.NearExpiryReport{AsOf: today.Format(dateLayout), Days: days}
+
+	lines, err := s.goldgymstock.GetNearExpiryBatches(ctx, today.AddDate(0, 0, days).Format(dateLayout), branchID)
+	if err != nil {
+		return report, errors.Wrap(err, "[Service][GetNearExpiryReport]")
+	}
+
+	report.Lines = make([]goldStockEntity.NearExpiryLine, 0, len(lines))
+	var total entity.Money
+	for _, line := range lines {
+		expiry, err := time.ParseInLocation(dateLayout, line.BatchExpiry, time.Local)
+		if err != nil {
+			continue
+		}
+		line.DaysLeft = int(expiry.Sub(today).Hours() / 24)
+		line.Expired = line.DaysLeft < 0
+		line.Value = line.BatchUnitCost.Mul(line.BatchQty)
+		if line.Expired {
+			report.ExpiredQty += line.BatchQty
+		} else {
+			report.NearQty += line.BatchQty
+		}
+		total += line.Value
+		report.Lines = append(report.Lines, line)
+	}
+	report.TotalValue = total
+	return report, nil
+}
+
+// WriteOffExpiredBatches writes off every batch that expired before today
+// through the stock ledger and returns how many units went out. A batch that
+// fails is logged and retried on the next run.
+func (s Service) WriteOffExpiredBatches(ctx context.Context) (int, error) {
+	now := s.now()
+	batches, err := s.goldgymstock.GetExpiredBatches(ctx, now.Format(dateLayout))
+	if err != nil {
+		return 0, errors.Wrap(err, "[Service][WriteOffExpiredBatches]")
+	}
+
+	var (
+		total   int
+		lastErr error
+	)
+	for _, batch := range batches {
+		written, err := s.goldgymstock.WriteOffBatch(ctx, batch, goldStockEntity.StockMovement{
+			StockCode:      batch.StockCode,
+			MovementType:   goldStockEntity.MovementWriteOff,
+			MovementReason: fmt.Sprintf("expired lot %s (exp %s)", batch.BatchLot, batch.BatchExpiry),
+			MovementRef:    "BATCH-" + strconv.FormatInt(batch.BatchID, 10),
+			MovementBy:     "system",
+			MovementAt:     now,
+		})
+		if err != nil {
+			log.Printf("[ERROR] [Service][WriteOffExpiredBatches] batch %d: %v", batch.BatchID, err)
+			lastErr = err
+			continue
+		}
+		total += written
+	}
+	if lastErr != nil {
+		return total, errors.Wrap(lastErr, "[Service][WriteOffExpiredBatches]")
+	}
+	return total, nil
+}
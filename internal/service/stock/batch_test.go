@@ -0,0 +1,180 @@
+package goldgym
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeStockData) GetStockBatches(ctx context.Context, stockcode string) ([]goldStockEntity.StockBatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var batches []goldStockEntity.StockBatch
+	for _, batch := range f.batches {
+		if batch.StockCode == stockcode && batch.BatchQty > 0 {
+			batches = append(batches, batch)
+		}
+	}
+	sort.SliceStable(batches, func(i, j int) bool {
+		a, b := batches[i].BatchExpiry, batches[j].BatchExpiry
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		return a < b
+	})
+	return batches, nil
+}
+
+func (f *fakeStockData) GetNearExpiryBatches(ctx context.Context, until string, branchID int64) ([]goldStockEntity.NearExpiryLine, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var lines []goldStockEntity.NearExpiryLine
+	for _, batch := range f.batches {
+		if branchID != 0 && batch.BatchBranchID != branchID {
+			continue
+		}
+		if batch.BatchQty > 0 && batch.BatchExpiry != "" && batch.BatchExpiry <= until {
+			lines = append(lines, goldStockEntity.NearExpiryLine{StockBatch: batch, StockName: f.stocks[batch.StockCode].StockName})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].BatchExpiry < lines[j].BatchExpiry })
+	return lines, nil
+}
+
+func (f *fakeStockData) GetExpiredBatches(ctx context.Context, today string) ([]goldStockEntity.StockBatch, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var batches []goldStockEntity.StockBatch
+	for _, batch := range f.batches {
+		if batch.BatchQty > 0 && batch.BatchExpiry != "" && batch.BatchExpiry < today {
+			batches = append(batches, batch)
+		}
+	}
+	return batches, nil
+}
+
+func (f *fakeStockData) WriteOffBatch(ctx context.Context, batch goldStockEntity.StockBatch, movement goldStockEntity.StockMovement) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.batches {
+		if f.batches[i].BatchID != batch.BatchID || f.batches[i].BatchQty != batch.BatchQty {
+			continue
+		}
+		f.batches[i].BatchQty = 0
+		stock := f.stocks[batch.StockCode]
+		written := batch.BatchQty
+		if stock.StockQTY < written {
+			written = stock.StockQTY
+		}
+		stock.StockQTY -= written
+		f.stocks[batch.StockCode] = stock
+		movement.MovementQty = -written
+		f.movements = append(f.movements, movement)
+		return written, nil
+	}
+	return 0, nil
+}
+
+// receiveLots membuat PO WHEY-1 lalu menerima tiga lot dengan expiry berbeda
+func receiveLots(t *testing.T, svc Service, supplier goldStockEntity.Supplier) goldStockEntity.PurchaseOrder {
+	ctx := context.Background()
+	po, err := svc.CreatePurchaseOrder(ctx, goldStockEntity.CreatePurchaseOrder{
+		SupplierID: supplier.SupplierID,
+		CreatedBy:  "rina",
+		Lines:      []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 30, UnitCost: entity.NewMoney(20000), Lot: "ignored"}},
+	})
+	require.NoError(t, err)
+	po, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{
+		POID:       po.POID,
+		ReceivedBy: "budi",
+		Lines: []goldStockEntity.POLineRequest{
+			{StockCode: "WHEY-1", Qty: 10, Lot: "L-LATE", ExpiryDate: "2027-06-30"},
+			{StockCode: "WHEY-1", Qty: 10, Lot: "L-SOON", ExpiryDate: "2026-11-05"},
+			{StockCode: "WHEY-1", Qty: 10, Lot: "L-NONE"},
+		},
+	})
+	require.NoError(t, err)
+	return po
+}
+
+func TestReceiveCreatesBatches(t *testing.T) {
+	data, svc, supplier := newPurchasingFixture(t)
+	ctx := context.Background()
+
+	po := receiveLots(t, svc, supplier)
+	assert.Equal(t, goldStockEntity.POStatusReceived, po.POStatus)
+	require.Len(t, data.batches, 3)
+	assert.Equal(t, "L-LATE", data.batches[0].BatchLot)
+	assert.Equal(t, entity.NewMoney(20000), data.batches[0].BatchUnitCost)
+	assert.Equal(t, po.POID, data.batches[0].BatchRef)
+	assert.Equal(t, "2026-11-05", data.receipts[1].ReceiptExpiry)
+
+	// getonestock menampilkan lot urut FEFO
+	stock, err := svc.GetOneStockProduct(ctx, "WHEY-1", "", "")
+	require.NoError(t, err)
+	require.Len(t, stock.StockBatches, 3)
+	assert.Equal(t, []string{"L-SOON", "L-LATE", "L-NONE"}, []string{stock.StockBatches[0].BatchLot, stock.StockBatches[1].BatchLot, stock.StockBatches[2].BatchLot})
+
+	po2, err := svc.CreatePurchaseOrder(ctx, goldStockEntity.CreatePurchaseOrder{SupplierID: supplier.SupplierID, CreatedBy: "rina", Lines: []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 5}}})
+	require.NoError(t, err)
+	for _, expiry := range []string{"2026-10-18", "18-10-2026"} {
+		_, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{POID: po2.POID, ReceivedBy: "budi", Lines: []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 5, ExpiryDate: expiry}}})
+		assert.Equal(t, entity.ErrInvalid, errors.Cause(err), expiry)
+	}
+}
+
+func TestNearExpiryReport(t *testing.T) {
+	data, svc, supplier := newPurchasingFixture(t)
+	ctx := context.Background()
+	receiveLots(t, svc, supplier)
+	data.batches = append(data.batches, goldStockEntity.StockBatch{BatchID: 9, StockCode: "ISO-1", BatchLot: "OLD", BatchExpiry: "2026-10-17", BatchQty: 4, BatchUnitCost: entity.NewMoney(4500)})
+
+	report, err := svc.GetNearExpiryReport(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 30, report.Days)
+	require.Len(t, report.Lines, 2)
+	assert.True(t, report.Lines[0].Expired)
+	assert.Equal(t, -2, report.Lines[0].DaysLeft)
+	assert.Equal(t, "L-SOON", report.Lines[1].BatchLot)
+	assert.Equal(t, 17, report.Lines[1].DaysLeft)
+	assert.Equal(t, 4, report.ExpiredQty)
+	assert.Equal(t, 10, report.NearQty)
+	assert.Equal(t, entity.NewMoney(4*4500+10*20000), report.TotalValue)
+
+	report, err = svc.GetNearExpiryReport(ctx, 365, 0)
+	require.NoError(t, err)
+	assert.Len(t, report.Lines, 3)
+}
+
+func TestWriteOffExpiredBatches(t *testing.T) {
+	data, svc, _ := newPurchasingFixture(t)
+	ctx := context.Background()
+	data.stocks["ISO-1"] = goldStockEntity.GetOneStock{StockCode: "ISO-1", StockQTY: 6}
+	data.batches = []goldStockEntity.StockBatch{
+		{BatchID: 1, StockCode: "ISO-1", BatchLot: "OLD", BatchExpiry: "2026-10-17", BatchQty: 4},
+		{BatchID: 2, StockCode: "ISO-1", BatchLot: "TODAY", BatchExpiry: "2026-10-19", BatchQty: 2},
+	}
+
+	n, err := svc.WriteOffExpiredBatches(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, 2, data.stocks["ISO-1"].StockQTY)
+	assert.Equal(t, 0, data.batches[0].BatchQty)
+	// yang expired hari ini masih boleh dijual
+	assert.Equal(t, 2, data.batches[1].BatchQty)
+	require.Len(t, data.movements, 1)
+	assert.Equal(t, goldStockEntity.MovementWriteOff, data.movements[0].MovementType)
+	assert.Equal(t, -4, data.movements[0].MovementQty)
+	assert.Equal(t, "BATCH-1", data.movements[0].MovementRef)
+
+	n, err = svc.WriteOffExpiredBatches(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
@@ -0,0 +1,99 @@
+package goldgym
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	branchEntity "gold-gym-be/internal/entity/branch"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	"strings"
+)
+
+// GetBranchStock lists every product with what branchID has on hand as its
+// stock_qty. Reorder suggestions stay on the company total, purchasing is
+// central.
+func (s Service) GetBranchStock(ctx context.Context, branchID int64) ([]goldStockEntity.GetOneStock, error) {
+	stocks, err := s.goldgymstock.GetBranchStock(ctx, branchID)
+	if err != nil {
+		return nil, errors.Wrap(err, "[Service][GetBranchStock]")
+	}
+	return stocks, nil
+}
+
+// TransferStock moves stock between two branches through the ledger: a
+// transfer out at the sending branch and a transfer in at the receiving one,
+// sharing one TR reference. The company total does not change.
+func (s Service) TransferStock(ctx context.Context, transfer goldStockEntity.StockTransfer) (string, error) {
+	var result string
+
+	transfer.StockCode = strings.TrimSpace(transfer.StockCode)
+	transfer.Reason = strings.TrimSpace(transfer.Reason)
+	transfer.TransferBy = strings.TrimSpace(transfer.TransferBy)
+	if transfer.StockCode == "" || transfer.Qty <= 0 || transfer.TransferBy == "" {
+		result = "Gagal"
+		return result, errors.Wrap(entity.ErrInvalid, "stock_code, a positive qty and transfer_by are required")
+	}
+	if transfer.FromBranchID == transfer.ToBranchID {
+		result = "Gagal"
+		return result, errors.Wrap(entity.ErrInvalid, "from_branch_id and to_branch_id must differ")
+	}
+	var err error
+	if transfer.FromBranchID, err = s.branch(ctx, transfer.FromBranchID); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][TransferStock]")
+	}
+	if transfer.ToBranchID, err = s.branch(ctx, transfer.ToBranchID); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][TransferStock]")
+	}
+	if transfer.FromBranchID == transfer.ToBranchID {
+		result = "Gagal"
+		return result, errors.Wrap(entity.ErrInvalid, "from_branch_id and to_branch_id must differ")
+	}
+	if transfer.Reason == "" {
+		transfer.Reason = "transfer"
+	}
+
+	t := s.now()
+	ref, err := newDocumentID("TR", t)
+	if err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][TransferStock]")
+	}
+	out := goldStockEntity.StockMovement{
+		StockCode:        transfer.StockCode,
+		MovementBranchID: transfer.FromBranchID,
+		MovementType:     goldStockEntity.MovementTransfer,
+		MovementQty:      -transfer.Qty,
+		MovementReason:   transfer.Reason,
+		MovementRef:      ref,
+		MovementBy:       transfer.TransferBy,
+		MovementAt:       t,
+	}
+	in := out
+	in.MovementBranchID = transfer.ToBranchID
+	in.MovementQty = transfer.Qty
+
+	if err := s.goldgymstock.TransferStock(ctx, transfer, out, in); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][TransferStock]")
+	}
+	result = "Berhasil"
+	return result, nil
+}
+
+// branch resolves the branch a write goes to: the main branch when none is
+// given, otherwise it has to be an open branch
+func (s Service) branch(ctx context.Context, branchID int64) (int64, error) {
+	if branchID == 0 || branchID == branchEntity.MainBranchID {
+		return branchEntity.MainBranchID, nil
+	}
+	active, err := s.goldgymstock.BranchActive(ctx, branchID)
+	if err != nil {
+		return 0, err
+	}
+	if !active {
+		return 0, errors.Wrap(entity.ErrInvalid, "unknown or closed branch_id")
+	}
+	return branchID, nil
+}
@@ -0,0 +1,148 @@
+package goldgym
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeStockData) BranchActive(ctx context.Context, branchID int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.branches[branchID], nil
+}
+
+// branchQty adalah proyeksi ledger per cabang, sama seperti stock_branch
+func (f *fakeStockData) branchQty(branchID int64, stockcode string) int {
+	var qty int
+	for _, m := range f.movements {
+		if m.MovementBranchID == branchID && m.StockCode == stockcode {
+			qty += m.MovementQty
+		}
+	}
+	return qty
+}
+
+func (f *fakeStockData) GetBranchStock(ctx context.Context, branchID int64) ([]goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stocks []goldStockEntity.GetOneStock
+	for code, stock := range f.stocks {
+		stock.StockQTY = f.branchQty(branchID, code)
+		stocks = append(stocks, stock)
+	}
+	sort.Slice(stocks, func(i, j int) bool { return stocks[i].StockCode < stocks[j].StockCode })
+	return stocks, nil
+}
+
+func (f *fakeStockData) TransferStock(ctx context.Context, transfer goldStockEntity.StockTransfer, out, in goldStockEntity.StockMovement) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.stocks[transfer.StockCode]; !ok {
+		return errors.Wrap(entity.ErrNotFound, transfer.StockCode)
+	}
+	if f.branchQty(transfer.FromBranchID, transfer.StockCode) < transfer.Qty {
+		return errors.Wrap(goldStockEntity.ErrNegativeStock, transfer.StockCode)
+	}
+	f.movements = append(f.movements, out, in)
+	return nil
+}
+
+func newBranchFixture(t *testing.T) (Service, *fakeStockData) {
+	data := newFakeStockData()
+	data.branches = map[int64]bool{2: true}
+	svc := New(data, nil, nil, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local) }
+
+	// restock tanpa cabang masuk ke cabang utama
+	_, err := svc.InsertStockSales(context.Background(), restock("ISO-1", 10))
+	require.NoError(t, err)
+	return svc, data
+}
+
+func TestTransferStock(t *testing.T) {
+	svc, data := newBranchFixture(t)
+	ctx := context.Background()
+
+	result, err := svc.TransferStock(ctx, goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 1, ToBranchID: 2, Qty: 4, TransferBy: "rina"})
+	require.NoError(t, err)
+	assert.Equal(t, "Berhasil", result)
+
+	// keluar di cabang asal, masuk di cabang tujuan, satu referensi
+	require.Len(t, data.movements, 3)
+	out, in := data.movements[1], data.movements[2]
+	assert.Equal(t, goldStockEntity.MovementTransfer, out.MovementType)
+	assert.Equal(t, int64(1), out.MovementBranchID)
+	assert.Equal(t, -4, out.MovementQty)
+	assert.Equal(t, int64(2), in.MovementBranchID)
+	assert.Equal(t, 4, in.MovementQty)
+	assert.Equal(t, out.MovementRef, in.MovementRef)
+	assert.Equal(t, "transfer", in.MovementReason)
+
+	main, err := svc.GetBranchStock(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, main, 1)
+	assert.Equal(t, 6, main[0].StockQTY)
+	second, err := svc.GetBranchStock(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, second[0].StockQTY)
+
+	// total perusahaan tetap
+	assert.Equal(t, 10, data.stocks["ISO-1"].StockQTY)
+
+	// kartu stok per cabang
+	card, err := svc.GetStockCard(ctx, "ISO-1", "2026-10-19", "2026-10-19", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), card.BranchID)
+	assert.Equal(t, 4, card.ClosingQty)
+}
+
+func TestTransferStockInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer goldStockEntity.StockTransfer
+		cause    error
+	}{
+		{"cabang sama", goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 2, ToBranchID: 2, Qty: 1, TransferBy: "rina"}, entity.ErrInvalid},
+		{"cabang utama tanpa id", goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 0, ToBranchID: 1, Qty: 1, TransferBy: "rina"}, entity.ErrInvalid},
+		{"qty nol", goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 1, ToBranchID: 2, TransferBy: "rina"}, entity.ErrInvalid},
+		{"tanpa petugas", goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 1, ToBranchID: 2, Qty: 1}, entity.ErrInvalid},
+		{"cabang tidak dikenal", goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 1, ToBranchID: 9, Qty: 1, TransferBy: "rina"}, entity.ErrInvalid},
+		{"stok cabang kurang", goldStockEntity.StockTransfer{StockCode: "ISO-1", FromBranchID: 1, ToBranchID: 2, Qty: 11, TransferBy: "rina"}, goldStockEntity.ErrNegativeStock},
+		{"kode tidak ada", goldStockEntity.StockTransfer{StockCode: "BAR-1", FromBranchID: 1, ToBranchID: 2, Qty: 1, TransferBy: "rina"}, entity.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, data := newBranchFixture(t)
+			result, err := svc.TransferStock(context.Background(), tt.transfer)
+			assert.Equal(t, "Gagal", result)
+			assert.Equal(t, tt.cause, errors.Cause(err))
+			assert.Len(t, data.movements, 1)
+		})
+	}
+}
+
+func TestBranchScopedWrites(t *testing.T) {
+	svc, data := newBranchFixture(t)
+	ctx := context.Background()
+
+	_, err := svc.InsertStockSales(ctx, goldStockEntity.InsertStockData{StockData: goldStockEntity.InsertStock{StockCode: "ISO-1", StockQTY: 5, StockBranchID: 2, StockUpdateBy: "rina"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), data.movements[1].MovementBranchID)
+
+	_, err = svc.InsertStockSales(ctx, goldStockEntity.InsertStockData{StockData: goldStockEntity.InsertStock{StockCode: "ISO-1", StockQTY: 5, StockBranchID: 7, StockUpdateBy: "rina"}})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+
+	_, err = svc.RecordMovement(ctx, goldStockEntity.StockMovement{StockCode: "ISO-1", MovementType: goldStockEntity.MovementWriteOff, MovementQty: 1, MovementReason: "rusak", MovementBy: "rina"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), data.movements[2].MovementBranchID)
+}
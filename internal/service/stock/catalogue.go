@@ -0,0 +1,342 @@
+package goldgym
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	"io"
+	"strings"
+)
+
+// maxImageSize caps product image uploads
+const maxImageSize = 5 << 20
+
+// imageExtensions are the accepted product image types
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+func (s Service) CreateCategory(ctx context.Context, category goldStockEntity.Category) (goldStockEntity.Category, error) {
+	category.CategoryName = strings.TrimSpace(category.CategoryName)
+	if category.CategoryName == "" {
+		return category, errors.Wrap(entity.ErrInvalid, "category_name is required")
+	}
+
+	_, err := s.goldgymstock.GetCategoryByName(ctx, category.CategoryName)
+	if err == nil {
+		return category, errors.Wrap(goldStockEntity.ErrDuplicateCategory, category.CategoryName)
+	}
+	if err.Error() != "record not found" {
+		return category, errors.Wrap(err, "[Service][CreateCategory]")
+	}
+
+	category.CategoryID, err = s.goldgymstock.InsertCategory(ctx, category)
+	if err != nil {
+		return category, errors.Wrap(err, "[Service][CreateCategory]")
+	}
+	return category, nil
+}
+
+func (s Service) ListCategories(ctx context.Context) ([]goldStockEntity.Category, error) {
+	categories, err := s.goldgymstock.GetCategories(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "[Service][ListCategories]")
+	}
+	return categories, nil
+}
+
+// CreateProduct registers a parent product, variants are linked afterwards
+// with SetVariant
+func (s Service) CreateProduct(ctx context.Context, product goldStockEntity.Product) (goldStockEntity.Product, error) {
+	product.ProductCode = strings.TrimSpace(product.ProductCode)
+	product.ProductName = strings.TrimSpace(product.ProductName)
+	product.ProductDescription = strings.TrimSpace(product.ProductDescription)
+	product.ProductImageURL = ""
+	if product.ProductCode == "" || product.ProductName == "" {
+		return product, errors.Wrap(entity.ErrInvalid, "product_code and product_name are required")
+	}
+
+	if product.ProductCategoryID != 0 {
+		category, err := s.goldgymstock.GetCategoryByID(ctx, product.ProductCategoryID)
+		if err != nil {
+			if err.Error() == "record not found" {
+				return product, errors.Wrap(entity.ErrInvalid, "unknown category_id")
+			}
+			return product, errors.Wrap(err, "[Service][CreateProduct]")
+		}
+		product.CategoryName = category.CategoryName
+	}
+
+	_, err := s.goldgymstock.GetProductByCode(ctx, product.ProductCode)
+	if err == nil {
+		return product, errors.Wrap(goldStockEntity.ErrDuplicateProduct, product.ProductCode)
+	}
+	if err.Error() != "record not found" {
+		return product, errors.Wrap(err, "[Service][CreateProduct]")
+	}
+
+	if err := s.goldgymstock.InsertProduct(ctx, product); err != nil {
+		return product, errors.Wrap(err, "[Service][CreateProduct]")
+	}
+	product.Variants = []goldStockEntity.GetOneStock{}
+	return product, nil
+}
+
+// ListProducts searches the catalogue by category and/or name, every product
+// with its variants and their barcodes
+func (s Service) ListProducts(ctx context.Context, filter goldStockEntity.CatalogueFilter) ([]goldStockEntity.Product, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	products, err := s.goldgymstock.GetProducts(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "[Service][ListProducts]")
+	}
+	if len(products) == 0 {
+		return []goldStockEntity.Product{}, nil
+	}
+	if err := s.fillVariants(ctx, products); err != nil {
+		return nil, errors.Wrap(err, "[Service][ListProducts]")
+	}
+	return products, nil
+}
+
+func (s Service) GetProduct(ctx context.Context, code string) (goldStockEntity.Product, error) {
+	product, err := s.product(ctx, code)
+	if err != nil {
+		return product, errors.Wrap(err, "[Service][GetProduct]")
+	}
+
+	products := []goldStockEntity.Product{product}
+	if err := s.fillVariants(ctx, products); err != nil {
+		return product, errors.Wrap(err, "[Service][GetProduct]")
+	}
+	return products[0], nil
+}
+
+// SetVariant links an existing stock code to a product as one of its
+// flavours/sizes, moving it away from any product it belonged to
+func (s Service) SetVariant(ctx context.Context, variant goldStockEntity.Variant) (goldStockEntity.Product, error) {
+	variant.StockCode = strings.TrimSpace(variant.StockCode)
+	variant.Flavour = strings.TrimSpace(variant.Flavour)
+	variant.Size = strings.TrimSpace(variant.Size)
+	if variant.StockCode == "" {
+		return goldStockEntity.Product{}, errors.Wrap(entity.ErrInvalid, "stock_code is required")
+	}
+	if _, err := s.product(ctx, variant.ProductCode); err != nil {
+		return goldStockEntity.Product{}, errors.Wrap(err, "[Service][SetVariant]")
+	}
+
+	if err := s.goldgymstock.SetVariant(ctx, variant); err != nil {
+		return goldStockEntity.Product{}, errors.Wrap(err, "[Service][SetVariant]")
+	}
+	return s.GetProduct(ctx, variant.ProductCode)
+}
+
+// RemoveVariant detaches stockcode from product code, the stock row itself
+// stays
+func (s Service) RemoveVariant(ctx context.Context, code, stockcode string) (string, error) {
+	stocks, err := s.goldgymstock.GetStocksByCode(ctx, []string{stockcode})
+	if err != nil {
+		return "Gagal", errors.Wrap(err, "[Service][RemoveVariant]")
+	}
+	if len(stocks) == 0 || stocks[0].StockProductCode != code {
+		return "Gagal", errors.Wrap(entity.ErrNotFound, "[Service][RemoveVariant]")
+	}
+
+	if err := s.goldgymstock.SetVariant(ctx, goldStockEntity.Variant{StockCode: stockcode}); err != nil {
+		return "Gagal", errors.Wrap(err, "[Service][RemoveVariant]")
+	}
+	return "Berhasil", nil
+}
+
+// AddBarcode assigns an EAN-13, EAN-8 or UPC-A barcode to a stock code. A
+// stock code may have several barcodes, a barcode only one stock code.
+func (s Service) AddBarcode(ctx context.Context, barcode goldStockEntity.Barcode) (goldStockEntity.Barcode, error) {
+	barcode.Barcode = normalizeBarcode(barcode.Barcode)
+	barcode.StockCode = strings.TrimSpace(barcode.StockCode)
+	if !ValidBarcode(barcode.Barcode) {
+		return barcode, errors.Wrap(entity.ErrInvalid, "barcode must be a valid EAN-13, EAN-8 or UPC-A")
+	}
+
+	stocks, err := s.goldgymstock.GetStocksByCode(ctx, []string{barcode.StockCode})
+	if err != nil {
+		return barcode, errors.Wrap(err, "[Service][AddBarcode]")
+	}
+	if len(stocks) == 0 {
+		return barcode, errors.Wrap(entity.ErrInvalid, "unknown stock_code")
+	}
+
+	existing, err := s.goldgymstock.GetBarcode(ctx, barcode.Barcode)
+	if err == nil {
+		return barcode, errors.Wrap(goldStockEntity.ErrDuplicateBarcode, existing.StockCode)
+	}
+	if err.Error() != "record not found" {
+		return barcode, errors.Wrap(err, "[Service][AddBarcode]")
+	}
+
+	if err := s.goldgymstock.InsertBarcode(ctx, barcode); err != nil {
+		return barcode, errors.Wrap(err, "[Service][AddBarcode]")
+	}
+	return barcode, nil
+}
+
+func (s Service) RemoveBarcode(ctx context.Context, barcode string) (string, error) {
+	if err := s.goldgymstock.DeleteBarcode(ctx, normalizeBarcode(barcode)); err != nil {
+		return "Gagal", errors.Wrap(err, "[Service][RemoveBarcode]")
+	}
+	return "Berhasil", nil
+}
+
+// ScanBarcode resolves a scanned barcode to the variant to sell and its
+// product. UPC-A scanners that report 13 digits with a leading zero are
+// accepted too.
+func (s Service) ScanBarcode(ctx context.Context, barcode string) (goldStockEntity.ScanResult, error) {
+	barcode = normalizeBarcode(barcode)
+	if !ValidBarcode(barcode) {
+		return goldStockEntity.ScanResult{}, errors.Wrap(entity.ErrInvalid, "barcode must be a valid EAN-13, EAN-8 or UPC-A")
+	}
+
+	stock, err := s.goldgymstock.GetStockByBarcode(ctx, barcode)
+	if errors.Cause(err) == entity.ErrNotFound && len(barcode) == 13 && barcode[0] == '0' {
+		stock, err = s.goldgymstock.GetStockByBarcode(ctx, barcode[1:])
+	}
+	if err != nil {
+		return goldStockEntity.ScanResult{}, errors.Wrap(err, "[Service][ScanBarcode]")
+	}
+
+	result := goldStockEntity.ScanResult{Barcode: barcode, Stock: stock}
+	if stock.StockProductCode != "" {
+		product, err := s.product(ctx, stock.StockProductCode)
+		if err != nil && errors.Cause(err) != entity.ErrNotFound {
+			return result, errors.Wrap(err, "[Service][ScanBarcode]")
+		}
+		if err == nil {
+			result.Product = &product
+		}
+	}
+	return result, nil
+}
+
+// UploadProductImage stores the image in Firebase storage and points the
+// product at it
+func (s Service) UploadProductImage(ctx context.Context, code, contentType string, size int64, body io.Reader) (goldStockEntity.Product, error) {
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return goldStockEntity.Product{}, errors.Wrap(entity.ErrInvalid, "image must be jpeg, png or webp")
+	}
+	if size <= 0 || size > maxImageSize {
+		return goldStockEntity.Product{}, errors.Wrap(entity.ErrInvalid, "image must be at most 5 MB")
+	}
+	product, err := s.product(ctx, code)
+	if err != nil {
+		return product, errors.Wrap(err, "[Service][UploadProductImage]")
+	}
+
+	name, err := newDocumentID("IMG", s.now())
+	if err != nil {
+		return product, errors.Wrap(err, "[Service][UploadProductImage]")
+	}
+	imageURL, err := s.goldgymstock.UploadImage(ctx, "products/"+product.ProductCode+"/"+name+ext, contentType, io.LimitReader(body, maxImageSize))
+	if err != nil {
+		return product, errors.Wrap(err, "[Service][UploadProductImage]")
+	}
+	if err := s.goldgymstock.UpdateProductImage(ctx, product.ProductCode, imageURL); err != nil {
+		return product, errors.Wrap(err, "[Service][UploadProductImage]")
+	}
+	return s.GetProduct(ctx, product.ProductCode)
+}
+
+// ValidBarcode checks the length and GS1 check digit of an EAN-13, EAN-8 or
+// UPC-A barcode
+func ValidBarcode(barcode string) bool {
+	switch len(barcode) {
+	case 8, 12, 13:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(barcode) - 2; i >= 0; i-- {
+		c := barcode[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		// weights alternate 3, 1, 3, ... from the digit next to the check digit
+		if (len(barcode)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := barcode[len(barcode)-1]
+	return check >= '0' && check <= '9' && int(check-'0') == (10-sum%10)%10
+}
+
+func normalizeBarcode(barcode string) string {
+	return strings.Join(strings.Fields(barcode), "")
+}
+
+// product reads a product, mapping a missing one to ErrNotFound
+func (s Service) product(ctx context.Context, code string) (goldStockEntity.Product, error) {
+	product, err := s.goldgymstock.GetProductByCode(ctx, strings.TrimSpace(code))
+	if err != nil {
+		if err.Error() == "record not found" {
+			return product, errors.Wrap(entity.ErrNotFound, code)
+		}
+		return product, err
+	}
+	return product, nil
+}
+
+// fillVariants attaches the variants and their barcodes to products
+func (s Service) fillVariants(ctx context.Context, products []goldStockEntity.Product) error {
+	codes := make([]string, 0, len(products))
+	for _, product := range products {
+		codes = append(codes, product.ProductCode)
+	}
+	variants, err := s.goldgymstock.GetVariants(ctx, codes)
+	if err != nil {
+		return err
+	}
+	if err := s.fillBarcodes(ctx, variants); err != nil {
+		return err
+	}
+
+	byProduct := make(map[string][]goldStockEntity.GetOneStock, len(products))
+	for _, variant := range variants {
+		byProduct[variant.StockProductCode] = append(byProduct[variant.StockProductCode], variant)
+	}
+	for i := range products {
+		products[i].Variants = byProduct[products[i].ProductCode]
+		if products[i].Variants == nil {
+			products[i].Variants = []goldStockEntity.GetOneStock{}
+		}
+	}
+	return nil
+}
+
+func (s Service) fillBarcodes(ctx context.Context, stocks []goldStockEntity.GetOneStock) error {
+	if len(stocks) == 0 {
+		return nil
+	}
+	codes := make([]string, 0, len(stocks))
+	for _, stock := range stocks {
+		codes = append(codes, stock.StockCode)
+	}
+	barcodes, err := s.goldgymstock.GetBarcodes(ctx, codes)
+	if err != nil {
+		return err
+	}
+
+	byStock := make(map[string][]string, len(stocks))
+	for _, barcode := range barcodes {
+		byStock[barcode.StockCode] = append(byStock[barcode.StockCode], barcode.Barcode)
+	}
+	for i := range stocks {
+		stocks[i].StockBarcodes = byStock[stocks[i].StockCode]
+	}
+	return nil
+}
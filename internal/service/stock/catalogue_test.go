@@ -0,0 +1,330 @@
+package goldgym
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeStockData) InsertCategory(ctx context.Context, category goldStockEntity.Category) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	category.CategoryID = int64(len(f.categories) + 1)
+	f.categories = append(f.categories, category)
+	return category.CategoryID, nil
+}
+
+func (f *fakeStockData) GetCategoryByName(ctx context.Context, name string) (goldStockEntity.Category, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.categories {
+		if c.CategoryName == name {
+			return c, nil
+		}
+	}
+	return goldStockEntity.Category{}, errRecordNotFound
+}
+
+func (f *fakeStockData) GetCategoryByID(ctx context.Context, id int64) (goldStockEntity.Category, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.categories {
+		if c.CategoryID == id {
+			return c, nil
+		}
+	}
+	return goldStockEntity.Category{}, errRecordNotFound
+}
+
+func (f *fakeStockData) GetCategories(ctx context.Context) ([]goldStockEntity.Category, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]goldStockEntity.Category(nil), f.categories...), nil
+}
+
+func (f *fakeStockData) InsertProduct(ctx context.Context, product goldStockEntity.Product) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.products[product.ProductCode] = product
+	return nil
+}
+
+func (f *fakeStockData) GetProductByCode(ctx context.Context, code string) (goldStockEntity.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	product, ok := f.products[code]
+	if !ok {
+		return goldStockEntity.Product{}, errRecordNotFound
+	}
+	return product, nil
+}
+
+func (f *fakeStockData) GetProducts(ctx context.Context, filter goldStockEntity.CatalogueFilter) ([]goldStockEntity.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var products []goldStockEntity.Product
+	for _, p := range f.products {
+		if (filter.CategoryID == 0 || p.ProductCategoryID == filter.CategoryID) &&
+			(filter.Query == "" || strings.Contains(strings.ToLower(p.ProductName), strings.ToLower(filter.Query))) {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ProductName < products[j].ProductName })
+	return products, nil
+}
+
+func (f *fakeStockData) GetVariants(ctx context.Context, productCodes []string) ([]goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	want := make(map[string]bool, len(productCodes))
+	for _, code := range productCodes {
+		want[code] = true
+	}
+	return f.sorted(func(s goldStockEntity.GetOneStock) bool { return want[s.StockProductCode] }), nil
+}
+
+func (f *fakeStockData) SetVariant(ctx context.Context, variant goldStockEntity.Variant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stock, ok := f.stocks[variant.StockCode]
+	if !ok {
+		return errors.Wrap(entity.ErrNotFound, variant.StockCode)
+	}
+	stock.StockProductCode, stock.StockFlavour, stock.StockSize = variant.ProductCode, variant.Flavour, variant.Size
+	f.stocks[variant.StockCode] = stock
+	return nil
+}
+
+func (f *fakeStockData) UpdateProductImage(ctx context.Context, code, imageURL string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	product := f.products[code]
+	product.ProductImageURL = imageURL
+	f.products[code] = product
+	return nil
+}
+
+func (f *fakeStockData) InsertBarcode(ctx context.Context, barcode goldStockEntity.Barcode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.barcodes[barcode.Barcode] = barcode.StockCode
+	return nil
+}
+
+func (f *fakeStockData) GetBarcode(ctx context.Context, barcode string) (goldStockEntity.Barcode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	code, ok := f.barcodes[barcode]
+	if !ok {
+		return goldStockEntity.Barcode{}, errRecordNotFound
+	}
+	return goldStockEntity.Barcode{Barcode: barcode, StockCode: code}, nil
+}
+
+func (f *fakeStockData) GetBarcodes(ctx context.Context, stockcodes []string) ([]goldStockEntity.Barcode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	want := make(map[string]bool, len(stockcodes))
+	for _, code := range stockcodes {
+		want[code] = true
+	}
+	var barcodes []goldStockEntity.Barcode
+	for barcode, code := range f.barcodes {
+		if want[code] {
+			barcodes = append(barcodes, goldStockEntity.Barcode{Barcode: barcode, StockCode: code})
+		}
+	}
+	sort.Slice(barcodes, func(i, j int) bool { return barcodes[i].Barcode < barcodes[j].Barcode })
+	return barcodes, nil
+}
+
+func (f *fakeStockData) DeleteBarcode(ctx context.Context, barcode string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.barcodes[barcode]; !ok {
+		return errors.Wrap(entity.ErrNotFound, barcode)
+	}
+	delete(f.barcodes, barcode)
+	return nil
+}
+
+func (f *fakeStockData) GetStockByBarcode(ctx context.Context, barcode string) (goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	code, ok := f.barcodes[barcode]
+	if !ok {
+		return goldStockEntity.GetOneStock{}, errors.Wrap(entity.ErrNotFound, barcode)
+	}
+	return f.stocks[code], nil
+}
+
+func (f *fakeStockData) UploadImage(ctx context.Context, path, contentType string, body io.Reader) (string, error) {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads[path] = string(b)
+	return "https://storage.test/" + path, nil
+}
+
+func newCatalogueFixture(t *testing.T) (*fakeStockData, Service) {
+	data := newFakeStockData()
+	data.stocks["WHEY-CHOC-1KG"] = goldStockEntity.GetOneStock{StockCode: "WHEY-CHOC-1KG", StockName: "Whey Choco 1kg", StockQTY: 5}
+	data.stocks["WHEY-VAN-1KG"] = goldStockEntity.GetOneStock{StockCode: "WHEY-VAN-1KG", StockName: "Whey Vanilla 1kg", StockQTY: 3}
+	data.stocks["ISO-1"] = goldStockEntity.GetOneStock{StockCode: "ISO-1", StockName: "Isotonic 500ml", StockQTY: 20}
+	svc := New(data, nil, nil, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local) }
+
+	ctx := context.Background()
+	category, err := svc.CreateCategory(ctx, goldStockEntity.Category{CategoryName: "Suplemen"})
+	require.NoError(t, err)
+	_, err = svc.CreateProduct(ctx, goldStockEntity.Product{ProductCode: "WHEY", ProductName: "Whey Protein", ProductCategoryID: category.CategoryID})
+	require.NoError(t, err)
+	return data, svc
+}
+
+func TestValidBarcode(t *testing.T) {
+	tests := []struct {
+		barcode string
+		want    bool
+	}{
+		{"4006381333931", true},  // EAN-13
+		{"96385074", true},       // EAN-8
+		{"036000291452", true},   // UPC-A
+		{"4006381333932", false}, // check digit salah
+		{"40063813339A1", false},
+		{"123", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.barcode, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidBarcode(tt.barcode))
+		})
+	}
+}
+
+func TestCreateCategoryAndProduct(t *testing.T) {
+	_, svc := newCatalogueFixture(t)
+	ctx := context.Background()
+
+	_, err := svc.CreateCategory(ctx, goldStockEntity.Category{CategoryName: " Suplemen "})
+	assert.Equal(t, goldStockEntity.ErrDuplicateCategory, errors.Cause(err))
+	_, err = svc.CreateProduct(ctx, goldStockEntity.Product{ProductCode: "WHEY", ProductName: "Lain"})
+	assert.Equal(t, goldStockEntity.ErrDuplicateProduct, errors.Cause(err))
+	_, err = svc.CreateProduct(ctx, goldStockEntity.Product{ProductCode: "BAR", ProductName: "Protein Bar", ProductCategoryID: 99})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+	_, err = svc.CreateProduct(ctx, goldStockEntity.Product{ProductCode: " "})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+}
+
+func TestVariantsAndSearch(t *testing.T) {
+	data, svc := newCatalogueFixture(t)
+	ctx := context.Background()
+
+	_, err := svc.SetVariant(ctx, goldStockEntity.Variant{ProductCode: "WHEY", StockCode: "WHEY-CHOC-1KG", Flavour: "Chocolate", Size: "1kg"})
+	require.NoError(t, err)
+	product, err := svc.SetVariant(ctx, goldStockEntity.Variant{ProductCode: "WHEY", StockCode: "WHEY-VAN-1KG", Flavour: "Vanilla", Size: "1kg"})
+	require.NoError(t, err)
+	require.Len(t, product.Variants, 2)
+	assert.Equal(t, "Chocolate", product.Variants[0].StockFlavour)
+
+	_, err = svc.SetVariant(ctx, goldStockEntity.Variant{ProductCode: "NOPE", StockCode: "ISO-1"})
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+	_, err = svc.SetVariant(ctx, goldStockEntity.Variant{ProductCode: "WHEY", StockCode: "NOPE"})
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+
+	// cari per kategori dan per nama
+	products, err := svc.ListProducts(ctx, goldStockEntity.CatalogueFilter{CategoryID: 1})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, "Suplemen", products[0].CategoryName)
+	assert.Len(t, products[0].Variants, 2)
+	products, err = svc.ListProducts(ctx, goldStockEntity.CatalogueFilter{Query: "bar"})
+	require.NoError(t, err)
+	assert.NotNil(t, products)
+	assert.Empty(t, products)
+
+	// variant yang dilepas tetap ada di stock
+	_, err = svc.RemoveVariant(ctx, "WHEY", "ISO-1")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+	_, err = svc.RemoveVariant(ctx, "WHEY", "WHEY-VAN-1KG")
+	require.NoError(t, err)
+	assert.Equal(t, "", data.stocks["WHEY-VAN-1KG"].StockProductCode)
+	product, err = svc.GetProduct(ctx, "WHEY")
+	require.NoError(t, err)
+	assert.Len(t, product.Variants, 1)
+}
+
+func TestBarcodeScan(t *testing.T) {
+	_, svc := newCatalogueFixture(t)
+	ctx := context.Background()
+
+	_, err := svc.SetVariant(ctx, goldStockEntity.Variant{ProductCode: "WHEY", StockCode: "WHEY-CHOC-1KG", Flavour: "Chocolate", Size: "1kg"})
+	require.NoError(t, err)
+	_, err = svc.AddBarcode(ctx, goldStockEntity.Barcode{Barcode: "4006 3813 3393 1", StockCode: "WHEY-CHOC-1KG"})
+	require.NoError(t, err)
+	_, err = svc.AddBarcode(ctx, goldStockEntity.Barcode{Barcode: "036000291452", StockCode: "ISO-1"})
+	require.NoError(t, err)
+
+	_, err = svc.AddBarcode(ctx, goldStockEntity.Barcode{Barcode: "4006381333931", StockCode: "ISO-1"})
+	assert.Equal(t, goldStockEntity.ErrDuplicateBarcode, errors.Cause(err))
+	_, err = svc.AddBarcode(ctx, goldStockEntity.Barcode{Barcode: "4006381333932", StockCode: "ISO-1"})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+	_, err = svc.AddBarcode(ctx, goldStockEntity.Barcode{Barcode: "96385074", StockCode: "NOPE"})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+
+	result, err := svc.ScanBarcode(ctx, "4006381333931")
+	require.NoError(t, err)
+	assert.Equal(t, "WHEY-CHOC-1KG", result.Stock.StockCode)
+	require.NotNil(t, result.Product)
+	assert.Equal(t, "Whey Protein", result.Product.ProductName)
+
+	// scanner yang mengirim UPC-A sebagai EAN-13 dengan nol di depan
+	result, err = svc.ScanBarcode(ctx, "0036000291452")
+	require.NoError(t, err)
+	assert.Equal(t, "ISO-1", result.Stock.StockCode)
+	assert.Nil(t, result.Product)
+
+	_, err = svc.ScanBarcode(ctx, "96385074")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+
+	// barcode ikut tampil di getonestock
+	stock, err := svc.GetOneStockProduct(ctx, "WHEY-CHOC-1KG", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4006381333931"}, stock.StockBarcodes)
+
+	_, err = svc.RemoveBarcode(ctx, "4006381333931")
+	require.NoError(t, err)
+	_, err = svc.ScanBarcode(ctx, "4006381333931")
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+}
+
+func TestUploadProductImage(t *testing.T) {
+	data, svc := newCatalogueFixture(t)
+	ctx := context.Background()
+
+	product, err := svc.UploadProductImage(ctx, "WHEY", "image/png", 4, strings.NewReader("\x89PNG"))
+	require.NoError(t, err)
+	assert.Regexp(t, `^https://storage\.test/products/WHEY/IMG20261019093000[0-9a-f]{6}\.png$`, product.ProductImageURL)
+	assert.Len(t, data.uploads, 1)
+
+	_, err = svc.UploadProductImage(ctx, "WHEY", "image/gif", 4, strings.NewReader("GIF8"))
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+	_, err = svc.UploadProductImage(ctx, "WHEY", "image/png", maxImageSize+1, strings.NewReader(""))
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+	_, err = svc.UploadProductImage(ctx, "NOPE", "image/png", 4, strings.NewReader("\x89PNG"))
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+}
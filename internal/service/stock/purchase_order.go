@@ -0,0 +1,348 @@
+package goldgym
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// CreateSupplier registers a new active supplier, supplier_code must be unique
+func (s Service) CreateSupplier(ctx context.Context, supplier goldStockEntity.Supplier) (goldStockEntity.Supplier, error) {
+	supplier.SupplierCode = strings.TrimSpace(supplier.SupplierCode)
+	supplier.SupplierName = strings.TrimSpace(supplier.SupplierName)
+	if supplier.SupplierCode == "" || supplier.SupplierName == "" {
+		return supplier, errors.Wrap(entity.ErrInvalid, "supplier_code and supplier_name are required")
+	}
+
+	_, err := s.goldgymstock.GetSupplierByCode(ctx, supplier.SupplierCode)
+	if err == nil {
+		return supplier, errors.Wrap(goldStockEntity.ErrDuplicateSupplier, supplier.SupplierCode)
+	}
+	if err.Error() != "record not found" {
+		return supplier, errors.Wrap(err, "[Service][CreateSupplier]")
+	}
+
+	supplier.SupplierID = 0
+	supplier.SupplierActive = true
+	supplier.SupplierID, err = s.goldgymstock.InsertSupplier(ctx, supplier)
+	if err != nil {
+		return supplier, errors.Wrap(err, "[Service][CreateSupplier]")
+	}
+	return supplier, nil
+}
+
+func (s Service) ListSuppliers(ctx context.Context) ([]goldStockEntity.Supplier, error) {
+	suppliers, err := s.goldgymstock.GetSuppliers(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "[Service][ListSuppliers]")
+	}
+	return suppliers, nil
+}
+
+// CreatePurchaseOrder opens a PO for an active supplier. Lines with the same
+// stock code are merged and every stock code must already exist.
+func (s Service) CreatePurchaseOrder(ctx context.Context, req goldStockEntity.CreatePurchaseOrder) (goldStockEntity.PurchaseOrder, error) {
+	var po goldStockEntity.PurchaseOrder
+
+	req.CreatedBy = strings.TrimSpace(req.CreatedBy)
+	if req.CreatedBy == "" {
+		return po, errors.Wrap(entity.ErrInvalid, "created_by is required")
+	}
+	if req.ExpectedDate != "" {
+		if _, err := time.Parse(dateLayout, req.ExpectedDate); err != nil {
+			return po, errors.Wrap(entity.ErrInvalid, "expected_date must be YYYY-MM-DD")
+		}
+	}
+	// lots and expiry dates are only known when the goods arrive
+	for i := range req.Lines {
+		req.Lines[i].Lot, req.Lines[i].ExpiryDate = "", ""
+	}
+	lines, err := mergePOLines(req.Lines)
+	if err != nil {
+		return po, errors.Wrap(err, "[Service][CreatePurchaseOrder]")
+	}
+
+	branchID, err := s.branch(ctx, req.BranchID)
+	if err != nil {
+		return po, errors.Wrap(err, "[Service][CreatePurchaseOrder]")
+	}
+
+	supplier, err := s.goldgymstock.GetSupplierByID(ctx, req.SupplierID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return po, errors.Wrap(entity.ErrInvalid, "unknown supplier_id")
+		}
+		return po, errors.Wrap(err, "[Service][CreatePurchaseOrder]")
+	}
+	if !supplier.SupplierActive {
+		return po, errors.Wrap(entity.ErrInvalid, "supplier is inactive")
+	}
+
+	codes := make([]string, 0, len(lines))
+	for _, line := range lines {
+		codes = append(codes, line.StockCode)
+	}
+	stocks, err := s.goldgymstock.GetStocksByCode(ctx, codes)
+	if err != nil {
+		return po, errors.Wrap(err, "[Service][CreatePurchaseOrder]")
+	}
+	known := make(map[string]bool, len(stocks))
+	for _, stock := range stocks {
+		known[stock.StockCode] = true
+	}
+
+	t := s.now()
+	po = goldStockEntity.PurchaseOrder{
+		POSupplierID:   supplier.SupplierID,
+		POBranchID:     branchID,
+		POStatus:       goldStockEntity.POStatusOpen,
+		PODate:         t.Format(dateLayout),
+		POExpectedDate: req.ExpectedDate,
+		PONote:         strings.TrimSpace(req.Note),
+		POCreatedBy:    req.CreatedBy,
+		POCreatedAt:    t,
+	}
+	po.POID, err = newDocumentID("PO", t)
+	if err != nil {
+		return po, errors.Wrap(err, "[Service][CreatePurchaseOrder]")
+	}
+
+	var total entity.Money
+	for _, line := range lines {
+		if !known[line.StockCode] {
+			return goldStockEntity.PurchaseOrder{}, errors.Wrap(entity.ErrInvalid, "unknown stock_code "+line.StockCode)
+		}
+		total += line.UnitCost.Mul(line.Qty)
+		po.Lines = append(po.Lines, goldStockEntity.PurchaseOrderLine{
+			POID:       po.POID,
+			StockCode:  line.StockCode,
+			QtyOrdered: line.Qty,
+			UnitCost:   line.UnitCost,
+		})
+	}
+	po.POTotal = total
+
+	if err := s.goldgymstock.InsertPurchaseOrder(ctx, po); err != nil {
+		return goldStockEntity.PurchaseOrder{}, errors.Wrap(err, "[Service][CreatePurchaseOrder]")
+	}
+	return po, nil
+}
+
+// ListPurchaseOrders returns the POs of one supplier and/or status with their lines
+func (s Service) ListPurchaseOrders(ctx context.Context, filter goldStockEntity.POFilter) ([]goldStockEntity.PurchaseOrder, error) {
+	pos, err := s.goldgymstock.GetPurchaseOrders(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "[Service][ListPurchaseOrders]")
+	}
+	if len(pos) == 0 {
+		return []goldStockEntity.PurchaseOrder{}, nil
+	}
+
+	ids := make([]string, 0, len(pos))
+	for _, po := range pos {
+		ids = append(ids, po.POID)
+	}
+	lines, err := s.goldgymstock.GetPurchaseOrderLines(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "[Service][ListPurchaseOrders]")
+	}
+	byPO := make(map[string][]goldStockEntity.PurchaseOrderLine, len(pos))
+	for _, line := range lines {
+		byPO[line.POID] = append(byPO[line.POID], line)
+	}
+	for i := range pos {
+		pos[i].Lines = byPO[pos[i].POID]
+	}
+	return pos, nil
+}
+
+func (s Service) GetPurchaseOrder(ctx context.Context, poID string) (goldStockEntity.PurchaseOrder, error) {
+	po, err := s.goldgymstock.GetPurchaseOrderByID(ctx, poID)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return po, errors.Wrap(entity.ErrNotFound, "[Service][GetPurchaseOrder]")
+		}
+		return po, errors.Wrap(err, "[Service][GetPurchaseOrder]")
+	}
+
+	po.Lines, err = s.goldgymstock.GetPurchaseOrderLines(ctx, []string{poID})
+	if err != nil {
+		return po, errors.Wrap(err, "[Service][GetPurchaseOrder]")
+	}
+	return po, nil
+}
+
+// ReceivePurchaseOrder books a full or partial delivery of a PO into stock.
+// Each line becomes a receipt and a stock batch at the billed cost (the PO
+// cost when not given) with its lot and expiry date, plus a restock movement
+// referencing the PO; the stock cost price moves to the weighted average. It
+// returns the PO as it stands afterwards.
+func (s Service) ReceivePurchaseOrder(ctx context.Context, req goldStockEntity.ReceivePurchaseOrder) (goldStockEntity.PurchaseOrder, error) {
+	req.ReceivedBy = strings.TrimSpace(req.ReceivedBy)
+	if req.ReceivedBy == "" {
+		return goldStockEntity.PurchaseOrder{}, errors.Wrap(entity.ErrInvalid, "received_by is required")
+	}
+	lines, err := mergePOLines(req.Lines)
+	if err != nil {
+		return goldStockEntity.PurchaseOrder{}, errors.Wrap(err, "[Service][ReceivePurchaseOrder]")
+	}
+
+	po, err := s.GetPurchaseOrder(ctx, req.POID)
+	if err != nil {
+		return po, err
+	}
+	if po.POStatus != goldStockEntity.POStatusOpen && po.POStatus != goldStockEntity.POStatusPartial {
+		return po, errors.Wrap(goldStockEntity.ErrPOClosed, po.POStatus)
+	}
+	ordered := make(map[string]goldStockEntity.PurchaseOrderLine, len(po.Lines))
+	for _, line := range po.Lines {
+		ordered[line.StockCode] = line
+	}
+
+	t := s.now()
+	today := t.Format(dateLayout)
+	receiving := make(map[string]int, len(lines))
+	receipts := make([]goldStockEntity.POReceipt, 0, len(lines))
+	batches := make([]goldStockEntity.StockBatch, 0, len(lines))
+	movements := make([]goldStockEntity.StockMovement, 0, len(lines))
+	for _, line := range lines {
+		poLine, ok := ordered[line.StockCode]
+		if !ok {
+			return po, errors.Wrap(entity.ErrInvalid, line.StockCode+" is not on this purchase order")
+		}
+		// fast path only, the data layer re-checks while incrementing
+		receiving[line.StockCode] += line.Qty
+		if receiving[line.StockCode] > poLine.QtyOrdered-poLine.QtyReceived {
+			return po, errors.Wrap(goldStockEntity.ErrOverReceive, line.StockCode)
+		}
+		if line.ExpiryDate != "" {
+			if _, err := time.Parse(dateLayout, line.ExpiryDate); err != nil {
+				return po, errors.Wrap(entity.ErrInvalid, "expiry_date must be YYYY-MM-DD")
+			}
+			// YYYY-MM-DD sorts as text
+			if line.ExpiryDate < today {
+				return po, errors.Wrap(entity.ErrInvalid, line.StockCode+" lot "+line.Lot+" is already expired")
+			}
+		}
+		cost := line.UnitCost
+		if cost == 0 {
+			cost = poLine.UnitCost
+		}
+		receipts = append(receipts, goldStockEntity.POReceipt{
+			POID:            po.POID,
+			StockCode:       line.StockCode,
+			ReceiptQty:      line.Qty,
+			ReceiptUnitCost: cost,
+			ReceiptLot:      line.Lot,
+			ReceiptExpiry:   line.ExpiryDate,
+			ReceiptNote:     strings.TrimSpace(req.Note),
+			ReceiptBy:       req.ReceivedBy,
+			ReceiptAt:       t,
+		})
+		batches = append(batches, goldStockEntity.StockBatch{
+			StockCode:        line.StockCode,
+			BatchLot:         line.Lot,
+			BatchExpiry:      line.ExpiryDate,
+			BatchQtyReceived: line.Qty,
+			BatchQty:         line.Qty,
+			BatchUnitCost:    cost,
+			BatchRef:         po.POID,
+			BatchReceivedAt:  t,
+		})
+		movements = append(movements, goldStockEntity.StockMovement{
+			StockCode:      line.StockCode,
+			MovementType:   goldStockEntity.MovementRestock,
+			MovementQty:    line.Qty,
+			MovementReason: "purchase order receipt",
+			MovementRef:    po.POID,
+			MovementBy:     req.ReceivedBy,
+			MovementAt:     t,
+		})
+	}
+
+	if _, err := s.goldgymstock.ReceivePurchaseOrder(ctx, po.POID, receipts, batches, movements); err != nil {
+		return po, errors.Wrap(err, "[Service][ReceivePurchaseOrder]")
+	}
+	return s.GetPurchaseOrder(ctx, po.POID)
+}
+
+// CancelPurchaseOrder closes a PO that is not fully received yet
+func (s Service) CancelPurchaseOrder(ctx context.Context, poID string) (string, error) {
+	var result string
+
+	if err := s.goldgymstock.CancelPurchaseOrder(ctx, poID); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][CancelPurchaseOrder]")
+	}
+	result = "Berhasil"
+	return result, nil
+}
+
+// GetOutstandingPurchaseOrders reports what is still to be delivered on open
+// POs, of one supplier and/or branch when supplierID and branchID are not
+// zero. Lines past their expected date are flagged overdue.
+func (s Service) GetOutstandingPurchaseOrders(ctx context.Context, supplierID, branchID int64) (goldStockEntity.OutstandingPOReport, error) {
+	report := goldStockEntity.OutstandingPOReport{AsOf: s.now().Format(dateLayout)}
+
+	lines, err := s.goldgymstock.GetOutstandingPOLines(ctx, supplierID, branchID)
+	if err != nil {
+		return report, errors.Wrap(err, "[Service][GetOutstandingPurchaseOrders]")
+	}
+
+	report.Lines = make([]goldStockEntity.OutstandingPOLine, 0, len(lines))
+	var total entity.Money
+	for _, line := range lines {
+		// YYYY-MM-DD sorts as text
+		line.Overdue = line.POExpectedDate != "" && line.POExpectedDate < report.AsOf
+		if line.Overdue {
+			report.OverdueLines++
+		}
+		total += line.OutstandingValue
+		report.Lines = append(report.Lines, line)
+	}
+	report.TotalValue = total
+	return report, nil
+}
+
+func mergePOLines(in []goldStockEntity.POLineRequest) ([]goldStockEntity.POLineRequest, error) {
+	if len(in) == 0 {
+		return nil, errors.Wrap(entity.ErrInvalid, "at least one line is required")
+	}
+
+	lines := make([]goldStockEntity.POLineRequest, 0, len(in))
+	index := make(map[string]int, len(in))
+	for _, line := range in {
+		line.StockCode = strings.TrimSpace(line.StockCode)
+		line.Lot = strings.TrimSpace(line.Lot)
+		if line.StockCode == "" || line.Qty <= 0 || line.UnitCost < 0 {
+			return nil, errors.Wrap(entity.ErrInvalid, "every line needs a stock_code, a positive qty and a unit_cost that is not negative")
+		}
+		key := line.StockCode + "\x00" + line.Lot + "\x00" + line.ExpiryDate
+		if i, ok := index[key]; ok {
+			if lines[i].UnitCost != line.UnitCost {
+				return nil, errors.Wrap(entity.ErrInvalid, line.StockCode+" appears twice with different unit costs")
+			}
+			lines[i].Qty += line.Qty
+			continue
+		}
+		index[key] = len(lines)
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// newDocumentID is prefix + timestamp + random suffix, e.g. PO20261019093015a1b2c3
+func newDocumentID(prefix string, t time.Time) (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + t.Format("20060102150405") + hex.EncodeToString(b), nil
+}
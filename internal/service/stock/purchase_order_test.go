@@ -0,0 +1,423 @@
+package goldgym
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errRecordNotFound = stderrors.New("record not found")
+
+func (f *fakeStockData) GetStocksByCode(ctx context.Context, codes []string) ([]goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stocks []goldStockEntity.GetOneStock
+	for _, code := range codes {
+		if stock, ok := f.stocks[code]; ok {
+			stocks = append(stocks, stock)
+		}
+	}
+	return stocks, nil
+}
+
+func (f *fakeStockData) InsertSupplier(ctx context.Context, supplier goldStockEntity.Supplier) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	supplier.SupplierID = int64(len(f.suppliers) + 1)
+	f.suppliers = append(f.suppliers, supplier)
+	return supplier.SupplierID, nil
+}
+
+func (f *fakeStockData) GetSupplierByCode(ctx context.Context, code string) (goldStockEntity.Supplier, error) {
+	for _, supplier := range f.suppliers {
+		if supplier.SupplierCode == code {
+			return supplier, nil
+		}
+	}
+	return goldStockEntity.Supplier{}, errRecordNotFound
+}
+
+func (f *fakeStockData) GetSupplierByID(ctx context.Context, id int64) (goldStockEntity.Supplier, error) {
+	for _, supplier := range f.suppliers {
+		if supplier.SupplierID == id {
+			return supplier, nil
+		}
+	}
+	return goldStockEntity.Supplier{}, errRecordNotFound
+}
+
+func (f *fakeStockData) GetSuppliers(ctx context.Context) ([]goldStockEntity.Supplier, error) {
+	return f.suppliers, nil
+}
+
+func (f *fakeStockData) InsertPurchaseOrder(ctx context.Context, po goldStockEntity.PurchaseOrder) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pos[po.POID] = po
+	return nil
+}
+
+func (f *fakeStockData) GetPurchaseOrders(ctx context.Context, filter goldStockEntity.POFilter) ([]goldStockEntity.PurchaseOrder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var pos []goldStockEntity.PurchaseOrder
+	for _, po := range f.pos {
+		if (filter.SupplierID == 0 || po.POSupplierID == filter.SupplierID) && (filter.Status == "" || po.POStatus == filter.Status) {
+			po.Lines = nil
+			pos = append(pos, po)
+		}
+	}
+	sort.Slice(pos, func(i, j int) bool { return pos[i].POID < pos[j].POID })
+	return pos, nil
+}
+
+func (f *fakeStockData) GetPurchaseOrderByID(ctx context.Context, poID string) (goldStockEntity.PurchaseOrder, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	po, ok := f.pos[poID]
+	if !ok {
+		return goldStockEntity.PurchaseOrder{}, errRecordNotFound
+	}
+	po.Lines = nil
+	return po, nil
+}
+
+func (f *fakeStockData) GetPurchaseOrderLines(ctx context.Context, poIDs []string) ([]goldStockEntity.PurchaseOrderLine, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var lines []goldStockEntity.PurchaseOrderLine
+	for _, id := range poIDs {
+		lines = append(lines, f.pos[id].Lines...)
+	}
+	return lines, nil
+}
+
+// ReceivePurchaseOrder meniru transaksi: semua line dicek dulu, baru diterapkan
+func (f *fakeStockData) ReceivePurchaseOrder(ctx context.Context, poID string, receipts []goldStockEntity.POReceipt, batches []goldStockEntity.StockBatch, movements []goldStockEntity.StockMovement) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	po, ok := f.pos[poID]
+	if !ok {
+		return "", errors.Wrap(entity.ErrNotFound, poID)
+	}
+	if po.POStatus != goldStockEntity.POStatusOpen && po.POStatus != goldStockEntity.POStatusPartial {
+		return "", errors.Wrap(goldStockEntity.ErrPOClosed, poID)
+	}
+
+	lines := append([]goldStockEntity.PurchaseOrderLine(nil), po.Lines...)
+	for _, receipt := range receipts {
+		found := false
+		for i := range lines {
+			if lines[i].StockCode == receipt.StockCode && lines[i].QtyReceived+receipt.ReceiptQty <= lines[i].QtyOrdered {
+				lines[i].QtyReceived += receipt.ReceiptQty
+				found = true
+			}
+		}
+		if !found {
+			return "", errors.Wrap(goldStockEntity.ErrOverReceive, receipt.StockCode)
+		}
+	}
+	for _, receipt := range receipts {
+		stock := f.stocks[receipt.StockCode]
+		onHand := stock.StockQTY
+		if onHand < 0 {
+			onHand = 0
+		}
+		stock.StockCostPrice = (stock.StockCostPrice.Mul(onHand) + receipt.ReceiptUnitCost.Mul(receipt.ReceiptQty)).Div(int64(onHand + receipt.ReceiptQty))
+		stock.StockQTY += receipt.ReceiptQty
+		f.stocks[receipt.StockCode] = stock
+	}
+	f.receipts = append(f.receipts, receipts...)
+	for _, batch := range batches {
+		batch.BatchID = int64(len(f.batches) + 1)
+		f.batches = append(f.batches, batch)
+	}
+	f.movements = append(f.movements, movements...)
+
+	po.Lines = lines
+	po.POStatus = goldStockEntity.POStatusReceived
+	for _, line := range lines {
+		if line.QtyReceived < line.QtyOrdered {
+			po.POStatus = goldStockEntity.POStatusPartial
+		}
+	}
+	f.pos[poID] = po
+	return po.POStatus, nil
+}
+
+func (f *fakeStockData) CancelPurchaseOrder(ctx context.Context, poID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	po, ok := f.pos[poID]
+	if !ok {
+		return errors.Wrap(entity.ErrNotFound, poID)
+	}
+	if po.POStatus != goldStockEntity.POStatusOpen && po.POStatus != goldStockEntity.POStatusPartial {
+		return errors.Wrap(goldStockEntity.ErrPOClosed, poID)
+	}
+	po.POStatus = goldStockEntity.POStatusCancelled
+	f.pos[poID] = po
+	return nil
+}
+
+func (f *fakeStockData) GetOutstandingPOLines(ctx context.Context, supplierID, branchID int64) ([]goldStockEntity.OutstandingPOLine, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []goldStockEntity.OutstandingPOLine
+	for _, po := range f.pos {
+		if po.POStatus != goldStockEntity.POStatusOpen && po.POStatus != goldStockEntity.POStatusPartial {
+			continue
+		}
+		if supplierID != 0 && po.POSupplierID != supplierID {
+			continue
+		}
+		if branchID != 0 && po.POBranchID != branchID {
+			continue
+		}
+		for _, line := range po.Lines {
+			if line.QtyReceived >= line.QtyOrdered {
+				continue
+			}
+			out = append(out, goldStockEntity.OutstandingPOLine{
+				POID:             po.POID,
+				POExpectedDate:   po.POExpectedDate,
+				POStatus:         po.POStatus,
+				StockCode:        line.StockCode,
+				QtyOrdered:       line.QtyOrdered,
+				QtyReceived:      line.QtyReceived,
+				QtyOutstanding:   line.QtyOrdered - line.QtyReceived,
+				UnitCost:         line.UnitCost,
+				OutstandingValue: line.UnitCost.Mul(line.QtyOrdered - line.QtyReceived),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].POID+out[i].StockCode < out[j].POID+out[j].StockCode })
+	return out, nil
+}
+
+// newPurchasingFixture: WHEY-1 ada 10 sachet dengan cost 20000, ISO-1 belum ada stok
+func newPurchasingFixture(t *testing.T) (*fakeStockData, Service, goldStockEntity.Supplier) {
+	data := newFakeStockData()
+	data.stocks["WHEY-1"] = goldStockEntity.GetOneStock{StockCode: "WHEY-1", StockQTY: 10, StockCostPrice: entity.NewMoney(20000)}
+	data.stocks["ISO-1"] = goldStockEntity.GetOneStock{StockCode: "ISO-1"}
+	svc := New(data, nil, nil, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local) }
+
+	supplier, err := svc.CreateSupplier(context.Background(), goldStockEntity.Supplier{SupplierCode: "SUP-1", SupplierName: "PT Nutrisi Sehat"})
+	require.NoError(t, err)
+	return data, svc, supplier
+}
+
+func TestCreateSupplier(t *testing.T) {
+	_, svc, supplier := newPurchasingFixture(t)
+	ctx := context.Background()
+
+	assert.Equal(t, int64(1), supplier.SupplierID)
+	assert.True(t, supplier.SupplierActive)
+
+	_, err := svc.CreateSupplier(ctx, goldStockEntity.Supplier{SupplierCode: " SUP-1 ", SupplierName: "Lain"})
+	assert.Equal(t, goldStockEntity.ErrDuplicateSupplier, errors.Cause(err))
+	_, err = svc.CreateSupplier(ctx, goldStockEntity.Supplier{SupplierCode: "SUP-2"})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+}
+
+func TestCreatePurchaseOrder(t *testing.T) {
+	data, svc, supplier := newPurchasingFixture(t)
+	ctx := context.Background()
+
+	po, err := svc.CreatePurchaseOrder(ctx, goldStockEntity.CreatePurchaseOrder{
+		SupplierID:   supplier.SupplierID,
+		ExpectedDate: "2026-10-25",
+		CreatedBy:    "rina",
+		Lines: []goldStockEntity.POLineRequest{
+			{StockCode: "WHEY-1", Qty: 10, UnitCost: entity.NewMoney(22000)},
+			{StockCode: "ISO-1", Qty: 24, UnitCost: entity.MoneyFromFloat(4500.5)},
+			{StockCode: "WHEY-1", Qty: 10, UnitCost: entity.NewMoney(22000)},
+		},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^PO20261019093000[0-9a-f]{6}$`, po.POID)
+	assert.Equal(t, goldStockEntity.POStatusOpen, po.POStatus)
+	assert.Equal(t, "2026-10-19", po.PODate)
+	assert.Equal(t, entity.NewMoney(548012), po.POTotal)
+	require.Len(t, po.Lines, 2)
+	assert.Equal(t, 20, po.Lines[0].QtyOrdered)
+	assert.Contains(t, data.pos, po.POID)
+
+	tests := []struct {
+		name string
+		req  goldStockEntity.CreatePurchaseOrder
+	}{
+		{"no created_by", goldStockEntity.CreatePurchaseOrder{SupplierID: 1, Lines: []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 1}}}},
+		{"no lines", goldStockEntity.CreatePurchaseOrder{SupplierID: 1, CreatedBy: "rina"}},
+		{"bad date", goldStockEntity.CreatePurchaseOrder{SupplierID: 1, CreatedBy: "rina", ExpectedDate: "25-10-2026", Lines: []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 1}}}},
+		{"unknown supplier", goldStockEntity.CreatePurchaseOrder{SupplierID: 9, CreatedBy: "rina", Lines: []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 1}}}},
+		{"unknown stock", goldStockEntity.CreatePurchaseOrder{SupplierID: 1, CreatedBy: "rina", Lines: []goldStockEntity.POLineRequest{{StockCode: "NOPE", Qty: 1}}}},
+		{"beda cost", goldStockEntity.CreatePurchaseOrder{SupplierID: 1, CreatedBy: "rina", Lines: []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 1, UnitCost: entity.NewMoney(1)}, {StockCode: "WHEY-1", Qty: 1, UnitCost: entity.NewMoney(2)}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreatePurchaseOrder(ctx, tt.req)
+			assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+		})
+	}
+	assert.Len(t, data.pos, 1)
+}
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	data, svc, supplier := newPurchasingFixture(t)
+	ctx := context.Background()
+
+	po, err := svc.CreatePurchaseOrder(ctx, goldStockEntity.CreatePurchaseOrder{
+		SupplierID: supplier.SupplierID,
+		CreatedBy:  "rina",
+		Lines: []goldStockEntity.POLineRequest{
+			{StockCode: "WHEY-1", Qty: 20, UnitCost: entity.NewMoney(23000)},
+			{StockCode: "ISO-1", Qty: 24, UnitCost: entity.NewMoney(4500)},
+		},
+	})
+	require.NoError(t, err)
+
+	// sebagian diterima, WHEY-1 ditagih lebih murah dari PO
+	po, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{
+		POID:       po.POID,
+		ReceivedBy: "budi",
+		Lines:      []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 10, UnitCost: entity.NewMoney(22000)}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, goldStockEntity.POStatusPartial, po.POStatus)
+	assert.Equal(t, 20, data.stocks["WHEY-1"].StockQTY)
+	// (10 x 20000 + 10 x 22000) / 20
+	assert.Equal(t, entity.NewMoney(21000), data.stocks["WHEY-1"].StockCostPrice)
+	require.Len(t, data.movements, 1)
+	assert.Equal(t, goldStockEntity.MovementRestock, data.movements[0].MovementType)
+	assert.Equal(t, po.POID, data.movements[0].MovementRef)
+	assert.Equal(t, entity.NewMoney(22000), data.receipts[0].ReceiptUnitCost)
+
+	// lebih dari sisa ditolak dan tidak mengubah apa pun
+	_, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{
+		POID:       po.POID,
+		ReceivedBy: "budi",
+		Lines:      []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 24}, {StockCode: "WHEY-1", Qty: 11}},
+	})
+	assert.Equal(t, goldStockEntity.ErrOverReceive, errors.Cause(err))
+	assert.Equal(t, 0, data.stocks["ISO-1"].StockQTY)
+
+	_, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{
+		POID:       po.POID,
+		ReceivedBy: "budi",
+		Lines:      []goldStockEntity.POLineRequest{{StockCode: "BAR-1", Qty: 1}},
+	})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+
+	// sisanya diterima dengan cost PO
+	po, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{
+		POID:       po.POID,
+		ReceivedBy: "budi",
+		Lines:      []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 24}, {StockCode: "WHEY-1", Qty: 10}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, goldStockEntity.POStatusReceived, po.POStatus)
+	assert.Equal(t, 24, data.stocks["ISO-1"].StockQTY)
+	assert.Equal(t, entity.NewMoney(4500), data.stocks["ISO-1"].StockCostPrice)
+	// (20 x 21000 + 10 x 23000) / 30
+	assert.Equal(t, entity.MoneyFromFloat(21666.67), data.stocks["WHEY-1"].StockCostPrice)
+
+	_, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{
+		POID:       po.POID,
+		ReceivedBy: "budi",
+		Lines:      []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 1}},
+	})
+	assert.Equal(t, goldStockEntity.ErrPOClosed, errors.Cause(err))
+
+	_, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{POID: "PO-missing", ReceivedBy: "budi", Lines: []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 1}}})
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+}
+
+func TestReceivePurchaseOrderParallel(t *testing.T) {
+	data, svc, supplier := newPurchasingFixture(t)
+	ctx := context.Background()
+
+	po, err := svc.CreatePurchaseOrder(ctx, goldStockEntity.CreatePurchaseOrder{
+		SupplierID: supplier.SupplierID,
+		CreatedBy:  "rina",
+		Lines:      []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 10, UnitCost: entity.NewMoney(4500)}},
+	})
+	require.NoError(t, err)
+
+	// 20 penerimaan 1 botol bersamaan, hanya 10 yang boleh masuk
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{
+				POID:       po.POID,
+				ReceivedBy: "budi",
+				Lines:      []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 1}},
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, data.stocks["ISO-1"].StockQTY)
+	assert.Len(t, data.movements, 10)
+	assert.Equal(t, goldStockEntity.POStatusReceived, data.pos[po.POID].POStatus)
+}
+
+func TestOutstandingAndCancelPurchaseOrder(t *testing.T) {
+	_, svc, supplier := newPurchasingFixture(t)
+	ctx := context.Background()
+
+	late, err := svc.CreatePurchaseOrder(ctx, goldStockEntity.CreatePurchaseOrder{
+		SupplierID:   supplier.SupplierID,
+		ExpectedDate: "2026-10-15",
+		CreatedBy:    "rina",
+		Lines:        []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 20, UnitCost: entity.NewMoney(22000)}},
+	})
+	require.NoError(t, err)
+	_, err = svc.ReceivePurchaseOrder(ctx, goldStockEntity.ReceivePurchaseOrder{POID: late.POID, ReceivedBy: "budi", Lines: []goldStockEntity.POLineRequest{{StockCode: "WHEY-1", Qty: 5}}})
+	require.NoError(t, err)
+	onTime, err := svc.CreatePurchaseOrder(ctx, goldStockEntity.CreatePurchaseOrder{
+		SupplierID:   supplier.SupplierID,
+		ExpectedDate: "2026-10-25",
+		CreatedBy:    "rina",
+		Lines:        []goldStockEntity.POLineRequest{{StockCode: "ISO-1", Qty: 10, UnitCost: entity.NewMoney(4500)}},
+	})
+	require.NoError(t, err)
+
+	report, err := svc.GetOutstandingPurchaseOrders(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-19", report.AsOf)
+	require.Len(t, report.Lines, 2)
+	assert.Equal(t, 1, report.OverdueLines)
+	assert.Equal(t, entity.NewMoney(15*22000+10*4500), report.TotalValue)
+
+	_, err = svc.CancelPurchaseOrder(ctx, onTime.POID)
+	require.NoError(t, err)
+	_, err = svc.CancelPurchaseOrder(ctx, onTime.POID)
+	assert.Equal(t, goldStockEntity.ErrPOClosed, errors.Cause(err))
+
+	report, err = svc.GetOutstandingPurchaseOrders(ctx, supplier.SupplierID, 0)
+	require.NoError(t, err)
+	require.Len(t, report.Lines, 1)
+	assert.Equal(t, 15, report.Lines[0].QtyOutstanding)
+	assert.True(t, report.Lines[0].Overdue)
+
+	pos, err := svc.ListPurchaseOrders(ctx, goldStockEntity.POFilter{Status: goldStockEntity.POStatusPartial})
+	require.NoError(t, err)
+	require.Len(t, pos, 1)
+	assert.Equal(t, late.POID, pos[0].POID)
+	assert.Len(t, pos[0].Lines, 1)
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"gold-gym-be/internal/entity"
 	firebaseEntity "gold-gym-be/internal/entity/firebase"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
 	goldStockEntity "gold-gym-be/internal/entity/stock"
 	jaegerLog "gold-gym-be/pkg/log"
+	"io"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	// "go.opentelemetry.io/otel/trace"
@@ -16,12 +19,56 @@ import (
 // Masukkan function dari package data ke dalam interface ini
 type Data interface {
 	GetOneStockProduct(ctx context.Context, stockcode string, stockname string, stockid string) (goldStockEntity.GetOneStock, error)
-	GetLastStock(ctx context.Context) (goldStockEntity.GetOneStock, error)
-	InsertStockSales(ctx context.Context, stock goldStockEntity.InsertStock) (string, error)
-	AddStockByDate(ctx context.Context, stock goldStockEntity.InsertStock) (string, error)
+	UpsertStock(ctx context.Context, stock goldStockEntity.InsertStock, movement goldStockEntity.StockMovement) (bool, error)
 	GetStockByID(ctx context.Context, stockcode string) ([]goldStockEntity.GetOneStock, error)
-	UpdateStockQty(ctx context.Context, stockQty int, stockcode string) (string, error)
+	RecordMovement(ctx context.Context, movement goldStockEntity.StockMovement) error
+	GetStockMovements(ctx context.Context, stockcode string, branchID int64, from, to time.Time) ([]goldStockEntity.StockMovement, error)
+	GetStockBalanceBefore(ctx context.Context, stockcode string, branchID int64, t time.Time) (int, error)
+	RebuildStockQty(ctx context.Context, stockcode string) (int64, error)
 	GetAllStockHeader(ctx context.Context) ([]goldStockEntity.GetOneStock, error)
+	UpdateReorderPoint(ctx context.Context, reorder goldStockEntity.ReorderPoint) error
+	GetLowStock(ctx context.Context, unalertedOnly bool) ([]goldStockEntity.GetOneStock, error)
+	ClaimLowStockAlert(ctx context.Context, stockcode string, event outboxEntity.Event) (bool, error)
+	GetLowStockToEmail(ctx context.Context) ([]goldStockEntity.GetOneStock, error)
+	ClaimLowStockEmail(ctx context.Context, stockcode string) (bool, error)
+	ReleaseLowStockEmail(ctx context.Context, stockcodes []string) error
+	ResetLowStockAlerts(ctx context.Context) error
+	GetStocksByCode(ctx context.Context, codes []string) ([]goldStockEntity.GetOneStock, error)
+	InsertSupplier(ctx context.Context, supplier goldStockEntity.Supplier) (int64, error)
+	GetSupplierByCode(ctx context.Context, code string) (goldStockEntity.Supplier, error)
+	GetSupplierByID(ctx context.Context, id int64) (goldStockEntity.Supplier, error)
+	GetSuppliers(ctx context.Context) ([]goldStockEntity.Supplier, error)
+	InsertPurchaseOrder(ctx context.Context, po goldStockEntity.PurchaseOrder) error
+	GetPurchaseOrders(ctx context.Context, filter goldStockEntity.POFilter) ([]goldStockEntity.PurchaseOrder, error)
+	GetPurchaseOrderByID(ctx context.Context, poID string) (goldStockEntity.PurchaseOrder, error)
+	GetPurchaseOrderLines(ctx context.Context, poIDs []string) ([]goldStockEntity.PurchaseOrderLine, error)
+	ReceivePurchaseOrder(ctx context.Context, poID string, receipts []goldStockEntity.POReceipt, batches []goldStockEntity.StockBatch, movements []goldStockEntity.StockMovement) (string, error)
+	CancelPurchaseOrder(ctx context.Context, poID string) error
+	GetOutstandingPOLines(ctx context.Context, supplierID, branchID int64) ([]goldStockEntity.OutstandingPOLine, error)
+	GetStockBatches(ctx context.Context, stockcode string) ([]goldStockEntity.StockBatch, error)
+	GetNearExpiryBatches(ctx context.Context, until string, branchID int64) ([]goldStockEntity.NearExpiryLine, error)
+	GetExpiredBatches(ctx context.Context, today string) ([]goldStockEntity.StockBatch, error)
+	WriteOffBatch(ctx context.Context, batch goldStockEntity.StockBatch, movement goldStockEntity.StockMovement) (int, error)
+	GetCostLayers(ctx context.Context) ([]goldStockEntity.StockCostLayer, error)
+	BranchActive(ctx context.Context, branchID int64) (bool, error)
+	GetBranchStock(ctx context.Context, branchID int64) ([]goldStockEntity.GetOneStock, error)
+	TransferStock(ctx context.Context, transfer goldStockEntity.StockTransfer, out, in goldStockEntity.StockMovement) error
+	InsertCategory(ctx context.Context, category goldStockEntity.Category) (int64, error)
+	GetCategoryByName(ctx context.Context, name string) (goldStockEntity.Category, error)
+	GetCategoryByID(ctx context.Context, id int64) (goldStockEntity.Category, error)
+	GetCategories(ctx context.Context) ([]goldStockEntity.Category, error)
+	InsertProduct(ctx context.Context, product goldStockEntity.Product) error
+	GetProductByCode(ctx context.Context, code string) (goldStockEntity.Product, error)
+	GetProducts(ctx context.Context, filter goldStockEntity.CatalogueFilter) ([]goldStockEntity.Product, error)
+	GetVariants(ctx context.Context, productCodes []string) ([]goldStockEntity.GetOneStock, error)
+	SetVariant(ctx context.Context, variant goldStockEntity.Variant) error
+	UpdateProductImage(ctx context.Context, code, imageURL string) error
+	InsertBarcode(ctx context.Context, barcode goldStockEntity.Barcode) error
+	GetBarcode(ctx context.Context, barcode string) (goldStockEntity.Barcode, error)
+	GetBarcodes(ctx context.Context, stockcodes []string) ([]goldStockEntity.Barcode, error)
+	DeleteBarcode(ctx context.Context, barcode string) error
+	GetStockByBarcode(ctx context.Context, barcode string) (goldStockEntity.GetOneStock, error)
+	UploadImage(ctx context.Context, path, contentType string, body io.Reader) (string, error)
 	GetAllStockHeaderToRedis(ctx context.Context) ([]goldStockEntity.GetOneStock, error) // GetGoldUser(ctx context.Context) ([]goldEntity.GetGoldUser, error)
 	GetFromFirebase(ctx context.Context, userID string) (*firebaseEntity.User, error)
 	CreateUser(ctx context.Context, user firebaseEntity.User) (string, error)
@@ -58,23 +105,34 @@ type Data interface {
 
 }
 
+// Notifier sends the low-stock alerts
+type Notifier interface {
+	SendEmail(ctx context.Context, to []string, subject, body string) error
+}
+
 // Service ...
 // Tambahkan variable sesuai banyak data layer yang dibutuhkan
 type Service struct {
-	goldgymstock Data
-	tracer       opentracing.Tracer
+	goldgymstock    Data
+	notifier        Notifier
+	alertRecipients []string
+	now             func() time.Time
+	tracer          opentracing.Tracer
 	// tracer trace.Tracer
 	logger jaegerLog.Factory
 }
 
 // New ...
 // Tambahkan parameter sesuai banyak data layer yang dibutuhkan
-func New(goldgymStockData Data, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
+func New(goldgymStockData Data, notifier Notifier, alertRecipients []string, tracer opentracing.Tracer, logger jaegerLog.Factory) Service {
 	// Assign variable dari parameter ke object
 	return Service{
-		goldgymstock: goldgymStockData,
-		tracer:       tracer,
-		logger:       logger,
+		goldgymstock:    goldgymStockData,
+		notifier:        notifier,
+		alertRecipients: alertRecipients,
+		now:             time.Now,
+		tracer:          tracer,
+		logger:          logger,
 	}
 }
 
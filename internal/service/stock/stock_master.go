@@ -13,11 +13,15 @@ package goldgym
 
 import (
 	"context"
+	"fmt"
+	"gold-gym-be/internal/entity"
 	firebaseEntity "gold-gym-be/internal/entity/firebase"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
 	goldStockEntity "gold-gym-be/internal/entity/stock"
 	"gold-gym-be/pkg/errors"
 	"log"
-	"strconv"
+	"strings"
+	"time"
 	// "os"
 	// "strings"
 	// "gold-gym-be/internal/entity/auth/v2"
@@ -34,84 +38,162 @@ func (s Service) GetOneStockProduct(ctx context.Context, stockcode string, stock
 	if err != nil {
 		return users, errors.Wrap(err, "[Service][GetGoldUser]")
 	}
+	if users.StockCode != "" {
+		users.StockBatches, err = s.goldgymstock.GetStockBatches(ctx, users.StockCode)
+		if err != nil {
+			return users, errors.Wrap(err, "[Service][GetOneStockProduct]")
+		}
+		stocks := []goldStockEntity.GetOneStock{users}
+		if err := s.fillBarcodes(ctx, stocks); err != nil {
+			return users, errors.Wrap(err, "[Service][GetOneStockProduct]")
+		}
+		users = stocks[0]
+	}
 	// if len(users) = 0 {}
 	log.Printf("testService %+v", users)
 	return users, nil
 }
 
+// InsertStockSales registers a new stock code or restocks an existing one at
+// a branch, the main branch when none is given. The quantity is added by the
+// database, so concurrent restocks never lose an update, and new codes get
+// their stock_id from AUTO_INCREMENT. Every restock is written to the stock
+// ledger.
 func (s Service) InsertStockSales(ctx context.Context, stock goldStockEntity.InsertStockData) (string, error) {
-	var (
-		result string
-		users  goldStockEntity.GetOneStock
-		err    error
-	)
-	log.Println("service GetGoldUser object")
+	var result string
 
-	log.Println("stock.StockData.StockCode", stock.StockData.StockCode)
+	stock.StockData.StockCode = strings.TrimSpace(stock.StockData.StockCode)
+	if stock.StockData.StockCode == "" || stock.StockData.StockQTY <= 0 || strings.TrimSpace(stock.StockData.StockUpdateBy) == "" {
+		result = "Gagal Insert"
+		return result, errors.Wrap(entity.ErrInvalid, "stock_code, a positive stock_qty and stock_update_by are required")
+	}
+	branchID, err := s.branch(ctx, stock.StockData.StockBranchID)
+	if err != nil {
+		result = "Gagal Insert"
+		return result, errors.Wrap(err, "[Service][UpsertStock]")
+	}
 
-	users, err = s.goldgymstock.GetOneStockProduct(ctx, stock.StockData.StockCode, "", "")
-	log.Println("servicegolduser", users)
+	created, err := s.goldgymstock.UpsertStock(ctx, stock.StockData, goldStockEntity.StockMovement{
+		StockCode:        stock.StockData.StockCode,
+		MovementBranchID: branchID,
+		MovementType:     goldStockEntity.MovementRestock,
+		MovementQty:      stock.StockData.StockQTY,
+		MovementReason:   "restock",
+		MovementBy:       stock.StockData.StockUpdateBy,
+		MovementAt:       s.now(),
+	})
 	if err != nil {
-		return result, errors.Wrap(err, "[Service][GetGoldUser]")
+		result = "Gagal Insert"
+		return result, errors.Wrap(err, "[Service][UpsertStock]")
 	}
+	if created {
+		result = "Berhasil Insert"
+		return result, nil
+	}
+	result = "Stock Updated"
+	return result, nil
+}
 
-	usersNull := goldStockEntity.GetOneStock{}
+// RecordMovement applies a manual ledger movement at a branch, the main
+// branch when none is given. Restocks and returns are always added,
+// write-offs always taken out; adjustments keep the sign given by the caller.
+// Sales are only recorded by the POS, and transfers only by TransferStock,
+// which books both sides of the move.
+func (s Service) RecordMovement(ctx context.Context, movement goldStockEntity.StockMovement) (string, error) {
+	var result string
+
+	movement.StockCode = strings.TrimSpace(movement.StockCode)
+	movement.MovementReason = strings.TrimSpace(movement.MovementReason)
+	movement.MovementBy = strings.TrimSpace(movement.MovementBy)
+	if movement.StockCode == "" || movement.MovementQty == 0 || movement.MovementReason == "" || movement.MovementBy == "" {
+		result = "Gagal"
+		return result, errors.Wrap(entity.ErrInvalid, "stock_code, a non-zero movement_qty, movement_reason and movement_by are required")
+	}
 
-	log.Printf("users %+v", users)
+	switch movement.MovementType {
+	case goldStockEntity.MovementRestock, goldStockEntity.MovementReturn:
+		movement.MovementQty = abs(movement.MovementQty)
+	case goldStockEntity.MovementWriteOff:
+		movement.MovementQty = -abs(movement.MovementQty)
+	case goldStockEntity.MovementAdjustment:
+	default:
+		result = "Gagal"
+		return result, errors.Wrap(entity.ErrInvalid, "movement_type must be restock, adjustment, writeoff or return")
+	}
+	branchID, err := s.branch(ctx, movement.MovementBranchID)
+	if err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][RecordMovement]")
+	}
+	movement.MovementID = 0
+	movement.MovementBranchID = branchID
+	movement.MovementAt = s.now()
 
-	if users == usersNull {
-		lastData, err := s.goldgymstock.GetLastStock(ctx)
-		if err != nil {
-			result = "Gagal Insert"
-			return result, errors.Wrap(err, "[Service][GetLastStock]")
-		}
-		if lastData == usersNull {
-			stock.StockData.StockID = "1"
-			result, err = s.goldgymstock.InsertStockSales(ctx, stock.StockData)
-			log.Println("servicegoldusers", result)
-			if err != nil {
-				result = "Gagal Insert"
-				return result, errors.Wrap(err, "[Service][InsertStockSales]")
-			}
-			result = "Berhasil Insert"
-		}
+	if err := s.goldgymstock.RecordMovement(ctx, movement); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][RecordMovement]")
+	}
+	result = "Berhasil"
+	return result, nil
+}
 
-		if lastData != usersNull {
-			id, _ := strconv.Atoi(lastData.StockID)
-			stockid := id + 1
-			stock.StockData.StockID = strconv.Itoa(stockid)
-			result, err = s.goldgymstock.InsertStockSales(ctx, stock.StockData)
-			log.Println("servicegoldusersssss", result)
-			if err != nil {
-				result = "Gagal Insert"
-				return result, errors.Wrap(err, "[Service][InsertStockSales]")
-			}
-			result = "Berhasil Insert"
-		}
-		// result, err = s.goldgymstock.InsertStockSales(ctx, stock.StockData)
-		// log.Println("servicegolduser", result)
-		// if err != nil {
-		// 	result = "Gagal Insert"
-		// 	return result, errors.Wrap(err, "[Service][InsertStockSales]")
-		// }
-		// result = "Berhasil Insert"
+// GetStockCard reports the movements of stockcode between from and to
+// (YYYY-MM-DD, both inclusive) with opening, running and closing balances, at
+// one branch when branchID is not zero
+func (s Service) GetStockCard(ctx context.Context, stockcode, from, to string, branchID int64) (goldStockEntity.StockCard, error) {
+	card := goldStockEntity.StockCard{StockCode: stockcode, BranchID: branchID, From: from, To: to}
+
+	fromDate, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return card, errors.Wrap(entity.ErrInvalid, "from must be YYYY-MM-DD")
+	}
+	toDate, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil || toDate.Before(fromDate) {
+		return card, errors.Wrap(entity.ErrInvalid, "to must be YYYY-MM-DD and not before from")
+	}
+	if strings.TrimSpace(stockcode) == "" {
+		return card, errors.Wrap(entity.ErrInvalid, "stockcode is required")
 	}
-	if users != usersNull {
-		_, err = s.goldgymstock.AddStockByDate(ctx, stock.StockData)
-		if err != nil {
-			result = "Gagal Insert - Update"
-			return result, errors.Wrap(err, "[Service][AddStockByDate]")
-		}
 
-		stockUpdated := users.StockQTY + stock.StockData.StockQTY
-		result, err = s.goldgymstock.UpdateStockQty(ctx, stockUpdated, stock.StockData.StockCode)
-		if err != nil {
-			result = "Gagal Update"
-			return result, errors.Wrap(err, "[Service][UpdateStockQty]")
+	card.OpeningQty, err = s.goldgymstock.GetStockBalanceBefore(ctx, stockcode, branchID, fromDate)
+	if err != nil {
+		return card, errors.Wrap(err, "[Service][GetStockCard]")
+	}
+	movements, err := s.goldgymstock.GetStockMovements(ctx, stockcode, branchID, fromDate, toDate.AddDate(0, 0, 1))
+	if err != nil {
+		return card, errors.Wrap(err, "[Service][GetStockCard]")
+	}
+
+	balance := card.OpeningQty
+	card.Lines = make([]goldStockEntity.StockCardLine, 0, len(movements))
+	for _, movement := range movements {
+		balance += movement.MovementQty
+		if movement.MovementQty > 0 {
+			card.InQty += movement.MovementQty
+		} else {
+			card.OutQty -= movement.MovementQty
 		}
-		result = "Stock Updated"
+		card.Lines = append(card.Lines, goldStockEntity.StockCardLine{StockMovement: movement, Balance: balance})
 	}
-	return result, nil
+	card.ClosingQty = balance
+	return card, nil
+}
+
+// RebuildStockQty recomputes stock_qty from the ledger for one stock code, or
+// for all of them when stockcode is empty, and returns how many had drifted
+func (s Service) RebuildStockQty(ctx context.Context, stockcode string) (int64, error) {
+	rows, err := s.goldgymstock.RebuildStockQty(ctx, strings.TrimSpace(stockcode))
+	if err != nil {
+		return 0, errors.Wrap(err, "[Service][RebuildStockQty]")
+	}
+	return rows, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
 }
 
 func (s Service) GetAllStockHeader(ctx context.Context) ([]goldStockEntity.GetOneStock, error) {
@@ -122,8 +204,9 @@ func (s Service) GetAllStockHeader(ctx context.Context) ([]goldStockEntity.GetOn
 	if err != nil {
 		return users, errors.Wrap(err, "[Service][GetAllStockHeader]")
 	}
-	// if len(users) = 0 {}
-	// log.Printf("testService %+v", users)
+	for i := range users {
+		users[i].StockSuggestedOrder = users[i].SuggestedOrder()
+	}
 	return users, nil
 }
 
@@ -135,11 +218,135 @@ func (s Service) GetAllStockHeaderToRedis(ctx context.Context) ([]goldStockEntit
 	if err != nil {
 		return users, errors.Wrap(err, "[Service][GetAllStockHeaderToRedis]")
 	}
-	// if len(users) = 0 {}
-	// log.Printf("testService %+v", users)
+	for i := range users {
+		users[i].StockSuggestedOrder = users[i].SuggestedOrder()
+	}
 	return users, nil
 }
 
+// SetReorderPoint changes the reorder point and quantity of one product and
+// re-arms its low-stock alert
+func (s Service) SetReorderPoint(ctx context.Context, reorder goldStockEntity.ReorderPoint) (string, error) {
+	var result string
+
+	reorder.StockCode = strings.TrimSpace(reorder.StockCode)
+	if reorder.StockCode == "" || reorder.ReorderPoint < 0 || reorder.ReorderQty < 0 {
+		result = "Gagal"
+		return result, errors.Wrap(entity.ErrInvalid, "stock_code is required and the reorder point and qty cannot be negative")
+	}
+
+	if err := s.goldgymstock.UpdateReorderPoint(ctx, reorder); err != nil {
+		result = "Gagal"
+		return result, errors.Wrap(err, "[Service][SetReorderPoint]")
+	}
+	result = "Berhasil"
+	return result, nil
+}
+
+// GetReorderList is the suggested purchase list: every product at or below
+// its reorder point with how much to order
+func (s Service) GetReorderList(ctx context.Context) ([]goldStockEntity.ReorderSuggestion, error) {
+	stocks, err := s.goldgymstock.GetLowStock(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "[Service][GetReorderList]")
+	}
+
+	list := make([]goldStockEntity.ReorderSuggestion, 0, len(stocks))
+	for _, stock := range stocks {
+		list = append(list, toSuggestion(stock))
+	}
+	return list, nil
+}
+
+// CheckLowStock publishes StockLow for every product that fell to its
+// reorder point since the last check and emails one digest of the products
+// not emailed yet, returning how many were emailed. Each product is claimed
+// in the database so running instances never alert twice: once together
+// with its StockLow event and once for the email. A failed email releases
+// only the email claim, so the next check sends it again without publishing
+// another StockLow. A product alerts again only after being restocked above
+// its point.
+func (s Service) CheckLowStock(ctx context.Context) (int, error) {
+	if err := s.goldgymstock.ResetLowStockAlerts(ctx); err != nil {
+		return 0, errors.Wrap(err, "[Service][CheckLowStock]")
+	}
+	stocks, err := s.goldgymstock.GetLowStock(ctx, true)
+	if err != nil {
+		return 0, errors.Wrap(err, "[Service][CheckLowStock]")
+	}
+	for _, stock := range stocks {
+		suggestion := toSuggestion(stock)
+		event, err := outboxEntity.NewEvent(outboxEntity.EventStockLow, outboxEntity.AggregateStock, stock.StockCode, outboxEntity.StockLow{
+			StockCode:    suggestion.StockCode,
+			StockName:    suggestion.StockName,
+			StockQty:     suggestion.StockQTY,
+			ReorderPoint: suggestion.ReorderPoint,
+			SuggestedQty: suggestion.SuggestedQty,
+		}, s.now())
+		if err != nil {
+			return 0, errors.Wrap(err, "[Service][CheckLowStock]")
+		}
+		if _, err := s.goldgymstock.ClaimLowStockAlert(ctx, stock.StockCode, event); err != nil {
+			return 0, errors.Wrap(err, "[Service][CheckLowStock]")
+		}
+	}
+
+	stocks, err = s.goldgymstock.GetLowStockToEmail(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "[Service][CheckLowStock]")
+	}
+	var (
+		claimed []string
+		lines   []string
+	)
+	for _, stock := range stocks {
+		ok, err := s.goldgymstock.ClaimLowStockEmail(ctx, stock.StockCode)
+		if err != nil {
+			s.releaseLowStock(ctx, claimed)
+			return 0, errors.Wrap(err, "[Service][CheckLowStock]")
+		}
+		if !ok {
+			continue
+		}
+		suggestion := toSuggestion(stock)
+		claimed = append(claimed, stock.StockCode)
+		lines = append(lines, fmt.Sprintf("- %s %s: %d %s left (reorder point %d), order %d",
+			suggestion.StockCode, suggestion.StockName, suggestion.StockQTY, suggestion.StockPack, suggestion.ReorderPoint, suggestion.SuggestedQty))
+	}
+	if len(claimed) == 0 {
+		return 0, nil
+	}
+
+	body := "These products are at or below their reorder point:\n\n" + strings.Join(lines, "\n") + "\n"
+	subject := fmt.Sprintf("[Gold Gym] Low stock: %d product(s)", len(claimed))
+	if err := s.notifier.SendEmail(ctx, s.alertRecipients, subject, body); err != nil {
+		// let the next check try again
+		s.releaseLowStock(ctx, claimed)
+		return 0, errors.Wrap(err, "[Service][CheckLowStock]")
+	}
+	return len(claimed), nil
+}
+
+func (s Service) releaseLowStock(ctx context.Context, stockcodes []string) {
+	if len(stockcodes) == 0 {
+		return
+	}
+	if err := s.goldgymstock.ReleaseLowStockEmail(ctx, stockcodes); err != nil {
+		log.Printf("[ERROR] [Service][CheckLowStock] release %v: %v", stockcodes, err)
+	}
+}
+
+func toSuggestion(stock goldStockEntity.GetOneStock) goldStockEntity.ReorderSuggestion {
+	return goldStockEntity.ReorderSuggestion{
+		StockCode:    stock.StockCode,
+		StockName:    stock.StockName,
+		StockPack:    stock.StockPack,
+		StockQTY:     stock.StockQTY,
+		ReorderPoint: stock.StockReorderPoint,
+		SuggestedQty: stock.SuggestedOrder(),
+	}
+}
+
 func (s Service) GetFromFirebase(ctx context.Context, userID string) (*firebaseEntity.User, error) {
 	// log.Println("service GetGoldUser object")
 
@@ -0,0 +1,525 @@
+package goldgym
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	firebaseEntity "gold-gym-be/internal/entity/firebase"
+	outboxEntity "gold-gym-be/internal/entity/outbox"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStockData meniru semantik SQL: upsert dan adjustment relatif yang atomik
+// per statement, guard stock_qty >= 0, dan stock_id dari AUTO_INCREMENT
+type fakeStockData struct {
+	mu        sync.Mutex
+	nextID    int
+	stocks    map[string]goldStockEntity.GetOneStock
+	movements []goldStockEntity.StockMovement
+	alerted   map[string]bool
+	emailed   map[string]bool
+	events    []outboxEntity.Event
+
+	suppliers []goldStockEntity.Supplier
+	pos       map[string]goldStockEntity.PurchaseOrder
+	receipts  []goldStockEntity.POReceipt
+	batches   []goldStockEntity.StockBatch
+	layers    []goldStockEntity.StockCostLayer
+
+	categories []goldStockEntity.Category
+	products   map[string]goldStockEntity.Product
+	barcodes   map[string]string
+	uploads    map[string]string
+
+	branches map[int64]bool
+}
+
+func newFakeStockData() *fakeStockData {
+	return &fakeStockData{
+		nextID:  1,
+		stocks:  map[string]goldStockEntity.GetOneStock{},
+		alerted: map[string]bool{},
+		emailed: map[string]bool{},
+		pos:     map[string]goldStockEntity.PurchaseOrder{},
+
+		products: map[string]goldStockEntity.Product{},
+		barcodes: map[string]string{},
+		uploads:  map[string]string{},
+	}
+}
+
+func (f *fakeStockData) GetOneStockProduct(ctx context.Context, stockcode string, stockname string, stockid string) (goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stocks[stockcode], nil
+}
+
+func (f *fakeStockData) UpsertStock(ctx context.Context, stock goldStockEntity.InsertStock, movement goldStockEntity.StockMovement) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.movements = append(f.movements, movement)
+	if current, ok := f.stocks[stock.StockCode]; ok {
+		current.StockQTY += stock.StockQTY
+		f.stocks[stock.StockCode] = current
+		return false, nil
+	}
+	f.stocks[stock.StockCode] = goldStockEntity.GetOneStock{
+		StockID:   strconv.Itoa(f.nextID),
+		StockCode: stock.StockCode,
+		StockQTY:  stock.StockQTY,
+	}
+	f.nextID++
+	return true, nil
+}
+
+func (f *fakeStockData) RecordMovement(ctx context.Context, movement goldStockEntity.StockMovement) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	current, ok := f.stocks[movement.StockCode]
+	if !ok {
+		return errors.Wrap(entity.ErrNotFound, movement.StockCode)
+	}
+	if current.StockQTY+movement.MovementQty < 0 {
+		return errors.Wrap(goldStockEntity.ErrNegativeStock, movement.StockCode)
+	}
+	current.StockQTY += movement.MovementQty
+	f.stocks[movement.StockCode] = current
+	f.movements = append(f.movements, movement)
+	return nil
+}
+
+func (f *fakeStockData) GetStockMovements(ctx context.Context, stockcode string, branchID int64, from, to time.Time) ([]goldStockEntity.StockMovement, error) {
+	var movements []goldStockEntity.StockMovement
+	for _, m := range f.movements {
+		if branchID != 0 && m.MovementBranchID != branchID {
+			continue
+		}
+		if m.StockCode == stockcode && !m.MovementAt.Before(from) && m.MovementAt.Before(to) {
+			movements = append(movements, m)
+		}
+	}
+	return movements, nil
+}
+
+func (f *fakeStockData) GetStockBalanceBefore(ctx context.Context, stockcode string, branchID int64, t time.Time) (int, error) {
+	var balance int
+	for _, m := range f.movements {
+		if branchID != 0 && m.MovementBranchID != branchID {
+			continue
+		}
+		if m.StockCode == stockcode && m.MovementAt.Before(t) {
+			balance += m.MovementQty
+		}
+	}
+	return balance, nil
+}
+
+func (f *fakeStockData) RebuildStockQty(ctx context.Context, stockcode string) (int64, error) {
+	var drifted int64
+	for code, stock := range f.stocks {
+		balance, _ := f.GetStockBalanceBefore(ctx, code, 0, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+		if (stockcode == "" || code == stockcode) && stock.StockQTY != balance {
+			stock.StockQTY = balance
+			f.stocks[code] = stock
+			drifted++
+		}
+	}
+	return drifted, nil
+}
+
+func (f *fakeStockData) GetStockByID(ctx context.Context, stockcode string) ([]goldStockEntity.GetOneStock, error) {
+	return nil, nil
+}
+
+func (f *fakeStockData) GetAllStockHeader(ctx context.Context) ([]goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sorted(func(goldStockEntity.GetOneStock) bool { return true }), nil
+}
+
+func (f *fakeStockData) UpdateReorderPoint(ctx context.Context, reorder goldStockEntity.ReorderPoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stock, ok := f.stocks[reorder.StockCode]
+	if !ok {
+		return errors.Wrap(entity.ErrNotFound, reorder.StockCode)
+	}
+	stock.StockReorderPoint = reorder.ReorderPoint
+	stock.StockReorderQty = reorder.ReorderQty
+	f.stocks[reorder.StockCode] = stock
+	delete(f.alerted, reorder.StockCode)
+	delete(f.emailed, reorder.StockCode)
+	return nil
+}
+
+func (f *fakeStockData) GetLowStock(ctx context.Context, unalertedOnly bool) ([]goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sorted(func(s goldStockEntity.GetOneStock) bool {
+		return s.StockReorderPoint > 0 && s.StockQTY <= s.StockReorderPoint && (!unalertedOnly || !f.alerted[s.StockCode])
+	}), nil
+}
+
+func (f *fakeStockData) ClaimLowStockAlert(ctx context.Context, stockcode string, event outboxEntity.Event) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.alerted[stockcode] {
+		return false, nil
+	}
+	f.alerted[stockcode] = true
+	f.events = append(f.events, event)
+	return true, nil
+}
+
+func (f *fakeStockData) GetLowStockToEmail(ctx context.Context) ([]goldStockEntity.GetOneStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sorted(func(s goldStockEntity.GetOneStock) bool {
+		return s.StockReorderPoint > 0 && s.StockQTY <= s.StockReorderPoint && f.alerted[s.StockCode] && !f.emailed[s.StockCode]
+	}), nil
+}
+
+func (f *fakeStockData) ClaimLowStockEmail(ctx context.Context, stockcode string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.alerted[stockcode] || f.emailed[stockcode] {
+		return false, nil
+	}
+	f.emailed[stockcode] = true
+	return true, nil
+}
+
+func (f *fakeStockData) ReleaseLowStockEmail(ctx context.Context, stockcodes []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, code := range stockcodes {
+		delete(f.emailed, code)
+	}
+	return nil
+}
+
+func (f *fakeStockData) ResetLowStockAlerts(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for code := range f.alerted {
+		if stock := f.stocks[code]; stock.StockQTY > stock.StockReorderPoint {
+			delete(f.alerted, code)
+			delete(f.emailed, code)
+		}
+	}
+	return nil
+}
+
+func (f *fakeStockData) sorted(keep func(goldStockEntity.GetOneStock) bool) []goldStockEntity.GetOneStock {
+	var stocks []goldStockEntity.GetOneStock
+	for _, stock := range f.stocks {
+		if keep(stock) {
+			stocks = append(stocks, stock)
+		}
+	}
+	sort.Slice(stocks, func(i, j int) bool { return stocks[i].StockCode < stocks[j].StockCode })
+	return stocks
+}
+
+func (f *fakeStockData) GetAllStockHeaderToRedis(ctx context.Context) ([]goldStockEntity.GetOneStock, error) {
+	return nil, nil
+}
+
+func (f *fakeStockData) GetFromFirebase(ctx context.Context, userID string) (*firebaseEntity.User, error) {
+	return nil, nil
+}
+
+func (f *fakeStockData) CreateUser(ctx context.Context, user firebaseEntity.User) (string, error) {
+	return "", nil
+}
+
+func restock(code string, qty int) goldStockEntity.InsertStockData {
+	return goldStockEntity.InsertStockData{StockData: goldStockEntity.InsertStock{StockCode: code, StockName: code, StockQTY: qty, StockUpdateBy: "rina"}}
+}
+
+func TestInsertStockSales(t *testing.T) {
+	data := newFakeStockData()
+	svc := New(data, nil, nil, nil, jaegerLog.Factory{})
+	ctx := context.Background()
+
+	result, err := svc.InsertStockSales(ctx, restock("WHEY-1", 10))
+	require.NoError(t, err)
+	assert.Equal(t, "Berhasil Insert", result)
+
+	result, err = svc.InsertStockSales(ctx, restock("WHEY-1", 5))
+	require.NoError(t, err)
+	assert.Equal(t, "Stock Updated", result)
+	assert.Equal(t, 15, data.stocks["WHEY-1"].StockQTY)
+
+	// setiap restock tercatat di ledger
+	require.Len(t, data.movements, 2)
+	assert.Equal(t, goldStockEntity.MovementRestock, data.movements[1].MovementType)
+	assert.Equal(t, 5, data.movements[1].MovementQty)
+	assert.Equal(t, "rina", data.movements[1].MovementBy)
+
+	_, err = svc.InsertStockSales(ctx, restock("WHEY-1", 0))
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+}
+
+func TestRecordMovement(t *testing.T) {
+	tests := []struct {
+		name     string
+		movement goldStockEntity.StockMovement
+		wantQty  int
+		wantErr  error
+	}{
+		{"write-off is always taken out", goldStockEntity.StockMovement{MovementType: goldStockEntity.MovementWriteOff, MovementQty: 2, MovementReason: "botol pecah"}, 8, nil},
+		{"return is always added", goldStockEntity.StockMovement{MovementType: goldStockEntity.MovementReturn, MovementQty: -1, MovementReason: "retur member"}, 11, nil},
+		{"adjustment keeps its sign", goldStockEntity.StockMovement{MovementType: goldStockEntity.MovementAdjustment, MovementQty: -3, MovementReason: "stock opname"}, 7, nil},
+		{"transfers only through TransferStock", goldStockEntity.StockMovement{MovementType: goldStockEntity.MovementTransfer, MovementQty: -4, MovementReason: "ke cabang"}, 10, entity.ErrInvalid},
+		{"sales only through the POS", goldStockEntity.StockMovement{MovementType: goldStockEntity.MovementSale, MovementQty: -1, MovementReason: "jual"}, 10, entity.ErrInvalid},
+		{"reason is required", goldStockEntity.StockMovement{MovementType: goldStockEntity.MovementWriteOff, MovementQty: 1}, 10, entity.ErrInvalid},
+		{"never below zero", goldStockEntity.StockMovement{MovementType: goldStockEntity.MovementWriteOff, MovementQty: 11, MovementReason: "kadaluarsa"}, 10, goldStockEntity.ErrNegativeStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newFakeStockData()
+			data.stocks["ISO-1"] = goldStockEntity.GetOneStock{StockCode: "ISO-1", StockQTY: 10}
+			svc := New(data, nil, nil, nil, jaegerLog.Factory{})
+
+			tt.movement.StockCode = "ISO-1"
+			tt.movement.MovementBy = "rina"
+			_, err := svc.RecordMovement(context.Background(), tt.movement)
+			assert.Equal(t, tt.wantErr, errors.Cause(err))
+			assert.Equal(t, tt.wantQty, data.stocks["ISO-1"].StockQTY)
+		})
+	}
+}
+
+func TestGetStockCardAndRebuild(t *testing.T) {
+	data := newFakeStockData()
+	svc := New(data, nil, nil, nil, jaegerLog.Factory{})
+	ctx := context.Background()
+
+	day := func(d, h int) func() time.Time {
+		return func() time.Time { return time.Date(2026, 10, d, h, 0, 0, 0, time.Local) }
+	}
+	svc.now = day(17, 9)
+	_, err := svc.InsertStockSales(ctx, restock("ISO-1", 20))
+	require.NoError(t, err)
+	svc.now = day(18, 10)
+	_, err = svc.RecordMovement(ctx, goldStockEntity.StockMovement{StockCode: "ISO-1", MovementType: goldStockEntity.MovementWriteOff, MovementQty: 3, MovementReason: "kadaluarsa", MovementBy: "rina"})
+	require.NoError(t, err)
+	svc.now = day(19, 23)
+	_, err = svc.InsertStockSales(ctx, restock("ISO-1", 5))
+	require.NoError(t, err)
+	svc.now = day(20, 8)
+	_, err = svc.RecordMovement(ctx, goldStockEntity.StockMovement{StockCode: "ISO-1", MovementType: goldStockEntity.MovementAdjustment, MovementQty: -1, MovementReason: "opname", MovementBy: "rina"})
+	require.NoError(t, err)
+
+	card, err := svc.GetStockCard(ctx, "ISO-1", "2026-10-18", "2026-10-19", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 20, card.OpeningQty)
+	assert.Equal(t, 5, card.InQty)
+	assert.Equal(t, 3, card.OutQty)
+	assert.Equal(t, 22, card.ClosingQty)
+	require.Len(t, card.Lines, 2)
+	assert.Equal(t, 17, card.Lines[0].Balance)
+	assert.Equal(t, 22, card.Lines[1].Balance)
+
+	_, err = svc.GetStockCard(ctx, "ISO-1", "2026-10-19", "2026-10-18", 0)
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+
+	// stock_qty adalah proyeksi ledger, drift diperbaiki oleh rebuild
+	stock := data.stocks["ISO-1"]
+	stock.StockQTY = 99
+	data.stocks["ISO-1"] = stock
+	drifted, err := svc.RebuildStockQty(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), drifted)
+	assert.Equal(t, 21, data.stocks["ISO-1"].StockQTY)
+}
+
+type fakeNotifier struct {
+	mu    sync.Mutex
+	err   error
+	sent  int
+	to    []string
+	body  string
+	title string
+}
+
+func (n *fakeNotifier) SendEmail(ctx context.Context, to []string, subject, body string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.err != nil {
+		return n.err
+	}
+	n.sent++
+	n.to, n.title, n.body = to, subject, body
+	return nil
+}
+
+func TestSuggestedOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		stock goldStockEntity.GetOneStock
+		want  int
+	}{
+		{"no reorder point", goldStockEntity.GetOneStock{StockQTY: 0}, 0},
+		{"above point", goldStockEntity.GetOneStock{StockQTY: 11, StockReorderPoint: 10, StockReorderQty: 24}, 0},
+		{"at point", goldStockEntity.GetOneStock{StockQTY: 10, StockReorderPoint: 10, StockReorderQty: 24}, 24},
+		{"no reorder qty pakai point", goldStockEntity.GetOneStock{StockQTY: 3, StockReorderPoint: 10}, 10},
+		{"shortfall lebih besar", goldStockEntity.GetOneStock{StockQTY: 0, StockReorderPoint: 30, StockReorderQty: 12}, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.stock.SuggestedOrder())
+		})
+	}
+}
+
+func newReorderFixture(t *testing.T) (*fakeStockData, *fakeNotifier, Service) {
+	data := newFakeStockData()
+	notifier := &fakeNotifier{}
+	svc := New(data, notifier, []string{"frontdesk@goldgym.id"}, nil, jaegerLog.Factory{})
+	ctx := context.Background()
+
+	for code, qty := range map[string]int{"WHEY-1": 4, "ISO-1": 20, "BAR-1": 1} {
+		_, err := svc.InsertStockSales(ctx, restock(code, qty))
+		require.NoError(t, err)
+	}
+	_, err := svc.SetReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: "WHEY-1", ReorderPoint: 5, ReorderQty: 24})
+	require.NoError(t, err)
+	_, err = svc.SetReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: "ISO-1", ReorderPoint: 10, ReorderQty: 12})
+	require.NoError(t, err)
+	return data, notifier, svc
+}
+
+func TestSetReorderPoint(t *testing.T) {
+	_, _, svc := newReorderFixture(t)
+	ctx := context.Background()
+
+	_, err := svc.SetReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: "WHEY-1", ReorderPoint: -1})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+	_, err = svc.SetReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: " "})
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+	_, err = svc.SetReorderPoint(ctx, goldStockEntity.ReorderPoint{StockCode: "NOPE", ReorderPoint: 1})
+	assert.Equal(t, entity.ErrNotFound, errors.Cause(err))
+}
+
+func TestReorderListAndGetAllStock(t *testing.T) {
+	_, _, svc := newReorderFixture(t)
+	ctx := context.Background()
+
+	list, err := svc.GetReorderList(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "WHEY-1", list[0].StockCode)
+	assert.Equal(t, 24, list[0].SuggestedQty)
+
+	// getallstock menampilkan saran order per produk
+	stocks, err := svc.GetAllStockHeader(ctx)
+	require.NoError(t, err)
+	suggested := map[string]int{}
+	for _, stock := range stocks {
+		suggested[stock.StockCode] = stock.StockSuggestedOrder
+	}
+	assert.Equal(t, map[string]int{"BAR-1": 0, "ISO-1": 0, "WHEY-1": 24}, suggested)
+}
+
+func TestCheckLowStock(t *testing.T) {
+	data, notifier, svc := newReorderFixture(t)
+	ctx := context.Background()
+
+	n, err := svc.CheckLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, notifier.sent)
+	assert.Equal(t, []string{"frontdesk@goldgym.id"}, notifier.to)
+	assert.Contains(t, notifier.body, "WHEY-1")
+	assert.NotContains(t, notifier.body, "ISO-1")
+
+	// event StockLow ikut ditulis saat claim
+	require.Len(t, data.events, 1)
+	assert.Equal(t, outboxEntity.EventStockLow, data.events[0].EventType)
+	assert.Equal(t, "stock:WHEY-1", data.events[0].Key())
+	var envelope outboxEntity.Envelope
+	require.NoError(t, json.Unmarshal(data.events[0].Envelope, &envelope))
+	assert.Equal(t, "goldgym.stock.low.v1", envelope.SchemaID)
+	assert.JSONEq(t, `{"stock_code":"WHEY-1","stock_name":"","stock_qty":4,"reorder_point":5,"suggested_qty":24}`, string(envelope.Payload))
+
+	// sudah dikirim, tidak dikirim ulang selama stok belum naik
+	n, err = svc.CheckLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 1, notifier.sent)
+
+	// ISO-1 turun ke reorder point
+	_, err = svc.RecordMovement(ctx, goldStockEntity.StockMovement{StockCode: "ISO-1", MovementType: goldStockEntity.MovementWriteOff, MovementQty: 10, MovementReason: "expired", MovementBy: "rina"})
+	require.NoError(t, err)
+	n, err = svc.CheckLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Contains(t, notifier.body, "ISO-1")
+	assert.NotContains(t, notifier.body, "WHEY-1")
+
+	// restock di atas point, lalu turun lagi: alert dikirim lagi
+	_, err = svc.InsertStockSales(ctx, restock("WHEY-1", 10))
+	require.NoError(t, err)
+	n, err = svc.CheckLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.False(t, data.alerted["WHEY-1"])
+	_, err = svc.RecordMovement(ctx, goldStockEntity.StockMovement{StockCode: "WHEY-1", MovementType: goldStockEntity.MovementWriteOff, MovementQty: 10, MovementReason: "rusak", MovementBy: "rina"})
+	require.NoError(t, err)
+	n, err = svc.CheckLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 3, notifier.sent)
+}
+
+func TestCheckLowStockSendFailed(t *testing.T) {
+	data, notifier, svc := newReorderFixture(t)
+	ctx := context.Background()
+
+	notifier.err = errors.New("smtp down")
+	_, err := svc.CheckLowStock(ctx)
+	require.Error(t, err)
+	// hanya claim email yang dilepas supaya dicoba lagi, StockLow tetap
+	assert.True(t, data.alerted["WHEY-1"])
+	assert.False(t, data.emailed["WHEY-1"])
+	_, err = svc.CheckLowStock(ctx)
+	require.Error(t, err)
+
+	notifier.err = nil
+	n, err := svc.CheckLowStock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	// StockLow tidak diterbitkan ulang oleh percobaan email berikutnya
+	assert.Len(t, data.events, 1)
+}
+
+func TestCheckLowStockParallel(t *testing.T) {
+	_, notifier, svc := newReorderFixture(t)
+	ctx := context.Background()
+
+	// beberapa instance mengecek bersamaan, alert hanya terkirim sekali
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.CheckLowStock(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, notifier.sent)
+}
@@ -0,0 +1,90 @@
+package goldgym
+
+import (
+	"context"
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+)
+
+// GetInventoryValuation values the stock on hand. With the average method
+// every unit is worth stock_cost_price; with fifo the units on hand are the
+// cost layers not yet sold or written off, valued at their receipt cost, and
+// units not covered by any layer fall back to stock_cost_price. Negative
+// quantities are valued at zero. An empty method means average.
+func (s Service) GetInventoryValuation(ctx context.Context, method string) (goldStockEntity.InventoryValuation, error) {
+	if method == "" {
+		method = goldStockEntity.ValuationAverage
+	}
+	if !goldStockEntity.ValidValuationMethod(method) {
+		return goldStockEntity.InventoryValuation{}, errors.Wrap(entity.ErrInvalid, "method must be average or fifo")
+	}
+
+	stocks, err := s.goldgymstock.GetAllStockHeader(ctx)
+	if err != nil {
+		return goldStockEntity.InventoryValuation{}, errors.Wrap(err, "[Service][GetInventoryValuation]")
+	}
+
+	byCode := make(map[string][]goldStockEntity.StockCostLayer)
+	if method == goldStockEntity.ValuationFIFO {
+		layers, err := s.goldgymstock.GetCostLayers(ctx)
+		if err != nil {
+			return goldStockEntity.InventoryValuation{}, errors.Wrap(err, "[Service][GetInventoryValuation]")
+		}
+		for _, layer := range layers {
+			byCode[layer.StockCode] = append(byCode[layer.StockCode], layer)
+		}
+	}
+
+	valuation := goldStockEntity.InventoryValuation{
+		AsOf:   s.now().Format(dateLayout),
+		Method: method,
+		Lines:  make([]goldStockEntity.InventoryValuationLine, 0, len(stocks)),
+	}
+	var total entity.Money
+	for _, stock := range stocks {
+		qty := stock.StockQTY
+		if qty < 0 {
+			qty = 0
+		}
+		value := stock.StockCostPrice.Mul(qty)
+		if method == goldStockEntity.ValuationFIFO {
+			value = fifoValue(qty, stock.StockCostPrice, byCode[stock.StockCode])
+		}
+
+		line := goldStockEntity.InventoryValuationLine{
+			StockCode: stock.StockCode,
+			StockName: stock.StockName,
+			StockQty:  qty,
+			Value:     value,
+		}
+		if qty > 0 {
+			line.UnitCost = value.Div(int64(qty))
+		}
+		valuation.Lines = append(valuation.Lines, line)
+		valuation.TotalQty += qty
+		total += value
+	}
+	valuation.TotalValue = total
+	return valuation, nil
+}
+
+// fifoValue values qty units against cost layers ordered newest first, so
+// when stock was corrected below the layers the oldest are left out. Whatever
+// the layers do not cover is valued at fallback.
+func fifoValue(qty int, fallback entity.Money, layers []goldStockEntity.StockCostLayer) entity.Money {
+	var value entity.Money
+	left := qty
+	for _, layer := range layers {
+		if left == 0 {
+			break
+		}
+		take := layer.LayerQty
+		if take > left {
+			take = left
+		}
+		value += layer.LayerUnitCost.Mul(take)
+		left -= take
+	}
+	return value + fallback.Mul(left)
+}
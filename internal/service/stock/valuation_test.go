@@ -0,0 +1,91 @@
+package goldgym
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"gold-gym-be/internal/entity"
+	goldStockEntity "gold-gym-be/internal/entity/stock"
+	"gold-gym-be/pkg/errors"
+	jaegerLog "gold-gym-be/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeStockData) GetCostLayers(ctx context.Context) ([]goldStockEntity.StockCostLayer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var layers []goldStockEntity.StockCostLayer
+	for _, layer := range f.layers {
+		if layer.LayerQty > 0 {
+			layers = append(layers, layer)
+		}
+	}
+	sort.SliceStable(layers, func(i, j int) bool {
+		if layers[i].StockCode != layers[j].StockCode {
+			return layers[i].StockCode < layers[j].StockCode
+		}
+		return layers[i].LayerID > layers[j].LayerID
+	})
+	return layers, nil
+}
+
+func newValuationFixture() Service {
+	data := newFakeStockData()
+	data.stocks["WHEY-1"] = goldStockEntity.GetOneStock{StockCode: "WHEY-1", StockName: "Whey Protein Sachet", StockQTY: 10, StockCostPrice: entity.NewMoney(21000)}
+	data.stocks["ISO-1"] = goldStockEntity.GetOneStock{StockCode: "ISO-1", StockName: "Isotonic 500ml", StockQTY: 3, StockCostPrice: entity.NewMoney(4000)}
+	data.stocks["BAR-1"] = goldStockEntity.GetOneStock{StockCode: "BAR-1", StockName: "Protein Bar", StockQTY: -2, StockCostPrice: entity.NewMoney(9000)}
+	// lot lama WHEY-1 masih tersisa 4 karena penjualan FEFO mengambil lot baru
+	// yang kedaluwarsa lebih dulu
+	data.layers = []goldStockEntity.StockCostLayer{
+		{LayerID: 1, StockCode: "WHEY-1", LayerQty: 4, LayerUnitCost: entity.NewMoney(20000)},
+		{LayerID: 2, StockCode: "WHEY-1", LayerQty: 6, LayerUnitCost: entity.NewMoney(22000)},
+		{LayerID: 3, StockCode: "ISO-1", LayerQty: 1, LayerUnitCost: entity.NewMoney(3000)},
+		{LayerID: 4, StockCode: "ISO-1", LayerQty: 0, LayerUnitCost: entity.NewMoney(9999)},
+	}
+	svc := New(data, nil, nil, nil, jaegerLog.Factory{})
+	svc.now = func() time.Time { return time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local) }
+	return svc
+}
+
+func TestInventoryValuation(t *testing.T) {
+	svc := newValuationFixture()
+	ctx := context.Background()
+
+	// default average: qty x stock_cost_price, stok minus bernilai 0
+	valuation, err := svc.GetInventoryValuation(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, "average", valuation.Method)
+	assert.Equal(t, "2026-10-19", valuation.AsOf)
+	require.Len(t, valuation.Lines, 3)
+	assert.Equal(t, "BAR-1", valuation.Lines[0].StockCode)
+	assert.Equal(t, 0, valuation.Lines[0].StockQty)
+	assert.Equal(t, entity.Money(0), valuation.Lines[0].Value)
+	assert.Equal(t, entity.NewMoney(12000), valuation.Lines[1].Value)
+	assert.Equal(t, entity.NewMoney(210000), valuation.Lines[2].Value)
+	assert.Equal(t, 13, valuation.TotalQty)
+	assert.Equal(t, entity.NewMoney(222000), valuation.TotalValue)
+
+	// fifo: sisa layer biaya yang sebenarnya, sisanya di luar layer pakai average
+	valuation, err = svc.GetInventoryValuation(ctx, "fifo")
+	require.NoError(t, err)
+	assert.Equal(t, entity.NewMoney(3000+2*4000), valuation.Lines[1].Value)
+	assert.Equal(t, entity.NewMoney(4*20000+6*22000), valuation.Lines[2].Value)
+	assert.Equal(t, entity.NewMoney(21200), valuation.Lines[2].UnitCost)
+	assert.Equal(t, entity.NewMoney(223000), valuation.TotalValue)
+
+	// stok lebih sedikit dari layer (koreksi manual): layer tertua yang dipotong
+	data := svc.goldgymstock.(*fakeStockData)
+	whey := data.stocks["WHEY-1"]
+	whey.StockQTY = 7
+	data.stocks["WHEY-1"] = whey
+	valuation, err = svc.GetInventoryValuation(ctx, "fifo")
+	require.NoError(t, err)
+	assert.Equal(t, entity.NewMoney(1*20000+6*22000), valuation.Lines[2].Value)
+
+	_, err = svc.GetInventoryValuation(ctx, "lifo")
+	assert.Equal(t, entity.ErrInvalid, errors.Cause(err))
+}
@@ -87,37 +87,9 @@ func RSAVerifySignature(publicKeyBase64, payload, signatureBase64 string) (bool,
 // payload: the string to sign
 // Returns: base64 encoded signature
 func RSASign(privateKeyInput, payload string) (string, error) {
-	var privateKeyBytes []byte
-	var err error
-
-	// Clean up the input - remove any whitespace/newlines that might break base64
-	cleanInput := strings.TrimSpace(privateKeyInput)
-
-	// Check if it's already a PEM string (not base64 encoded)
-	if strings.Contains(cleanInput, "-----BEGIN") {
-		privateKeyBytes = []byte(cleanInput)
-	} else {
-		// It's base64 encoded - decode it first
-		// Try standard base64 first
-		privateKeyBytes, err = base64.StdEncoding.DecodeString(cleanInput)
-		if err != nil {
-			// Try URL-safe base64
-			urlSafe := strings.ReplaceAll(cleanInput, "-", "+")
-			urlSafe = strings.ReplaceAll(urlSafe, "_", "/")
-
-			// Add padding if needed
-			switch len(urlSafe) % 4 {
-			case 2:
-				urlSafe += "=="
-			case 3:
-				urlSafe += "="
-			}
-
-			privateKeyBytes, err = base64.StdEncoding.DecodeString(urlSafe)
-			if err != nil {
-				return "", errors.New("failed to decode private key from base64: " + err.Error())
-			}
-		}
+	privateKeyBytes, err := decodePrivateKeyInput(privateKeyInput)
+	if err != nil {
+		return "", err
 	}
 
 	// Parse the private key
@@ -138,6 +110,43 @@ func RSASign(privateKeyInput, payload string) (string, error) {
 	return base64.StdEncoding.EncodeToString(signature), nil
 }
 
+// decodePrivateKeyInput accepts raw PEM, base64 encoded PEM or base64 encoded DER
+// (standard or URL-safe alphabet) and returns the bytes to hand to the parsers
+func decodePrivateKeyInput(privateKeyInput string) ([]byte, error) {
+	// Clean up the input - remove any whitespace/newlines that might break base64
+	cleanInput := strings.TrimSpace(privateKeyInput)
+
+	// Check if it's already a PEM string (not base64 encoded)
+	if strings.Contains(cleanInput, "-----BEGIN") {
+		return []byte(cleanInput), nil
+	}
+
+	// It's base64 encoded - decode it first
+	// Try standard base64 first
+	privateKeyBytes, err := base64.StdEncoding.DecodeString(cleanInput)
+	if err == nil {
+		return privateKeyBytes, nil
+	}
+
+	// Try URL-safe base64
+	urlSafe := strings.ReplaceAll(cleanInput, "-", "+")
+	urlSafe = strings.ReplaceAll(urlSafe, "_", "/")
+
+	// Add padding if needed
+	switch len(urlSafe) % 4 {
+	case 2:
+		urlSafe += "=="
+	case 3:
+		urlSafe += "="
+	}
+
+	privateKeyBytes, err = base64.StdEncoding.DecodeString(urlSafe)
+	if err != nil {
+		return nil, errors.New("failed to decode private key from base64: " + err.Error())
+	}
+	return privateKeyBytes, nil
+}
+
 // parsePrivateKey tries to parse a private key from various formats
 func parsePrivateKey(keyBytes []byte) (*rsa.PrivateKey, error) {
 	// Try to parse as PEM first
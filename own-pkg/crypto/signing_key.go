@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+// JWK is the public half of a signing key in RFC 7517 form
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// ParseSigningKey parses an RSA or EC private key.
+// privateKeyInput accepts the same formats as RSASign (PEM, base64 PEM, base64 DER)
+// in PKCS8, PKCS1 (RSA) or SEC1 (EC) encoding.
+func ParseSigningKey(privateKeyInput string) (crypto.Signer, error) {
+	keyBytes, err := decodePrivateKeyInput(privateKeyInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(keyBytes); block != nil {
+		keyBytes = block.Bytes
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(keyBytes); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case *ecdsa.PrivateKey:
+			return k, nil
+		}
+		return nil, errors.New("unsupported private key type (PKCS8)")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(keyBytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(keyBytes); err == nil {
+		return key, nil
+	}
+
+	return nil, errors.New("failed to parse private key: not PKCS8, PKCS1 or SEC1 format")
+}
+
+// PublicJWK describes the public key of signer as a JWK for the given kid and alg
+func PublicJWK(kid, alg string, signer crypto.Signer) (JWK, error) {
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kid: kid,
+			Kty: "RSA",
+			Alg: alg,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return JWK{}, errors.New("only P-256 EC keys are supported")
+		}
+		// coordinates are fixed-width for the curve (RFC 7518 §6.2.1.2)
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kid: kid,
+			Kty: "EC",
+			Alg: alg,
+			Use: "sig",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size))),
+		}, nil
+	}
+	return JWK{}, errors.New("unsupported public key type")
+}
@@ -0,0 +1,152 @@
+// Package jwtkeys signs and verifies our access tokens with asymmetric keys.
+//
+// A KeySet holds every key that is currently published. Exactly one of them is
+// active and used for signing; the others are still accepted for verification
+// and listed in the JWKS so tokens signed before a rotation stay valid until
+// they expire. Rotation is therefore: add the new key, deploy, switch
+// active_kid, deploy, and drop the old key once its last token has expired.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	ownCrypto "gold-gym-be/own-pkg/crypto"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	// ErrUnknownKey is returned when a token names a kid that is not in the set
+	ErrUnknownKey = errors.New("jwtkeys: unknown key id")
+)
+
+// Key is one signing key with its JWS algorithm (RS256 or ES256)
+type Key struct {
+	KID       string
+	Algorithm string
+	Signer    crypto.Signer
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []ownCrypto.JWK `json:"keys"`
+}
+
+type entry struct {
+	method jwt.SigningMethod
+	signer crypto.Signer
+	jwk    ownCrypto.JWK
+}
+
+// KeySet ...
+type KeySet struct {
+	active string
+	keys   map[string]entry
+	order  []string
+}
+
+// New builds a KeySet signing with activeKID
+func New(activeKID string, keys ...Key) (*KeySet, error) {
+	ks := &KeySet{
+		active: activeKID,
+		keys:   make(map[string]entry, len(keys)),
+	}
+
+	for _, k := range keys {
+		if k.KID == "" {
+			return nil, errors.New("jwtkeys: key without kid")
+		}
+		if _, dup := ks.keys[k.KID]; dup {
+			return nil, fmt.Errorf("jwtkeys: duplicate kid %q", k.KID)
+		}
+
+		method, err := signingMethod(k.Algorithm, k.Signer)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: key %q: %w", k.KID, err)
+		}
+		jwk, err := ownCrypto.PublicJWK(k.KID, k.Algorithm, k.Signer)
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: key %q: %w", k.KID, err)
+		}
+
+		ks.keys[k.KID] = entry{method: method, signer: k.Signer, jwk: jwk}
+		ks.order = append(ks.order, k.KID)
+	}
+
+	if _, ok := ks.keys[activeKID]; !ok {
+		return nil, fmt.Errorf("jwtkeys: active kid %q is not configured", activeKID)
+	}
+	return ks, nil
+}
+
+// Generate creates a single ephemeral RS256 key. Tokens signed with it do not
+// survive a restart, so it is only meant for local development and tests.
+func Generate(kid string) (*KeySet, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return New(kid, Key{KID: kid, Algorithm: "RS256", Signer: key})
+}
+
+// ActiveKID returns the kid used for new tokens
+func (ks *KeySet) ActiveKID() string {
+	return ks.active
+}
+
+// Sign signs claims with the active key and sets the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	e := ks.keys[ks.active]
+
+	token := jwt.NewWithClaims(e.method, claims)
+	token.Header["kid"] = ks.active
+	return token.SignedString(e.signer)
+}
+
+// Parse verifies a token against the key named by its kid header. The token
+// algorithm must match the algorithm registered for that key.
+func (ks *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		e, ok := ks.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if t.Method.Alg() != e.method.Alg() {
+			return nil, fmt.Errorf("jwtkeys: unexpected signing method %v for kid %q", t.Header["alg"], kid)
+		}
+		return e.signer.Public(), nil
+	})
+}
+
+// JWKS returns the public keys of every key in the set
+func (ks *KeySet) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]ownCrypto.JWK, 0, len(ks.order))}
+	for _, kid := range ks.order {
+		set.Keys = append(set.Keys, ks.keys[kid].jwk)
+	}
+	return set
+}
+
+func signingMethod(alg string, signer crypto.Signer) (jwt.SigningMethod, error) {
+	switch alg {
+	case "RS256":
+		if _, ok := signer.(*rsa.PrivateKey); !ok {
+			return nil, errors.New("RS256 requires an RSA key")
+		}
+		return jwt.SigningMethodRS256, nil
+	case "ES256":
+		k, ok := signer.(*ecdsa.PrivateKey)
+		if !ok || k.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 EC key")
+		}
+		return jwt.SigningMethodES256, nil
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", alg)
+}
@@ -0,0 +1,118 @@
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	ownCrypto "gold-gym-be/own-pkg/crypto"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaKey(t *testing.T, kid string) Key {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return Key{KID: kid, Algorithm: "RS256", Signer: k}
+}
+
+func ecKeyPEM(t *testing.T) string {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(k)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "budi@test.com", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestSignAndParseES256FromPEM(t *testing.T) {
+	signer, err := ownCrypto.ParseSigningKey(ecKeyPEM(t))
+	require.NoError(t, err)
+
+	ks, err := New("ec-1", Key{KID: "ec-1", Algorithm: "ES256", Signer: signer})
+	require.NoError(t, err)
+
+	raw, err := ks.Sign(claims())
+	require.NoError(t, err)
+
+	token, err := ks.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", token.Method.Alg())
+	assert.Equal(t, "ec-1", token.Header["kid"])
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "EC", jwks.Keys[0].Kty)
+	assert.Equal(t, "P-256", jwks.Keys[0].Crv)
+}
+
+func TestRotation(t *testing.T) {
+	oldKey, newKey := rsaKey(t, "2026-09"), rsaKey(t, "2026-10")
+
+	before, err := New("2026-09", oldKey, newKey)
+	require.NoError(t, err)
+	issuedBefore, err := before.Sign(claims())
+	require.NoError(t, err)
+
+	after, err := New("2026-10", oldKey, newKey)
+	require.NoError(t, err)
+	issuedAfter, err := after.Sign(claims())
+	require.NoError(t, err)
+
+	// both generations verify while both keys are published
+	_, err = after.Parse(issuedBefore)
+	assert.NoError(t, err)
+	_, err = after.Parse(issuedAfter)
+	assert.NoError(t, err)
+	assert.Len(t, after.JWKS().Keys, 2)
+
+	// once the old key is dropped its tokens are rejected
+	retired, err := New("2026-10", newKey)
+	require.NoError(t, err)
+	_, err = retired.Parse(issuedBefore)
+	assert.Error(t, err)
+}
+
+func TestParseRejectsForeignAndHMACTokens(t *testing.T) {
+	ks, err := Generate("dev")
+	require.NoError(t, err)
+
+	other, err := Generate("dev")
+	require.NoError(t, err)
+	foreign, err := other.Sign(claims())
+	require.NoError(t, err)
+	_, err = ks.Parse(foreign)
+	assert.Error(t, err)
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	hs.Header["kid"] = "dev"
+	legacy, err := hs.SignedString([]byte("shared-secret"))
+	require.NoError(t, err)
+	_, err = ks.Parse(legacy)
+	assert.Error(t, err)
+}
+
+func TestNewValidation(t *testing.T) {
+	k := rsaKey(t, "a")
+
+	_, err := New("missing", k)
+	assert.Error(t, err)
+
+	_, err = New("a", k, k)
+	assert.Error(t, err)
+
+	_, err = New("a", Key{KID: "a", Algorithm: "ES256", Signer: k.Signer})
+	assert.Error(t, err)
+}
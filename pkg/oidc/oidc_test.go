@@ -0,0 +1,165 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"gold-gym-be/pkg/oidc"
+	"gold-gym-be/pkg/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8085/gold-gym/v2/userdata/oidc/fake/callback"
+
+func newProvider(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	t.Helper()
+	srv := oidctest.NewServer("gold-gym", "s3cret")
+	t.Cleanup(srv.Close)
+
+	p := oidc.NewProvider(oidc.Config{
+		Issuer:       srv.Issuer(),
+		ClientID:     "gold-gym",
+		ClientSecret: "s3cret",
+		RedirectURL:  redirectURL,
+	}, nil)
+	return srv, p
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	srv, p := newProvider(t)
+	srv.SetUser(oidctest.User{Subject: "sub-1", Email: "budi@mail.com", EmailVerified: true, Name: "Budi"})
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", oidc.CodeChallengeS256(verifier))
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+
+	code, state, err := srv.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	tok, err := p.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	claims, err := p.VerifyIDToken(ctx, tok.IDToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sub-1", claims.Subject)
+	assert.Equal(t, "budi@mail.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "Budi", claims.Name)
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	ctx := context.Background()
+	srv, p := newProvider(t)
+
+	verifier, _ := oidc.NewCodeVerifier()
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", oidc.CodeChallengeS256(verifier))
+	require.NoError(t, err)
+	code, _, err := srv.Authorize(authURL)
+	require.NoError(t, err)
+
+	_, err = p.Exchange(ctx, code, "not-the-verifier")
+	assert.Error(t, err)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+	srv, p := newProvider(t)
+	user := oidctest.User{Subject: "sub-1", Email: "budi@mail.com", EmailVerified: true}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		nonce   string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			token: func() string {
+				tok, _ := srv.SignIDToken(user, "n", time.Now().Add(time.Hour))
+				return tok
+			},
+			nonce: "n",
+		},
+		{
+			name: "nonce mismatch",
+			token: func() string {
+				tok, _ := srv.SignIDToken(user, "n", time.Now().Add(time.Hour))
+				return tok
+			},
+			nonce:   "other",
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				tok, _ := srv.SignIDToken(user, "n", time.Now().Add(-time.Hour))
+				return tok
+			},
+			nonce:   "n",
+			wantErr: true,
+		},
+		{
+			name: "foreign signature",
+			token: func() string {
+				other := oidctest.NewServer("gold-gym", "s3cret")
+				defer other.Close()
+				tok, _ := other.SignIDToken(user, "n", time.Now().Add(time.Hour))
+				return tok
+			},
+			nonce:   "n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.VerifyIDToken(ctx, tt.token(), tt.nonce)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUnknownKidRefetchIsThrottled(t *testing.T) {
+	ctx := context.Background()
+	srv, p := newProvider(t)
+	user := oidctest.User{Subject: "sub-1", Email: "budi@mail.com", EmailVerified: true}
+
+	tok, err := srv.SignIDToken(user, "n", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, err = p.VerifyIDToken(ctx, tok, "n")
+	require.NoError(t, err)
+	require.Equal(t, 1, srv.JWKSRequests())
+
+	// kid palsu tidak boleh memicu fetch JWKS berulang
+	for i := 0; i < 5; i++ {
+		forged, err := srv.SignIDTokenWithKid(user, "n", time.Now().Add(time.Hour), fmt.Sprintf("forged-%d", i))
+		require.NoError(t, err)
+		_, err = p.VerifyIDToken(ctx, forged, "n")
+		assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken), "got %v", err)
+	}
+	assert.Equal(t, 1, srv.JWKSRequests())
+
+	// key yang sudah dikenal tetap diverifikasi dari cache
+	_, err = p.VerifyIDToken(ctx, tok, "n")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, srv.JWKSRequests())
+}
@@ -0,0 +1,28 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// RandomString returns a URL-safe random string built from n random bytes,
+// used for state, nonce and PKCE verifiers
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewCodeVerifier returns a PKCE code verifier (43 chars, RFC 7636 §4.1)
+func NewCodeVerifier() (string, error) {
+	return RandomString(32)
+}
+
+// CodeChallengeS256 derives the S256 code challenge for a verifier
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
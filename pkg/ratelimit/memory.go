@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many calls the memory limiter serves between sweeps of
+// idle keys
+const sweepEvery = 1024
+
+type bucketState struct {
+	tokens float64
+	last   time.Time
+}
+
+type windowState struct {
+	start time.Time
+	prev  int
+	cur   int
+}
+
+type memoryEntry struct {
+	bucket  bucketState
+	window  windowState
+	expires time.Time
+}
+
+// Memory keeps counters in process memory. Limits are per instance, so use
+// Redis when the service runs with more than one replica.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	calls   int
+}
+
+// NewMemory ...
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]*memoryEntry)}
+}
+
+// Allow ...
+func (m *Memory) Allow(_ context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.calls%sweepEvery == 0 {
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+	}
+
+	e, ok := m.entries[key]
+	if !ok {
+		e = &memoryEntry{
+			bucket: bucketState{tokens: float64(rule.Limit), last: now},
+			window: windowState{start: now.Truncate(rule.Window)},
+		}
+		m.entries[key] = e
+	}
+	e.expires = now.Add(2 * rule.Window)
+
+	if rule.Algorithm == AlgorithmTokenBucket {
+		return takeToken(&e.bucket, rule, now), nil
+	}
+	return countWindow(&e.window, rule, now), nil
+}
+
+// takeToken refills the bucket at Limit tokens per Window and takes one token
+func takeToken(s *bucketState, rule Rule, now time.Time) Result {
+	limit := float64(rule.Limit)
+	rate := limit / float64(rule.Window) // tokens per nanosecond
+
+	if elapsed := now.Sub(s.last); elapsed > 0 {
+		s.tokens = math.Min(limit, s.tokens+float64(elapsed)*rate)
+		s.last = now
+	}
+
+	res := Result{Limit: rule.Limit}
+	if s.tokens >= 1 {
+		s.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - s.tokens) / rate)
+	}
+	res.Remaining = int(math.Floor(s.tokens))
+	res.ResetAfter = time.Duration((limit - s.tokens) / rate)
+	return res
+}
+
+// countWindow approximates a sliding window by weighting the previous fixed
+// window by how much of it still overlaps the sliding one
+func countWindow(s *windowState, rule Rule, now time.Time) Result {
+	start := now.Truncate(rule.Window)
+	switch {
+	case start.Sub(s.start) >= 2*rule.Window:
+		s.prev, s.cur = 0, 0
+	case start.After(s.start):
+		s.prev, s.cur = s.cur, 0
+	}
+	s.start = start
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(rule.Window)
+	count := float64(s.prev)*weight + float64(s.cur)
+
+	res := Result{Limit: rule.Limit, ResetAfter: rule.Window - elapsed}
+	if count+1 <= float64(rule.Limit) {
+		s.cur++
+		count++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = windowRetryAfter(s.prev, s.cur, rule, elapsed)
+	}
+	res.Remaining = int(math.Max(0, math.Floor(float64(rule.Limit)-count)))
+	return res
+}
+
+// windowRetryAfter is how long until the weighted previous window has decayed
+// enough to admit one more request
+func windowRetryAfter(prev, cur int, rule Rule, elapsed time.Duration) time.Duration {
+	free := float64(rule.Limit - cur - 1)
+	if free < 0 || prev == 0 {
+		return rule.Window - elapsed
+	}
+	at := time.Duration(float64(rule.Window) * (1 - free/float64(prev)))
+	if at <= elapsed {
+		return 0
+	}
+	return at - elapsed
+}
@@ -0,0 +1,206 @@
+// Package ratelimit implements request rate limiting shared by every HTTP router.
+//
+// Rules are matched on method, route and the `type=` operation, and count per
+// key (client IP, authenticated user or authenticated API client). Two
+// algorithms are available: a token bucket, which allows short bursts up to
+// the limit, and a sliding window counter, which enforces the limit over any
+// window-sized span.
+// Counters live in Redis so limits hold across replicas, or in memory for
+// single-instance and local runs.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+
+	KeyByIP     = "ip"
+	KeyByUser   = "user"
+	KeyByClient = "client"
+)
+
+// Rule is one limit. Empty Method/Path/Type match anything; a Path ending in
+// "*" matches by prefix.
+type Rule struct {
+	Name      string
+	Method    string
+	Path      string
+	Type      string
+	Limit     int
+	Window    time.Duration
+	KeyBy     string
+	Algorithm string
+}
+
+// Result of counting one request against a rule
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter is a counter backend
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule, now time.Time) (Result, error)
+}
+
+// Request is what the router adapters extract from an incoming request
+type Request struct {
+	Method        string
+	Path          string // route template when the router exposes one
+	Type          string // the `type` query parameter
+	IP            string
+	Authorization string
+}
+
+// Decision is returned to the router adapter
+type Decision struct {
+	Result
+	Rule  *Rule
+	Error error
+}
+
+// Headers returns the X-RateLimit-* headers (and Retry-After when rejected)
+func (d Decision) Headers() map[string]string {
+	if d.Rule == nil {
+		return nil
+	}
+	h := map[string]string{
+		"X-RateLimit-Limit":     strconv.Itoa(d.Limit),
+		"X-RateLimit-Remaining": strconv.Itoa(d.Remaining),
+		"X-RateLimit-Reset":     strconv.Itoa(ceilSeconds(d.ResetAfter)),
+	}
+	if !d.Allowed {
+		h["Retry-After"] = strconv.Itoa(ceilSeconds(d.RetryAfter))
+	}
+	return h
+}
+
+// Enforcer matches requests to rules and counts them with a Limiter
+type Enforcer struct {
+	limiter Limiter
+	rules   []Rule
+	def     *Rule
+
+	// IdentifyUser resolves the authenticated user from the Authorization
+	// header; requests without one fall back to the IP key.
+	IdentifyUser func(ctx context.Context, authorization string) string
+
+	// IdentifyClient resolves the authenticated API client from the
+	// Authorization header; requests without one fall back to the IP key.
+	IdentifyClient func(ctx context.Context, authorization string) string
+
+	now func() time.Time
+}
+
+// NewEnforcer builds an Enforcer. def applies when no rule matches; a zero
+// def.Limit leaves unmatched requests unlimited.
+func NewEnforcer(limiter Limiter, def Rule, rules ...Rule) *Enforcer {
+	e := &Enforcer{
+		limiter: limiter,
+		now:     time.Now,
+	}
+	for _, r := range rules {
+		e.rules = append(e.rules, normalize(r))
+	}
+	if def.Limit > 0 {
+		d := normalize(def)
+		if d.Name == "" {
+			d.Name = "default"
+		}
+		e.def = &d
+	}
+	return e
+}
+
+// Check counts req against the first matching rule. Backend failures are
+// reported in Decision.Error and fail open.
+func (e *Enforcer) Check(ctx context.Context, req Request) Decision {
+	rule := e.match(req)
+	if rule == nil {
+		return Decision{Result: Result{Allowed: true}}
+	}
+
+	key := "ratelimit:" + rule.Name + ":" + e.key(ctx, *rule, req)
+	res, err := e.limiter.Allow(ctx, key, *rule, e.now())
+	if err != nil {
+		return Decision{Result: Result{Allowed: true}, Error: err}
+	}
+	return Decision{Result: res, Rule: rule}
+}
+
+func (e *Enforcer) match(req Request) *Rule {
+	for i := range e.rules {
+		r := &e.rules[i]
+		if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+			continue
+		}
+		if r.Type != "" && !strings.EqualFold(r.Type, req.Type) {
+			continue
+		}
+		if r.Path != "" && !matchPath(r.Path, req.Path) {
+			continue
+		}
+		return r
+	}
+	return e.def
+}
+
+func (e *Enforcer) key(ctx context.Context, rule Rule, req Request) string {
+	switch rule.KeyBy {
+	case KeyByUser:
+		if e.IdentifyUser != nil && req.Authorization != "" {
+			if user := e.IdentifyUser(ctx, req.Authorization); user != "" {
+				return "user:" + user
+			}
+		}
+	case KeyByClient:
+		if e.IdentifyClient != nil && req.Authorization != "" {
+			if client := e.IdentifyClient(ctx, req.Authorization); client != "" {
+				return "client:" + client
+			}
+		}
+	}
+	return "ip:" + req.IP
+}
+
+func matchPath(pattern, path string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	path = strings.TrimSuffix(path, "/")
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(path, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == path
+}
+
+func normalize(r Rule) Rule {
+	if r.Window <= 0 {
+		r.Window = time.Minute
+	}
+	if r.Algorithm == "" {
+		r.Algorithm = AlgorithmSlidingWindow
+	}
+	if r.KeyBy == "" {
+		r.KeyBy = KeyByIP
+	}
+	if r.Name == "" {
+		r.Name = strings.Trim(strings.Join([]string{strings.ToLower(r.Method), r.Path, r.Type}, ":"), ":")
+	}
+	return r
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
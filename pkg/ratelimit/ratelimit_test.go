@@ -0,0 +1,176 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// backends runs each test against both limiters so they stay in agreement
+func backends(t *testing.T) map[string]Limiter {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return map[string]Limiter{
+		"memory": NewMemory(),
+		"redis":  NewRedis(rdb),
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	rule := normalize(Rule{Name: "otp", Limit: 3, Window: 30 * time.Second, Algorithm: AlgorithmTokenBucket})
+	start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	for name, l := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// the full bucket allows a burst of Limit requests
+			for i := 0; i < 3; i++ {
+				res, err := l.Allow(ctx, "k", rule, start)
+				require.NoError(t, err)
+				assert.True(t, res.Allowed)
+				assert.Equal(t, 2-i, res.Remaining)
+			}
+
+			res, err := l.Allow(ctx, "k", rule, start)
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 10*time.Second, res.RetryAfter)
+			assert.Equal(t, 30*time.Second, res.ResetAfter)
+
+			// one token is back after Window/Limit
+			res, err = l.Allow(ctx, "k", rule, start.Add(10*time.Second))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 0, res.Remaining)
+
+			// other keys have their own bucket
+			res, err = l.Allow(ctx, "other", rule, start)
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+		})
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	rule := normalize(Rule{Name: "login", Limit: 4, Window: time.Minute})
+	start := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	for name, l := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for i := 0; i < 4; i++ {
+				res, err := l.Allow(ctx, "k", rule, start.Add(30*time.Second))
+				require.NoError(t, err)
+				assert.True(t, res.Allowed)
+				assert.Equal(t, 3-i, res.Remaining)
+			}
+			res, err := l.Allow(ctx, "k", rule, start.Add(30*time.Second))
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 30*time.Second, res.ResetAfter)
+			assert.Equal(t, 30*time.Second, res.RetryAfter)
+
+			// 5s into the next window the previous 4 still weigh more than 3
+			res, err = l.Allow(ctx, "k", rule, start.Add(65*time.Second))
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 10*time.Second, res.RetryAfter)
+
+			res, err = l.Allow(ctx, "k", rule, start.Add(75*time.Second))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+
+			// after two idle windows everything is forgotten
+			res, err = l.Allow(ctx, "k", rule, start.Add(4*time.Minute))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 3, res.Remaining)
+		})
+	}
+}
+
+func TestEnforcerMatchesRulesAndKeys(t *testing.T) {
+	e := NewEnforcer(NewMemory(),
+		Rule{Limit: 100},
+		Rule{Name: "otp", Method: "PUT", Type: "updateotp", Limit: 1, Window: time.Minute},
+		Rule{Name: "link", Method: "POST", Path: "/gold-gym/v2/userdata/oidc/:provider/link", Limit: 1, KeyBy: KeyByUser},
+		Rule{Name: "partner", Path: "/partner/*", Limit: 1, KeyBy: KeyByClient},
+	)
+	e.IdentifyUser = func(_ context.Context, authorization string) string {
+		if authorization == "Bearer budi" {
+			return "budi@test.com"
+		}
+		return ""
+	}
+	e.IdentifyClient = func(_ context.Context, authorization string) string {
+		switch authorization {
+		case "Bearer token-a":
+			return "client-a"
+		case "Bearer token-b":
+			return "client-b"
+		}
+		return ""
+	}
+	ctx := context.Background()
+
+	otp := Request{Method: "PUT", Path: "/gold-gym/v2/userdata", Type: "updateotp", IP: "10.0.0.1"}
+	d := e.Check(ctx, otp)
+	require.NotNil(t, d.Rule)
+	assert.Equal(t, "otp", d.Rule.Name)
+	assert.True(t, d.Allowed)
+	assert.False(t, e.Check(ctx, otp).Allowed)
+
+	// same route, other operation or other IP is not affected
+	other := otp
+	other.Type = "updatenama"
+	d = e.Check(ctx, other)
+	assert.Equal(t, "default", d.Rule.Name)
+	assert.True(t, d.Allowed)
+	other = otp
+	other.IP = "10.0.0.2"
+	assert.True(t, e.Check(ctx, other).Allowed)
+
+	// user keyed rules follow the member across IPs
+	link := Request{Method: "POST", Path: "/gold-gym/v2/userdata/oidc/:provider/link", IP: "10.0.0.1", Authorization: "Bearer budi"}
+	assert.True(t, e.Check(ctx, link).Allowed)
+	link.IP = "10.0.0.9"
+	assert.False(t, e.Check(ctx, link).Allowed)
+
+	// client keyed rules with prefix paths follow the authenticated client
+	partner := Request{Method: "GET", Path: "/partner/v1/stock", IP: "10.0.0.1", Authorization: "Bearer token-a"}
+	assert.True(t, e.Check(ctx, partner).Allowed)
+	partner.IP = "10.0.0.9"
+	assert.False(t, e.Check(ctx, partner).Allowed)
+	partner.Authorization = "Bearer token-b"
+	assert.True(t, e.Check(ctx, partner).Allowed)
+
+	// tokens that do not resolve to a client share the IP bucket, so
+	// rotating them does not reset the limit
+	forged := Request{Method: "GET", Path: "/partner/v1/stock", IP: "10.0.0.3", Authorization: "Bearer forged-1"}
+	assert.True(t, e.Check(ctx, forged).Allowed)
+	forged.Authorization = "Bearer forged-2"
+	assert.False(t, e.Check(ctx, forged).Allowed)
+}
+
+func TestDecisionHeaders(t *testing.T) {
+	rule := Rule{Name: "otp"}
+	d := Decision{Rule: &rule, Result: Result{Limit: 5, Remaining: 0, ResetAfter: 1500 * time.Millisecond, RetryAfter: 200 * time.Millisecond}}
+
+	h := d.Headers()
+	assert.Equal(t, "5", h["X-RateLimit-Limit"])
+	assert.Equal(t, "0", h["X-RateLimit-Remaining"])
+	assert.Equal(t, "2", h["X-RateLimit-Reset"])
+	assert.Equal(t, "1", h["Retry-After"])
+
+	assert.Nil(t, Decision{Result: Result{Allowed: true}}.Headers())
+}
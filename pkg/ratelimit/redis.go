@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Both scripts mirror takeToken and countWindow so the backends agree. Times
+// are passed in milliseconds from the caller to keep the scripts deterministic.
+var (
+	tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = limit / window
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil or last == nil then
+	tokens = limit
+	last = now
+end
+if now > last then
+	tokens = math.min(limit, tokens + (now - last) * rate)
+	last = now
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "last", last)
+redis.call("PEXPIRE", KEYS[1], window * 2)
+return {allowed, math.floor(tokens), math.ceil((limit - tokens) / rate), retry}`)
+
+	slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+
+local cur = tonumber(redis.call("GET", KEYS[1]) or "0")
+local prev = tonumber(redis.call("GET", KEYS[2]) or "0")
+local count = prev * (1 - elapsed / window) + cur
+
+local allowed = 0
+if count + 1 <= limit then
+	cur = redis.call("INCR", KEYS[1])
+	redis.call("PEXPIRE", KEYS[1], window * 2)
+	count = count + 1
+	allowed = 1
+end
+
+local remaining = math.floor(limit - count)
+if remaining < 0 then
+	remaining = 0
+end
+return {allowed, remaining, prev, cur}`)
+)
+
+// Redis keeps counters in Redis so every replica shares the same limits
+type Redis struct {
+	rdb *redis.Client
+}
+
+// NewRedis ...
+func NewRedis(rdb *redis.Client) *Redis {
+	return &Redis{rdb: rdb}
+}
+
+// Allow ...
+func (r *Redis) Allow(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	window := rule.Window.Milliseconds()
+
+	if rule.Algorithm == AlgorithmTokenBucket {
+		v, err := tokenBucketScript.Run(ctx, r.rdb, []string{key}, rule.Limit, window, now.UnixMilli()).Int64Slice()
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{
+			Allowed:    v[0] == 1,
+			Limit:      rule.Limit,
+			Remaining:  int(v[1]),
+			ResetAfter: time.Duration(v[2]) * time.Millisecond,
+			RetryAfter: time.Duration(v[3]) * time.Millisecond,
+		}, nil
+	}
+
+	start := now.Truncate(rule.Window)
+	elapsed := now.Sub(start)
+	// the hash tag keeps both windows in the same cluster slot
+	keys := []string{
+		"{" + key + "}:" + strconv.FormatInt(start.UnixMilli(), 10),
+		"{" + key + "}:" + strconv.FormatInt(start.Add(-rule.Window).UnixMilli(), 10),
+	}
+	v, err := slidingWindowScript.Run(ctx, r.rdb, keys, rule.Limit, window, elapsed.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	res := Result{
+		Allowed:    v[0] == 1,
+		Limit:      rule.Limit,
+		Remaining:  int(v[1]),
+		ResetAfter: rule.Window - elapsed,
+	}
+	if !res.Allowed {
+		res.RetryAfter = windowRetryAfter(int(v[2]), int(v[3]), rule, elapsed)
+	}
+	return res, nil
+}